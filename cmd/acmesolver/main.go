@@ -19,20 +19,25 @@ package main
 import (
 	"flag"
 	"log"
+	"strings"
 
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/http/solver"
 	"github.com/jetstack/cert-manager/pkg/logs"
 )
 
-// acmesolver solves ACME http-01 challenges. This is intended to run as a pod
-// in the target kubernetes cluster in order to solve challenges for
-// cert-manager.
+// acmesolver solves ACME http-01 and tls-alpn-01 challenges. This is intended
+// to run as a pod in the target kubernetes cluster in order to solve
+// challenges for cert-manager.
 
 var (
 	listenPort = flag.Int("listen-port", 8089, "the port number to listen on for connections")
 	domain     = flag.String("domain", "", "the domain name to verify")
 	token      = flag.String("token", "", "the challenge token to verify against")
 	key        = flag.String("key", "", "the challenge key to respond with")
+
+	challengeType = flag.String("challenge-type", "http-01", "the type of challenge to solve, either http-01 or tls-alpn-01")
+
+	extraBasePaths = flag.String("extra-base-paths", "", "comma separated list of additional base paths to accept challenge requests on")
 )
 
 func main() {
@@ -41,12 +46,27 @@ func main() {
 	flag.Parse()
 	ctx := logs.NewContext(nil, nil, "acmesolver")
 
+	if *challengeType == "tls-alpn-01" {
+		s := &solver.TLSALPN01Solver{
+			ListenPort: *listenPort,
+			Domain:     *domain,
+			Key:        *key,
+		}
+		if err := s.Listen(ctx); err != nil {
+			log.Fatalf("error listening for connections: %s", err.Error())
+		}
+		return
+	}
+
 	s := &solver.HTTP01Solver{
 		ListenPort: *listenPort,
 		Domain:     *domain,
 		Token:      *token,
 		Key:        *key,
 	}
+	if *extraBasePaths != "" {
+		s.ExtraBasePaths = strings.Split(*extraBasePaths, ",")
+	}
 
 	if err := s.Listen(ctx); err != nil {
 		log.Fatalf("error listening for connections: %s", err.Error())
@@ -26,6 +26,8 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -45,6 +47,7 @@ import (
 	"github.com/jetstack/cert-manager/pkg/controller"
 	"github.com/jetstack/cert-manager/pkg/controller/clusterissuers"
 	dnsutil "github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
+	acmehttp "github.com/jetstack/cert-manager/pkg/issuer/acme/http"
 	logf "github.com/jetstack/cert-manager/pkg/logs"
 	"github.com/jetstack/cert-manager/pkg/metrics"
 	"github.com/jetstack/cert-manager/pkg/util"
@@ -160,6 +163,15 @@ func buildControllerContext(ctx context.Context, stopCh <-chan struct{}, opts *o
 		return nil, nil, fmt.Errorf("error creating kubernetes client: %s", err.Error())
 	}
 
+	// Create a Kubernetes dynamic client
+	dynamicCl, err := dynamic.NewForConfig(kubeCfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating kubernetes dynamic client: %s", err.Error())
+	}
+
+	ingressAPIGroup := acmehttp.DiscoverIngressAPIGroup(cl.Discovery())
+	log.WithValues("group", ingressAPIGroup).Info("configured acme http01 ingress api group")
+
 	nameservers := opts.DNS01RecursiveNameservers
 	if len(nameservers) == 0 {
 		nameservers = dnsutil.RecursiveNameservers
@@ -196,7 +208,20 @@ func buildControllerContext(ctx context.Context, stopCh <-chan struct{}, opts *o
 	eventBroadcaster.StartRecordingToSink(&corev1.EventSinkImpl{Interface: cl.CoreV1().Events("")})
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: controllerAgentName})
 
-	sharedInformerFactory := informers.NewSharedInformerFactoryWithOptions(intcl, time.Second*30, informers.WithNamespace(opts.Namespace))
+	informerOpts := []informers.SharedInformerOption{informers.WithNamespace(opts.Namespace)}
+	var certificateSelector labels.Selector
+	if opts.CertificateSelector != "" {
+		certificateSelector, err = labels.Parse(opts.CertificateSelector)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error parsing certificate selector: %s", err.Error())
+		}
+		// only watch cert-manager resources matching the selector, so that
+		// instances with disjoint selectors never act on the same resources
+		informerOpts = append(informerOpts, informers.WithTweakListOptions(func(o *metav1.ListOptions) {
+			o.LabelSelector = opts.CertificateSelector
+		}))
+	}
+	sharedInformerFactory := informers.NewSharedInformerFactoryWithOptions(intcl, time.Second*30, informerOpts...)
 	kubeSharedInformerFactory := kubeinformers.NewSharedInformerFactoryWithOptions(cl, time.Second*30, kubeinformers.WithNamespace(opts.Namespace))
 	return &controller.Context{
 		RootContext:               ctx,
@@ -204,25 +229,69 @@ func buildControllerContext(ctx context.Context, stopCh <-chan struct{}, opts *o
 		RESTConfig:                kubeCfg,
 		Client:                    cl,
 		CMClient:                  intcl,
+		DynamicClient:             dynamicCl,
 		Recorder:                  recorder,
 		KubeSharedInformerFactory: kubeSharedInformerFactory,
 		SharedInformerFactory:     sharedInformerFactory,
 		Namespace:                 opts.Namespace,
+		CertificateSelector:       certificateSelector,
 		Clock:                     clock.RealClock{},
 		ACMEOptions: controller.ACMEOptions{
 			HTTP01SolverImage:                 opts.ACMEHTTP01SolverImage,
+			HTTP01SolverImagePullSecrets:      opts.ACMEHTTP01SolverImagePullSecrets,
 			HTTP01SolverResourceRequestCPU:    HTTP01SolverResourceRequestCPU,
 			HTTP01SolverResourceRequestMemory: HTTP01SolverResourceRequestMemory,
 			HTTP01SolverResourceLimitsCPU:     HTTP01SolverResourceLimitsCPU,
 			HTTP01SolverResourceLimitsMemory:  HTTP01SolverResourceLimitsMemory,
+			HTTP01SolverNamePrefix:            opts.ACMEHTTP01SolverNamePrefix,
+			HTTP01SolverNamingStrategy:        opts.ACMEHTTP01SolverNamingStrategy,
+			HTTP01SolverServicePortName:       opts.ACMEHTTP01SolverServicePortName,
+			HTTP01SolverServicePort80:         opts.ACMEHTTP01SolverServicePort80,
+			HTTP01SolverCreateRetries:         opts.ACMEHTTP01SolverCreateRetries,
+			HTTP01SolverCreateTimeout:         opts.ACMEHTTP01SolverCreateTimeout,
+			HTTP01SolverMaxIngresses:          opts.ACMEHTTP01SolverMaxIngresses,
+			HTTP01SolverTimeout:               opts.ACMEHTTP01SolverTimeout,
+			HTTP01SolverIngressDeleteTimeout:  opts.ACMEHTTP01SolverIngressDeleteTimeout,
+			HTTP01SolverRegexPaths:            opts.ACMEHTTP01SolverRegexPaths,
+			HTTP01SolverPathSuffix:            opts.ACMEHTTP01SolverPathSuffix,
+			HTTP01SelfCheckViaIngress:         opts.ACMEHTTP01SelfCheckViaIngress,
+			HTTP01SelfCheckTimeout:            opts.ACMEHTTP01SelfCheckTimeout,
+			HTTP01SelfCheckInterval:           opts.ACMEHTTP01SelfCheckInterval,
+			HTTP01SolverAllowedNamespaces:     opts.ACMEHTTP01SolverAllowedNamespaces,
+			HTTP01SolverDeniedNamespaces:      opts.ACMEHTTP01SolverDeniedNamespaces,
+			HTTP01DisableIngressCreation:      opts.ACMEHTTP01DisableIngressCreation,
+			HTTP01RequireIngressOptIn:         opts.ACMEHTTP01RequireIngressOptIn,
+			HTTP01IngressAPIGroup:             ingressAPIGroup,
+			HTTP01AllowForceCleanup:           opts.ACMEHTTP01AllowForceCleanup,
+			HTTP01ForceCleanupNamespaces:      opts.ACMEHTTP01ForceCleanupNamespaces,
+			HTTP01ForceCleanupDryRun:          opts.ACMEHTTP01ForceCleanupDryRun,
+			HTTP01SolverNamespace:             opts.ACMEHTTP01SolverNamespace,
+			HTTP01SolverPlainOwnerRefs:        opts.ACMEHTTP01SolverPlainOwnerRefs,
+			HTTP01SolverInlineKeyAuthClasses:  opts.ACMEHTTP01SolverInlineKeyAuthClasses,
+			HTTP01ReuseWildcardIngresses:      opts.ACMEHTTP01ReuseWildcardIngresses,
+			HTTP01SolverReuseIngresses:        opts.ACMEHTTP01SolverReuseIngresses,
+			HTTP01SolverIngressQPS:            opts.ACMEHTTP01SolverIngressQPS,
+			HTTP01SolverIngressBurst:          opts.ACMEHTTP01SolverIngressBurst,
+			HTTP01SolverCleanupGracePeriod:    opts.ACMEHTTP01SolverCleanupGracePeriod,
+			HTTP01SolverCleanupOrder:          opts.ACMEHTTP01SolverCleanupOrder,
+			HTTP01SolverIngressRetention:      opts.ACMEHTTP01SolverIngressRetention,
+			HTTP01SolverReconcileBudget:       opts.ACMEHTTP01SolverReconcileBudget,
+			HTTP01SolverAppendPaths:           opts.ACMEHTTP01SolverAppendPaths,
 			DNS01CheckAuthoritative:           !opts.DNS01RecursiveNameserversOnly,
 			DNS01Nameservers:                  nameservers,
+			ChallengeCleanupJanitorPeriod:     opts.ACMEChallengeCleanupJanitorPeriod,
+			ChallengeRequeueJitter:            opts.ACMEChallengeRequeueJitter,
+			AuthorizationPollInterval:         opts.ACMEAuthorizationPollInterval,
+			AuthorizationPollBackoffFactor:    opts.ACMEAuthorizationPollBackoffFactor,
+			AuthorizationPollMaxInterval:      opts.ACMEAuthorizationPollMaxInterval,
 		},
 		IssuerOptions: controller.IssuerOptions{
 			ClusterIssuerAmbientCredentials: opts.ClusterIssuerAmbientCredentials,
 			IssuerAmbientCredentials:        opts.IssuerAmbientCredentials,
 			ClusterResourceNamespace:        opts.ClusterResourceNamespace,
 			RenewBeforeExpiryDuration:       opts.RenewBeforeExpiryDuration,
+			IssuerUnavailableInitialBackoff: opts.IssuerUnavailableInitialBackoff,
+			IssuerUnavailableMaxBackoff:     opts.IssuerUnavailableMaxBackoff,
 		},
 		IngressShimOptions: controller.IngressShimOptions{
 			DefaultIssuerName:                 opts.DefaultIssuerName,
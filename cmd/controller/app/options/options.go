@@ -19,9 +19,13 @@ package options
 import (
 	"fmt"
 	"net"
+	"strings"
 	"time"
 
 	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	cm "github.com/jetstack/cert-manager/pkg/apis/certmanager"
 	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha2"
@@ -37,6 +41,7 @@ import (
 	ingressshimcontroller "github.com/jetstack/cert-manager/pkg/controller/ingress-shim"
 	issuerscontroller "github.com/jetstack/cert-manager/pkg/controller/issuers"
 	"github.com/jetstack/cert-manager/pkg/controller/webhookbootstrap"
+	acmehttp "github.com/jetstack/cert-manager/pkg/issuer/acme/http"
 	"github.com/jetstack/cert-manager/pkg/util"
 )
 
@@ -45,6 +50,7 @@ type ControllerOptions struct {
 	Kubeconfig               string
 	ClusterResourceNamespace string
 	Namespace                string
+	CertificateSelector      string
 
 	LeaderElect                 bool
 	LeaderElectionNamespace     string
@@ -55,15 +61,59 @@ type ControllerOptions struct {
 	EnabledControllers []string
 
 	ACMEHTTP01SolverImage                 string
+	ACMEHTTP01SolverImagePullSecrets      []string
 	ACMEHTTP01SolverResourceRequestCPU    string
 	ACMEHTTP01SolverResourceRequestMemory string
 	ACMEHTTP01SolverResourceLimitsCPU     string
 	ACMEHTTP01SolverResourceLimitsMemory  string
+	ACMEHTTP01SolverNamePrefix            string
+	ACMEHTTP01SolverNamingStrategy        string
+	ACMEHTTP01SolverServicePortName       string
+	ACMEHTTP01SolverServicePort80         bool
+	ACMEHTTP01SolverCreateRetries         int
+	ACMEHTTP01SolverCreateTimeout         time.Duration
+	ACMEHTTP01SolverMaxIngresses          int
+	ACMEHTTP01SolverTimeout               time.Duration
+	ACMEHTTP01SolverIngressDeleteTimeout  time.Duration
+	ACMEHTTP01SolverIngressRetention      time.Duration
+	ACMEHTTP01SolverCleanupGracePeriod    time.Duration
+	ACMEHTTP01SolverCleanupOrder          []string
+	ACMEHTTP01SolverReconcileBudget       time.Duration
+	ACMEHTTP01SolverRegexPaths            bool
+	ACMEHTTP01SolverPathSuffix            string
+	ACMEHTTP01SolverAppendPaths           bool
+	ACMEHTTP01SelfCheckViaIngress         bool
+	ACMEHTTP01SelfCheckTimeout            time.Duration
+	ACMEHTTP01SelfCheckInterval           time.Duration
+	ACMEHTTP01SolverAllowedNamespaces     []string
+	ACMEHTTP01SolverDeniedNamespaces      []string
+	ACMEHTTP01DisableIngressCreation      bool
+	ACMEHTTP01RequireIngressOptIn         bool
+	ACMEHTTP01AllowForceCleanup           bool
+	ACMEHTTP01ForceCleanupNamespaces      []string
+	ACMEHTTP01ForceCleanupDryRun          bool
+	ACMEHTTP01SolverNamespace             string
+	ACMEHTTP01SolverPlainOwnerRefs        bool
+	ACMEHTTP01SolverInlineKeyAuthClasses  []string
+	ACMEHTTP01ReuseWildcardIngresses      bool
+	ACMEHTTP01SolverReuseIngresses        bool
+	ACMEHTTP01SolverIngressQPS            float32
+	ACMEHTTP01SolverIngressBurst          int
+	ACMEChallengeCleanupJanitorPeriod     time.Duration
+	ACMEChallengeRequeueJitter            float64
+	ACMEAuthorizationPollInterval         time.Duration
+	ACMEAuthorizationPollBackoffFactor    float64
+	ACMEAuthorizationPollMaxInterval      time.Duration
 
 	ClusterIssuerAmbientCredentials bool
 	IssuerAmbientCredentials        bool
 	RenewBeforeExpiryDuration       time.Duration
 
+	// Backoff applied when an issuer cannot be reached while signing a
+	// CertificateRequest or processing an ACME Order
+	IssuerUnavailableInitialBackoff time.Duration
+	IssuerUnavailableMaxBackoff     time.Duration
+
 	// Default issuer/certificates details consumed by ingress-shim
 	DefaultIssuerName                 string
 	DefaultIssuerKind                 string
@@ -111,6 +161,8 @@ const (
 	defaultClusterIssuerAmbientCredentials = true
 	defaultIssuerAmbientCredentials        = false
 	defaultRenewBeforeExpiryDuration       = cmapi.DefaultRenewBefore
+	defaultIssuerUnavailableInitialBackoff = 30 * time.Second
+	defaultIssuerUnavailableMaxBackoff     = 30 * time.Minute
 
 	defaultTLSACMEIssuerName           = ""
 	defaultTLSACMEIssuerKind           = "Issuer"
@@ -123,6 +175,27 @@ const (
 
 	defaultMaxConcurrentChallenges = 60
 
+	defaultACMEHTTP01SolverCreateRetries = 3
+	defaultACMEHTTP01SolverCreateTimeout = 30 * time.Second
+	defaultACMEHTTP01SolverMaxIngresses  = 0
+
+	defaultACMEHTTP01SolverTimeout              = 20 * time.Minute
+	defaultACMEHTTP01SolverIngressDeleteTimeout = 0
+	defaultACMEHTTP01SolverIngressRetention     = 0
+	defaultACMEHTTP01SolverCleanupGracePeriod   = 0
+	defaultACMEHTTP01SolverReconcileBudget      = 0
+	defaultACMEHTTP01SolverRegexPaths           = false
+	defaultACMEHTTP01SolverAppendPaths          = false
+	defaultACMEHTTP01SelfCheckViaIngress        = false
+	defaultACMEHTTP01SelfCheckTimeout           = 15 * time.Minute
+	defaultACMEHTTP01SelfCheckInterval          = 2 * time.Second
+	defaultACMEChallengeCleanupJanitorPeriod    = 15 * time.Minute
+	defaultACMEChallengeRequeueJitter           = 0.2
+	defaultACMEAuthorizationPollInterval        = 0
+	defaultACMEAuthorizationPollBackoffFactor   = 1.0
+	defaultACMEAuthorizationPollMaxInterval     = 0
+	defaultACMEHTTP01SolverIngressBurst         = 10
+
 	defaultWebhookNamespace         = "cert-manager"
 	defaultWebhookCASecretName      = "cert-manager-webhook-ca"
 	defaultWebhookServingSecretName = "cert-manager-webhook-tls"
@@ -144,6 +217,7 @@ var (
 	defaultACMEHTTP01SolverResourceRequestMemory = "64Mi"
 	defaultACMEHTTP01SolverResourceLimitsCPU     = "100m"
 	defaultACMEHTTP01SolverResourceLimitsMemory  = "64Mi"
+	defaultACMEHTTP01SolverNamePrefix            = "cm-acme-http-solver-"
 
 	defaultAutoCertificateAnnotations = []string{"kubernetes.io/tls-acme"}
 
@@ -178,6 +252,8 @@ func NewControllerOptions() *ControllerOptions {
 		ClusterIssuerAmbientCredentials:   defaultClusterIssuerAmbientCredentials,
 		IssuerAmbientCredentials:          defaultIssuerAmbientCredentials,
 		RenewBeforeExpiryDuration:         defaultRenewBeforeExpiryDuration,
+		IssuerUnavailableInitialBackoff:   defaultIssuerUnavailableInitialBackoff,
+		IssuerUnavailableMaxBackoff:       defaultIssuerUnavailableMaxBackoff,
 		DefaultIssuerName:                 defaultTLSACMEIssuerName,
 		DefaultIssuerKind:                 defaultTLSACMEIssuerKind,
 		DefaultIssuerGroup:                defaultTLSACMEIssuerGroup,
@@ -200,6 +276,12 @@ func (s *ControllerOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.Namespace, "namespace", defaultNamespace, ""+
 		"If set, this limits the scope of cert-manager to a single namespace and ClusterIssuers are disabled. "+
 		"If not specified, all namespaces will be watched")
+	fs.StringVar(&s.CertificateSelector, "certificate-selector", "", ""+
+		"If set, this limits the scope of cert-manager to cert-manager resources matching the given label "+
+		"selector. This applies to Certificates as well as the Issuers, ClusterIssuers and ACME resources used "+
+		"to issue them, so that multiple instances of cert-manager can each reconcile a subset of resources. "+
+		"Labels on Certificates are copied to the CertificateRequests, Orders and Challenges created for them. "+
+		"Certificates are only created for Ingresses matching the selector, as they inherit the Ingress's labels.")
 	fs.BoolVar(&s.LeaderElect, "leader-elect", true, ""+
 		"If true, cert-manager will perform leader election between instances to ensure no more "+
 		"than one instance of cert-manager operates at a time")
@@ -225,6 +307,9 @@ func (s *ControllerOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.ACMEHTTP01SolverImage, "acme-http01-solver-image", defaultACMEHTTP01SolverImage, ""+
 		"The docker image to use to solve ACME HTTP01 challenges. You most likely will not "+
 		"need to change this parameter unless you are testing a new feature or developing cert-manager.")
+	fs.StringSliceVar(&s.ACMEHTTP01SolverImagePullSecrets, "acme-http01-solver-image-pull-secrets", nil, ""+
+		"Comma-separated list of the names of secrets used to pull the ACME HTTP01 solver image, such as when "+
+		"it has been mirrored to a private registry. The secrets must exist in the namespace of each solver pod.")
 
 	fs.StringVar(&s.ACMEHTTP01SolverResourceRequestCPU, "acme-http01-solver-resource-request-cpu", defaultACMEHTTP01SolverResourceRequestCPU, ""+
 		"Defines the resource request CPU size when spawning new ACME HTTP01 challenge solver pods.")
@@ -238,6 +323,169 @@ func (s *ControllerOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&s.ACMEHTTP01SolverResourceLimitsMemory, "acme-http01-solver-resource-limits-memory", defaultACMEHTTP01SolverResourceLimitsMemory, ""+
 		"Defines the resource limits Memory size when spawning new ACME HTTP01 challenge solver pods.")
 
+	fs.StringVar(&s.ACMEHTTP01SolverNamePrefix, "acme-http01-solver-name-prefix", defaultACMEHTTP01SolverNamePrefix, ""+
+		"The prefix used when generating names for the pods, services and ingresses created to solve ACME HTTP01 challenges. "+
+		"A random suffix will be appended to this prefix by the API server.")
+
+	fs.StringVar(&s.ACMEHTTP01SolverNamingStrategy, "acme-http01-solver-naming-strategy", acmehttp.GenerateNamingStrategy, ""+
+		"The strategy used to name the services and ingresses created to solve ACME HTTP01 challenges. "+
+		"'"+acmehttp.GenerateNamingStrategy+"' appends a random suffix to the solver name prefix. "+
+		"'"+acmehttp.DeterministicNamingStrategy+"' appends a hash of the challenge to the solver name prefix, "+
+		"so that the same challenge always results in the same names.")
+
+	fs.StringVar(&s.ACMEHTTP01SolverServicePortName, "acme-http01-solver-service-port-name", "", ""+
+		"If set, the port of the services created to solve ACME HTTP01 challenges will be given this name, and "+
+		"solver ingress paths will reference the service port by this name rather than by number. This is "+
+		"required by some service meshes that route traffic based on port names.")
+
+	fs.BoolVar(&s.ACMEHTTP01SolverServicePort80, "acme-http01-solver-service-port-80", false, ""+
+		"If true, the services created to solve ACME HTTP01 challenges using an ingress will additionally expose "+
+		"the solver on port 80, and solver ingress paths will reference port 80. This is a workaround for load "+
+		"balancers that only forward traffic to port 80 of a service. Existing solver services are updated to add "+
+		"or remove the port when this is changed.")
+
+	fs.IntVar(&s.ACMEHTTP01SolverCreateRetries, "acme-http01-solver-create-retries", defaultACMEHTTP01SolverCreateRetries, ""+
+		"The number of times creating an ACME HTTP01 challenge solver pod, service or ingress will be retried "+
+		"if the API server returns a transient error, such as a timeout or throttling response.")
+
+	fs.DurationVar(&s.ACMEHTTP01SolverCreateTimeout, "acme-http01-solver-create-timeout", defaultACMEHTTP01SolverCreateTimeout, ""+
+		"The maximum total amount of time to spend retrying the creation of an ACME HTTP01 challenge solver "+
+		"pod, service or ingress before failing the challenge sync.")
+	fs.IntVar(&s.ACMEHTTP01SolverMaxIngresses, "acme-http01-solver-max-ingresses", defaultACMEHTTP01SolverMaxIngresses, ""+
+		"The maximum number of ACME HTTP01 challenge solver ingresses that may exist in a single namespace at once. "+
+		"Challenges that would exceed this limit wait for earlier solver ingresses to be cleaned up before their "+
+		"own ingress is created, smoothing the load placed on ingress controllers by certificates with many "+
+		"DNS names. If zero, the number of solver ingresses is not limited.")
+	fs.DurationVar(&s.ACMEHTTP01SolverTimeout, "acme-http01-solver-timeout", defaultACMEHTTP01SolverTimeout, ""+
+		"The maximum amount of time an ACME HTTP01 challenge may take to be presented and pass the self check, "+
+		"measured from when it was first presented. Challenges that exceed this are marked as errored so "+
+		"that the order can be retried later. If zero, challenges are never timed out. If set, must be at "+
+		"least --acme-http01-self-check-timeout, as a self check cannot run for longer than this timeout.")
+
+	fs.DurationVar(&s.ACMEHTTP01SolverIngressDeleteTimeout, "acme-http01-solver-ingress-delete-timeout", defaultACMEHTTP01SolverIngressDeleteTimeout, ""+
+		"The maximum amount of time to wait for deleted ACME HTTP01 challenge solver ingresses to be removed when "+
+		"cleaning up a challenge. If the ingresses have not been removed within this time, the challenge will be "+
+		"requeued. If zero, cleanup will not wait for ingresses to be removed.")
+	fs.DurationVar(&s.ACMEHTTP01SolverIngressRetention, "acme-http01-solver-ingress-retention", defaultACMEHTTP01SolverIngressRetention, ""+
+		"The amount of time to retain ACME HTTP01 challenge solver ingresses after their challenge has been cleaned "+
+		"up, as a record of how the challenge was solved. Retained ingresses are annotated with the time they expire "+
+		"and deleted by the challenge cleanup janitor once it has passed. If zero, solver ingresses are deleted "+
+		"when their challenge is cleaned up.")
+	fs.DurationVar(&s.ACMEHTTP01SolverCleanupGracePeriod, "acme-http01-solver-cleanup-grace-period", defaultACMEHTTP01SolverCleanupGracePeriod, ""+
+		"The amount of time to retain the ACME HTTP01 challenge solver pod, service and ingress after a challenge "+
+		"has become valid, for ACME servers that validate a challenge again shortly afterwards. If zero, solver "+
+		"resources are cleaned up as soon as the challenge is valid.")
+	fs.StringSliceVar(&s.ACMEHTTP01SolverCleanupOrder, "acme-http01-solver-cleanup-order", acmehttp.DefaultCleanupOrder, ""+
+		"The order in which the "+strings.Join(acmehttp.DefaultCleanupOrder, ", ")+" of ACME HTTP01 challenge solvers "+
+		"are cleaned up. Each is only cleaned up once the previous one has been, so the default stops routing "+
+		"requests to the solver before removing its backend.")
+	fs.DurationVar(&s.ACMEHTTP01SolverReconcileBudget, "acme-http01-solver-reconcile-budget", defaultACMEHTTP01SolverReconcileBudget, ""+
+		"The maximum amount of time a single sync of an ACME HTTP01 challenge may spend presenting the challenge "+
+		"and running its self check. Challenges that exceed it are requeued and retried later, so that a challenge "+
+		"that never becomes reachable does not block other challenges from being processed. It must allow for the "+
+		"self check to pass several times, at the self check interval. If zero, no budget is applied.")
+	fs.BoolVar(&s.ACMEHTTP01SolverRegexPaths, "acme-http01-solver-regex-paths", defaultACMEHTTP01SolverRegexPaths, ""+
+		"If true, the paths added to ingress resources to solve ACME HTTP01 challenges will be escaped and "+
+		"anchored as regular expressions, so that they match exactly when used with ingress controllers that "+
+		"treat ingress paths as regexes (e.g. ingress-nginx with the use-regex annotation).")
+	fs.StringVar(&s.ACMEHTTP01SolverPathSuffix, "acme-http01-solver-path-suffix", "", ""+
+		"A suffix appended verbatim to the paths added to ingress resources to solve ACME HTTP01 challenges, "+
+		"for ingress controllers that require paths in a particular form to match, e.g. '/*' or "+
+		"'(/|$)(.*)' for ingress-nginx with a rewrite-target annotation. If regex paths are enabled, the "+
+		"suffix is added before the end of line anchor.")
+	fs.BoolVar(&s.ACMEHTTP01SolverAppendPaths, "acme-http01-solver-append-paths", defaultACMEHTTP01SolverAppendPaths, ""+
+		"If true, the paths added to existing ingress resources to solve ACME HTTP01 challenges are appended after "+
+		"the ingress's own paths. By default they are placed first, so that they take precedence over catch-all "+
+		"paths such as '/' with ingress controllers that match paths in order.")
+	fs.BoolVar(&s.ACMEHTTP01SelfCheckViaIngress, "acme-http01-self-check-via-ingress", defaultACMEHTTP01SelfCheckViaIngress, ""+
+		"If true, the ACME HTTP01 self check will be performed against the load balancer address of the solver "+
+		"ingress once it has been assigned one, rather than against the challenged domain. This exercises the "+
+		"same route through the ingress controller that the ACME server will use, and requires the ingress "+
+		"load balancer to be reachable from the cert-manager controller.")
+	fs.DurationVar(&s.ACMEHTTP01SelfCheckTimeout, "acme-http01-self-check-timeout", defaultACMEHTTP01SelfCheckTimeout, ""+
+		"The maximum amount of time a single ACME HTTP01 self check may spend waiting for the challenge to "+
+		"become reachable. Increase this in environments where ingress changes are slow to propagate, such "+
+		"as those using cloud load balancers. Must not be greater than --acme-http01-solver-timeout, if set.")
+	fs.DurationVar(&s.ACMEHTTP01SelfCheckInterval, "acme-http01-self-check-interval", defaultACMEHTTP01SelfCheckInterval, ""+
+		"The amount of time to wait between the reachability tests performed by an ACME HTTP01 self check. "+
+		"Must be less than --acme-http01-self-check-timeout.")
+	fs.StringSliceVar(&s.ACMEHTTP01SolverAllowedNamespaces, "acme-http01-solver-allowed-namespaces", nil, ""+
+		"Comma-separated list of namespaces that ACME HTTP01 challenge solver resources may be created in. "+
+		"If set, challenges whose solver resources would be created in any other namespace will fail to be presented. "+
+		"If --acme-http01-solver-namespace is set, that namespace is checked rather than the challenge's. "+
+		"If not set, all namespaces are allowed.")
+	fs.StringSliceVar(&s.ACMEHTTP01SolverDeniedNamespaces, "acme-http01-solver-denied-namespaces", nil, ""+
+		"Comma-separated list of namespaces that ACME HTTP01 challenge solver resources must not be created in. "+
+		"Challenges whose solver resources would be created in these namespaces will fail to be presented.")
+	fs.StringVar(&s.ACMEHTTP01SolverNamespace, "acme-http01-solver-namespace", "", ""+
+		"The namespace to create ACME HTTP01 challenge solver pods, services and ingresses in, for ingress "+
+		"controllers that only watch a dedicated namespace. Solver resources outside of the namespace of their "+
+		"challenge cannot be garbage collected if the challenge is deleted without being cleaned up. "+
+		"If not set, solver resources are created in the namespace of the challenge.")
+	fs.BoolVar(&s.ACMEHTTP01ReuseWildcardIngresses, "acme-http01-reuse-wildcard-ingresses", false, ""+
+		"If true, ACME HTTP01 challenge paths will be added to an existing ingress with a wildcard rule (such "+
+		"as '*.example.com') matching the challenged domain, rather than creating a separate solver ingress. "+
+		"Only applies to HTTP01 ingress solvers that do not specify an ingress name or selector.")
+	fs.BoolVar(&s.ACMEHTTP01SolverReuseIngresses, "acme-http01-solver-reuse-ingresses", false, ""+
+		"If true, an ACME HTTP01 solver ingress created for another challenge for the same domain and "+
+		"ingress class will be reused, rather than creating a new solver ingress. The ingress is shared by "+
+		"both challenges, and is only deleted once neither challenge owns it. Ignored if "+
+		"--acme-http01-solver-namespace is set.")
+	fs.Float32Var(&s.ACMEHTTP01SolverIngressQPS, "acme-http01-solver-ingress-qps", 0, ""+
+		"The maximum number of create, update, patch and delete calls per second made for ACME HTTP01 "+
+		"challenge solver ingresses, shared across all challenges. If zero, these calls are not rate limited.")
+	fs.IntVar(&s.ACMEHTTP01SolverIngressBurst, "acme-http01-solver-ingress-burst", defaultACMEHTTP01SolverIngressBurst, ""+
+		"The maximum burst of calls made for ACME HTTP01 challenge solver ingresses above "+
+		"--acme-http01-solver-ingress-qps. Only used if --acme-http01-solver-ingress-qps is set.")
+	fs.BoolVar(&s.ACMEHTTP01DisableIngressCreation, "acme-http01-disable-ingress-creation", false, ""+
+		"If true, ingress resources will never be created to solve ACME HTTP01 challenges. Instead, challenges "+
+		"must be solved by adding paths to an existing ingress, specified by name or label selector on the "+
+		"issuer's HTTP01 ingress solver.")
+	fs.BoolVar(&s.ACMEHTTP01RequireIngressOptIn, "acme-http01-require-ingress-opt-in", false, ""+
+		"If true, challenge paths will only be added to existing ingress resources that have opted in by setting "+
+		"the 'acme.cert-manager.io/allow-path-injection' or 'acme.cert-manager.io/http01-edit-in-place' "+
+		"annotation to \"true\". This protects unrelated ingresses from being modified if an issuer names the "+
+		"wrong ingress.")
+	fs.BoolVar(&s.ACMEHTTP01AllowForceCleanup, "acme-http01-allow-force-cleanup", false, ""+
+		"If true, all ACME HTTP01 challenge solver ingresses in a namespace may be deleted at once, regardless of "+
+		"the challenge that owns them. This is intended for recovering from a bad rollout and is never done as "+
+		"part of normal challenge processing.")
+	fs.StringSliceVar(&s.ACMEHTTP01ForceCleanupNamespaces, "acme-http01-force-cleanup-namespaces", nil, ""+
+		"Comma-separated list of namespaces whose ACME HTTP01 challenge solver ingresses will all be deleted once "+
+		"when the challenges controller starts. Requires --acme-http01-allow-force-cleanup unless "+
+		"--acme-http01-force-cleanup-dry-run is set.")
+	fs.BoolVar(&s.ACMEHTTP01ForceCleanupDryRun, "acme-http01-force-cleanup-dry-run", false, ""+
+		"If true, the solver ingresses that would be deleted in the namespaces given by "+
+		"--acme-http01-force-cleanup-namespaces are logged rather than deleted.")
+	fs.BoolVar(&s.ACMEHTTP01SolverPlainOwnerRefs, "acme-http01-solver-plain-owner-references", false, ""+
+		"If true, the resources created to solve ACME HTTP01 challenges will reference their challenge with a plain "+
+		"owner reference rather than a controller reference. This allows another controller to own solver "+
+		"ingresses, as a resource may only have one controller. Solver resources are found using either kind of "+
+		"owner reference, so this can be changed while challenges are in progress.")
+	fs.StringSliceVar(&s.ACMEHTTP01SolverInlineKeyAuthClasses, "acme-http01-solver-inline-key-authorization-classes", nil, ""+
+		"The ingress classes for which the key authorization for ACME HTTP01 challenges will be served directly "+
+		"by the ingress controller, using a configuration snippet annotation on the solver ingress, so that no "+
+		"solver pod or service is created. Only list classes served by ingress-nginx with snippet annotations "+
+		"allowed, as requests for the challenge will otherwise fail. Challenges using any other ingress class, "+
+		"an ingress without a class, an existing ingress or an existing service are still solved using a solver pod.")
+	fs.DurationVar(&s.ACMEChallengeCleanupJanitorPeriod, "acme-challenge-cleanup-janitor-period", defaultACMEChallengeCleanupJanitorPeriod, ""+
+		"How often to retry cleaning up ACME challenges (such as DNS01 TXT records) that previously failed to be "+
+		"cleaned up. If zero, failed clean ups are only retried with the controller's usual error backoff.")
+	fs.Float64Var(&s.ACMEChallengeRequeueJitter, "acme-challenge-requeue-jitter", defaultACMEChallengeRequeueJitter, ""+
+		"The maximum fraction of the requeue interval that is randomly added when re-checking a presented ACME "+
+		"challenge, so that challenges created at the same time are not all re-checked at once. If zero, no "+
+		"jitter is added.")
+	fs.DurationVar(&s.ACMEAuthorizationPollInterval, "acme-authorization-poll-interval", defaultACMEAuthorizationPollInterval, ""+
+		"The initial delay between polls of the ACME server while waiting for an accepted challenge's authorization "+
+		"to become valid or invalid. If zero, the delay requested by the ACME server's Retry-After header is used, "+
+		"or one second if none is given.")
+	fs.Float64Var(&s.ACMEAuthorizationPollBackoffFactor, "acme-authorization-poll-backoff-factor", defaultACMEAuthorizationPollBackoffFactor, ""+
+		"The factor the delay between polls of a pending ACME authorization is multiplied by after each poll. "+
+		"Only used if --acme-authorization-poll-interval is set.")
+	fs.DurationVar(&s.ACMEAuthorizationPollMaxInterval, "acme-authorization-poll-max-interval", defaultACMEAuthorizationPollMaxInterval, ""+
+		"The maximum delay between polls of a pending ACME authorization. If zero, the delay is not capped. "+
+		"Only used if --acme-authorization-poll-interval is set.")
+
 	fs.BoolVar(&s.ClusterIssuerAmbientCredentials, "cluster-issuer-ambient-credentials", defaultClusterIssuerAmbientCredentials, ""+
 		"Whether a cluster-issuer may make use of ambient credentials for issuers. 'Ambient Credentials' are credentials drawn from the environment, metadata services, or local files which are not explicitly configured in the ClusterIssuer API object. "+
 		"When this flag is enabled, the following sources for credentials are also used: "+
@@ -250,6 +498,11 @@ func (s *ControllerOptions) AddFlags(fs *pflag.FlagSet) {
 		"The default 'renew before expiry' time for Certificates. "+
 		"Once a certificate is within this duration until expiry, a new Certificate "+
 		"will be attempted to be issued.")
+	fs.DurationVar(&s.IssuerUnavailableInitialBackoff, "issuer-unavailable-initial-backoff", defaultIssuerUnavailableInitialBackoff, ""+
+		"The initial time to wait before retrying a CertificateRequest or ACME Order when its issuer could not be reached. "+
+		"The delay doubles on each consecutive failure up to --issuer-unavailable-max-backoff.")
+	fs.DurationVar(&s.IssuerUnavailableMaxBackoff, "issuer-unavailable-max-backoff", defaultIssuerUnavailableMaxBackoff, ""+
+		"The maximum time to wait before retrying a CertificateRequest or ACME Order when its issuer could not be reached.")
 	fs.StringSliceVar(&s.DefaultAutoCertificateAnnotations, "auto-certificate-annotations", defaultAutoCertificateAnnotations, ""+
 		"The annotation consumed by the ingress-shim controller to indicate a ingress is requesting a certificate")
 
@@ -292,6 +545,10 @@ func (s *ControllerOptions) AddFlags(fs *pflag.FlagSet) {
 }
 
 func (o *ControllerOptions) Validate() error {
+	if _, err := labels.Parse(o.CertificateSelector); err != nil {
+		return fmt.Errorf("invalid certificate selector %q: %v", o.CertificateSelector, err)
+	}
+
 	switch o.DefaultIssuerKind {
 	case "Issuer":
 	case "ClusterIssuer":
@@ -299,6 +556,127 @@ func (o *ControllerOptions) Validate() error {
 		return fmt.Errorf("invalid default issuer kind: %v", o.DefaultIssuerKind)
 	}
 
+	// GenerateName appends a 5 character random suffix to the prefix, and
+	// solver services require the resulting name to be a valid DNS-1035 label
+	if errs := validation.IsDNS1035Label(o.ACMEHTTP01SolverNamePrefix + "abcde"); len(errs) > 0 {
+		return fmt.Errorf("invalid ACME HTTP01 solver name prefix %q: %s", o.ACMEHTTP01SolverNamePrefix, strings.Join(errs, ", "))
+	}
+
+	if !acmehttp.NamingStrategyRegistered(o.ACMEHTTP01SolverNamingStrategy) {
+		return fmt.Errorf("invalid ACME HTTP01 solver naming strategy %q", o.ACMEHTTP01SolverNamingStrategy)
+	}
+
+	if name := o.ACMEHTTP01SolverServicePortName; name != "" {
+		if errs := validation.IsValidPortName(name); len(errs) > 0 {
+			return fmt.Errorf("invalid ACME HTTP01 solver service port name %q: %s", name, strings.Join(errs, ", "))
+		}
+		if o.ACMEHTTP01SolverServicePort80 {
+			return fmt.Errorf("ACME HTTP01 solver service port name cannot be set when exposing solver services on port 80")
+		}
+	}
+
+	if len(o.ACMEHTTP01ForceCleanupNamespaces) > 0 && !o.ACMEHTTP01AllowForceCleanup && !o.ACMEHTTP01ForceCleanupDryRun {
+		return fmt.Errorf("--acme-http01-force-cleanup-namespaces requires --acme-http01-allow-force-cleanup or --acme-http01-force-cleanup-dry-run")
+	}
+
+	if o.ACMEHTTP01SolverCreateRetries < 0 {
+		return fmt.Errorf("invalid number of ACME HTTP01 solver create retries: %d", o.ACMEHTTP01SolverCreateRetries)
+	}
+
+	if o.ACMEHTTP01SolverMaxIngresses < 0 {
+		return fmt.Errorf("invalid ACME HTTP01 solver max ingresses: %d", o.ACMEHTTP01SolverMaxIngresses)
+	}
+
+	if o.ACMEHTTP01SolverTimeout < 0 {
+		return fmt.Errorf("invalid ACME HTTP01 solver timeout: %s", o.ACMEHTTP01SolverTimeout)
+	}
+
+	if o.ACMEHTTP01SolverIngressDeleteTimeout < 0 {
+		return fmt.Errorf("invalid ACME HTTP01 solver ingress delete timeout: %s", o.ACMEHTTP01SolverIngressDeleteTimeout)
+	}
+
+	if o.ACMEHTTP01SolverIngressRetention < 0 {
+		return fmt.Errorf("invalid ACME HTTP01 solver ingress retention: %s", o.ACMEHTTP01SolverIngressRetention)
+	}
+
+	if o.ACMEHTTP01SolverIngressRetention > 0 && o.ACMEChallengeCleanupJanitorPeriod == 0 {
+		return fmt.Errorf("the ACME challenge cleanup janitor must be enabled to delete retained ACME HTTP01 solver ingresses")
+	}
+
+	if o.ACMEHTTP01SolverCleanupGracePeriod < 0 {
+		return fmt.Errorf("invalid ACME HTTP01 solver cleanup grace period: %s", o.ACMEHTTP01SolverCleanupGracePeriod)
+	}
+
+	if err := acmehttp.ValidateCleanupOrder(o.ACMEHTTP01SolverCleanupOrder); err != nil {
+		return fmt.Errorf("invalid ACME HTTP01 solver cleanup order: %v", err)
+	}
+
+	if o.ACMEHTTP01SolverReconcileBudget < 0 {
+		return fmt.Errorf("invalid ACME HTTP01 solver reconcile budget: %s", o.ACMEHTTP01SolverReconcileBudget)
+	}
+
+	if o.ACMEHTTP01SelfCheckTimeout <= 0 {
+		return fmt.Errorf("invalid ACME HTTP01 self check timeout: %s", o.ACMEHTTP01SelfCheckTimeout)
+	}
+
+	if o.ACMEHTTP01SolverTimeout > 0 && o.ACMEHTTP01SolverTimeout < o.ACMEHTTP01SelfCheckTimeout {
+		return fmt.Errorf("invalid ACME HTTP01 solver timeout %s: must be zero or at least the self check timeout (%s)", o.ACMEHTTP01SolverTimeout, o.ACMEHTTP01SelfCheckTimeout)
+	}
+
+	if o.ACMEHTTP01SelfCheckInterval <= 0 || o.ACMEHTTP01SelfCheckInterval >= o.ACMEHTTP01SelfCheckTimeout {
+		return fmt.Errorf("invalid ACME HTTP01 self check interval %s: must be greater than zero and less than the self check timeout (%s)", o.ACMEHTTP01SelfCheckInterval, o.ACMEHTTP01SelfCheckTimeout)
+	}
+
+	if o.ACMEHTTP01SolverIngressQPS < 0 {
+		return fmt.Errorf("invalid ACME HTTP01 solver ingress QPS: %v", o.ACMEHTTP01SolverIngressQPS)
+	}
+
+	if o.ACMEHTTP01SolverIngressQPS > 0 && o.ACMEHTTP01SolverIngressBurst < 1 {
+		return fmt.Errorf("invalid ACME HTTP01 solver ingress burst %d: must be at least 1", o.ACMEHTTP01SolverIngressBurst)
+	}
+
+	if o.ACMEHTTP01SolverNamespace != "" {
+		if errs := validation.IsDNS1123Label(o.ACMEHTTP01SolverNamespace); len(errs) > 0 {
+			return fmt.Errorf("invalid ACME HTTP01 solver namespace %q: %s", o.ACMEHTTP01SolverNamespace, strings.Join(errs, ", "))
+		}
+	}
+
+	denied := sets.NewString(o.ACMEHTTP01SolverDeniedNamespaces...)
+	for _, ns := range o.ACMEHTTP01SolverAllowedNamespaces {
+		if denied.Has(ns) {
+			return fmt.Errorf("namespace %q cannot be both allowed and denied for ACME HTTP01 solvers", ns)
+		}
+	}
+
+	if o.ACMEChallengeCleanupJanitorPeriod < 0 {
+		return fmt.Errorf("invalid ACME challenge cleanup janitor period: %s", o.ACMEChallengeCleanupJanitorPeriod)
+	}
+
+	if o.ACMEChallengeRequeueJitter < 0 || o.ACMEChallengeRequeueJitter > 1 {
+		return fmt.Errorf("invalid ACME challenge requeue jitter %v: must be between 0 and 1", o.ACMEChallengeRequeueJitter)
+	}
+
+	if o.ACMEAuthorizationPollInterval < 0 {
+		return fmt.Errorf("invalid ACME authorization poll interval: %s", o.ACMEAuthorizationPollInterval)
+	}
+
+	if o.ACMEAuthorizationPollBackoffFactor < 1 {
+		return fmt.Errorf("invalid ACME authorization poll backoff factor %v: must be at least 1", o.ACMEAuthorizationPollBackoffFactor)
+	}
+
+	if o.ACMEAuthorizationPollMaxInterval < 0 {
+		return fmt.Errorf("invalid ACME authorization poll max interval: %s", o.ACMEAuthorizationPollMaxInterval)
+	}
+
+	if o.IssuerUnavailableInitialBackoff <= 0 {
+		return fmt.Errorf("invalid issuer unavailable initial backoff: %s", o.IssuerUnavailableInitialBackoff)
+	}
+
+	if o.IssuerUnavailableMaxBackoff < o.IssuerUnavailableInitialBackoff {
+		return fmt.Errorf("issuer unavailable max backoff (%s) must not be less than the initial backoff (%s)",
+			o.IssuerUnavailableMaxBackoff, o.IssuerUnavailableInitialBackoff)
+	}
+
 	for _, server := range o.DNS01RecursiveNameservers {
 		// ensure all servers have a port number
 		_, _, err := net.SplitHostPort(server)
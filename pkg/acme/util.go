@@ -17,10 +17,14 @@ limitations under the License.
 package acme
 
 import (
+	"net/http"
+
+	acmeapi "golang.org/x/crypto/acme"
 	corev1 "k8s.io/api/core/v1"
 
 	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
 	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
+	cmerrors "github.com/jetstack/cert-manager/pkg/util/errors"
 )
 
 // IsFinalState will return true if the given ACME State is a 'final' state.
@@ -51,3 +55,15 @@ func PrivateKeySelector(sel cmmeta.SecretKeySelector) cmmeta.SecretKeySelector {
 	}
 	return sel
 }
+
+// IsUnavailable returns true if err indicates that the ACME server could not
+// be reached, or failed to serve a request due to a server side error, rather
+// than rejecting the request. Such errors are expected to resolve without
+// intervention once the ACME server is available again.
+func IsUnavailable(err error) bool {
+	if cmerrors.IsIssuerUnavailable(err) || cmerrors.IsConnectionError(err) {
+		return true
+	}
+	acmeErr, ok := err.(*acmeapi.Error)
+	return ok && acmeErr.StatusCode >= http.StatusInternalServerError
+}
@@ -32,3 +32,12 @@ func DefaultCertDuration(d *metav1.Duration) time.Duration {
 
 	return certDuration
 }
+
+func DefaultNotBeforeSkew(d *metav1.Duration) time.Duration {
+	skew := v1alpha2.DefaultNotBeforeSkew
+	if d != nil {
+		skew = d.Duration
+	}
+
+	return skew
+}
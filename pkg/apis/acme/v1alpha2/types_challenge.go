@@ -119,4 +119,43 @@ type ChallengeStatus struct {
 	// If not set, the state of the challenge is unknown.
 	// +optional
 	State State `json:"state,omitempty"`
+
+	// PresentedURL is the URL that an HTTP01 challenge is served at once it
+	// has been presented. It can be requested manually to verify that the
+	// challenge has been presented correctly.
+	// +optional
+	PresentedURL string `json:"presentedURL,omitempty"`
+
+	// SolverService is the name and port of the service that requests for
+	// an HTTP01 challenge are routed to, in the form '<name>:<port>'.
+	// It is provided for debugging purposes only.
+	// +optional
+	SolverService string `json:"solverService,omitempty"`
+
+	// SolverAddress is the IP address or hostname assigned by the ingress
+	// controller to the ingress serving an HTTP01 challenge. The challenged
+	// domain should resolve to this address for the ACME server to reach the
+	// challenge. It is empty until the ingress controller has assigned an
+	// address, and is never set for challenges solved using a NodePort
+	// service or a Gateway API HTTPRoute.
+	// +optional
+	SolverAddress string `json:"solverAddress,omitempty"`
+
+	// SolverIngress is the name of the existing ingress that the paths for
+	// an HTTP01 challenge were added to, if the ingress was chosen using an
+	// ingress selector or a wildcard rule. The same ingress is cleaned up,
+	// even if a different ingress would be chosen by then.
+	// +optional
+	SolverIngress string `json:"solverIngress,omitempty"`
+
+	// ValidatedAt is the time at which the challenge was first observed to be
+	// valid. Solver resources for a valid challenge may be retained for a
+	// grace period after this time before they are cleaned up.
+	// +optional
+	ValidatedAt *metav1.Time `json:"validatedAt,omitempty"`
+
+	// PresentStartedAt is the time at which the challenge was first
+	// presented. The HTTP01 solver timeout is measured from this time.
+	// +optional
+	PresentStartedAt *metav1.Time `json:"presentStartedAt,omitempty"`
 }
@@ -35,6 +35,25 @@ const (
 	// IngressEditInPlaceAnnotation is used to toggle the use of ingressClass instead
 	// of ingress on the created Certificate resource
 	IngressEditInPlaceAnnotationKey = "acme.cert-manager.io/http01-edit-in-place"
+
+	// IngressDisablePathInjectionAnnotationKey can be set to "true" on an
+	// existing ingress named in a HTTP01 solver's ingress.name field to stop
+	// cert-manager from adding challenge paths to it. Challenges using the
+	// ingress will fail to be presented until the annotation is removed.
+	// Paths that have already been added are still removed when their
+	// challenge is cleaned up.
+	IngressDisablePathInjectionAnnotationKey = "acme.cert-manager.io/disable-path-injection"
+
+	// IngressAllowPathInjectionAnnotationKey must be set to "true" on an
+	// existing ingress named in a HTTP01 solver's ingress.name field before
+	// cert-manager will add challenge paths to it, if the controller has been
+	// configured to require ingresses to opt in.
+	IngressAllowPathInjectionAnnotationKey = "acme.cert-manager.io/allow-path-injection"
+
+	// ACMEOrderURLAnnotationKey is set on Challenge resources to the URL of
+	// the ACME order they were created for, so that resources created to
+	// solve the challenge can be traced back to a specific order.
+	ACMEOrderURLAnnotationKey = "acme.cert-manager.io/order-url"
 )
 
 const (
@@ -18,7 +18,9 @@ package v1alpha3
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
 	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
 )
@@ -49,6 +51,13 @@ type ACMEIssuer struct {
 	// ACME challenges for the matching domains.
 	// +optional
 	Solvers []ACMEChallengeSolver `json:"solvers,omitempty"`
+
+	// DefaultHTTP01IngressClass is the ingress class used by HTTP01 ingress
+	// solvers on this issuer that do not specify a 'class', 'name' or
+	// 'ingressSelector' of their own. If not set, such solvers create
+	// ingresses without an ingress class.
+	// +optional
+	DefaultHTTP01IngressClass *string `json:"defaultHTTP01IngressClass,omitempty"`
 }
 
 // ACMEExternalAcccountBinding is a reference to a CA external account of the ACME
@@ -91,6 +100,9 @@ type ACMEChallengeSolver struct {
 
 	// +optional
 	DNS01 *ACMEChallengeSolverDNS01 `json:"dns01,omitempty"`
+
+	// +optional
+	TLSALPN01 *ACMEChallengeSolverTLSALPN01 `json:"tlsALPN01,omitempty"`
 }
 
 // CertificateDomainSelector selects certificates using a label selector, and
@@ -138,6 +150,32 @@ type ACMEChallengeSolverHTTP01 struct {
 	// provisioned by cert-manager for each Challenge to be completed.
 	// +optional
 	Ingress *ACMEChallengeSolverHTTP01Ingress `json:"ingress"`
+
+	// The NodePort based HTTP01 challenge solver will solve challenges by
+	// exposing 'challenge solver' pods directly using a NodePort service,
+	// without creating or modifying any Ingress resources.
+	// This is intended for clusters without an ingress controller, where
+	// traffic to port 80 is routed to the allocated node port externally.
+	// Only one of 'ingress' or 'nodePort' may be specified.
+	// +optional
+	NodePort *ACMEChallengeSolverHTTP01NodePort `json:"nodePort,omitempty"`
+
+	// The Gateway API based HTTP01 challenge solver will solve challenges by
+	// creating HTTPRoute resources from the Kubernetes Gateway API in order to
+	// route requests for '/.well-known/acme-challenge/XYZ' to 'challenge
+	// solver' pods, for clusters that use the Gateway API instead of Ingress.
+	// Only one of 'ingress', 'nodePort' or 'gatewayHTTPRoute' may be specified.
+	// +optional
+	GatewayHTTPRoute *ACMEChallengeSolverHTTP01GatewayHTTPRoute `json:"gatewayHTTPRoute,omitempty"`
+
+	// Strategy is the name of a custom HTTP01 challenge solver implementation
+	// that has been registered with the cert-manager controller, to be used
+	// for routing requests for '/.well-known/acme-challenge/XYZ' instead of
+	// one of the built-in solvers.
+	// If set, none of 'ingress', 'nodePort' or 'gatewayHTTPRoute' may be
+	// specified.
+	// +optional
+	Strategy string `json:"strategy,omitempty"`
 }
 
 type ACMEChallengeSolverHTTP01Ingress struct {
@@ -159,12 +197,178 @@ type ACMEChallengeSolverHTTP01Ingress struct {
 	// +optional
 	Name string `json:"name,omitempty"`
 
+	// A label selector for an existing ingress resource that should have ACME
+	// challenge solving routes inserted into it, in the same namespace as the
+	// challenge. Exactly one ingress must match the selector.
+	// Only one of 'name' or 'ingressSelector' may be specified.
+	// +optional
+	IngressSelector *metav1.LabelSelector `json:"ingressSelector,omitempty"`
+
+	// The name of an existing service, in the same namespace as the
+	// challenge, that routes to the ACME challenge solver. If set, the solver
+	// service will not be created and ingress paths will route to this
+	// service instead. The service must expose port 8089.
+	// Only one of 'serviceName' or 'serviceType' may be specified.
+	// +optional
+	ServiceName string `json:"serviceName,omitempty"`
+
+	// Additional path prefixes that the challenge token should be served
+	// under, alongside the canonical '/.well-known/acme-challenge' path.
+	// This is useful when a proxy in front of the ingress controller rewrites
+	// the request path before forwarding it, e.g. '/acme-challenge'.
+	// Each prefix must begin with, but not end with, a '/'.
+	// +optional
+	ExtraPathPrefixes []string `json:"extraPathPrefixes,omitempty"`
+
+	// A template for the path that the challenge is served under, in place of
+	// the canonical '/.well-known/acme-challenge/{token}' path. The
+	// placeholders '{domain}' and '{token}' are replaced with the challenged
+	// domain and the challenge token. This is useful behind a CDN that only
+	// forwards certain paths, with the ingress controller rewriting requests
+	// to the canonical path. The template must begin with a '/' and end with
+	// '/{token}'. The same path is used when cleaning up the challenge.
+	// +optional
+	PathTemplate string `json:"pathTemplate,omitempty"`
+
+	// If set, the ACME challenge solver pod will be run in the host network
+	// namespace, listening on this port of the node it is scheduled to. The
+	// solver service is then created without a cluster IP, so that ingress
+	// controllers route challenge requests directly to the node. This is
+	// useful in clusters where the pod network is not reachable from the
+	// ingress controller. Cannot be used with 'serviceName', and
+	// 'serviceType' must be empty or 'ClusterIP'.
+	// +optional
+	HostNetworkPort int32 `json:"hostNetworkPort,omitempty"`
+
+	// If true, the solver service will be created as a headless service,
+	// i.e. without a cluster IP, so that ingress controllers and service
+	// meshes that require it route challenge requests directly to the solver
+	// pod. The service port is unchanged. Cannot be used with 'serviceName',
+	// and 'serviceType' must be empty or 'ClusterIP'.
+	// +optional
+	HeadlessService bool `json:"headlessService,omitempty"`
+
+	// Optional pod template used to configure the ACME challenge solver pods
+	// used for HTTP01 challenges
+	// +optional
+	PodTemplate *ACMEChallengeSolverHTTP01IngressPodTemplate `json:"podTemplate,omitempty"`
+
+	// Optional ingress template used to configure the ingress resources
+	// created to solve HTTP01 challenges. The rules and paths required to
+	// solve the challenge are merged into the template, so that the rest of
+	// the ingress can be fully customised. Cannot be used with 'name' or
+	// 'ingressSelector'.
+	// +optional
+	IngressTemplate *ACMEChallengeSolverHTTP01IngressTemplate `json:"ingressTemplate,omitempty"`
+
+	// If true, the default backend of the ingress resources created to solve
+	// HTTP01 challenges is set to the solver service, for ingress controllers
+	// that do not route requests to the paths of a rule unless the ingress
+	// also has a default backend. Cannot be used with 'name' or
+	// 'ingressSelector', or with an ingress template that sets a default
+	// backend.
+	// +optional
+	SolverDefaultBackend bool `json:"solverDefaultBackend,omitempty"`
+}
+
+type ACMEChallengeSolverHTTP01IngressTemplate struct {
+	// ObjectMeta overrides for the ingress used to solve HTTP01 challenges.
+	// Only the 'labels' and 'annotations' fields may be set.
+	// Annotations that overlap with in-built values will override the
+	// in-built values, whereas in-built labels cannot be overridden.
+	// +optional
+	ACMEChallengeSolverHTTP01IngressObjectMeta `json:"metadata,omitempty"`
+
+	// Spec is the base spec of the ingress used to solve HTTP01 challenges,
+	// e.g. to add a default backend, TLS configuration or additional rules.
+	// The paths for the challenge are added to the rule for the challenged
+	// domain, creating it if required, and take precedence over any paths
+	// in the template with the same value.
+	// +optional
+	Spec *extv1beta1.IngressSpec `json:"spec,omitempty"`
+}
+
+type ACMEChallengeSolverHTTP01IngressObjectMeta struct {
+	// Annotations that should be added to the created ACME HTTP01 solver ingresses.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Labels that should be added to the created ACME HTTP01 solver ingresses.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type ACMEChallengeSolverHTTP01NodePort struct {
+	// The node port to expose the challenge solver service on.
+	// If not specified, a port will be allocated by the apiserver.
+	// If specified, the port must be within the default node port range of
+	// 30000-32767, and the solver's selector must match a single DNS name
+	// using 'dnsNames', as node ports cannot be shared between challenges.
+	// +optional
+	Port int32 `json:"port,omitempty"`
+
+	// Optional pod template used to configure the ACME challenge solver pods
+	// used for HTTP01 challenges
+	// +optional
+	PodTemplate *ACMEChallengeSolverHTTP01IngressPodTemplate `json:"podTemplate,omitempty"`
+}
+
+type ACMEChallengeSolverHTTP01GatewayHTTPRoute struct {
+	// Optional service type for Kubernetes solver service
+	// +optional
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+
+	// The Gateways that the HTTPRoute resources created to solve challenges
+	// will be attached to. At least one Gateway must be specified.
+	ParentRefs []ACMEChallengeSolverHTTP01GatewayParentRef `json:"parentRefs"`
+
+	// Labels that will be added to the HTTPRoute resources created to solve
+	// challenges, e.g. so that they are permitted by a Gateway's
+	// 'allowedRoutes' selector.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+
 	// Optional pod template used to configure the ACME challenge solver pods
 	// used for HTTP01 challenges
 	// +optional
 	PodTemplate *ACMEChallengeSolverHTTP01IngressPodTemplate `json:"podTemplate,omitempty"`
 }
 
+// ACMEChallengeSolverTLSALPN01 configures the TLS-ALPN-01 challenge solver.
+// It will solve challenges by exposing 'challenge solver' pods that serve the
+// TLS-ALPN-01 challenge certificate using a Service. Routing TLS connections
+// for the challenged domain on port 443 to the Service, e.g. using an ingress
+// controller that supports TLS passthrough, is left to the user. The Service
+// is named after the challenged domain, e.g. 'cm-acme-tls-alpn-example-com'
+// for 'example.com', so that routing can be configured in advance.
+type ACMEChallengeSolverTLSALPN01 struct {
+	// Optional service type for Kubernetes solver service
+	// +optional
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+
+	// Optional pod template used to configure the ACME challenge solver pods
+	// used for TLS-ALPN-01 challenges
+	// +optional
+	PodTemplate *ACMEChallengeSolverHTTP01IngressPodTemplate `json:"podTemplate,omitempty"`
+}
+
+// ACMEChallengeSolverHTTP01GatewayParentRef is a reference to a Gateway that
+// HTTP01 solver HTTPRoute resources will be attached to.
+type ACMEChallengeSolverHTTP01GatewayParentRef struct {
+	// The name of the Gateway.
+	Name string `json:"name"`
+
+	// The namespace of the Gateway. If not specified, the Gateway is assumed
+	// to be in the same namespace as the challenge.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// The name of the listener on the Gateway to attach to. If not
+	// specified, the route is attached to all listeners that allow it.
+	// +optional
+	SectionName string `json:"sectionName,omitempty"`
+}
+
 type ACMEChallengeSolverHTTP01IngressPodTemplate struct {
 	// ObjectMeta overrides for the pod used to solve HTTP01 challenges.
 	// Only the 'labels' and 'annotations' fields may be set.
@@ -202,6 +406,19 @@ type ACMEChallengeSolverHTTP01IngressPodSpec struct {
 	// If specified, the pod's tolerations.
 	// +optional
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// If specified, the compute resources requested by and limits applied to
+	// the solver container. Any requests or limits not set here default to
+	// the values configured on the cert-manager controller.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// If specified, secrets in the namespace of the solver pod used to pull
+	// the solver image, in addition to any configured on the cert-manager
+	// controller. This is required if the solver image has been mirrored
+	// to a private registry.
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
 }
 
 type ACMEChallengeSolverDNS01 struct {
@@ -179,6 +179,9 @@ const (
 
 	// ACMEChallengeTypeDNS01 denotes a Challenge is of type dns-01
 	ACMEChallengeTypeDNS01 ACMEChallengeType = "dns-01"
+
+	// ACMEChallengeTypeTLSALPN01 denotes a Challenge is of type tls-alpn-01
+	ACMEChallengeTypeTLSALPN01 ACMEChallengeType = "tls-alpn-01"
 )
 
 // State represents the state of an ACME resource, such as an Order.
@@ -30,6 +30,14 @@ const (
 
 	// Default duration before certificate expiration if  Issuer.spec.renewBefore is not set
 	DefaultRenewBefore = time.Hour * 24 * 30
+
+	// default duration to backdate the NotBefore time of certificates issued
+	// by the CA and SelfSigned issuers if notBeforeSkew is not set
+	DefaultNotBeforeSkew = time.Minute
+
+	// maximum permitted duration to backdate the NotBefore time of certificates
+	// issued by the CA and SelfSigned issuers
+	MaximumNotBeforeSkew = time.Hour
 )
 
 const (
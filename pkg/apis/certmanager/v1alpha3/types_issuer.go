@@ -147,7 +147,13 @@ type VenafiCloud struct {
 	APITokenSecretRef cmmeta.SecretKeySelector `json:"apiTokenSecretRef"`
 }
 
-type SelfSignedIssuer struct{}
+type SelfSignedIssuer struct {
+	// NotBeforeSkew is the duration by which the NotBefore time of issued
+	// certificates is backdated, to tolerate clients with skewed clocks.
+	// Defaults to 1 minute if not set, and may not be longer than 1 hour.
+	// +optional
+	NotBeforeSkew *metav1.Duration `json:"notBeforeSkew,omitempty"`
+}
 
 type VaultIssuer struct {
 	// Vault authentication
@@ -218,8 +224,39 @@ type CAIssuer struct {
 	// SecretName is the name of the secret used to sign Certificates issued
 	// by this Issuer.
 	SecretName string `json:"secretName"`
+
+	// NotBeforeSkew is the duration by which the NotBefore time of issued
+	// certificates is backdated, to tolerate clients with skewed clocks.
+	// Defaults to 1 minute if not set, and may not be longer than 1 hour.
+	// +optional
+	NotBeforeSkew *metav1.Duration `json:"notBeforeSkew,omitempty"`
+
+	// SignatureAlgorithm is the algorithm used by the CA to sign issued
+	// certificates. It must be compatible with the type of the CA's private
+	// key. If not set, the algorithm implied by the CA key is used.
+	// +optional
+	SignatureAlgorithm SignatureAlgorithm `json:"signatureAlgorithm,omitempty"`
+
+	// IssuingCertificateURLs is a list of URLs at which the CA certificate
+	// can be fetched. They are set as the Authority Information Access (AIA)
+	// 'CA Issuers' URLs on issued certificates, allowing clients to build the
+	// chain when intermediates are not presented to them.
+	// +optional
+	IssuingCertificateURLs []string `json:"issuingCertificateURLs,omitempty"`
 }
 
+// +kubebuilder:validation:Enum=SHA256WithRSA;SHA384WithRSA;SHA512WithRSA;ECDSAWithSHA256;ECDSAWithSHA384;ECDSAWithSHA512
+type SignatureAlgorithm string
+
+const (
+	SHA256WithRSA   SignatureAlgorithm = "SHA256WithRSA"
+	SHA384WithRSA   SignatureAlgorithm = "SHA384WithRSA"
+	SHA512WithRSA   SignatureAlgorithm = "SHA512WithRSA"
+	ECDSAWithSHA256 SignatureAlgorithm = "ECDSAWithSHA256"
+	ECDSAWithSHA384 SignatureAlgorithm = "ECDSAWithSHA384"
+	ECDSAWithSHA512 SignatureAlgorithm = "ECDSAWithSHA512"
+)
+
 // IssuerStatus contains status information about an Issuer
 type IssuerStatus struct {
 	// +optional
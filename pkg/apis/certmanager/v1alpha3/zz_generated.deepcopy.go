@@ -30,6 +30,16 @@ import (
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CAIssuer) DeepCopyInto(out *CAIssuer) {
 	*out = *in
+	if in.NotBeforeSkew != nil {
+		in, out := &in.NotBeforeSkew, &out.NotBeforeSkew
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.IssuingCertificateURLs != nil {
+		in, out := &in.IssuingCertificateURLs, &out.IssuingCertificateURLs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -477,7 +487,7 @@ func (in *IssuerConfig) DeepCopyInto(out *IssuerConfig) {
 	if in.CA != nil {
 		in, out := &in.CA, &out.CA
 		*out = new(CAIssuer)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Vault != nil {
 		in, out := &in.Vault, &out.Vault
@@ -487,7 +497,7 @@ func (in *IssuerConfig) DeepCopyInto(out *IssuerConfig) {
 	if in.SelfSigned != nil {
 		in, out := &in.SelfSigned, &out.SelfSigned
 		*out = new(SelfSignedIssuer)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Venafi != nil {
 		in, out := &in.Venafi, &out.Venafi
@@ -588,6 +598,11 @@ func (in *IssuerStatus) DeepCopy() *IssuerStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SelfSignedIssuer) DeepCopyInto(out *SelfSignedIssuer) {
 	*out = *in
+	if in.NotBeforeSkew != nil {
+		in, out := &in.NotBeforeSkew, &out.NotBeforeSkew
+		*out = new(v1.Duration)
+		**out = **in
+	}
 	return
 }
 
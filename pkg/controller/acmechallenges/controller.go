@@ -27,6 +27,7 @@ import (
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
 
 	"github.com/jetstack/cert-manager/pkg/acme"
 	cmclient "github.com/jetstack/cert-manager/pkg/client/clientset/versioned"
@@ -38,6 +39,7 @@ import (
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/http"
 	logf "github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/metrics"
 )
 
 type controller struct {
@@ -55,13 +57,16 @@ type controller struct {
 	// ACME challenge solvers are instantiated once at the time of controller
 	// construction.
 	// This also allows for easy mocking of the different challenge mechanisms.
-	dnsSolver  solver
-	httpSolver solver
+	dnsSolver     solver
+	httpSolver    solver
+	tlsALPNSolver solver
 	// scheduler marks challenges as Processing=true if they can be scheduled
 	// for processing. This job runs periodically every N seconds, so it cannot
 	// be constructed as a traditional controller.
 	scheduler *scheduler.Scheduler
 
+	// clock is used when recording the time a Challenge became valid
+	clock clock.Clock
 	// used to record Events about resources to the API
 	recorder record.EventRecorder
 	// clientset used to update cert-manager API resources
@@ -75,6 +80,21 @@ type controller struct {
 	log logr.Logger
 
 	dns01Nameservers []string
+
+	// requeueJitter is the maximum fraction of challengeRequeuePeriod that is
+	// randomly added when requeuing a challenge awaiting propagation
+	requeueJitter float64
+
+	// authzBackoff configures how often the ACME server is polled for the
+	// state of an authorization after a challenge has been accepted
+	authzBackoff authorizationBackoff
+
+	// forceCleanupNamespaces are the namespaces whose HTTP01 solver
+	// ingresses are all deleted when the controller starts
+	forceCleanupNamespaces []string
+	// forceCleanupDryRun causes the ingresses that would be force deleted
+	// to be logged instead
+	forceCleanupDryRun bool
 }
 
 func (c *controller) Register(ctx *controllerpkg.Context) (workqueue.RateLimitingInterface, []cache.InformerSynced, []controllerpkg.RunFunc, error) {
@@ -92,7 +112,7 @@ func (c *controller) Register(ctx *controllerpkg.Context) (workqueue.RateLimitin
 	// cache when managing pod/service/ingress resources
 	podInformer := ctx.KubeSharedInformerFactory.Core().V1().Pods()
 	serviceInformer := ctx.KubeSharedInformerFactory.Core().V1().Services()
-	ingressInformer := ctx.KubeSharedInformerFactory.Extensions().V1beta1().Ingresses()
+	ingressInformer := http.IngressInformer(ctx)
 	// build a list of InformerSynced functions that will be returned by the Register method.
 	// the controller will only begin processing items once all of these informers have synced.
 	mustSync := []cache.InformerSynced{
@@ -101,7 +121,7 @@ func (c *controller) Register(ctx *controllerpkg.Context) (workqueue.RateLimitin
 		secretInformer.Informer().HasSynced,
 		podInformer.Informer().HasSynced,
 		serviceInformer.Informer().HasSynced,
-		ingressInformer.Informer().HasSynced,
+		ingressInformer.HasSynced,
 	}
 
 	// set all the references to the listers for used by the Sync function
@@ -119,13 +139,22 @@ func (c *controller) Register(ctx *controllerpkg.Context) (workqueue.RateLimitin
 
 	// register handler functions
 	challengeInformer.Informer().AddEventHandler(&controllerpkg.QueuingEventHandler{Queue: c.queue})
+	// re-queue challenges whose HTTP01 solver service has been deleted so
+	// that the service is re-created without waiting for a resync
+	serviceInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{DeleteFunc: c.handleSolverServiceDeleted})
 
 	c.helper = issuer.NewHelper(c.issuerLister, c.clusterIssuerLister)
 	c.acmeHelper = acme.NewHelper(c.secretLister, ctx.ClusterResourceNamespace)
 	c.scheduler = scheduler.New(logf.NewContext(ctx.RootContext, c.log), c.challengeLister, ctx.SchedulerOptions.MaxConcurrentChallenges)
 	c.recorder = ctx.Recorder
+	c.clock = ctx.Clock
 	c.cmClient = ctx.CMClient
-	c.httpSolver = http.NewSolver(ctx)
+	httpSolver := http.NewSolver(ctx)
+	c.httpSolver = httpSolver
+	metrics.Default.SetActiveChallengesFunc(func() (interface{}, error) {
+		return httpSolver.ActiveChallenges()
+	})
+	c.tlsALPNSolver = http.NewTLSALPNSolver(httpSolver)
 	var err error
 	c.dnsSolver, err = dns.NewSolver(ctx)
 	if err != nil {
@@ -134,10 +163,52 @@ func (c *controller) Register(ctx *controllerpkg.Context) (workqueue.RateLimitin
 
 	// read options from context
 	c.dns01Nameservers = ctx.ACMEOptions.DNS01Nameservers
+	c.requeueJitter = ctx.ACMEOptions.ChallengeRequeueJitter
+	c.authzBackoff = authorizationBackoff{
+		Interval:    ctx.ACMEOptions.AuthorizationPollInterval,
+		Factor:      ctx.ACMEOptions.AuthorizationPollBackoffFactor,
+		MaxInterval: ctx.ACMEOptions.AuthorizationPollMaxInterval,
+	}
+	c.forceCleanupNamespaces = ctx.ACMEOptions.HTTP01ForceCleanupNamespaces
+	c.forceCleanupDryRun = ctx.ACMEOptions.HTTP01ForceCleanupDryRun
 
 	return c.queue, mustSync, nil, nil
 }
 
+// handleSolverServiceDeleted queues the Challenge that owns a deleted HTTP01
+// solver Service, so that the Service can be re-created if the Challenge is
+// still being processed.
+func (c *controller) handleSolverServiceDeleted(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	svc, ok := obj.(*corev1.Service)
+	if !ok {
+		c.log.Error(nil, "object passed to handleSolverServiceDeleted is not a Service")
+		return
+	}
+	if !http.IsSolverResource(svc) {
+		return
+	}
+	// the service may only have a plain owner reference to its challenge, or
+	// be labelled with its UID if it was created in the solver namespace
+	log := logf.WithResource(c.log, svc)
+	ch, err := http.SolverChallenge(c.challengeLister, svc)
+	if err != nil {
+		log.Error(err, "error getting challenge referenced by solver service")
+		return
+	}
+	if ch == nil {
+		return
+	}
+	key, err := controllerpkg.KeyFunc(ch)
+	if err != nil {
+		log.Error(err, "error computing key for resource")
+		return
+	}
+	c.queue.Add(key)
+}
+
 // MaxChallengesPerSchedule is the maximum number of challenges that can be
 // scheduled with a single call to the scheduler.
 // This provides a very crude rate limit on how many challenges we will schedule
@@ -208,9 +279,15 @@ const (
 func init() {
 	controllerpkg.Register(ControllerName, func(ctx *controllerpkg.Context) (controllerpkg.Interface, error) {
 		c := &controller{}
-		return controllerpkg.NewBuilder(ctx, ControllerName).
+		b := controllerpkg.NewBuilder(ctx, ControllerName).
 			For(c).
-			With(c.runScheduler, time.Second).
-			Complete()
+			With(c.runScheduler, time.Second)
+		if len(ctx.ACMEOptions.HTTP01ForceCleanupNamespaces) > 0 {
+			b = b.First(c.runForceCleanup)
+		}
+		if period := ctx.ACMEOptions.ChallengeCleanupJanitorPeriod; period > 0 {
+			b = b.With(c.runCleanupJanitor, period)
+		}
+		return b.Complete()
 	})
 }
@@ -0,0 +1,142 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acmechallenges
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	testpkg "github.com/jetstack/cert-manager/pkg/controller/test"
+	logf "github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func TestHandleSolverServiceDeleted(t *testing.T) {
+	ch := gen.Challenge("test-challenge")
+	ch.UID = "challenge-uid"
+	ownerRef := *metav1.NewControllerRef(ch, cmacme.SchemeGroupVersion.WithKind("Challenge"))
+	plainOwnerRef := ownerRef
+	plainOwnerRef.Controller = nil
+	staleOwnerRef := ownerRef
+	staleOwnerRef.UID = "other-uid"
+	solverLabels := map[string]string{"acme.cert-manager.io/http01-solver": "true"}
+
+	tests := map[string]struct {
+		obj           interface{}
+		expectedQueue int
+	}{
+		"should queue the challenge owning a deleted solver service": {
+			obj: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "cm-acme-http-solver-abcde",
+					Namespace:       gen.DefaultTestNamespace,
+					Labels:          solverLabels,
+					OwnerReferences: []metav1.OwnerReference{ownerRef},
+				},
+			},
+			expectedQueue: 1,
+		},
+		"should queue the challenge owning a solver service in a tombstone": {
+			obj: cache.DeletedFinalStateUnknown{
+				Key: gen.DefaultTestNamespace + "/cm-acme-http-solver-abcde",
+				Obj: &corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "cm-acme-http-solver-abcde",
+						Namespace:       gen.DefaultTestNamespace,
+						Labels:          solverLabels,
+						OwnerReferences: []metav1.OwnerReference{ownerRef},
+					},
+				},
+			},
+			expectedQueue: 1,
+		},
+		"should queue the challenge with a plain owner reference to a deleted solver service": {
+			obj: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "cm-acme-http-solver-abcde",
+					Namespace:       gen.DefaultTestNamespace,
+					Labels:          solverLabels,
+					OwnerReferences: []metav1.OwnerReference{plainOwnerRef},
+				},
+			},
+			expectedQueue: 1,
+		},
+		"should queue the challenge labelled on a deleted solver service in another namespace": {
+			obj: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "cm-acme-http-solver-abcde",
+					Namespace: "solver-namespace",
+					Labels: map[string]string{
+						"acme.cert-manager.io/http01-solver":      "true",
+						"acme.cert-manager.io/http-challenge-uid": "challenge-uid",
+					},
+				},
+			},
+			expectedQueue: 1,
+		},
+		"should ignore solver services owned by a challenge that no longer exists": {
+			obj: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "cm-acme-http-solver-abcde",
+					Namespace:       gen.DefaultTestNamespace,
+					Labels:          solverLabels,
+					OwnerReferences: []metav1.OwnerReference{staleOwnerRef},
+				},
+			},
+			expectedQueue: 0,
+		},
+		"should ignore services not created by the HTTP01 solver": {
+			obj: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            "some-service",
+					Namespace:       gen.DefaultTestNamespace,
+					OwnerReferences: []metav1.OwnerReference{ownerRef},
+				},
+			},
+			expectedQueue: 0,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := &testpkg.Builder{
+				T:                  t,
+				CertManagerObjects: []runtime.Object{ch},
+			}
+			b.Init()
+			defer b.Stop()
+
+			c := &controller{
+				challengeLister: b.SharedInformerFactory.Acme().V1alpha2().Challenges().Lister(),
+				queue:           workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+				log:             logf.Log,
+			}
+			b.Start()
+
+			c.handleSolverServiceDeleted(test.obj)
+
+			if l := c.queue.Len(); l != test.expectedQueue {
+				t.Errorf("expected %d items to be queued but got %d", test.expectedQueue, l)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acmechallenges
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/jetstack/cert-manager/pkg/acme"
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	controllerpkg "github.com/jetstack/cert-manager/pkg/controller"
+	logf "github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/metrics"
+)
+
+// runCleanupJanitor re-queues all challenges whose solver is due to be
+// cleaned up but has not been, so that clean up is retried even if the
+// challenge has been backed off for a long time, and records the number of
+// such challenges so that abandoned records can be alerted on.
+// Legacy solver ingresses are migrated first, so that they are cleaned up
+// along with the challenges they were created for.
+func (c *controller) runCleanupJanitor(ctx context.Context) {
+	log := logf.FromContext(ctx, "cleanupJanitor")
+
+	if migrator, ok := c.httpSolver.(legacyIngressMigrator); ok {
+		if err := migrator.MigrateLegacyIngresses(ctx); err != nil {
+			log.Error(err, "error migrating legacy HTTP01 solver ingresses")
+		}
+	}
+
+	chs, err := c.challengeLister.List(labels.Everything())
+	if err != nil {
+		log.Error(err, "error listing challenges")
+		return
+	}
+
+	pending := map[string]int{
+		string(cmacme.ACMEChallengeTypeHTTP01):    0,
+		string(cmacme.ACMEChallengeTypeDNS01):     0,
+		string(cmacme.ACMEChallengeTypeTLSALPN01): 0,
+	}
+	for _, ch := range chs {
+		if !cleanupPending(ch) {
+			continue
+		}
+		pending[string(ch.Spec.Type)]++
+
+		key, err := controllerpkg.KeyFunc(ch)
+		if err != nil {
+			logf.WithResource(log, ch).Error(err, "error computing key for challenge")
+			continue
+		}
+		logf.WithResource(log, ch).V(logf.DebugLevel).Info("re-queuing challenge to retry clean up")
+		c.queue.Add(key)
+	}
+
+	metrics.Default.SetACMEChallengesPendingCleanup(pending)
+
+	if sweeper, ok := c.httpSolver.(expiredIngressSweeper); ok {
+		if err := sweeper.DeleteExpiredIngresses(ctx); err != nil {
+			log.Error(err, "error deleting expired HTTP01 solver ingresses")
+		}
+	}
+}
+
+// runForceCleanup deletes every HTTP01 solver ingress in the configured
+// namespaces, or logs the ingresses that would be deleted if running in dry
+// run mode. It is run once when the controller starts.
+func (c *controller) runForceCleanup(ctx context.Context) {
+	log := logf.FromContext(ctx, "forceCleanup")
+
+	cleaner, ok := c.httpSolver.(forceCleaner)
+	if !ok {
+		return
+	}
+	for _, namespace := range c.forceCleanupNamespaces {
+		log := log.WithValues("namespace", namespace)
+		if c.forceCleanupDryRun {
+			names, err := cleaner.CleanupAllDryRun(ctx, namespace)
+			if err != nil {
+				log.Error(err, "error listing HTTP01 solver ingresses")
+				continue
+			}
+			log.Info("dry run: would force delete HTTP01 solver ingresses", "ingresses", names)
+			continue
+		}
+		if err := cleaner.CleanupAll(ctx, namespace); err != nil {
+			log.Error(err, "error force deleting HTTP01 solver ingresses")
+			continue
+		}
+		log.Info("force deleted HTTP01 solver ingresses")
+	}
+}
+
+// forceCleaner is implemented by solvers that can delete all of their
+// resources in a namespace, regardless of the challenge that owns them.
+type forceCleaner interface {
+	CleanupAll(ctx context.Context, namespace string) error
+	CleanupAllDryRun(ctx context.Context, namespace string) ([]string, error)
+}
+
+// expiredIngressSweeper is implemented by solvers that retain ingresses after
+// clean up, which are deleted by the janitor once they have expired.
+type expiredIngressSweeper interface {
+	DeleteExpiredIngresses(ctx context.Context) error
+}
+
+// legacyIngressMigrator is implemented by solvers that can label solver
+// ingresses created by older versions of cert-manager.
+type legacyIngressMigrator interface {
+	MigrateLegacyIngresses(ctx context.Context) error
+}
+
+// cleanupPending returns true if the solver for the given challenge should
+// have been cleaned up but has not yet been.
+func cleanupPending(ch *cmacme.Challenge) bool {
+	if ch.DeletionTimestamp != nil {
+		if !ch.Status.Processing {
+			return false
+		}
+		for _, f := range ch.Finalizers {
+			if f == cmacme.ACMEFinalizer {
+				return true
+			}
+		}
+		return false
+	}
+	return acme.IsFinalState(ch.Status.State) && ch.Status.Presented
+}
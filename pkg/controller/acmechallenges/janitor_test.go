@@ -0,0 +1,168 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package acmechallenges
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/workqueue"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	testpkg "github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/metrics"
+	"github.com/jetstack/cert-manager/test/unit/gen"
+)
+
+func TestRunCleanupJanitor(t *testing.T) {
+	defer metrics.ACMEChallengesPendingCleanup.Reset()
+
+	deletedAt := metav1.NewTime(time.Now())
+	challenges := []runtime.Object{
+		// presented DNS01 challenge in a final state that failed to clean up
+		gen.Challenge("dns-valid-presented",
+			gen.SetChallengeType("dns-01"),
+			gen.SetChallengeProcessing(true),
+			gen.SetChallengePresented(true),
+			gen.SetChallengeState(cmacme.Valid),
+		),
+		// DNS01 challenge in a final state that has been cleaned up
+		gen.Challenge("dns-valid-cleaned-up",
+			gen.SetChallengeType("dns-01"),
+			gen.SetChallengePresented(false),
+			gen.SetChallengeState(cmacme.Valid),
+		),
+		// DNS01 challenge still being solved
+		gen.Challenge("dns-pending",
+			gen.SetChallengeType("dns-01"),
+			gen.SetChallengeProcessing(true),
+			gen.SetChallengePresented(true),
+			gen.SetChallengeState(cmacme.Pending),
+		),
+		// deleted HTTP01 challenge whose finalizer has not yet been removed
+		gen.Challenge("http-deleted",
+			gen.SetChallengeType("http-01"),
+			gen.SetChallengeProcessing(true),
+			gen.SetChallengeFinalizers([]string{cmacme.ACMEFinalizer}),
+			gen.SetChallengeDeletionTimestamp(deletedAt),
+		),
+	}
+
+	b := &testpkg.Builder{
+		T:                  t,
+		CertManagerObjects: challenges,
+	}
+	b.Init()
+	defer b.Stop()
+
+	c := &controller{
+		challengeLister: b.SharedInformerFactory.Acme().V1alpha2().Challenges().Lister(),
+		queue:           workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+	}
+	b.Start()
+
+	// a label set recorded by a previous run should not be reported again
+	metrics.ACMEChallengesPendingCleanup.WithLabelValues("stale").Set(1)
+
+	c.runCleanupJanitor(context.Background())
+
+	expectedKeys := map[string]bool{
+		gen.DefaultTestNamespace + "/dns-valid-presented": true,
+		gen.DefaultTestNamespace + "/http-deleted":        true,
+	}
+	if l := c.queue.Len(); l != len(expectedKeys) {
+		t.Errorf("expected %d items to be queued but got %d", len(expectedKeys), l)
+	}
+	for c.queue.Len() > 0 {
+		key, _ := c.queue.Get()
+		if !expectedKeys[key.(string)] {
+			t.Errorf("unexpected challenge %q queued", key)
+		}
+		c.queue.Done(key)
+	}
+
+	if err := testutil.CollectAndCompare(
+		metrics.ACMEChallengesPendingCleanup,
+		strings.NewReader(`
+	# HELP certmanager_acme_challenges_pending_cleanup The number of ACME challenges whose solver has not yet been successfully cleaned up.
+	# TYPE certmanager_acme_challenges_pending_cleanup gauge
+	certmanager_acme_challenges_pending_cleanup{type="dns-01"} 1
+	certmanager_acme_challenges_pending_cleanup{type="http-01"} 1
+	certmanager_acme_challenges_pending_cleanup{type="tls-alpn-01"} 0
+`),
+		"certmanager_acme_challenges_pending_cleanup",
+	); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+// fakeForceCleanSolver is a fakeSolver that also implements forceCleaner.
+type fakeForceCleanSolver struct {
+	*fakeSolver
+	cleaned []string
+	dryRun  []string
+}
+
+func (f *fakeForceCleanSolver) CleanupAll(ctx context.Context, namespace string) error {
+	f.cleaned = append(f.cleaned, namespace)
+	return nil
+}
+
+func (f *fakeForceCleanSolver) CleanupAllDryRun(ctx context.Context, namespace string) ([]string, error) {
+	f.dryRun = append(f.dryRun, namespace)
+	return nil, nil
+}
+
+func TestRunForceCleanup(t *testing.T) {
+	namespaces := []string{"ns-a", "ns-b"}
+	tests := map[string]struct {
+		dryRun          bool
+		expectedCleaned []string
+		expectedDryRun  []string
+	}{
+		"should force delete solver ingresses in each namespace": {
+			expectedCleaned: namespaces,
+		},
+		"should only list solver ingresses in dry run mode": {
+			dryRun:         true,
+			expectedDryRun: namespaces,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := &fakeForceCleanSolver{fakeSolver: &fakeSolver{}}
+			c := &controller{
+				httpSolver:             s,
+				forceCleanupNamespaces: namespaces,
+				forceCleanupDryRun:     test.dryRun,
+			}
+			c.runForceCleanup(context.Background())
+			if !reflect.DeepEqual(s.cleaned, test.expectedCleaned) {
+				t.Errorf("expected %v to be cleaned up but got %v", test.expectedCleaned, s.cleaned)
+			}
+			if !reflect.DeepEqual(s.dryRun, test.expectedDryRun) {
+				t.Errorf("expected %v to be dry run but got %v", test.expectedDryRun, s.dryRun)
+			}
+		})
+	}
+}
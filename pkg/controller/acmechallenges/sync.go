@@ -24,7 +24,10 @@ import (
 
 	acmeapi "golang.org/x/crypto/acme"
 	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/jetstack/cert-manager/pkg/acme"
 	acmecl "github.com/jetstack/cert-manager/pkg/acme/client"
@@ -33,6 +36,7 @@ import (
 	controllerpkg "github.com/jetstack/cert-manager/pkg/controller"
 	"github.com/jetstack/cert-manager/pkg/feature"
 	dnsutil "github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
+	"github.com/jetstack/cert-manager/pkg/issuer/acme/http"
 	logf "github.com/jetstack/cert-manager/pkg/logs"
 	"github.com/jetstack/cert-manager/pkg/metrics"
 	utilfeature "github.com/jetstack/cert-manager/pkg/util/feature"
@@ -40,6 +44,10 @@ import (
 
 const (
 	reasonDomainVerified = "DomainVerified"
+
+	// challengeRequeuePeriod is how long to wait before re-checking a
+	// presented challenge that has not yet propagated
+	challengeRequeuePeriod = time.Second * 10
 )
 
 // solver solves ACME challenges by presenting the given token and key in an
@@ -55,6 +63,27 @@ type solver interface {
 	CleanUp(ctx context.Context, issuer cmapi.GenericIssuer, ch *cmacme.Challenge) error
 }
 
+// debugSolver is implemented by solvers that serve challenges at a URL that
+// can be requested manually to debug the challenge.
+// ingressSolver is implemented by solvers that report the change presenting a
+// challenge made to its solver ingress.
+type ingressSolver interface {
+	// PresentIngress presents the challenge like Present, and also returns
+	// the change that was made to the solver ingress.
+	PresentIngress(ctx context.Context, issuer cmapi.GenericIssuer, ch *cmacme.Challenge) (http.IngressAction, error)
+}
+
+type debugSolver interface {
+	// PresentedURL returns the URL the challenge is served at once presented.
+	PresentedURL(ch *cmacme.Challenge) string
+	// SolverService returns the name and port of the service that requests
+	// for the challenge are routed to.
+	SolverService(ctx context.Context, ch *cmacme.Challenge) (string, error)
+	// SolverAddress returns the address the challenge is served at by the
+	// ingress controller, or an empty string if it is not yet known.
+	SolverAddress(ctx context.Context, ch *cmacme.Challenge) (string, error)
+}
+
 // Sync will process this ACME Challenge.
 // It is the core control function for ACME challenges.
 func (c *controller) Sync(ctx context.Context, ch *cmacme.Challenge) (err error) {
@@ -81,8 +110,9 @@ func (c *controller) Sync(ctx context.Context, ch *cmacme.Challenge) (err error)
 	}()
 
 	// bail out early on if processing=false, as this challenge has not been
-	// scheduled yet.
-	if ch.Status.Processing == false {
+	// scheduled yet. Challenges in a final state whose clean up has been
+	// deferred are no longer processing, but must still be cleaned up.
+	if ch.Status.Processing == false && !(acme.IsFinalState(ch.Status.State) && ch.Status.Presented) {
 		return nil
 	}
 
@@ -101,7 +131,25 @@ func (c *controller) Sync(ctx context.Context, ch *cmacme.Challenge) (err error)
 				return err
 			}
 
+			if ch.Status.State == cmacme.Valid && ch.Status.ValidatedAt == nil {
+				validatedAt := metav1.NewTime(c.clock.Now())
+				ch.Status.ValidatedAt = &validatedAt
+			}
+
 			err = solver.CleanUp(ctx, genericIssuer, ch)
+			if deferred, ok := err.(*http.CleanUpDeferredError); ok {
+				log.V(logf.DebugLevel).Info("deferring clean up of challenge", "retryAfter", deferred.RetryAfter)
+				key, err := controllerpkg.KeyFunc(ch)
+				// This is an unexpected edge case and should never occur
+				if err != nil {
+					return err
+				}
+				c.queue.AddAfter(key, deferred.RetryAfter)
+				// the challenge no longer needs to hold its processing slot
+				// whilst its solver resources are retained
+				ch.Status.Processing = false
+				return nil
+			}
 			if err != nil {
 				c.recorder.Eventf(ch, corev1.EventTypeWarning, "CleanUpError", "Error cleaning up challenge: %v", err)
 				ch.Status.Reason = err.Error()
@@ -110,6 +158,8 @@ func (c *controller) Sync(ctx context.Context, ch *cmacme.Challenge) (err error)
 			}
 
 			ch.Status.Presented = false
+			ch.Status.PresentedURL = ""
+			ch.Status.SolverService = ""
 		}
 
 		ch.Status.Processing = false
@@ -168,42 +218,129 @@ func (c *controller) Sync(ctx context.Context, ch *cmacme.Challenge) (err error)
 	}
 
 	if !ch.Status.Presented {
-		err := solver.Present(ctx, genericIssuer, ch)
+		needsPresent, err := c.needsPresent(ctx, cl, ch)
+		if err != nil {
+			return handleError(ch, err)
+		}
+		if !needsPresent {
+			// the change in the challenge's state will trigger a resync,
+			// which finishes processing it without presenting it
+			log.Info("authorization for challenge is already valid, skipping presenting it")
+			ch.Status.State = cmacme.Valid
+			ch.Status.Reason = "Authorization is already valid"
+			return nil
+		}
+
+		if ch.Status.PresentStartedAt == nil {
+			presentStartedAt := metav1.NewTime(c.clock.Now())
+			ch.Status.PresentStartedAt = &presentStartedAt
+		}
+		action, err := present(ctx, solver, genericIssuer, ch)
+		if http.IsTimeoutError(err) {
+			c.timedOut(ctx, ch, err)
+			return nil
+		}
+		if http.IsReconcileBudgetExceededError(err) {
+			// yield the worker to other challenges and carry on presenting
+			// this one later, without applying the error back-off
+			log.V(logf.DebugLevel).Info("presenting challenge exceeded reconcile budget, requeueing", "error", err)
+			ch.Status.Reason = err.Error()
+			return c.requeue(ch)
+		}
+		if http.IsIngressLimitReached(err) {
+			// the challenge is presented once earlier challenges in the
+			// namespace have been cleaned up, so it is retried periodically
+			// rather than with the error back-off
+			log.V(logf.DebugLevel).Info("solver ingress limit reached, requeueing", "error", err)
+			ch.Status.Reason = err.Error()
+			return c.requeue(ch)
+		}
 		if err != nil {
-			c.recorder.Eventf(ch, corev1.EventTypeWarning, "PresentError", "Error presenting challenge: %v", err)
+			// use the solver's reason for the failure if it has one, so that
+			// users can alert on specific failures
+			reason := "PresentError"
+			if r := http.FailureReasonFor(err); r != "" {
+				reason = string(r)
+			}
+			c.recorder.Eventf(ch, corev1.EventTypeWarning, reason, "Error presenting challenge: %v", err)
 			ch.Status.Reason = err.Error()
 			return err
 		}
 
 		ch.Status.Presented = true
+		recordDebugInfo(ctx, solver, ch)
 		c.recorder.Eventf(ch, corev1.EventTypeNormal, "Presented", "Presented challenge using %s challenge mechanism", ch.Spec.Type)
+
+		if action != http.IngressActionNone {
+			// give the ingress controller time to pick up the new or
+			// modified ingress rather than failing the first self check
+			log.V(logf.DebugLevel).Info("solver ingress changed, requeueing before checking challenge", "action", action)
+			return c.requeue(ch)
+		}
 	}
+	recordSolverAddress(ctx, solver, ch)
 
 	err = solver.Check(ctx, genericIssuer, ch)
+	if http.IsTimeoutError(err) {
+		c.timedOut(ctx, ch, err)
+		return nil
+	}
 	if err != nil {
-		log.Error(err, "propagation check failed")
+		log.Error(err, "propagation check failed", "reason", http.FailureReasonFor(err))
 		ch.Status.Reason = fmt.Sprintf("Waiting for %s challenge propagation: %s", ch.Spec.Type, err)
 
-		key, err := controllerpkg.KeyFunc(ch)
-		// This is an unexpected edge case and should never occur
-		if err != nil {
-			return err
-		}
+		return c.requeue(ch)
+	}
 
-		// retry after 10s
-		c.queue.AddAfter(key, time.Second*10)
+	err = c.acceptChallenge(ctx, cl, ch)
+	if err != nil {
+		return err
+	}
 
-		return nil
+	return nil
+}
+
+// present presents the given challenge using the given solver, returning the
+// change made to the solver ingress if the solver reports it.
+func present(ctx context.Context, s solver, issuer cmapi.GenericIssuer, ch *cmacme.Challenge) (http.IngressAction, error) {
+	if is, ok := s.(ingressSolver); ok {
+		return is.PresentIngress(ctx, issuer, ch)
 	}
+	return http.IngressActionNone, s.Present(ctx, issuer, ch)
+}
 
-	err = c.acceptChallenge(ctx, cl, ch)
+// timedOut marks the given challenge as errored after its solver timed out,
+// so that the order fails and is retried later, subject to the usual backoff.
+func (c *controller) timedOut(ctx context.Context, ch *cmacme.Challenge, err error) {
+	logf.FromContext(ctx).Error(err, "challenge timed out")
+	c.recorder.Eventf(ch, corev1.EventTypeWarning, "Timeout", "Challenge timed out: %v", err)
+	ch.Status.State = cmacme.Errored
+	ch.Status.Reason = err.Error()
+}
+
+// requeue adds the given challenge back to the queue after requeueDelay.
+func (c *controller) requeue(ch *cmacme.Challenge) error {
+	key, err := controllerpkg.KeyFunc(ch)
+	// This is an unexpected edge case and should never occur
 	if err != nil {
 		return err
 	}
 
+	c.queue.AddAfter(key, c.requeueDelay())
+
 	return nil
 }
 
+// requeueDelay returns how long to wait before re-checking a presented
+// challenge, adding up to requeueJitter of the requeue period at random so
+// that challenges created at the same time do not all retry at once.
+func (c *controller) requeueDelay() time.Duration {
+	if c.requeueJitter <= 0 {
+		return challengeRequeuePeriod
+	}
+	return wait.Jitter(challengeRequeuePeriod, c.requeueJitter)
+}
+
 // handleError will handle ACME error types, updating the challenge resource
 // with any new information found whilst inspecting the error response.
 // This may include marking the challenge as expired.
@@ -240,6 +377,10 @@ func handleError(ch *cmacme.Challenge, err error) error {
 
 // handleFinalizer will attempt to 'finalize' the Challenge resource by calling
 // CleanUp if the resource is in a 'processing' state.
+// The finalizer is only removed once CleanUp has succeeded, so that resources
+// created to solve the challenge are not orphaned. If the challenge cannot be
+// cleaned up because its issuer or solver no longer exists, the finalizer is
+// removed so that deletion is not blocked forever.
 func (c *controller) handleFinalizer(ctx context.Context, ch *cmacme.Challenge) (err error) {
 	log := logf.FromContext(ctx, "finalizer")
 	if len(ch.Finalizers) == 0 {
@@ -257,6 +398,10 @@ func (c *controller) handleFinalizer(ctx context.Context, ch *cmacme.Challenge)
 			err = utilerrors.NewAggregate([]error{err, updateErr})
 			return
 		}
+		// retain the finalizer so that clean up is retried
+		if err != nil {
+			return
+		}
 		// call Update to remove the metadata.finalizers entry
 		ch.Finalizers = ch.Finalizers[1:]
 		_, updateErr = c.cmClient.AcmeV1alpha2().Challenges(ch.Namespace).Update(ch)
@@ -271,6 +416,10 @@ func (c *controller) handleFinalizer(ctx context.Context, ch *cmacme.Challenge)
 	}
 
 	genericIssuer, err := c.helper.GetGenericIssuer(ch.Spec.IssuerRef, ch.Namespace)
+	if k8sErrors.IsNotFound(err) {
+		log.Error(err, "issuer not found, skipping challenge clean up")
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("error reading (cluster)issuer %q: %v", ch.Spec.IssuerRef.Name, err)
 	}
@@ -286,7 +435,7 @@ func (c *controller) handleFinalizer(ctx context.Context, ch *cmacme.Challenge)
 		c.recorder.Eventf(ch, corev1.EventTypeWarning, "CleanUpError", "Error cleaning up challenge: %v", err)
 		ch.Status.Reason = err.Error()
 		log.Error(err, "error cleaning up challenge")
-		return nil
+		return err
 	}
 
 	return nil
@@ -352,7 +501,7 @@ func (c *controller) acceptChallenge(ctx context.Context, cl acmecl.Interface, c
 	}
 
 	log.Info("waiting for authorization for domain")
-	authorization, err := cl.WaitAuthorization(ctx, ch.Spec.AuthzURL)
+	authorization, err := c.waitAuthorization(ctx, cl, ch.Spec.AuthzURL)
 	if err != nil {
 		log.Error(err, "error waiting for authorization")
 		return c.handleAuthorizationError(ch, err)
@@ -365,6 +514,96 @@ func (c *controller) acceptChallenge(ctx context.Context, cl acmecl.Interface, c
 	return nil
 }
 
+// authorizationBackoff configures how often the ACME server is polled for the
+// state of an authorization after a challenge has been accepted.
+type authorizationBackoff struct {
+	// Interval is the delay before a pending authorization is first polled
+	// again. If zero, the ACME client's own polling behaviour is used, which
+	// waits for the server's Retry-After period or one second.
+	Interval time.Duration
+
+	// Factor multiplies the delay after each poll of a pending authorization.
+	// Values less than 1 are treated as 1.
+	Factor float64
+
+	// MaxInterval caps the delay between polls. If zero, the delay is not
+	// capped.
+	MaxInterval time.Duration
+}
+
+// next returns the delay to wait after a poll that followed a delay of d.
+func (b authorizationBackoff) next(d time.Duration) time.Duration {
+	if b.Factor > 1 {
+		d = time.Duration(float64(d) * b.Factor)
+	}
+	if b.MaxInterval > 0 && d > b.MaxInterval {
+		d = b.MaxInterval
+	}
+	return d
+}
+
+// needsPresent returns false if the authorization the given challenge
+// belongs to is already valid, e.g. because the ACME server reused an
+// authorization that was validated for an earlier order, in which case
+// presenting the challenge would only create solver resources that are
+// immediately cleaned up again.
+func (c *controller) needsPresent(ctx context.Context, cl acmecl.Interface, ch *cmacme.Challenge) (bool, error) {
+	if ch.Spec.AuthzURL == "" {
+		return true, nil
+	}
+	authz, err := cl.GetAuthorization(ctx, ch.Spec.AuthzURL)
+	if err != nil {
+		return false, err
+	}
+	return authz.Status != acmeapi.StatusValid, nil
+}
+
+// waitAuthorization polls the authorization at the given URL until it is
+// valid or invalid, or the context is done, waiting between polls according
+// to the controller's authorization backoff.
+// As with WaitAuthorization, an *acmeapi.AuthorizationError is returned if the
+// authorization is invalid.
+func (c *controller) waitAuthorization(ctx context.Context, cl acmecl.Interface, url string) (*acmeapi.Authorization, error) {
+	if c.authzBackoff.Interval <= 0 {
+		return cl.WaitAuthorization(ctx, url)
+	}
+
+	delay := c.authzBackoff.Interval
+	if c.authzBackoff.MaxInterval > 0 && delay > c.authzBackoff.MaxInterval {
+		delay = c.authzBackoff.MaxInterval
+	}
+	for {
+		authz, err := cl.GetAuthorization(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		switch authz.Status {
+		case acmeapi.StatusValid:
+			return authz, nil
+		case acmeapi.StatusInvalid:
+			authErr := &acmeapi.AuthorizationError{
+				URI:        url,
+				Identifier: authz.Identifier.Value,
+			}
+			for _, chal := range authz.Challenges {
+				if chal.Error != nil {
+					authErr.Errors = append(authErr.Errors, chal.Error)
+				}
+			}
+			return nil, authErr
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+		delay = c.authzBackoff.next(delay)
+	}
+}
+
 func (c *controller) handleAuthorizationError(ch *cmacme.Challenge, err error) error {
 	authErr, ok := err.(*acmeapi.AuthorizationError)
 	if !ok {
@@ -392,6 +631,44 @@ func (c *controller) solverFor(challengeType cmacme.ACMEChallengeType) (solver,
 		return c.httpSolver, nil
 	case "dns-01":
 		return c.dnsSolver, nil
+	case "tls-alpn-01":
+		return c.tlsALPNSolver, nil
 	}
 	return nil, fmt.Errorf("no solver for %q implemented", challengeType)
 }
+
+// recordDebugInfo records the URL a presented challenge is served at, and the
+// service requests for it are routed to, on the challenge's status if the
+// solver supports it.
+func recordDebugInfo(ctx context.Context, s solver, ch *cmacme.Challenge) {
+	ds, ok := s.(debugSolver)
+	if !ok {
+		return
+	}
+	ch.Status.PresentedURL = ds.PresentedURL(ch)
+	svc, err := ds.SolverService(ctx, ch)
+	if err != nil {
+		logf.FromContext(ctx).V(logf.DebugLevel).Info("failed to determine solver service for challenge", "error", err)
+		return
+	}
+	ch.Status.SolverService = svc
+}
+
+// recordSolverAddress records the address a presented challenge is served at
+// by the ingress controller on the challenge's status if the solver supports
+// it. The address is only assigned some time after the challenge has been
+// presented, so this is done on every sync until it is known.
+func recordSolverAddress(ctx context.Context, s solver, ch *cmacme.Challenge) {
+	ds, ok := s.(debugSolver)
+	if !ok {
+		return
+	}
+	addr, err := ds.SolverAddress(ctx, ch)
+	if err != nil {
+		logf.FromContext(ctx).V(logf.DebugLevel).Info("failed to determine solver address for challenge", "error", err)
+		return
+	}
+	if addr != "" {
+		ch.Status.SolverAddress = addr
+	}
+}
@@ -20,10 +20,14 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	acmeapi "golang.org/x/crypto/acme"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	coretesting "k8s.io/client-go/testing"
+	fakeclock "k8s.io/utils/clock/testing"
 
 	acmecl "github.com/jetstack/cert-manager/pkg/acme/client"
 	acmefake "github.com/jetstack/cert-manager/pkg/acme/fake"
@@ -32,6 +36,7 @@ import (
 	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
 	testpkg "github.com/jetstack/cert-manager/pkg/controller/test"
 	"github.com/jetstack/cert-manager/pkg/issuer"
+	"github.com/jetstack/cert-manager/pkg/issuer/acme/http"
 	"github.com/jetstack/cert-manager/test/unit/gen"
 )
 
@@ -60,16 +65,47 @@ type fakeSolver struct {
 	fakeCleanUp func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error
 }
 
+// fakeDebugSolver is a fakeSolver that also implements debugSolver.
+type fakeDebugSolver struct {
+	*fakeSolver
+	presentedURL  string
+	solverService string
+	solverAddress string
+}
+
+func (f *fakeDebugSolver) PresentedURL(ch *cmacme.Challenge) string {
+	return f.presentedURL
+}
+
+func (f *fakeDebugSolver) SolverService(ctx context.Context, ch *cmacme.Challenge) (string, error) {
+	return f.solverService, nil
+}
+
+func (f *fakeDebugSolver) SolverAddress(ctx context.Context, ch *cmacme.Challenge) (string, error) {
+	return f.solverAddress, nil
+}
+
+// fakeIngressSolver is a fakeSolver that also implements ingressSolver.
+type fakeIngressSolver struct {
+	*fakeSolver
+	action http.IngressAction
+}
+
+func (f *fakeIngressSolver) PresentIngress(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) (http.IngressAction, error) {
+	return f.action, f.Present(ctx, issuer, ch)
+}
+
 type testT struct {
 	challenge  *cmacme.Challenge
 	builder    *testpkg.Builder
-	httpSolver *fakeSolver
-	dnsSolver  *fakeSolver
+	httpSolver solver
+	dnsSolver  solver
 	expectErr  bool
 	acmeClient *acmecl.FakeACME
 }
 
 func TestSyncHappyPath(t *testing.T) {
+	fixedClock := fakeclock.NewFakeClock(time.Now())
 	testIssuerHTTP01Enabled := gen.Issuer("testissuer", gen.SetIssuerACME(cmacme.ACMEIssuer{
 		Solvers: []cmacme.ACMEChallengeSolver{
 			{
@@ -130,6 +166,137 @@ func TestSyncHappyPath(t *testing.T) {
 				},
 			},
 			builder: &testpkg.Builder{
+				Clock: fixedClock,
+				CertManagerObjects: []runtime.Object{gen.ChallengeFrom(baseChallenge,
+					gen.SetChallengeProcessing(true),
+					gen.SetChallengeURL("testurl"),
+					gen.SetChallengeState(cmacme.Pending),
+					gen.SetChallengeType("http-01"),
+				), testIssuerHTTP01Enabled},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(cmacme.SchemeGroupVersion.WithResource("challenges"),
+						"status",
+						gen.DefaultTestNamespace,
+						gen.ChallengeFrom(baseChallenge,
+							gen.SetChallengePresentStartedAt(metav1.NewTime(fixedClock.Now())),
+							gen.SetChallengeProcessing(true),
+							gen.SetChallengeURL("testurl"),
+							gen.SetChallengeState(cmacme.Pending),
+							gen.SetChallengePresented(true),
+							gen.SetChallengeType("http-01"),
+							gen.SetChallengeReason("Waiting for http-01 challenge propagation: some error"),
+						))),
+				},
+				ExpectedEvents: []string{
+					"Normal Presented Presented challenge using http-01 challenge mechanism",
+				},
+			},
+		},
+		"skip presenting the challenge if its authorization is already valid": {
+			challenge: gen.ChallengeFrom(baseChallenge,
+				gen.SetChallengeProcessing(true),
+				gen.SetChallengeURL("testurl"),
+				gen.SetChallengeAuthzURL("testauthzurl"),
+				gen.SetChallengeState(cmacme.Pending),
+				gen.SetChallengeType("http-01"),
+			),
+			httpSolver: &fakeSolver{
+				fakePresent: func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+					return fmt.Errorf("unexpected call to Present")
+				},
+			},
+			builder: &testpkg.Builder{
+				CertManagerObjects: []runtime.Object{gen.ChallengeFrom(baseChallenge,
+					gen.SetChallengeProcessing(true),
+					gen.SetChallengeURL("testurl"),
+					gen.SetChallengeAuthzURL("testauthzurl"),
+					gen.SetChallengeState(cmacme.Pending),
+					gen.SetChallengeType("http-01"),
+				), testIssuerHTTP01Enabled},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(cmacme.SchemeGroupVersion.WithResource("challenges"),
+						"status",
+						gen.DefaultTestNamespace,
+						gen.ChallengeFrom(baseChallenge,
+							gen.SetChallengeProcessing(true),
+							gen.SetChallengeURL("testurl"),
+							gen.SetChallengeAuthzURL("testauthzurl"),
+							gen.SetChallengeState(cmacme.Valid),
+							gen.SetChallengeType("http-01"),
+							gen.SetChallengeReason("Authorization is already valid"),
+						))),
+				},
+			},
+			acmeClient: &acmecl.FakeACME{
+				FakeGetAuthorization: func(ctx context.Context, url string) (*acmeapi.Authorization, error) {
+					return &acmeapi.Authorization{Status: acmeapi.StatusValid}, nil
+				},
+			},
+		},
+		"call Present if the authorization of the challenge is pending": {
+			challenge: gen.ChallengeFrom(baseChallenge,
+				gen.SetChallengeProcessing(true),
+				gen.SetChallengeURL("testurl"),
+				gen.SetChallengeAuthzURL("testauthzurl"),
+				gen.SetChallengeState(cmacme.Pending),
+				gen.SetChallengeType("http-01"),
+			),
+			httpSolver: &fakeSolver{
+				fakePresent: func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+					return nil
+				},
+				fakeCheck: func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+					return fmt.Errorf("some error")
+				},
+			},
+			builder: &testpkg.Builder{
+				Clock: fixedClock,
+				CertManagerObjects: []runtime.Object{gen.ChallengeFrom(baseChallenge,
+					gen.SetChallengeProcessing(true),
+					gen.SetChallengeURL("testurl"),
+					gen.SetChallengeAuthzURL("testauthzurl"),
+					gen.SetChallengeState(cmacme.Pending),
+					gen.SetChallengeType("http-01"),
+				), testIssuerHTTP01Enabled},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(cmacme.SchemeGroupVersion.WithResource("challenges"),
+						"status",
+						gen.DefaultTestNamespace,
+						gen.ChallengeFrom(baseChallenge,
+							gen.SetChallengePresentStartedAt(metav1.NewTime(fixedClock.Now())),
+							gen.SetChallengeProcessing(true),
+							gen.SetChallengeURL("testurl"),
+							gen.SetChallengeAuthzURL("testauthzurl"),
+							gen.SetChallengeState(cmacme.Pending),
+							gen.SetChallengePresented(true),
+							gen.SetChallengeType("http-01"),
+							gen.SetChallengeReason("Waiting for http-01 challenge propagation: some error"),
+						))),
+				},
+				ExpectedEvents: []string{
+					"Normal Presented Presented challenge using http-01 challenge mechanism",
+				},
+			},
+			acmeClient: &acmecl.FakeACME{
+				FakeGetAuthorization: func(ctx context.Context, url string) (*acmeapi.Authorization, error) {
+					return &acmeapi.Authorization{Status: acmeapi.StatusPending}, nil
+				},
+			},
+		},
+		"use the failure reason of the solver as the reason of the event if Present fails": {
+			challenge: gen.ChallengeFrom(baseChallenge,
+				gen.SetChallengeProcessing(true),
+				gen.SetChallengeURL("testurl"),
+				gen.SetChallengeState(cmacme.Pending),
+				gen.SetChallengeType("http-01"),
+			),
+			httpSolver: &fakeSolver{
+				fakePresent: func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+					return &http.SolverError{Reason: http.FailureReasonPathInjectionDenied, Err: fmt.Errorf("some error")}
+				},
+			},
+			builder: &testpkg.Builder{
+				Clock: fixedClock,
 				CertManagerObjects: []runtime.Object{gen.ChallengeFrom(baseChallenge,
 					gen.SetChallengeProcessing(true),
 					gen.SetChallengeURL("testurl"),
@@ -141,10 +308,59 @@ func TestSyncHappyPath(t *testing.T) {
 						"status",
 						gen.DefaultTestNamespace,
 						gen.ChallengeFrom(baseChallenge,
+							gen.SetChallengePresentStartedAt(metav1.NewTime(fixedClock.Now())),
+							gen.SetChallengeProcessing(true),
+							gen.SetChallengeURL("testurl"),
+							gen.SetChallengeState(cmacme.Pending),
+							gen.SetChallengeType("http-01"),
+							gen.SetChallengeReason("some error"),
+						))),
+				},
+				ExpectedEvents: []string{
+					"Warning PathInjectionDenied Error presenting challenge: some error",
+				},
+			},
+			expectErr: true,
+		},
+		"record the presented URL and solver service if supported by the solver": {
+			challenge: gen.ChallengeFrom(baseChallenge,
+				gen.SetChallengeProcessing(true),
+				gen.SetChallengeURL("testurl"),
+				gen.SetChallengeState(cmacme.Pending),
+				gen.SetChallengeType("http-01"),
+			),
+			httpSolver: &fakeDebugSolver{
+				fakeSolver: &fakeSolver{
+					fakePresent: func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+						return nil
+					},
+					fakeCheck: func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+						return fmt.Errorf("some error")
+					},
+				},
+				presentedURL:  "http://example.com/.well-known/acme-challenge/token",
+				solverService: "cm-acme-http-solver-abcde:8089",
+			},
+			builder: &testpkg.Builder{
+				Clock: fixedClock,
+				CertManagerObjects: []runtime.Object{gen.ChallengeFrom(baseChallenge,
+					gen.SetChallengeProcessing(true),
+					gen.SetChallengeURL("testurl"),
+					gen.SetChallengeState(cmacme.Pending),
+					gen.SetChallengeType("http-01"),
+				), testIssuerHTTP01Enabled},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(cmacme.SchemeGroupVersion.WithResource("challenges"),
+						"status",
+						gen.DefaultTestNamespace,
+						gen.ChallengeFrom(baseChallenge,
+							gen.SetChallengePresentStartedAt(metav1.NewTime(fixedClock.Now())),
 							gen.SetChallengeProcessing(true),
 							gen.SetChallengeURL("testurl"),
 							gen.SetChallengeState(cmacme.Pending),
 							gen.SetChallengePresented(true),
+							gen.SetChallengePresentedURL("http://example.com/.well-known/acme-challenge/token"),
+							gen.SetChallengeSolverService("cm-acme-http-solver-abcde:8089"),
 							gen.SetChallengeType("http-01"),
 							gen.SetChallengeReason("Waiting for http-01 challenge propagation: some error"),
 						))),
@@ -154,6 +370,239 @@ func TestSyncHappyPath(t *testing.T) {
 				},
 			},
 		},
+		"record the solver address once assigned if supported by the solver": {
+			challenge: gen.ChallengeFrom(baseChallenge,
+				gen.SetChallengeProcessing(true),
+				gen.SetChallengeURL("testurl"),
+				gen.SetChallengeState(cmacme.Pending),
+				gen.SetChallengeType("http-01"),
+				gen.SetChallengePresented(true),
+			),
+			httpSolver: &fakeDebugSolver{
+				fakeSolver: &fakeSolver{
+					fakeCheck: func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+						return fmt.Errorf("some error")
+					},
+				},
+				solverAddress: "1.2.3.4",
+			},
+			builder: &testpkg.Builder{
+				CertManagerObjects: []runtime.Object{gen.ChallengeFrom(baseChallenge,
+					gen.SetChallengeProcessing(true),
+					gen.SetChallengeURL("testurl"),
+					gen.SetChallengeState(cmacme.Pending),
+					gen.SetChallengeType("http-01"),
+					gen.SetChallengePresented(true),
+				), testIssuerHTTP01Enabled},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(cmacme.SchemeGroupVersion.WithResource("challenges"),
+						"status",
+						gen.DefaultTestNamespace,
+						gen.ChallengeFrom(baseChallenge,
+							gen.SetChallengeProcessing(true),
+							gen.SetChallengeURL("testurl"),
+							gen.SetChallengeState(cmacme.Pending),
+							gen.SetChallengePresented(true),
+							gen.SetChallengeSolverAddress("1.2.3.4"),
+							gen.SetChallengeType("http-01"),
+							gen.SetChallengeReason("Waiting for http-01 challenge propagation: some error"),
+						))),
+				},
+			},
+		},
+		"requeue the challenge without checking it if presenting it created a solver ingress": {
+			challenge: gen.ChallengeFrom(baseChallenge,
+				gen.SetChallengeProcessing(true),
+				gen.SetChallengeURL("testurl"),
+				gen.SetChallengeState(cmacme.Pending),
+				gen.SetChallengeType("http-01"),
+			),
+			httpSolver: &fakeIngressSolver{
+				fakeSolver: &fakeSolver{
+					fakePresent: func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+						return nil
+					},
+					fakeCheck: func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+						return fmt.Errorf("unexpected check")
+					},
+				},
+				action: http.IngressActionCreated,
+			},
+			builder: &testpkg.Builder{
+				Clock: fixedClock,
+				CertManagerObjects: []runtime.Object{gen.ChallengeFrom(baseChallenge,
+					gen.SetChallengeProcessing(true),
+					gen.SetChallengeURL("testurl"),
+					gen.SetChallengeState(cmacme.Pending),
+					gen.SetChallengeType("http-01"),
+				), testIssuerHTTP01Enabled},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(cmacme.SchemeGroupVersion.WithResource("challenges"),
+						"status",
+						gen.DefaultTestNamespace,
+						gen.ChallengeFrom(baseChallenge,
+							gen.SetChallengePresentStartedAt(metav1.NewTime(fixedClock.Now())),
+							gen.SetChallengeProcessing(true),
+							gen.SetChallengeURL("testurl"),
+							gen.SetChallengeState(cmacme.Pending),
+							gen.SetChallengePresented(true),
+							gen.SetChallengeType("http-01"),
+						))),
+				},
+				ExpectedEvents: []string{
+					"Normal Presented Presented challenge using http-01 challenge mechanism",
+				},
+			},
+		},
+		"requeue the challenge without presenting it if Present exceeds the reconcile budget": {
+			challenge: gen.ChallengeFrom(baseChallenge,
+				gen.SetChallengeProcessing(true),
+				gen.SetChallengeURL("testurl"),
+				gen.SetChallengeState(cmacme.Pending),
+				gen.SetChallengeType("http-01"),
+			),
+			httpSolver: &fakeSolver{
+				fakePresent: func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+					return &http.ReconcileBudgetExceededError{Budget: 30 * time.Second}
+				},
+			},
+			builder: &testpkg.Builder{
+				Clock: fixedClock,
+				CertManagerObjects: []runtime.Object{gen.ChallengeFrom(baseChallenge,
+					gen.SetChallengeProcessing(true),
+					gen.SetChallengeURL("testurl"),
+					gen.SetChallengeState(cmacme.Pending),
+					gen.SetChallengeType("http-01"),
+				), testIssuerHTTP01Enabled},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(cmacme.SchemeGroupVersion.WithResource("challenges"),
+						"status",
+						gen.DefaultTestNamespace,
+						gen.ChallengeFrom(baseChallenge,
+							gen.SetChallengePresentStartedAt(metav1.NewTime(fixedClock.Now())),
+							gen.SetChallengeProcessing(true),
+							gen.SetChallengeURL("testurl"),
+							gen.SetChallengeState(cmacme.Pending),
+							gen.SetChallengeType("http-01"),
+							gen.SetChallengeReason("HTTP01 solver did not complete within its reconcile budget of 30s, retrying later"),
+						))),
+				},
+			},
+		},
+		"requeue the challenge without presenting it if the solver ingress limit is reached": {
+			challenge: gen.ChallengeFrom(baseChallenge,
+				gen.SetChallengeProcessing(true),
+				gen.SetChallengeURL("testurl"),
+				gen.SetChallengeState(cmacme.Pending),
+				gen.SetChallengeType("http-01"),
+			),
+			httpSolver: &fakeSolver{
+				fakePresent: func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+					return utilerrors.NewAggregate([]error{nil, http.ErrIngressLimitReached})
+				},
+			},
+			builder: &testpkg.Builder{
+				Clock: fixedClock,
+				CertManagerObjects: []runtime.Object{gen.ChallengeFrom(baseChallenge,
+					gen.SetChallengeProcessing(true),
+					gen.SetChallengeURL("testurl"),
+					gen.SetChallengeState(cmacme.Pending),
+					gen.SetChallengeType("http-01"),
+				), testIssuerHTTP01Enabled},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(cmacme.SchemeGroupVersion.WithResource("challenges"),
+						"status",
+						gen.DefaultTestNamespace,
+						gen.ChallengeFrom(baseChallenge,
+							gen.SetChallengePresentStartedAt(metav1.NewTime(fixedClock.Now())),
+							gen.SetChallengeProcessing(true),
+							gen.SetChallengeURL("testurl"),
+							gen.SetChallengeState(cmacme.Pending),
+							gen.SetChallengeType("http-01"),
+							gen.SetChallengeReason(http.ErrIngressLimitReached.Error()),
+						))),
+				},
+			},
+		},
+		"mark the challenge as errored if the solver times out": {
+			challenge: gen.ChallengeFrom(baseChallenge,
+				gen.SetChallengeProcessing(true),
+				gen.SetChallengeURL("testurl"),
+				gen.SetChallengeState(cmacme.Pending),
+				gen.SetChallengeType("http-01"),
+				gen.SetChallengePresented(true),
+			),
+			httpSolver: &fakeSolver{
+				fakeCheck: func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+					return &http.TimeoutError{Timeout: 5 * time.Minute}
+				},
+			},
+			builder: &testpkg.Builder{
+				CertManagerObjects: []runtime.Object{gen.ChallengeFrom(baseChallenge,
+					gen.SetChallengeProcessing(true),
+					gen.SetChallengeURL("testurl"),
+					gen.SetChallengeState(cmacme.Pending),
+					gen.SetChallengeType("http-01"),
+					gen.SetChallengePresented(true),
+				), testIssuerHTTP01Enabled},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(cmacme.SchemeGroupVersion.WithResource("challenges"),
+						"status",
+						gen.DefaultTestNamespace,
+						gen.ChallengeFrom(baseChallenge,
+							gen.SetChallengeProcessing(true),
+							gen.SetChallengeURL("testurl"),
+							gen.SetChallengeState(cmacme.Errored),
+							gen.SetChallengeType("http-01"),
+							gen.SetChallengePresented(true),
+							gen.SetChallengeReason("timed out after 5m0s waiting for HTTP01 challenge to be presented and pass the self check"),
+						))),
+				},
+				ExpectedEvents: []string{
+					"Warning Timeout Challenge timed out: timed out after 5m0s waiting for HTTP01 challenge to be presented and pass the self check",
+				},
+			},
+		},
+		"mark the challenge as errored if the solver times out while presenting it": {
+			challenge: gen.ChallengeFrom(baseChallenge,
+				gen.SetChallengeProcessing(true),
+				gen.SetChallengeURL("testurl"),
+				gen.SetChallengeState(cmacme.Pending),
+				gen.SetChallengeType("http-01"),
+				gen.SetChallengePresentStartedAt(metav1.NewTime(fixedClock.Now().Add(-10*time.Minute))),
+			),
+			httpSolver: &fakeSolver{
+				fakePresent: func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+					return &http.TimeoutError{Timeout: 5 * time.Minute}
+				},
+			},
+			builder: &testpkg.Builder{
+				Clock: fixedClock,
+				CertManagerObjects: []runtime.Object{gen.ChallengeFrom(baseChallenge,
+					gen.SetChallengeProcessing(true),
+					gen.SetChallengeURL("testurl"),
+					gen.SetChallengeState(cmacme.Pending),
+					gen.SetChallengeType("http-01"),
+					gen.SetChallengePresentStartedAt(metav1.NewTime(fixedClock.Now().Add(-10*time.Minute))),
+				), testIssuerHTTP01Enabled},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(cmacme.SchemeGroupVersion.WithResource("challenges"),
+						"status",
+						gen.DefaultTestNamespace,
+						gen.ChallengeFrom(baseChallenge,
+							gen.SetChallengePresentStartedAt(metav1.NewTime(fixedClock.Now().Add(-10*time.Minute))),
+							gen.SetChallengeProcessing(true),
+							gen.SetChallengeURL("testurl"),
+							gen.SetChallengeState(cmacme.Errored),
+							gen.SetChallengeType("http-01"),
+							gen.SetChallengeReason("timed out after 5m0s waiting for HTTP01 challenge to be presented and pass the self check"),
+						))),
+				},
+				ExpectedEvents: []string{
+					"Warning Timeout Challenge timed out: timed out after 5m0s waiting for HTTP01 challenge to be presented and pass the self check",
+				},
+			},
+		},
 		"accept the challenge if the self check is passing": {
 			challenge: gen.ChallengeFrom(baseChallenge,
 				gen.SetChallengeProcessing(true),
@@ -346,6 +795,7 @@ func TestSyncHappyPath(t *testing.T) {
 				},
 			},
 			builder: &testpkg.Builder{
+				Clock: fixedClock,
 				CertManagerObjects: []runtime.Object{gen.ChallengeFrom(baseChallenge,
 					gen.SetChallengeProcessing(true),
 					gen.SetChallengeURL("testurl"),
@@ -363,10 +813,92 @@ func TestSyncHappyPath(t *testing.T) {
 							gen.SetChallengeState(cmacme.Valid),
 							gen.SetChallengeType("http-01"),
 							gen.SetChallengePresented(false),
+							gen.SetChallengeValidatedAt(metav1.NewTime(fixedClock.Now())),
 						))),
 				},
 			},
 		},
+		"record the validation time and release processing if clean up is deferred": {
+			challenge: gen.ChallengeFrom(baseChallenge,
+				gen.SetChallengeProcessing(true),
+				gen.SetChallengeURL("testurl"),
+				gen.SetChallengeState(cmacme.Valid),
+				gen.SetChallengeType("http-01"),
+				gen.SetChallengePresented(true),
+			),
+			httpSolver: &fakeSolver{
+				fakeCleanUp: func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+					if ch.Status.ValidatedAt == nil || !ch.Status.ValidatedAt.Time.Equal(fixedClock.Now()) {
+						return fmt.Errorf("expected validation time to be recorded before clean up, got %v", ch.Status.ValidatedAt)
+					}
+					return &http.CleanUpDeferredError{RetryAfter: time.Minute}
+				},
+			},
+			builder: &testpkg.Builder{
+				Clock: fixedClock,
+				CertManagerObjects: []runtime.Object{gen.ChallengeFrom(baseChallenge,
+					gen.SetChallengeProcessing(true),
+					gen.SetChallengeURL("testurl"),
+					gen.SetChallengeState(cmacme.Valid),
+					gen.SetChallengeType("http-01"),
+					gen.SetChallengePresented(true),
+				), testIssuerHTTP01Enabled},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(
+						coretesting.NewUpdateSubresourceAction(cmacme.SchemeGroupVersion.WithResource("challenges"),
+							"status",
+							gen.DefaultTestNamespace,
+							gen.ChallengeFrom(baseChallenge,
+								gen.SetChallengeProcessing(false),
+								gen.SetChallengeURL("testurl"),
+								gen.SetChallengeState(cmacme.Valid),
+								gen.SetChallengeType("http-01"),
+								gen.SetChallengePresented(true),
+								gen.SetChallengeValidatedAt(metav1.NewTime(fixedClock.Now())),
+							))),
+				},
+			},
+		},
+		"clean up a valid challenge that is no longer processing once clean up is no longer deferred": {
+			challenge: gen.ChallengeFrom(baseChallenge,
+				gen.SetChallengeProcessing(false),
+				gen.SetChallengeURL("testurl"),
+				gen.SetChallengeState(cmacme.Valid),
+				gen.SetChallengeType("http-01"),
+				gen.SetChallengePresented(true),
+				gen.SetChallengeValidatedAt(metav1.NewTime(fixedClock.Now())),
+			),
+			httpSolver: &fakeSolver{
+				fakeCleanUp: func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+					return nil
+				},
+			},
+			builder: &testpkg.Builder{
+				Clock: fixedClock,
+				CertManagerObjects: []runtime.Object{gen.ChallengeFrom(baseChallenge,
+					gen.SetChallengeProcessing(false),
+					gen.SetChallengeURL("testurl"),
+					gen.SetChallengeState(cmacme.Valid),
+					gen.SetChallengeType("http-01"),
+					gen.SetChallengePresented(true),
+					gen.SetChallengeValidatedAt(metav1.NewTime(fixedClock.Now())),
+				), testIssuerHTTP01Enabled},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(
+						coretesting.NewUpdateSubresourceAction(cmacme.SchemeGroupVersion.WithResource("challenges"),
+							"status",
+							gen.DefaultTestNamespace,
+							gen.ChallengeFrom(baseChallenge,
+								gen.SetChallengeProcessing(false),
+								gen.SetChallengeURL("testurl"),
+								gen.SetChallengeState(cmacme.Valid),
+								gen.SetChallengeType("http-01"),
+								gen.SetChallengePresented(false),
+								gen.SetChallengeValidatedAt(metav1.NewTime(fixedClock.Now())),
+							))),
+				},
+			},
+		},
 		"mark the challenge as not processing if it is already failed": {
 			challenge: gen.ChallengeFrom(baseChallenge,
 				gen.SetChallengeProcessing(true),
@@ -411,6 +943,101 @@ func TestSyncHappyPath(t *testing.T) {
 	}
 }
 
+func TestSyncFinalizer(t *testing.T) {
+	testIssuerHTTP01Enabled := gen.Issuer("testissuer", gen.SetIssuerACME(cmacme.ACMEIssuer{
+		Solvers: []cmacme.ACMEChallengeSolver{
+			{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+				},
+			},
+		},
+	}))
+	deletingChallenge := gen.Challenge("testchal",
+		gen.SetChallengeIssuer(cmmeta.ObjectReference{
+			Name: "testissuer",
+		}),
+		gen.SetChallengeProcessing(true),
+		gen.SetChallengeURL("testurl"),
+		gen.SetChallengeState(cmacme.Pending),
+		gen.SetChallengeType("http-01"),
+		gen.SetChallengePresented(true),
+		gen.SetChallengeFinalizers([]string{cmacme.ACMEFinalizer}),
+		gen.SetChallengeDeletionTimestamp(metav1.Now()),
+	)
+
+	tests := map[string]testT{
+		"remove the finalizer once clean up succeeds": {
+			challenge: deletingChallenge.DeepCopy(),
+			httpSolver: &fakeSolver{
+				fakeCleanUp: func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+					return nil
+				},
+			},
+			builder: &testpkg.Builder{
+				CertManagerObjects: []runtime.Object{deletingChallenge.DeepCopy(), testIssuerHTTP01Enabled},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(cmacme.SchemeGroupVersion.WithResource("challenges"),
+						"status",
+						gen.DefaultTestNamespace,
+						deletingChallenge.DeepCopy())),
+					testpkg.NewAction(coretesting.NewUpdateAction(cmacme.SchemeGroupVersion.WithResource("challenges"),
+						gen.DefaultTestNamespace,
+						gen.ChallengeFrom(deletingChallenge,
+							gen.SetChallengeFinalizers([]string{}),
+						))),
+				},
+			},
+		},
+		"retain the finalizer and retry if clean up fails": {
+			challenge: deletingChallenge.DeepCopy(),
+			httpSolver: &fakeSolver{
+				fakeCleanUp: func(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+					return fmt.Errorf("some error")
+				},
+			},
+			builder: &testpkg.Builder{
+				CertManagerObjects: []runtime.Object{deletingChallenge.DeepCopy(), testIssuerHTTP01Enabled},
+				ExpectedEvents: []string{
+					"Warning CleanUpError Error cleaning up challenge: some error",
+				},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(cmacme.SchemeGroupVersion.WithResource("challenges"),
+						"status",
+						gen.DefaultTestNamespace,
+						gen.ChallengeFrom(deletingChallenge,
+							gen.SetChallengeReason("some error"),
+						))),
+				},
+			},
+			expectErr: true,
+		},
+		"remove the finalizer without clean up if the issuer no longer exists": {
+			challenge: deletingChallenge.DeepCopy(),
+			builder: &testpkg.Builder{
+				CertManagerObjects: []runtime.Object{deletingChallenge.DeepCopy()},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(cmacme.SchemeGroupVersion.WithResource("challenges"),
+						"status",
+						gen.DefaultTestNamespace,
+						deletingChallenge.DeepCopy())),
+					testpkg.NewAction(coretesting.NewUpdateAction(cmacme.SchemeGroupVersion.WithResource("challenges"),
+						gen.DefaultTestNamespace,
+						gen.ChallengeFrom(deletingChallenge,
+							gen.SetChallengeFinalizers([]string{}),
+						))),
+				},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			runTest(t, test)
+		})
+	}
+}
+
 func runTest(t *testing.T, test testT) {
 	test.builder.T = t
 	test.builder.Init()
@@ -441,3 +1068,126 @@ func runTest(t *testing.T, test testT) {
 
 	test.builder.CheckAndFinish(err)
 }
+
+func TestRequeueDelay(t *testing.T) {
+	c := &controller{}
+	if d := c.requeueDelay(); d != challengeRequeuePeriod {
+		t.Errorf("expected requeue delay %s without jitter but got %s", challengeRequeuePeriod, d)
+	}
+
+	c.requeueJitter = 0.5
+	max := challengeRequeuePeriod + challengeRequeuePeriod/2
+	for i := 0; i < 100; i++ {
+		if d := c.requeueDelay(); d < challengeRequeuePeriod || d > max {
+			t.Fatalf("expected requeue delay between %s and %s but got %s", challengeRequeuePeriod, max, d)
+		}
+	}
+}
+
+func TestAuthorizationBackoffNext(t *testing.T) {
+	tests := map[string]struct {
+		backoff  authorizationBackoff
+		delay    time.Duration
+		expected time.Duration
+	}{
+		"constant delay with no factor": {
+			backoff:  authorizationBackoff{Interval: time.Second},
+			delay:    time.Second,
+			expected: time.Second,
+		},
+		"delay grows by the factor": {
+			backoff:  authorizationBackoff{Interval: time.Second, Factor: 2},
+			delay:    2 * time.Second,
+			expected: 4 * time.Second,
+		},
+		"delay is capped at the max interval": {
+			backoff:  authorizationBackoff{Interval: time.Second, Factor: 2, MaxInterval: 3 * time.Second},
+			delay:    2 * time.Second,
+			expected: 3 * time.Second,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if d := test.backoff.next(test.delay); d != test.expected {
+				t.Errorf("expected next delay %s but got %s", test.expected, d)
+			}
+		})
+	}
+}
+
+func TestWaitAuthorization(t *testing.T) {
+	t.Run("uses the ACME client's polling if no interval is configured", func(t *testing.T) {
+		c := &controller{}
+		cl := &acmecl.FakeACME{
+			FakeWaitAuthorization: func(context.Context, string) (*acmeapi.Authorization, error) {
+				return &acmeapi.Authorization{Status: acmeapi.StatusValid}, nil
+			},
+		}
+		authz, err := c.waitAuthorization(context.Background(), cl, "testurl")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if authz.Status != acmeapi.StatusValid {
+			t.Errorf("expected a valid authorization but got %q", authz.Status)
+		}
+	})
+
+	t.Run("polls until the authorization is valid", func(t *testing.T) {
+		c := &controller{authzBackoff: authorizationBackoff{Interval: time.Millisecond, Factor: 2, MaxInterval: 2 * time.Millisecond}}
+		polls := 0
+		cl := &acmecl.FakeACME{
+			FakeGetAuthorization: func(context.Context, string) (*acmeapi.Authorization, error) {
+				polls++
+				if polls < 3 {
+					return &acmeapi.Authorization{Status: acmeapi.StatusPending}, nil
+				}
+				return &acmeapi.Authorization{Status: acmeapi.StatusValid}, nil
+			},
+		}
+		authz, err := c.waitAuthorization(context.Background(), cl, "testurl")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if authz.Status != acmeapi.StatusValid {
+			t.Errorf("expected a valid authorization but got %q", authz.Status)
+		}
+		if polls != 3 {
+			t.Errorf("expected 3 polls but got %d", polls)
+		}
+	})
+
+	t.Run("returns an authorization error if the authorization is invalid", func(t *testing.T) {
+		c := &controller{authzBackoff: authorizationBackoff{Interval: time.Millisecond}}
+		cl := &acmecl.FakeACME{
+			FakeGetAuthorization: func(context.Context, string) (*acmeapi.Authorization, error) {
+				return &acmeapi.Authorization{
+					Status:     acmeapi.StatusInvalid,
+					Identifier: acmeapi.AuthzID{Value: "example.com"},
+					Challenges: []*acmeapi.Challenge{{Error: fmt.Errorf("an error happened")}},
+				}, nil
+			},
+		}
+		_, err := c.waitAuthorization(context.Background(), cl, "testurl")
+		authErr, ok := err.(*acmeapi.AuthorizationError)
+		if !ok {
+			t.Fatalf("expected an authorization error but got: %v", err)
+		}
+		if authErr.Identifier != "example.com" || len(authErr.Errors) != 1 {
+			t.Errorf("unexpected authorization error: %v", authErr)
+		}
+	})
+
+	t.Run("stops polling when the context is done", func(t *testing.T) {
+		c := &controller{authzBackoff: authorizationBackoff{Interval: time.Hour}}
+		cl := &acmecl.FakeACME{
+			FakeGetAuthorization: func(context.Context, string) (*acmeapi.Authorization, error) {
+				return &acmeapi.Authorization{Status: acmeapi.StatusPending}, nil
+			},
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := c.waitAuthorization(ctx, cl, "testurl"); err != context.Canceled {
+			t.Errorf("expected context.Canceled but got: %v", err)
+		}
+	})
+}
@@ -18,10 +18,13 @@ package acmeorders
 
 import (
 	"context"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
@@ -29,6 +32,7 @@ import (
 	"k8s.io/utils/clock"
 
 	"github.com/jetstack/cert-manager/pkg/acme"
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
 	cmclient "github.com/jetstack/cert-manager/pkg/client/clientset/versioned"
 	cmacmelisters "github.com/jetstack/cert-manager/pkg/client/listers/acme/v1alpha2"
 	cmlisters "github.com/jetstack/cert-manager/pkg/client/listers/certmanager/v1alpha2"
@@ -61,6 +65,17 @@ type controller struct {
 	// so the handleOwnedResource method can enqueue resources
 	queue workqueue.RateLimitingInterface
 
+	// issuerUnavailableBackoff computes the delay before retrying an Order
+	// whose ACME server could not be reached. It is kept separate from the
+	// queue's rate limiter so that outages are retried with a longer backoff
+	// than other errors.
+	issuerUnavailableBackoff workqueue.RateLimiter
+
+	// resumedOrders maps the key of each in-flight Order whose state has been
+	// read from the ACME server since this controller started to its UID
+	resumedOrders     map[string]types.UID
+	resumedOrdersLock sync.Mutex
+
 	// logger to be used by this controller
 	log logr.Logger
 }
@@ -74,6 +89,9 @@ func (c *controller) Register(ctx *controllerpkg.Context) (workqueue.RateLimitin
 
 	// create a queue used to queue up items to be processed
 	c.queue = workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(time.Second*5, time.Minute*30), ControllerName)
+	c.issuerUnavailableBackoff = workqueue.NewItemExponentialFailureRateLimiter(
+		ctx.IssuerOptions.IssuerUnavailableInitialBackoff, ctx.IssuerOptions.IssuerUnavailableMaxBackoff)
+	c.resumedOrders = make(map[string]types.UID)
 
 	// obtain references to all the informers used by this controller
 	orderInformer := ctx.SharedInformerFactory.Acme().V1alpha2().Orders()
@@ -139,6 +157,7 @@ func (c *controller) ProcessItem(ctx context.Context, key string) error {
 	if err != nil {
 		if k8sErrors.IsNotFound(err) {
 			log.Error(err, "order in work queue no longer exists")
+			c.forgetResumedOrder(key)
 			return nil
 		}
 
@@ -146,13 +165,66 @@ func (c *controller) ProcessItem(ctx context.Context, key string) error {
 	}
 
 	ctx = logf.NewContext(ctx, logf.WithResource(log, order))
-	return c.Sync(ctx, order)
+	err = c.Sync(ctx, order)
+	if acme.IsUnavailable(err) {
+		c.requeueIssuerUnavailable(ctx, key, order, err)
+		return nil
+	}
+	c.issuerUnavailableBackoff.Forget(key)
+	return err
+}
+
+// requeueIssuerUnavailable schedules the Order to be synced again once the
+// issuer unavailable backoff for it has elapsed. An Event is only fired when
+// the ACME server first becomes unavailable to avoid flooding the API server
+// during an outage.
+func (c *controller) requeueIssuerUnavailable(ctx context.Context, key string, o *cmacme.Order, err error) {
+	if c.issuerUnavailableBackoff.NumRequeues(key) == 0 {
+		c.recorder.Eventf(o, corev1.EventTypeWarning, reasonIssuerUnavailable,
+			"ACME server is unavailable, the order will be retried with backoff: %v", err)
+	}
+	delay := c.issuerUnavailableBackoff.When(key)
+	logf.FromContext(ctx).Error(err, "ACME server is unavailable, retrying order after backoff", "backoff", delay)
+	c.queue.AddAfter(key, delay)
+}
+
+// orderResumed returns true if the state of the given Order has been read
+// from the ACME server since this controller started.
+func (c *controller) orderResumed(o *cmacme.Order) bool {
+	key, err := keyFunc(o)
+	if err != nil {
+		return false
+	}
+	c.resumedOrdersLock.Lock()
+	defer c.resumedOrdersLock.Unlock()
+	uid, ok := c.resumedOrders[key]
+	return ok && uid == o.UID
+}
+
+// markOrderResumed records that the state of the given Order has been read
+// from the ACME server.
+func (c *controller) markOrderResumed(o *cmacme.Order) {
+	key, err := keyFunc(o)
+	if err != nil {
+		return
+	}
+	c.resumedOrdersLock.Lock()
+	defer c.resumedOrdersLock.Unlock()
+	c.resumedOrders[key] = o.UID
+}
+
+func (c *controller) forgetResumedOrder(key string) {
+	c.resumedOrdersLock.Lock()
+	defer c.resumedOrdersLock.Unlock()
+	delete(c.resumedOrders, key)
 }
 
 var keyFunc = controllerpkg.KeyFunc
 
 const (
 	ControllerName = "orders"
+
+	reasonIssuerUnavailable = "IssuerUnavailable"
 )
 
 func init() {
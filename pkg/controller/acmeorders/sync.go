@@ -36,6 +36,7 @@ import (
 	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
 	logf "github.com/jetstack/cert-manager/pkg/logs"
 	"github.com/jetstack/cert-manager/pkg/metrics"
+	cmerrors "github.com/jetstack/cert-manager/pkg/util/errors"
 )
 
 func (c *controller) Sync(ctx context.Context, o *cmacme.Order) (err error) {
@@ -75,7 +76,11 @@ func (c *controller) Sync(ctx context.Context, o *cmacme.Order) (err error) {
 	switch {
 	case o.Status.URL == "":
 		log.Info("Creating new ACME order as status.url is not set")
-		return c.createOrder(ctx, cl, o)
+		if err := c.createOrder(ctx, cl, o); err != nil {
+			return err
+		}
+		c.markOrderResumed(o)
+		return nil
 	case o.Status.FinalizeURL == "":
 		log.Info("Updating Order status as status.finalizeURL is not set")
 		_, err := c.updateOrderStatus(ctx, cl, o)
@@ -105,6 +110,31 @@ func (c *controller) Sync(ctx context.Context, o *cmacme.Order) (err error) {
 		return c.deleteAllChallenges(o)
 	}
 
+	// if the controller has restarted whilst this Order was in-flight, re-read
+	// its state from the ACME server before continuing, so the existing order
+	// is resumed from the state it is actually in. A new order will only be
+	// created (as a new Order resource) if this one turns out to be invalid.
+	if !c.orderResumed(o) {
+		log.Info("Resuming in-flight ACME order, fetching current Order state from ACME server")
+		_, err := c.updateOrderStatus(ctx, cl, o)
+		if acmeErr, ok := err.(*acmeapi.Error); ok {
+			if acmeErr.StatusCode >= 400 && acmeErr.StatusCode < 500 {
+				log.Error(err, "failed to update Order status due to a 4xx error, marking Order as failed")
+				c.setOrderState(&o.Status, string(cmacme.Errored))
+				o.Status.Reason = fmt.Sprintf("Failed to retrieve Order resource: %v", err)
+				return nil
+			}
+		}
+		if err != nil {
+			return err
+		}
+		c.markOrderResumed(o)
+		if acme.IsFailureState(o.Status.State) {
+			log.Info("Resumed ACME order is in a failed state")
+			return nil
+		}
+	}
+
 	dbg.Info("Computing list of Challenge resources that need to exist to complete this Order")
 	requiredChallenges, err := buildRequiredChallenges(ctx, cl, genericIssuer, o)
 	if err != nil {
@@ -206,7 +236,7 @@ func (c *controller) createOrder(ctx context.Context, cl acmecl.Interface, o *cm
 		}
 	}
 	if err != nil {
-		return fmt.Errorf("error creating new order: %v", err)
+		return wrapACMEError(err, "error creating new order")
 	}
 	log.Info("submitted Order to ACME server")
 
@@ -218,6 +248,17 @@ func (c *controller) createOrder(ctx context.Context, cl acmecl.Interface, o *cm
 	return nil
 }
 
+// wrapACMEError annotates an error returned by the ACME client with the given
+// message, preserving whether it was caused by the ACME server being
+// unavailable.
+func wrapACMEError(err error, message string) error {
+	wrapped := fmt.Errorf("%s: %v", message, err)
+	if acme.IsUnavailable(err) {
+		return cmerrors.NewIssuerUnavailable(wrapped)
+	}
+	return wrapped
+}
+
 func (c *controller) updateOrderStatus(ctx context.Context, cl acmecl.Interface, o *cmacme.Order) (*acmeapi.Order, error) {
 	log := logf.FromContext(ctx)
 	if o.Status.URL == "" {
@@ -467,11 +508,11 @@ func (c *controller) finalizeOrder(ctx context.Context, cl acmecl.Interface, o *
 		}
 	}
 	if errUpdate != nil {
-		return fmt.Errorf("error syncing order status: %v", errUpdate)
+		return wrapACMEError(errUpdate, "error syncing order status")
 	}
 	// check for errors from FinalizeOrder
 	if err != nil {
-		return fmt.Errorf("error finalizing order: %v", err)
+		return wrapACMEError(err, "error finalizing order")
 	}
 
 	return c.storeCertificateOnStatus(ctx, o, certSlice)
@@ -18,6 +18,10 @@ package acmeorders
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -295,6 +299,46 @@ dGVzdA==
 				},
 			},
 		},
+		"resume an in-flight order after a restart and update the order state if it has become invalid": {
+			order:     testOrderPending,
+			restarted: true,
+			builder: &testpkg.Builder{
+				CertManagerObjects: []runtime.Object{testIssuerHTTP01TestCom, testOrderPending, testAuthorizationChallenge},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(cmacme.SchemeGroupVersion.WithResource("orders"),
+						"status",
+						testOrderInvalid.Namespace, testOrderInvalid)),
+				},
+			},
+			acmeClient: &acmecl.FakeACME{
+				FakeGetOrder: func(_ context.Context, url string) (*acmeapi.Order, error) {
+					return testACMEOrderInvalid, nil
+				},
+			},
+		},
+		"resume an in-flight order after a restart without creating a new order if it is still pending": {
+			order:     testOrderPending,
+			restarted: true,
+			builder: &testpkg.Builder{
+				CertManagerObjects: []runtime.Object{testIssuerHTTP01TestCom, testOrderPending, testAuthorizationChallenge},
+				ExpectedActions:    []testpkg.Action{},
+			},
+			acmeClient: &acmecl.FakeACME{
+				FakeGetOrder: func(_ context.Context, url string) (*acmeapi.Order, error) {
+					if url != testOrderPending.Status.URL {
+						t.Errorf("expected order %q to be resumed but got %q", testOrderPending.Status.URL, url)
+					}
+					return testACMEOrderPending, nil
+				},
+				FakeAuthorizeOrder: func(ctx context.Context, id []acmeapi.AuthzID, opt ...acmeapi.OrderOption) (*acmeapi.Order, error) {
+					t.Errorf("expected existing order to be resumed but a new order was created")
+					return nil, fmt.Errorf("unexpected call to AuthorizeOrder")
+				},
+				FakeHTTP01ChallengeResponse: func(s string) (string, error) {
+					return "key", nil
+				},
+			},
+		},
 		"do nothing if the order is valid": {
 			order: testOrderValid,
 			builder: &testpkg.Builder{
@@ -331,6 +375,9 @@ type testT struct {
 	builder    *testpkg.Builder
 	acmeClient acmecl.Interface
 	expectErr  bool
+	// restarted simulates the controller having restarted since the order
+	// was last synced, so its state has not yet been read from the ACME server
+	restarted bool
 }
 
 func runTest(t *testing.T, test testT) {
@@ -345,6 +392,9 @@ func runTest(t *testing.T, test testT) {
 			return test.acmeClient, nil
 		},
 	}
+	if !test.restarted {
+		c.markOrderResumed(test.order)
+	}
 	test.builder.Start()
 
 	err := c.Sync(context.Background(), test.order)
@@ -357,3 +407,88 @@ func runTest(t *testing.T, test testT) {
 
 	test.builder.CheckAndFinish(err)
 }
+
+func TestProcessItemIssuerUnavailable(t *testing.T) {
+	testIssuer := gen.Issuer("testissuer", gen.SetIssuerACME(cmacme.ACMEIssuer{}))
+	testOrder := gen.Order("testorder",
+		gen.SetOrderCommonName("test.com"),
+		gen.SetOrderIssuer(cmmeta.ObjectReference{
+			Name: testIssuer.Name,
+		}),
+	)
+
+	tests := map[string]struct {
+		authorizeErr   error
+		expectErr      bool
+		expectRequeues int
+	}{
+		"should retry with backoff if the ACME server returns a server error": {
+			authorizeErr:   &acmeapi.Error{StatusCode: http.StatusServiceUnavailable},
+			expectRequeues: 2,
+		},
+		"should retry with backoff if the ACME server cannot be reached": {
+			authorizeErr:   &net.OpError{Op: "dial", Net: "tcp", Err: fmt.Errorf("connection refused")},
+			expectRequeues: 2,
+		},
+		"should return other errors": {
+			authorizeErr: fmt.Errorf("some error"),
+			expectErr:    true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := &testpkg.Builder{
+				T:                  t,
+				CertManagerObjects: []runtime.Object{testIssuer, testOrder},
+			}
+			builder.Init()
+			defer builder.Stop()
+
+			c := &controller{}
+			c.Register(builder.Context)
+			c.acmeHelper = &acmefake.Helper{
+				ClientForIssuerFunc: func(iss v1alpha2.GenericIssuer) (acmecl.Interface, error) {
+					return &acmecl.FakeACME{
+						FakeAuthorizeOrder: func(context.Context, []acmeapi.AuthzID, ...acmeapi.OrderOption) (*acmeapi.Order, error) {
+							return nil, test.authorizeErr
+						},
+					}, nil
+				},
+			}
+			builder.Start()
+
+			key, err := keyFunc(testOrder)
+			if err != nil {
+				t.Fatalf("error building key: %v", err)
+			}
+			// sync the order twice to ensure the backoff grows and only a
+			// single Event is fired for the outage
+			for i := 0; i < 2; i++ {
+				err := c.ProcessItem(context.Background(), key)
+				if err != nil && !test.expectErr {
+					t.Errorf("expected no error but got: %v", err)
+				}
+				if err == nil && test.expectErr {
+					t.Errorf("expected an error but got none")
+				}
+			}
+
+			if n := c.issuerUnavailableBackoff.NumRequeues(key); n != test.expectRequeues {
+				t.Errorf("expected %d issuer unavailable requeues but got %d", test.expectRequeues, n)
+			}
+			var events []string
+			for _, e := range builder.Events() {
+				if strings.Contains(e, reasonIssuerUnavailable) {
+					events = append(events, e)
+				}
+			}
+			expectEvents := 0
+			if test.expectRequeues > 0 {
+				expectEvents = 1
+			}
+			if len(events) != expectEvents {
+				t.Errorf("expected %d %s events but got: %v", expectEvents, reasonIssuerUnavailable, events)
+			}
+		})
+	}
+}
@@ -61,10 +61,17 @@ func buildChallenge(ctx context.Context, cl acmecl.Interface, issuer cmapi.Gener
 		return nil, err
 	}
 
+	var annotations map[string]string
+	if o.Status.URL != "" {
+		annotations = map[string]string{cmacme.ACMEOrderURLAnnotationKey: o.Status.URL}
+	}
+
 	return &cmacme.Challenge{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:            chName,
 			Namespace:       o.Namespace,
+			Labels:          o.Labels,
+			Annotations:     annotations,
 			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(o, orderGvk)},
 			Finalizers:      []string{cmacme.ACMEFinalizer},
 		},
@@ -125,6 +132,8 @@ func challengeSpecForAuthorization(ctx context.Context, cl acmecl.Interface, iss
 				return &ch
 			case ch.Type == "dns-01" && solver.DNS01 != nil:
 				return &ch
+			case ch.Type == "tls-alpn-01" && solver.TLSALPN01 != nil:
+				return &ch
 			}
 		}
 		return nil
@@ -278,8 +287,11 @@ func challengeSpecForAuthorization(ctx context.Context, cl acmecl.Interface, iss
 	if err := applyIngressParameterAnnotationOverrides(o, selectedSolver); err != nil {
 		return nil, err
 	}
+	// 5. fall back to the issuer's default ingress class if the solver does
+	//    not otherwise specify which ingress to use
+	applyDefaultIngressClass(issuer.GetSpec().ACME, selectedSolver)
 
-	// 5. construct Challenge resource with spec.solver field set
+	// 6. construct Challenge resource with spec.solver field set
 	return &cmacme.ChallengeSpec{
 		AuthzURL:  authz.URL,
 		Type:      selectedChallenge.Type,
@@ -309,6 +321,7 @@ func applyIngressParameterAnnotationOverrides(o *cmacme.Order, s *cmacme.ACMECha
 	if hasManualIngressClass || hasManualIngressName {
 		s.HTTP01.Ingress.Class = nil
 		s.HTTP01.Ingress.Name = ""
+		s.HTTP01.Ingress.IngressSelector = nil
 	}
 	if hasManualIngressName {
 		s.HTTP01.Ingress.Name = manualIngressName
@@ -319,6 +332,21 @@ func applyIngressParameterAnnotationOverrides(o *cmacme.Order, s *cmacme.ACMECha
 	return nil
 }
 
+// applyDefaultIngressClass sets the ingress class of an HTTP01 ingress solver
+// to the issuer's DefaultHTTP01IngressClass if the solver does not specify a
+// class, name or ingress selector of its own.
+func applyDefaultIngressClass(iss *cmacme.ACMEIssuer, s *cmacme.ACMEChallengeSolver) {
+	if iss == nil || iss.DefaultHTTP01IngressClass == nil || s.HTTP01 == nil || s.HTTP01.Ingress == nil {
+		return
+	}
+	ing := s.HTTP01.Ingress
+	if ing.Class != nil || ing.Name != "" || ing.IngressSelector != nil {
+		return
+	}
+	class := *iss.DefaultHTTP01IngressClass
+	ing.Class = &class
+}
+
 func keyForChallenge(cl acmecl.Interface, challenge *cmacme.ACMEChallenge) (string, error) {
 	var err error
 	switch challenge.Type {
@@ -326,6 +354,10 @@ func keyForChallenge(cl acmecl.Interface, challenge *cmacme.ACMEChallenge) (stri
 		return cl.HTTP01ChallengeResponse(challenge.Token)
 	case cmacme.ACMEChallengeTypeDNS01:
 		return cl.DNS01ChallengeRecord(challenge.Token)
+	case cmacme.ACMEChallengeTypeTLSALPN01:
+		// the tls-alpn-01 challenge certificate is derived from the same key
+		// authorization that is served for http-01 challenges
+		return cl.HTTP01ChallengeResponse(challenge.Token)
 	default:
 		err = fmt.Errorf("unsupported challenge type %s", challenge.Type)
 	}
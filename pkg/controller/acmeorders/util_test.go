@@ -49,6 +49,18 @@ func TestChallengeSpecForAuthorization(t *testing.T) {
 			},
 		},
 	}
+	noClassSolverHTTP01 := cmacme.ACMEChallengeSolver{
+		HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+			Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+		},
+	}
+	classSolverHTTP01 := cmacme.ACMEChallengeSolver{
+		HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+			Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+				Class: pointer.StringPtr("solver-class"),
+			},
+		},
+	}
 	emptySelectorSolverDNS01 := cmacme.ACMEChallengeSolver{
 		DNS01: &cmacme.ACMEChallengeSolverDNS01{
 			Cloudflare: &cmacme.ACMEIssuerDNS01ProviderCloudflare{
@@ -56,6 +68,9 @@ func TestChallengeSpecForAuthorization(t *testing.T) {
 			},
 		},
 	}
+	emptySelectorSolverTLSALPN01 := cmacme.ACMEChallengeSolver{
+		TLSALPN01: &cmacme.ACMEChallengeSolverTLSALPN01{},
+	}
 	nonMatchingSelectorSolver := cmacme.ACMEChallengeSolver{
 		Selector: &cmacme.CertificateDNSNameSelector{
 			MatchLabels: map[string]string{
@@ -88,6 +103,10 @@ func TestChallengeSpecForAuthorization(t *testing.T) {
 		Type:  "dns-01",
 		Token: "dns-01-token",
 	}
+	acmeChallengeTLSALPN01 := &cmacme.ACMEChallenge{
+		Type:  "tls-alpn-01",
+		Token: "tls-alpn-01-token",
+	}
 
 	tests := map[string]struct {
 		acmeClient acmecl.Interface
@@ -204,6 +223,116 @@ func TestChallengeSpecForAuthorization(t *testing.T) {
 			},
 			expectedError: true,
 		},
+		"should use the issuer default ingress class if the solver does not specify one": {
+			acmeClient: basicACMEClient,
+			issuer: &v1alpha2.Issuer{
+				Spec: v1alpha2.IssuerSpec{
+					IssuerConfig: v1alpha2.IssuerConfig{
+						ACME: &cmacme.ACMEIssuer{
+							Solvers:                   []cmacme.ACMEChallengeSolver{noClassSolverHTTP01},
+							DefaultHTTP01IngressClass: pointer.StringPtr("issuer-class"),
+						},
+					},
+				},
+			},
+			order: &cmacme.Order{
+				Spec: cmacme.OrderSpec{
+					DNSNames: []string{"example.com"},
+				},
+			},
+			authz: &cmacme.ACMEAuthorization{
+				Identifier: "example.com",
+				Challenges: []cmacme.ACMEChallenge{*acmeChallengeHTTP01},
+			},
+			expectedChallengeSpec: &cmacme.ChallengeSpec{
+				Type:    "http-01",
+				DNSName: "example.com",
+				Token:   acmeChallengeHTTP01.Token,
+				Key:     "http01",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+							Class: pointer.StringPtr("issuer-class"),
+						},
+					},
+				},
+			},
+		},
+		"should prefer the solver ingress class over the issuer default": {
+			acmeClient: basicACMEClient,
+			issuer: &v1alpha2.Issuer{
+				Spec: v1alpha2.IssuerSpec{
+					IssuerConfig: v1alpha2.IssuerConfig{
+						ACME: &cmacme.ACMEIssuer{
+							Solvers:                   []cmacme.ACMEChallengeSolver{classSolverHTTP01},
+							DefaultHTTP01IngressClass: pointer.StringPtr("issuer-class"),
+						},
+					},
+				},
+			},
+			order: &cmacme.Order{
+				Spec: cmacme.OrderSpec{
+					DNSNames: []string{"example.com"},
+				},
+			},
+			authz: &cmacme.ACMEAuthorization{
+				Identifier: "example.com",
+				Challenges: []cmacme.ACMEChallenge{*acmeChallengeHTTP01},
+			},
+			expectedChallengeSpec: &cmacme.ChallengeSpec{
+				Type:    "http-01",
+				DNSName: "example.com",
+				Token:   acmeChallengeHTTP01.Token,
+				Key:     "http01",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+							Class: pointer.StringPtr("solver-class"),
+						},
+					},
+				},
+			},
+		},
+		"should prefer the ingress class override annotation over the issuer default": {
+			acmeClient: basicACMEClient,
+			issuer: &v1alpha2.Issuer{
+				Spec: v1alpha2.IssuerSpec{
+					IssuerConfig: v1alpha2.IssuerConfig{
+						ACME: &cmacme.ACMEIssuer{
+							Solvers:                   []cmacme.ACMEChallengeSolver{noClassSolverHTTP01},
+							DefaultHTTP01IngressClass: pointer.StringPtr("issuer-class"),
+						},
+					},
+				},
+			},
+			order: &cmacme.Order{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						cmacme.ACMECertificateHTTP01IngressClassOverride: "test-class-to-override",
+					},
+				},
+				Spec: cmacme.OrderSpec{
+					DNSNames: []string{"example.com"},
+				},
+			},
+			authz: &cmacme.ACMEAuthorization{
+				Identifier: "example.com",
+				Challenges: []cmacme.ACMEChallenge{*acmeChallengeHTTP01},
+			},
+			expectedChallengeSpec: &cmacme.ChallengeSpec{
+				Type:    "http-01",
+				DNSName: "example.com",
+				Token:   acmeChallengeHTTP01.Token,
+				Key:     "http01",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+							Class: pointer.StringPtr("test-class-to-override"),
+						},
+					},
+				},
+			},
+		},
 		"should ignore HTTP01 override annotations if DNS01 solver is chosen": {
 			acmeClient: basicACMEClient,
 			issuer: &v1alpha2.Issuer{
@@ -237,6 +366,34 @@ func TestChallengeSpecForAuthorization(t *testing.T) {
 				Solver:  &emptySelectorSolverDNS01,
 			},
 		},
+		"should select a tls-alpn-01 solver if the authorization offers a tls-alpn-01 challenge": {
+			acmeClient: basicACMEClient,
+			issuer: &v1alpha2.Issuer{
+				Spec: v1alpha2.IssuerSpec{
+					IssuerConfig: v1alpha2.IssuerConfig{
+						ACME: &cmacme.ACMEIssuer{
+							Solvers: []cmacme.ACMEChallengeSolver{emptySelectorSolverTLSALPN01},
+						},
+					},
+				},
+			},
+			order: &cmacme.Order{
+				Spec: cmacme.OrderSpec{
+					DNSNames: []string{"example.com"},
+				},
+			},
+			authz: &cmacme.ACMEAuthorization{
+				Identifier: "example.com",
+				Challenges: []cmacme.ACMEChallenge{*acmeChallengeHTTP01, *acmeChallengeTLSALPN01},
+			},
+			expectedChallengeSpec: &cmacme.ChallengeSpec{
+				Type:    "tls-alpn-01",
+				DNSName: "example.com",
+				Token:   acmeChallengeTLSALPN01.Token,
+				Key:     "http01",
+				Solver:  &emptySelectorSolverTLSALPN01,
+			},
+		},
 		"should use configured default solver when no others are present": {
 			acmeClient: basicACMEClient,
 			issuer: &v1alpha2.Issuer{
@@ -1274,3 +1431,56 @@ func TestChallengeSpecForAuthorization(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildChallengeCopiesOrderLabels(t *testing.T) {
+	cl := &acmecl.FakeACME{
+		FakeHTTP01ChallengeResponse: func(string) (string, error) {
+			return "http01", nil
+		},
+	}
+	issuer := &v1alpha2.Issuer{
+		Spec: v1alpha2.IssuerSpec{
+			IssuerConfig: v1alpha2.IssuerConfig{
+				ACME: &cmacme.ACMEIssuer{
+					Solvers: []cmacme.ACMEChallengeSolver{
+						{
+							HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+								Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	order := &cmacme.Order{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-order",
+			Namespace: "default",
+			Labels: map[string]string{
+				"environment": "staging",
+			},
+		},
+		Spec: cmacme.OrderSpec{
+			DNSNames: []string{"example.com"},
+		},
+	}
+	authz := cmacme.ACMEAuthorization{
+		Identifier: "example.com",
+		Challenges: []cmacme.ACMEChallenge{
+			{
+				URL:   "http://challengeurl",
+				Token: "token",
+				Type:  cmacme.ACMEChallengeTypeHTTP01,
+			},
+		},
+	}
+
+	ch, err := buildChallenge(context.Background(), cl, issuer, order, authz)
+	if err != nil {
+		t.Fatalf("unexpected error building challenge: %v", err)
+	}
+	if !reflect.DeepEqual(ch.Labels, order.Labels) {
+		t.Errorf("expected challenge labels %v but got %v", order.Labels, ch.Labels)
+	}
+}
@@ -97,6 +97,7 @@ func (b *Builder) Complete() (Interface, error) {
 		syncHandler:         b.impl.ProcessItem,
 		mustSync:            mustSync,
 		additionalInformers: additionalInformers,
+		runFirstFuncs:       b.runFirstFuncs,
 		runDurationFuncs:    b.runDurationFuncs,
 		queue:               queue,
 	}, nil
@@ -111,6 +111,24 @@ func (c *CA) Sign(ctx context.Context, cr *cmapi.CertificateRequest, issuerObj c
 		return nil, nil
 	}
 
+	sigAlgo, err := pki.SignatureAlgorithmForKey(issuerObj.GetSpec().CA.SignatureAlgorithm, caKey.Public())
+	if err != nil {
+		message := "Invalid signature algorithm for CA key"
+		c.reporter.Failed(cr, err, "SigningError", message)
+		log.Error(err, message)
+		return nil, nil
+	}
+	if sigAlgo != x509.UnknownSignatureAlgorithm {
+		template.SignatureAlgorithm = sigAlgo
+	}
+
+	// advertise where clients can fetch the CA certificate when building
+	// the chain, if configured
+	template.IssuingCertificateURL = issuerObj.GetSpec().CA.IssuingCertificateURLs
+
+	// backdate the certificate to tolerate clients with skewed clocks
+	template.NotBefore = template.NotBefore.Add(-apiutil.DefaultNotBeforeSkew(issuerObj.GetSpec().CA.NotBeforeSkew))
+
 	certPEM, caPEM, err := pki.SignCSRTemplate(caCerts, caKey, template)
 	if err != nil {
 		message := "Error signing certificate"
@@ -146,12 +146,65 @@ func TestSign(t *testing.T) {
 		t.Error(err)
 		t.FailNow()
 	}
-	certPEM, _, err := pki.SignCSRTemplate([]*x509.Certificate{template}, skRSA, template)
+	// the CA issuer backdates the NotBefore time of issued certificates by
+	// the default skew, as the issuer does not specify one
+	skewedTemplate := *template
+	skewedTemplate.NotBefore = template.NotBefore.Add(-cmapi.DefaultNotBeforeSkew)
+	certPEM, _, err := pki.SignCSRTemplate([]*x509.Certificate{template}, skRSA, &skewedTemplate)
 	if err != nil {
 		t.Error(err)
 		t.FailNow()
 	}
 
+	skewIssuer := gen.IssuerFrom(baseIssuer,
+		gen.SetIssuerCA(cmapi.CAIssuer{
+			SecretName:    "root-ca-secret",
+			NotBeforeSkew: &metav1.Duration{Duration: 5 * time.Minute},
+		}),
+	)
+	skewedTemplate.NotBefore = template.NotBefore.Add(-5 * time.Minute)
+	skewedCertPEM, _, err := pki.SignCSRTemplate([]*x509.Certificate{template}, skRSA, &skewedTemplate)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	sigAlgoIssuer := gen.IssuerFrom(baseIssuer,
+		gen.SetIssuerCA(cmapi.CAIssuer{
+			SecretName:         "root-ca-secret",
+			SignatureAlgorithm: cmapi.SHA384WithRSA,
+		}),
+	)
+	skewedTemplate.NotBefore = template.NotBefore.Add(-cmapi.DefaultNotBeforeSkew)
+	skewedTemplate.SignatureAlgorithm = x509.SHA384WithRSA
+	sha384CertPEM, _, err := pki.SignCSRTemplate([]*x509.Certificate{template}, skRSA, &skewedTemplate)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	aiaIssuer := gen.IssuerFrom(baseIssuer,
+		gen.SetIssuerCA(cmapi.CAIssuer{
+			SecretName:             "root-ca-secret",
+			IssuingCertificateURLs: []string{"http://pki.example.com/ca.crt"},
+		}),
+	)
+	aiaTemplate := *template
+	aiaTemplate.NotBefore = template.NotBefore.Add(-cmapi.DefaultNotBeforeSkew)
+	aiaTemplate.IssuingCertificateURL = []string{"http://pki.example.com/ca.crt"}
+	aiaCertPEM, _, err := pki.SignCSRTemplate([]*x509.Certificate{template}, skRSA, &aiaTemplate)
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+
+	badSigAlgoIssuer := gen.IssuerFrom(baseIssuer,
+		gen.SetIssuerCA(cmapi.CAIssuer{
+			SecretName:         "root-ca-secret",
+			SignatureAlgorithm: cmapi.ECDSAWithSHA384,
+		}),
+	)
+
 	metaFixedClockStart := metav1.NewTime(fixedClockStart)
 	tests := map[string]testT{
 		"a missing CA key pair should set the condition to pending and wait for a re-sync": {
@@ -313,7 +366,8 @@ func TestSign(t *testing.T) {
 					return nil, err
 				}
 
-				return template, nil
+				tmpl := *template
+				return &tmpl, nil
 			},
 			builder: &testpkg.Builder{
 				KubeObjects:        []runtime.Object{rsaCASecret},
@@ -341,6 +395,133 @@ func TestSign(t *testing.T) {
 				},
 			},
 		},
+		"a configured notBeforeSkew should backdate the issued certificate": {
+			certificateRequest: baseCR.DeepCopy(),
+			templateGenerator: func(cr *cmapi.CertificateRequest) (*x509.Certificate, error) {
+				tmpl := *template
+				return &tmpl, nil
+			},
+			builder: &testpkg.Builder{
+				KubeObjects:        []runtime.Object{rsaCASecret},
+				CertManagerObjects: []runtime.Object{baseCR.DeepCopy(), skewIssuer},
+				ExpectedEvents: []string{
+					"Normal CertificateIssued Certificate fetched from issuer successfully",
+				},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(
+						cmapi.SchemeGroupVersion.WithResource("certificaterequests"),
+						"status",
+						gen.DefaultTestNamespace,
+						gen.CertificateRequestFrom(baseCR,
+							gen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+								Type:               cmapi.CertificateRequestConditionReady,
+								Status:             cmmeta.ConditionTrue,
+								Reason:             cmapi.CertificateRequestReasonIssued,
+								Message:            "Certificate fetched from issuer successfully",
+								LastTransitionTime: &metaFixedClockStart,
+							}),
+							gen.SetCertificateRequestCA(rsaPEMCert),
+							gen.SetCertificateRequestCertificate(skewedCertPEM),
+						),
+					)),
+				},
+			},
+		},
+		"a configured signatureAlgorithm should be used to sign the issued certificate": {
+			certificateRequest: baseCR.DeepCopy(),
+			templateGenerator: func(cr *cmapi.CertificateRequest) (*x509.Certificate, error) {
+				tmpl := *template
+				return &tmpl, nil
+			},
+			builder: &testpkg.Builder{
+				KubeObjects:        []runtime.Object{rsaCASecret},
+				CertManagerObjects: []runtime.Object{baseCR.DeepCopy(), sigAlgoIssuer},
+				ExpectedEvents: []string{
+					"Normal CertificateIssued Certificate fetched from issuer successfully",
+				},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(
+						cmapi.SchemeGroupVersion.WithResource("certificaterequests"),
+						"status",
+						gen.DefaultTestNamespace,
+						gen.CertificateRequestFrom(baseCR,
+							gen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+								Type:               cmapi.CertificateRequestConditionReady,
+								Status:             cmmeta.ConditionTrue,
+								Reason:             cmapi.CertificateRequestReasonIssued,
+								Message:            "Certificate fetched from issuer successfully",
+								LastTransitionTime: &metaFixedClockStart,
+							}),
+							gen.SetCertificateRequestCA(rsaPEMCert),
+							gen.SetCertificateRequestCertificate(sha384CertPEM),
+						),
+					)),
+				},
+			},
+		},
+		"configured issuingCertificateURLs should be set on the issued certificate": {
+			certificateRequest: baseCR.DeepCopy(),
+			templateGenerator: func(cr *cmapi.CertificateRequest) (*x509.Certificate, error) {
+				tmpl := *template
+				return &tmpl, nil
+			},
+			builder: &testpkg.Builder{
+				KubeObjects:        []runtime.Object{rsaCASecret},
+				CertManagerObjects: []runtime.Object{baseCR.DeepCopy(), aiaIssuer},
+				ExpectedEvents: []string{
+					"Normal CertificateIssued Certificate fetched from issuer successfully",
+				},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(
+						cmapi.SchemeGroupVersion.WithResource("certificaterequests"),
+						"status",
+						gen.DefaultTestNamespace,
+						gen.CertificateRequestFrom(baseCR,
+							gen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+								Type:               cmapi.CertificateRequestConditionReady,
+								Status:             cmmeta.ConditionTrue,
+								Reason:             cmapi.CertificateRequestReasonIssued,
+								Message:            "Certificate fetched from issuer successfully",
+								LastTransitionTime: &metaFixedClockStart,
+							}),
+							gen.SetCertificateRequestCA(rsaPEMCert),
+							gen.SetCertificateRequestCertificate(aiaCertPEM),
+						),
+					)),
+				},
+			},
+		},
+		"a signatureAlgorithm that does not match the CA key should set condition to failed": {
+			certificateRequest: baseCR.DeepCopy(),
+			templateGenerator: func(cr *cmapi.CertificateRequest) (*x509.Certificate, error) {
+				tmpl := *template
+				return &tmpl, nil
+			},
+			builder: &testpkg.Builder{
+				KubeObjects:        []runtime.Object{rsaCASecret},
+				CertManagerObjects: []runtime.Object{baseCR.DeepCopy(), badSigAlgoIssuer},
+				ExpectedEvents: []string{
+					"Warning SigningError Invalid signature algorithm for CA key: signature algorithm ECDSAWithSHA384 cannot be used with a RSA key",
+				},
+				ExpectedActions: []testpkg.Action{
+					testpkg.NewAction(coretesting.NewUpdateSubresourceAction(
+						cmapi.SchemeGroupVersion.WithResource("certificaterequests"),
+						"status",
+						gen.DefaultTestNamespace,
+						gen.CertificateRequestFrom(baseCR.DeepCopy(),
+							gen.SetCertificateRequestStatusCondition(cmapi.CertificateRequestCondition{
+								Type:               cmapi.CertificateRequestConditionReady,
+								Status:             cmmeta.ConditionFalse,
+								Reason:             cmapi.CertificateRequestReasonFailed,
+								Message:            "Invalid signature algorithm for CA key: signature algorithm ECDSAWithSHA384 cannot be used with a RSA key",
+								LastTransitionTime: &metaFixedClockStart,
+							}),
+							gen.SetCertificateRequestFailureTime(metaFixedClockStart),
+						),
+					)),
+				},
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -57,6 +57,12 @@ type Controller struct {
 	queue   workqueue.RateLimitingInterface
 	metrics *metrics.Metrics
 
+	// issuerUnavailableBackoff computes the delay before retrying a
+	// CertificateRequest whose issuer could not be reached. It is kept
+	// separate from the queue's rate limiter so that outages are retried
+	// with a longer backoff than other errors.
+	issuerUnavailableBackoff workqueue.RateLimiter
+
 	// logger to be used by this controller
 	log logr.Logger
 
@@ -110,6 +116,8 @@ func (c *Controller) Register(ctx *controllerpkg.Context) (workqueue.RateLimitin
 
 	// create a queue used to queue up items to be processed
 	c.queue = workqueue.NewNamedRateLimitingQueue(controllerpkg.DefaultItemBasedRateLimiter(), ControllerName)
+	c.issuerUnavailableBackoff = workqueue.NewItemExponentialFailureRateLimiter(
+		ctx.IssuerOptions.IssuerUnavailableInitialBackoff, ctx.IssuerOptions.IssuerUnavailableMaxBackoff)
 
 	issuerInformer := ctx.SharedInformerFactory.Certmanager().V1alpha2().Issuers()
 	c.issuerLister = issuerInformer.Lister()
@@ -125,6 +125,9 @@ func (s *SelfSigned) Sign(ctx context.Context, cr *cmapi.CertificateRequest, iss
 		return nil, nil
 	}
 
+	// backdate the certificate to tolerate clients with skewed clocks
+	template.NotBefore = template.NotBefore.Add(-apiutil.DefaultNotBeforeSkew(issuerObj.GetSpec().SelfSigned.NotBeforeSkew))
+
 	// extract the public component of the key
 	publickey, err := pki.PublicKeyForPrivateKey(privatekey)
 	if err != nil {
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"reflect"
 
+	"github.com/go-logr/logr"
 	"github.com/kr/pretty"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -32,6 +33,7 @@ import (
 	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
 	internalapi "github.com/jetstack/cert-manager/pkg/internal/apis/certmanager"
 	logf "github.com/jetstack/cert-manager/pkg/logs"
+	cmerrors "github.com/jetstack/cert-manager/pkg/util/errors"
 	"github.com/jetstack/cert-manager/pkg/util/pki"
 	"github.com/jetstack/cert-manager/pkg/webhook"
 )
@@ -131,6 +133,13 @@ func (c *Controller) Sync(ctx context.Context, cr *v1alpha2.CertificateRequest)
 
 	// Attempt to call the Sign function on our issuer
 	resp, err := c.issuer.Sign(ctx, crCopy, issuerObj)
+	if cmerrors.IsIssuerUnavailable(err) {
+		c.reporter.IssuerUnavailable(crCopy, err)
+		c.requeueIssuerUnavailable(log, crCopy)
+		return nil
+	}
+	c.forgetIssuerUnavailable(crCopy)
+
 	if err != nil {
 		log.Error(err, "error issuing certificate request")
 		return err
@@ -160,6 +169,27 @@ func (c *Controller) Sync(ctx context.Context, cr *v1alpha2.CertificateRequest)
 	return nil
 }
 
+// requeueIssuerUnavailable schedules the CertificateRequest to be synced again
+// once the issuer unavailable backoff for it has elapsed.
+func (c *Controller) requeueIssuerUnavailable(log logr.Logger, cr *v1alpha2.CertificateRequest) {
+	key, err := keyFunc(cr)
+	if err != nil {
+		log.Error(err, "failed to construct key for resource")
+		return
+	}
+	delay := c.issuerUnavailableBackoff.When(key)
+	log.Info("issuer is unavailable, retrying certificate request after backoff", "backoff", delay)
+	c.queue.AddAfter(key, delay)
+}
+
+func (c *Controller) forgetIssuerUnavailable(cr *v1alpha2.CertificateRequest) {
+	key, err := keyFunc(cr)
+	if err != nil {
+		return
+	}
+	c.issuerUnavailableBackoff.Forget(key)
+}
+
 func (c *Controller) updateCertificateRequestStatus(ctx context.Context, old, new *v1alpha2.CertificateRequest) (*v1alpha2.CertificateRequest, error) {
 	log := logf.FromContext(ctx, "updateStatus")
 	oldBytes, _ := json.Marshal(old.Status)
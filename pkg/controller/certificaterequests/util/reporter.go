@@ -80,6 +80,20 @@ func (r *Reporter) Pending(cr *cmapi.CertificateRequest, err error, reason, mess
 		cmmeta.ConditionFalse, cmapi.CertificateRequestReasonPending, message)
 }
 
+// IssuerUnavailable marks the CertificateRequest as not ready because the
+// issuer could not be reached. An Event is only fired when the request first
+// enters this state to avoid flooding the API server during an outage.
+func (r *Reporter) IssuerUnavailable(cr *cmapi.CertificateRequest, err error) {
+	message := fmt.Sprintf("Issuer is unavailable, the request will be retried with backoff: %v", err)
+
+	if apiutil.CertificateRequestReadyReason(cr) != cmapi.CertificateRequestReasonIssuerUnavailable {
+		r.recorder.Event(cr, corev1.EventTypeWarning, cmapi.CertificateRequestReasonIssuerUnavailable, message)
+	}
+
+	apiutil.SetCertificateRequestCondition(cr, cmapi.CertificateRequestConditionReady,
+		cmmeta.ConditionFalse, cmapi.CertificateRequestReasonIssuerUnavailable, message)
+}
+
 func (r *Reporter) Ready(cr *cmapi.CertificateRequest) {
 	r.recorder.Event(cr, corev1.EventTypeNormal, "CertificateIssued", readyMessage)
 	apiutil.SetCertificateRequestCondition(cr, cmapi.CertificateRequestConditionReady,
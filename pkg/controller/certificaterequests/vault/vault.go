@@ -30,6 +30,7 @@ import (
 	vaultinternal "github.com/jetstack/cert-manager/pkg/internal/vault"
 	"github.com/jetstack/cert-manager/pkg/issuer"
 	logf "github.com/jetstack/cert-manager/pkg/logs"
+	cmerrors "github.com/jetstack/cert-manager/pkg/util/errors"
 )
 
 const (
@@ -87,6 +88,13 @@ func (v *Vault) Sign(ctx context.Context, cr *v1alpha2.CertificateRequest, issue
 
 	certDuration := apiutil.DefaultCertDuration(cr.Spec.Duration)
 	certPem, caPem, err := client.Sign(cr.Spec.CSRPEM, certDuration)
+	if cmerrors.IsIssuerUnavailable(err) {
+		// Let the CertificateRequest controller retry with backoff rather
+		// than failing the request whilst Vault is down.
+		log.Error(err, "vault is unavailable")
+		return nil, err
+	}
+
 	if err != nil {
 		message := "Vault failed to sign certificate"
 
@@ -21,6 +21,8 @@ import (
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/dynamic"
 	kubeinformers "k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -48,6 +50,9 @@ type Context struct {
 	Client kubernetes.Interface
 	// CMClient is a cert-manager clientset
 	CMClient clientset.Interface
+	// DynamicClient is a Kubernetes dynamic client, used to manage resources
+	// that there is no typed client for, such as Gateway API resources
+	DynamicClient dynamic.Interface
 	// Recorder to record events to
 	Recorder record.EventRecorder
 
@@ -62,6 +67,12 @@ type Context struct {
 	// If unset, operates on all namespaces
 	Namespace string
 
+	// CertificateSelector limits the cert-manager resources watched by
+	// SharedInformerFactory. Controllers that create cert-manager resources
+	// from other resources only do so for resources matching it, so that the
+	// resources they create are watched. If nil, all resources are watched.
+	CertificateSelector labels.Selector
+
 	// Clock should be used to access the current time instead of relying on
 	// time.Now, to make it easier to test controllers that utilise time
 	Clock clock.Clock
@@ -92,6 +103,17 @@ type IssuerOptions struct {
 	// Once a certificate is within this duration until expiry, a new Certificate
 	// will be attempted to be issued.
 	RenewBeforeExpiryDuration time.Duration
+
+	// IssuerUnavailableInitialBackoff is the delay before a CertificateRequest
+	// or ACME Order is retried after its issuer could not be reached. The
+	// delay doubles on each consecutive failure, capped at
+	// IssuerUnavailableMaxBackoff.
+	IssuerUnavailableInitialBackoff time.Duration
+
+	// IssuerUnavailableMaxBackoff is the maximum delay before a
+	// CertificateRequest or ACME Order is retried after its issuer could not
+	// be reached.
+	IssuerUnavailableMaxBackoff time.Duration
 }
 
 type ACMEOptions struct {
@@ -99,6 +121,10 @@ type ACMEOptions struct {
 	// challenges
 	HTTP01SolverImage string
 
+	// HTTP01SolverImagePullSecrets are the names of the secrets used to pull
+	// the HTTP01 solver image
+	HTTP01SolverImagePullSecrets []string
+
 	// HTTP01SolverResourceRequestCPU defines the ACME pod's resource request CPU size
 	HTTP01SolverResourceRequestCPU resource.Quantity
 
@@ -111,6 +137,169 @@ type ACMEOptions struct {
 	// HTTP01SolverResourceLimitsMemory defines the ACME pod's resource limits Memory size
 	HTTP01SolverResourceLimitsMemory resource.Quantity
 
+	// HTTP01SolverNamePrefix is the GenerateName prefix used for the pods,
+	// services and ingresses created to solve ACME HTTP01 challenges
+	HTTP01SolverNamePrefix string
+
+	// HTTP01SolverNamingStrategy is the name of the strategy used to name
+	// HTTP01 solver services and ingresses. If empty, names are generated
+	// from HTTP01SolverNamePrefix.
+	HTTP01SolverNamingStrategy string
+
+	// HTTP01SolverServicePortName, if set, is the name given to the port of
+	// HTTP01 solver services, and causes solver ingress paths to reference
+	// the service port by name rather than by number.
+	HTTP01SolverServicePortName string
+
+	// HTTP01SolverServicePort80, if true, additionally exposes HTTP01 solver
+	// services on port 80 and causes solver ingress paths to reference that
+	// port, for load balancers that only forward traffic to port 80.
+	HTTP01SolverServicePort80 bool
+
+	// HTTP01SolverCreateRetries is the number of times the creation of a
+	// HTTP01 solver pod, service or ingress will be retried if the apiserver
+	// returns a transient error
+	HTTP01SolverCreateRetries int
+
+	// HTTP01SolverCreateTimeout bounds the total time spent retrying the
+	// creation of a HTTP01 solver pod, service or ingress
+	HTTP01SolverCreateTimeout time.Duration
+
+	// HTTP01SolverMaxIngresses is the maximum number of HTTP01 solver
+	// ingresses that may exist in a single namespace at once. If zero, the
+	// number of solver ingresses is not limited.
+	HTTP01SolverMaxIngresses int
+
+	// HTTP01SolverTimeout is the maximum amount of time a HTTP01 challenge
+	// may take to be presented and pass the self check, measured from when
+	// it was first presented. If zero, challenges never time out.
+	HTTP01SolverTimeout time.Duration
+
+	// HTTP01SolverIngressDeleteTimeout is the maximum amount of time to wait
+	// for deleted HTTP01 solver ingresses to be removed when cleaning up a
+	// challenge. If zero, cleanup does not wait.
+	HTTP01SolverIngressDeleteTimeout time.Duration
+
+	// HTTP01SolverIngressRetention is how long HTTP01 solver ingresses are
+	// retained after their challenge has been cleaned up, before they are
+	// deleted by the cleanup janitor. If zero, they are deleted immediately.
+	HTTP01SolverIngressRetention time.Duration
+
+	// HTTP01SolverRegexPaths causes the paths added to ingress resources to
+	// be escaped and anchored as regular expressions, for ingress
+	// controllers that treat ingress paths as regexes.
+	HTTP01SolverRegexPaths bool
+
+	// HTTP01SolverPathSuffix is appended to the paths added to ingress
+	// resources, for ingress controllers that require a particular path form
+	// such as '/*' to match.
+	HTTP01SolverPathSuffix string
+
+	// HTTP01SolverAppendPaths causes the paths added to existing ingress
+	// resources to be appended after the ingress's own paths rather than
+	// placed first.
+	HTTP01SolverAppendPaths bool
+
+	// HTTP01SelfCheckViaIngress causes the HTTP01 self check to be performed
+	// against the load balancer address of the solver ingress, using the
+	// challenged domain as the Host header, rather than against the domain.
+	HTTP01SelfCheckViaIngress bool
+
+	// HTTP01SelfCheckTimeout is the maximum amount of time a single HTTP01
+	// self check may spend waiting for the challenge to become reachable.
+	HTTP01SelfCheckTimeout time.Duration
+
+	// HTTP01SelfCheckInterval is the amount of time to wait between the
+	// reachability tests performed by a HTTP01 self check.
+	HTTP01SelfCheckInterval time.Duration
+
+	// HTTP01SolverAllowedNamespaces is the list of namespaces that HTTP01
+	// solver resources may be created in. If empty, all namespaces are
+	// allowed.
+	HTTP01SolverAllowedNamespaces []string
+
+	// HTTP01SolverDeniedNamespaces is the list of namespaces that HTTP01
+	// solver resources must not be created in.
+	HTTP01SolverDeniedNamespaces []string
+
+	// HTTP01DisableIngressCreation prevents HTTP01 solver ingresses from
+	// being created, so that only existing ingresses are modified.
+	HTTP01DisableIngressCreation bool
+
+	// HTTP01RequireIngressOptIn prevents challenge paths from being added to
+	// existing ingresses that have not opted in using an annotation.
+	HTTP01RequireIngressOptIn bool
+
+	// HTTP01IngressAPIGroup is the API group used to manage HTTP01 solver
+	// ingresses, either "extensions" or "networking.k8s.io". If empty, the
+	// extensions group is used.
+	HTTP01IngressAPIGroup string
+
+	// HTTP01AllowForceCleanup permits all HTTP01 solver ingresses in a
+	// namespace to be deleted at once, regardless of their owner.
+	HTTP01AllowForceCleanup bool
+
+	// HTTP01ForceCleanupNamespaces are the namespaces whose HTTP01 solver
+	// ingresses are all deleted once when the challenges controller starts.
+	// This requires HTTP01AllowForceCleanup unless HTTP01ForceCleanupDryRun
+	// is set.
+	HTTP01ForceCleanupNamespaces []string
+
+	// HTTP01ForceCleanupDryRun causes the solver ingresses that would be
+	// deleted in HTTP01ForceCleanupNamespaces to be logged rather than
+	// deleted.
+	HTTP01ForceCleanupDryRun bool
+
+	// HTTP01SolverNamespace is the namespace HTTP01 solver resources are
+	// created in. If empty, the namespace of the challenge is used.
+	HTTP01SolverNamespace string
+
+	// HTTP01SolverPlainOwnerRefs, if true, causes HTTP01 solver resources to
+	// reference their challenge with a plain owner reference rather than a
+	// controller reference, so that they may be controlled by another
+	// controller.
+	HTTP01SolverPlainOwnerRefs bool
+
+	// HTTP01SolverInlineKeyAuthClasses are the ingress classes served by
+	// ingress-nginx with snippet annotations allowed, for which the key
+	// authorization is served by the ingress controller from an annotation on
+	// the HTTP01 solver ingress instead of by a solver pod.
+	HTTP01SolverInlineKeyAuthClasses []string
+
+	// HTTP01ReuseWildcardIngresses causes HTTP01 solver paths to be added to
+	// an existing ingress with a wildcard rule matching the challenged domain
+	// instead of creating a solver ingress.
+	HTTP01ReuseWildcardIngresses bool
+
+	// HTTP01SolverReuseIngresses causes solver ingresses created for other
+	// challenges for the same domain to be shared with new challenges,
+	// rather than creating a solver ingress for each challenge.
+	HTTP01SolverReuseIngresses bool
+
+	// HTTP01SolverIngressQPS is the maximum rate of Create, Update, Patch and
+	// Delete calls made for HTTP01 solver ingresses. If zero, calls are not
+	// rate limited.
+	HTTP01SolverIngressQPS float32
+
+	// HTTP01SolverIngressBurst is the maximum burst of ingress calls allowed
+	// above HTTP01SolverIngressQPS.
+	HTTP01SolverIngressBurst int
+
+	// HTTP01SolverCleanupGracePeriod is how long HTTP01 solver resources are
+	// retained after a challenge has become valid. If zero, they are cleaned
+	// up immediately.
+	HTTP01SolverCleanupGracePeriod time.Duration
+
+	// HTTP01SolverCleanupOrder is the order in which the ingress, service and
+	// pod of HTTP01 solvers are cleaned up. Each is cleaned up only once the
+	// previous one has been. If empty, ingresses are cleaned up first.
+	HTTP01SolverCleanupOrder []string
+
+	// HTTP01SolverReconcileBudget bounds the time a single call to Present or
+	// Check on the HTTP01 solver may take, after which the challenge is
+	// requeued. If zero, no budget is applied.
+	HTTP01SolverReconcileBudget time.Duration
+
 	// DNS01CheckAuthoritative is a flag for controlling if auth nss are used
 	// for checking propogation of an RR. This is the ideal scenario
 	DNS01CheckAuthoritative bool
@@ -118,6 +307,30 @@ type ACMEOptions struct {
 	// DNS01Nameservers is a list of nameservers to use when performing self-checks
 	// for ACME DNS01 validations.
 	DNS01Nameservers []string
+
+	// ChallengeCleanupJanitorPeriod is how often challenges whose solver
+	// records or resources failed to be cleaned up are re-queued so that
+	// clean up is retried. If zero, the janitor is disabled.
+	ChallengeCleanupJanitorPeriod time.Duration
+
+	// ChallengeRequeueJitter is the maximum fraction of the requeue interval
+	// randomly added when re-checking a presented challenge, spreading out
+	// the checks of challenges that were created at the same time.
+	ChallengeRequeueJitter float64
+
+	// AuthorizationPollInterval is the initial delay between polls of the
+	// ACME server while waiting for an accepted challenge's authorization to
+	// reach a final state. If zero, the ACME server's Retry-After period or
+	// one second is used.
+	AuthorizationPollInterval time.Duration
+
+	// AuthorizationPollBackoffFactor multiplies the delay between polls of a
+	// pending authorization after each poll.
+	AuthorizationPollBackoffFactor float64
+
+	// AuthorizationPollMaxInterval caps the delay between polls of a pending
+	// authorization. If zero, the delay is not capped.
+	AuthorizationPollMaxInterval time.Duration
 }
 
 type IngressShimOptions struct {
@@ -22,6 +22,7 @@ import (
 
 	"github.com/go-logr/logr"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes"
 	extlisters "k8s.io/client-go/listers/extensions/v1beta1"
@@ -65,6 +66,11 @@ type controller struct {
 
 	helper   issuer.Helper
 	defaults defaults
+
+	// certificateSelector, if set, limits the ingresses that Certificates
+	// are created for to those matching it. Certificates inherit the labels
+	// of their ingress, so this ensures they are visible to this controller.
+	certificateSelector labels.Selector
 }
 
 // Register registers and constructs the controller using the provided context.
@@ -117,6 +123,7 @@ func (c *controller) Register(ctx *controllerpkg.Context) (workqueue.RateLimitin
 		ctx.DefaultIssuerKind,
 		ctx.DefaultIssuerGroup,
 	}
+	c.certificateSelector = ctx.CertificateSelector
 
 	return c.queue, mustSync, nil, nil
 }
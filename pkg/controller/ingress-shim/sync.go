@@ -46,6 +46,11 @@ func (c *controller) Sync(ctx context.Context, ing *extv1beta1.Ingress) error {
 
 	metrics.Default.IncrementSyncCallCount(ControllerName)
 
+	if c.certificateSelector != nil && !c.certificateSelector.Matches(labels.Set(ing.Labels)) {
+		log.V(logs.DebugLevel).Info("not syncing ingress resource as it does not match the certificate selector")
+		return nil
+	}
+
 	if !shouldSync(ing, c.defaults.autoCertificateAnnotations) {
 		log.Info(fmt.Sprintf("not syncing ingress resource as it does not contain a %q or %q annotation",
 			cmapi.IngressIssuerNameAnnotationKey, cmapi.IngressClusterIssuerNameAnnotationKey))
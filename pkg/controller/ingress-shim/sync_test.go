@@ -24,6 +24,7 @@ import (
 
 	extv1beta1 "k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	types "k8s.io/apimachinery/pkg/types"
 	coretesting "k8s.io/client-go/testing"
@@ -93,12 +94,66 @@ func TestSync(t *testing.T) {
 		DefaultIssuerName   string
 		DefaultIssuerKind   string
 		DefaultIssuerGroup  string
+		CertificateSelector string
 		Err                 bool
 		ExpectedCreate      []*cmapi.Certificate
 		ExpectedUpdate      []*cmapi.Certificate
 		ExpectedDelete      []*cmapi.Certificate
 	}
+	selectorIngress := func(lbls map[string]string) *extv1beta1.Ingress {
+		return &extv1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "ingress-name",
+				Namespace: gen.DefaultTestNamespace,
+				Labels:    lbls,
+				Annotations: map[string]string{
+					cmapi.IngressClusterIssuerNameAnnotationKey: "issuer-name",
+				},
+				UID: types.UID("ingress-name"),
+			},
+			Spec: extv1beta1.IngressSpec{
+				TLS: []extv1beta1.IngressTLS{
+					{
+						Hosts:      []string{"example.com"},
+						SecretName: "example-com-tls",
+					},
+				},
+			},
+		}
+	}
 	tests := []testT{
+		{
+			Name:                "not create a Certificate for an ingress that does not match the certificate selector",
+			Issuer:              clusterIssuer,
+			Ingress:             selectorIngress(map[string]string{"shard": "b"}),
+			ClusterIssuerLister: []runtime.Object{clusterIssuer},
+			CertificateSelector: "shard=a",
+		},
+		{
+			Name:                "create a Certificate matching the certificate selector for an ingress that matches it",
+			Issuer:              clusterIssuer,
+			Ingress:             selectorIngress(map[string]string{"shard": "a"}),
+			ClusterIssuerLister: []runtime.Object{clusterIssuer},
+			CertificateSelector: "shard=a",
+			ExpectedCreate: []*cmapi.Certificate{
+				{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "example-com-tls",
+						Namespace:       gen.DefaultTestNamespace,
+						Labels:          map[string]string{"shard": "a"},
+						OwnerReferences: buildOwnerReferences("ingress-name", gen.DefaultTestNamespace),
+					},
+					Spec: cmapi.CertificateSpec{
+						DNSNames:   []string{"example.com"},
+						SecretName: "example-com-tls",
+						IssuerRef: cmmeta.ObjectReference{
+							Name: "issuer-name",
+							Kind: "ClusterIssuer",
+						},
+					},
+				},
+			},
+		},
 		{
 			Name:   "return a single HTTP01 Certificate for an ingress with a single valid TLS entry and HTTP01 annotations using edit-in-place",
 			Issuer: acmeClusterIssuer,
@@ -920,6 +975,13 @@ func TestSync(t *testing.T) {
 				},
 				helper: &fakeHelper{issuer: test.Issuer},
 			}
+			if test.CertificateSelector != "" {
+				selector, err := labels.Parse(test.CertificateSelector)
+				if err != nil {
+					t.Fatalf("error parsing certificate selector: %v", err)
+				}
+				c.certificateSelector = selector
+			}
 			b.Start()
 
 			err := c.Sync(context.Background(), test.Ingress)
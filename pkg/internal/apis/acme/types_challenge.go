@@ -106,4 +106,31 @@ type ChallengeStatus struct {
 	// State contains the current 'state' of the challenge.
 	// If not set, the state of the challenge is unknown.
 	State State
+
+	// PresentedURL is the URL that an HTTP01 challenge is served at once it
+	// has been presented.
+	PresentedURL string
+
+	// SolverService is the name and port of the service that requests for
+	// an HTTP01 challenge are routed to, in the form '<name>:<port>'.
+	SolverService string
+
+	// SolverAddress is the IP address or hostname assigned by the ingress
+	// controller to the ingress serving an HTTP01 challenge. It is never set
+	// for challenges solved using a NodePort service or a Gateway API
+	// HTTPRoute.
+	SolverAddress string
+
+	// SolverIngress is the name of the existing ingress that the paths for
+	// an HTTP01 challenge were added to, if the ingress was chosen using an
+	// ingress selector or a wildcard rule.
+	SolverIngress string
+
+	// ValidatedAt is the time at which the challenge was first observed to be
+	// valid.
+	ValidatedAt *metav1.Time
+
+	// PresentStartedAt is the time at which the challenge was first
+	// presented.
+	PresentStartedAt *metav1.Time
 }
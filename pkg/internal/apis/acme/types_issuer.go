@@ -18,7 +18,9 @@ package acme
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
 	apiext "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	cmmeta "github.com/jetstack/cert-manager/pkg/internal/apis/meta"
 )
@@ -45,6 +47,10 @@ type ACMEIssuer struct {
 	// Solvers is a list of challenge solvers that will be used to solve
 	// ACME challenges for the matching domains.
 	Solvers []ACMEChallengeSolver
+
+	// DefaultHTTP01IngressClass is the ingress class used by HTTP01 ingress
+	// solvers on this issuer that do not specify one of their own.
+	DefaultHTTP01IngressClass *string
 }
 
 // ACMEExternalAcccountBinding is a reference to a CA external account of the ACME
@@ -82,6 +88,8 @@ type ACMEChallengeSolver struct {
 	HTTP01 *ACMEChallengeSolverHTTP01
 
 	DNS01 *ACMEChallengeSolverDNS01
+
+	TLSALPN01 *ACMEChallengeSolverTLSALPN01
 }
 
 // CertificateDomainSelector selects certificates using a label selector, and
@@ -125,6 +133,22 @@ type ACMEChallengeSolverHTTP01 struct {
 	// '/.well-known/acme-challenge/XYZ' to 'challenge solver' pods that are
 	// provisioned by cert-manager for each Challenge to be completed.
 	Ingress *ACMEChallengeSolverHTTP01Ingress
+
+	// The NodePort based HTTP01 challenge solver will solve challenges by
+	// exposing 'challenge solver' pods directly using a NodePort service,
+	// without creating or modifying any Ingress resources.
+	// This is intended for clusters without an ingress controller, where
+	// traffic to port 80 is routed to the allocated node port externally.
+	// Only one of 'ingress' or 'nodePort' may be specified.
+	NodePort *ACMEChallengeSolverHTTP01NodePort
+
+	// The Gateway API based HTTP01 challenge solver will solve challenges by
+	// creating HTTPRoute resources from the Kubernetes Gateway API.
+	GatewayHTTPRoute *ACMEChallengeSolverHTTP01GatewayHTTPRoute
+
+	// Strategy is the name of a custom HTTP01 challenge solver implementation
+	// registered with the cert-manager controller.
+	Strategy string
 }
 
 type ACMEChallengeSolverHTTP01Ingress struct {
@@ -143,11 +167,112 @@ type ACMEChallengeSolverHTTP01Ingress struct {
 	// ingress resources.
 	Name string
 
+	// A label selector for an existing ingress resource that should have ACME
+	// challenge solving routes inserted into it.
+	IngressSelector *metav1.LabelSelector
+
+	// The name of an existing service that routes to the ACME challenge
+	// solver. If set, the solver service will not be created.
+	ServiceName string
+
+	// Additional path prefixes that the challenge token should be served
+	// under, alongside the canonical '/.well-known/acme-challenge' path.
+	ExtraPathPrefixes []string
+
+	// A template for the path that the challenge is served under, with the
+	// placeholders '{domain}' and '{token}'.
+	PathTemplate string
+
+	// If set, the ACME challenge solver pod will be run in the host network
+	// namespace, listening on this port of the node it is scheduled to.
+	HostNetworkPort int32
+
+	// If true, the solver service will be created without a cluster IP.
+	HeadlessService bool
+
+	// Optional pod template used to configure the ACME challenge solver pods
+	// used for HTTP01 challenges
+	PodTemplate *ACMEChallengeSolverHTTP01IngressPodTemplate
+
+	// Optional ingress template used to configure the ingress resources
+	// created to solve HTTP01 challenges.
+	IngressTemplate *ACMEChallengeSolverHTTP01IngressTemplate
+
+	// If true, the default backend of the ingress resources created to solve
+	// HTTP01 challenges is set to the solver service.
+	SolverDefaultBackend bool
+}
+
+type ACMEChallengeSolverHTTP01IngressTemplate struct {
+	// ObjectMeta overrides for the ingress used to solve HTTP01 challenges.
+	// Only the 'labels' and 'annotations' fields may be set.
+	ACMEChallengeSolverHTTP01IngressObjectMeta
+
+	// Spec is the base spec of the ingress used to solve HTTP01 challenges.
+	Spec *extv1beta1.IngressSpec
+}
+
+type ACMEChallengeSolverHTTP01IngressObjectMeta struct {
+	// Annotations that should be added to the created ACME HTTP01 solver ingresses.
+	Annotations map[string]string
+
+	// Labels that should be added to the created ACME HTTP01 solver ingresses.
+	Labels map[string]string
+}
+
+type ACMEChallengeSolverHTTP01NodePort struct {
+	// The node port to expose the challenge solver service on.
+	// If not specified, a port will be allocated by the apiserver.
+	// If specified, the port must be within the default node port range of
+	// 30000-32767, and the solver's selector must match a single DNS name
+	// using 'dnsNames', as node ports cannot be shared between challenges.
+	Port int32
+
 	// Optional pod template used to configure the ACME challenge solver pods
 	// used for HTTP01 challenges
 	PodTemplate *ACMEChallengeSolverHTTP01IngressPodTemplate
 }
 
+type ACMEChallengeSolverHTTP01GatewayHTTPRoute struct {
+	// Optional service type for Kubernetes solver service
+	ServiceType corev1.ServiceType
+
+	// The Gateways that the HTTPRoute resources created to solve challenges
+	// will be attached to.
+	ParentRefs []ACMEChallengeSolverHTTP01GatewayParentRef
+
+	// Labels that will be added to the HTTPRoute resources created to solve
+	// challenges.
+	Labels map[string]string
+
+	// Optional pod template used to configure the ACME challenge solver pods
+	// used for HTTP01 challenges
+	PodTemplate *ACMEChallengeSolverHTTP01IngressPodTemplate
+}
+
+// ACMEChallengeSolverTLSALPN01 configures the TLS-ALPN-01 challenge solver.
+type ACMEChallengeSolverTLSALPN01 struct {
+	// Optional service type for Kubernetes solver service
+	ServiceType corev1.ServiceType
+
+	// Optional pod template used to configure the ACME challenge solver pods
+	// used for TLS-ALPN-01 challenges
+	PodTemplate *ACMEChallengeSolverHTTP01IngressPodTemplate
+}
+
+// ACMEChallengeSolverHTTP01GatewayParentRef is a reference to a Gateway that
+// HTTP01 solver HTTPRoute resources will be attached to.
+type ACMEChallengeSolverHTTP01GatewayParentRef struct {
+	// The name of the Gateway.
+	Name string
+
+	// The namespace of the Gateway.
+	Namespace string
+
+	// The name of the listener on the Gateway to attach to.
+	SectionName string
+}
+
 type ACMEChallengeSolverHTTP01IngressPodTemplate struct {
 	// ObjectMeta overrides for the pod used to solve HTTP01 challenges.
 	// Only the 'labels' and 'annotations' fields may be set.
@@ -180,6 +305,13 @@ type ACMEChallengeSolverHTTP01IngressPodSpec struct {
 
 	// If specified, the pod's tolerations.
 	Tolerations []corev1.Toleration
+
+	// If specified, the compute resources requested by and limits applied to
+	// the solver container.
+	Resources *corev1.ResourceRequirements
+
+	// If specified, secrets used to pull the solver image.
+	ImagePullSecrets []corev1.LocalObjectReference
 }
 
 type ACMEChallengeSolverDNS01 struct {
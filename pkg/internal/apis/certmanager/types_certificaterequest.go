@@ -23,9 +23,10 @@ import (
 )
 
 const (
-	CertificateRequestReasonPending = "Pending"
-	CertificateRequestReasonFailed  = "Failed"
-	CertificateRequestReasonIssued  = "Issued"
+	CertificateRequestReasonPending           = "Pending"
+	CertificateRequestReasonFailed            = "Failed"
+	CertificateRequestReasonIssued            = "Issued"
+	CertificateRequestReasonIssuerUnavailable = "IssuerUnavailable"
 )
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
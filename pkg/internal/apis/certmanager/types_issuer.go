@@ -126,7 +126,12 @@ type VenafiCloud struct {
 	APITokenSecretRef cmmeta.SecretKeySelector
 }
 
-type SelfSignedIssuer struct{}
+type SelfSignedIssuer struct {
+	// NotBeforeSkew is the duration by which the NotBefore time of issued
+	// certificates is backdated, to tolerate clients with skewed clocks.
+	// Defaults to 1 minute if not set, and may not be longer than 1 hour.
+	NotBeforeSkew *metav1.Duration
+}
 
 type VaultIssuer struct {
 	// Vault authentication
@@ -195,8 +200,32 @@ type CAIssuer struct {
 	// SecretName is the name of the secret used to sign Certificates issued
 	// by this Issuer.
 	SecretName string
+
+	// NotBeforeSkew is the duration by which the NotBefore time of issued
+	// certificates is backdated, to tolerate clients with skewed clocks.
+	// Defaults to 1 minute if not set, and may not be longer than 1 hour.
+	NotBeforeSkew *metav1.Duration
+
+	// SignatureAlgorithm is the algorithm used by the CA to sign issued
+	// certificates. If not set, the algorithm implied by the CA key is used.
+	SignatureAlgorithm SignatureAlgorithm
+
+	// IssuingCertificateURLs is a list of URLs at which the CA certificate
+	// can be fetched, set as the AIA 'CA Issuers' URLs on issued certificates.
+	IssuingCertificateURLs []string
 }
 
+type SignatureAlgorithm string
+
+const (
+	SHA256WithRSA   SignatureAlgorithm = "SHA256WithRSA"
+	SHA384WithRSA   SignatureAlgorithm = "SHA384WithRSA"
+	SHA512WithRSA   SignatureAlgorithm = "SHA512WithRSA"
+	ECDSAWithSHA256 SignatureAlgorithm = "ECDSAWithSHA256"
+	ECDSAWithSHA384 SignatureAlgorithm = "ECDSAWithSHA384"
+	ECDSAWithSHA512 SignatureAlgorithm = "ECDSAWithSHA512"
+)
+
 // IssuerStatus contains status information about an Issuer
 type IssuerStatus struct {
 	Conditions []IssuerCondition
@@ -19,18 +19,36 @@ package validation
 import (
 	"crypto/x509"
 	"fmt"
+	"net/url"
+	"path"
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/runtime"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
+	cmapiv1alpha2 "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha2"
 	cmacme "github.com/jetstack/cert-manager/pkg/internal/apis/acme"
 	"github.com/jetstack/cert-manager/pkg/internal/apis/certmanager"
 	"github.com/jetstack/cert-manager/pkg/internal/apis/certmanager/validation/util"
 	cmmeta "github.com/jetstack/cert-manager/pkg/internal/apis/meta"
 )
 
+// acmeChallengePathPrefix is the path prefix that HTTP01 challenge tokens are
+// served under.
+const acmeChallengePathPrefix = "/.well-known/acme-challenge/"
+
+// minNodePort and maxNodePort bound the default range that the apiserver
+// allocates node ports from. The range configured on the apiserver cannot be
+// determined, so fixed node ports are validated against the default range.
+const (
+	minNodePort = 30000
+	maxNodePort = 32767
+)
+
 // Validation functions for cert-manager v1alpha2 Issuer types
 
 func ValidateIssuer(obj runtime.Object) field.ErrorList {
@@ -117,6 +135,10 @@ func ValidateACMEIssuerConfig(iss *cmacme.ACMEIssuer, fldPath *field.Path) field
 		}
 	}
 
+	if iss.DefaultHTTP01IngressClass != nil && len(*iss.DefaultHTTP01IngressClass) == 0 {
+		el = append(el, field.Required(fldPath.Child("defaultHTTP01IngressClass"), "must not be empty if specified"))
+	}
+
 	for i, sol := range iss.Solvers {
 		el = append(el, ValidateACMEIssuerChallengeSolverConfig(&sol, fldPath.Child("solvers").Index(i))...)
 	}
@@ -131,6 +153,12 @@ func ValidateACMEIssuerChallengeSolverConfig(sol *cmacme.ACMEChallengeSolver, fl
 	if sol.HTTP01 != nil {
 		numProviders++
 		el = append(el, ValidateACMEIssuerChallengeSolverHTTP01Config(sol.HTTP01, fldPath.Child("http01"))...)
+		// node ports are allocated cluster wide, so a fixed node port can
+		// only be used by a single challenge at a time
+		if sol.HTTP01.NodePort != nil && sol.HTTP01.NodePort.Port != 0 && !selectsSingleDNSName(sol.Selector) {
+			el = append(el, field.Forbidden(fldPath.Child("http01", "nodePort", "port"),
+				"a fixed node port may only be used by a solver whose selector matches a single DNS name using 'dnsNames', as node ports cannot be shared between challenges"))
+		}
 	}
 	if sol.DNS01 != nil {
 		if numProviders > 0 {
@@ -140,6 +168,14 @@ func ValidateACMEIssuerChallengeSolverConfig(sol *cmacme.ACMEChallengeSolver, fl
 			el = append(el, ValidateACMEChallengeSolverDNS01(sol.DNS01, fldPath.Child("dns01"))...)
 		}
 	}
+	if sol.TLSALPN01 != nil {
+		if numProviders > 0 {
+			el = append(el, field.Forbidden(fldPath, "may not specify more than one solver type in a single solver"))
+		} else {
+			numProviders++
+			el = append(el, ValidateACMEIssuerChallengeSolverTLSALPN01Config(sol.TLSALPN01, fldPath.Child("tlsALPN01"))...)
+		}
+	}
 	if numProviders == 0 {
 		el = append(el, field.Required(fldPath, "no solver type configured"))
 	}
@@ -155,6 +191,29 @@ func ValidateACMEIssuerChallengeSolverHTTP01Config(http01 *cmacme.ACMEChallengeS
 		numDefined++
 		el = append(el, ValidateACMEIssuerChallengeSolverHTTP01IngressConfig(http01.Ingress, fldPath.Child("ingress"))...)
 	}
+	if http01.NodePort != nil {
+		if numDefined > 0 {
+			el = append(el, field.Forbidden(fldPath, "only one of 'ingress' or 'nodePort' should be specified"))
+		} else {
+			numDefined++
+			el = append(el, ValidateACMEIssuerChallengeSolverHTTP01NodePortConfig(http01.NodePort, fldPath.Child("nodePort"))...)
+		}
+	}
+	if http01.GatewayHTTPRoute != nil {
+		if numDefined > 0 {
+			el = append(el, field.Forbidden(fldPath, "only one of 'ingress', 'nodePort' or 'gatewayHTTPRoute' should be specified"))
+		} else {
+			numDefined++
+			el = append(el, ValidateACMEIssuerChallengeSolverHTTP01GatewayHTTPRouteConfig(http01.GatewayHTTPRoute, fldPath.Child("gatewayHTTPRoute"))...)
+		}
+	}
+	if len(http01.Strategy) > 0 {
+		if numDefined > 0 {
+			el = append(el, field.Forbidden(fldPath.Child("strategy"), "'strategy' cannot be specified alongside 'ingress', 'nodePort' or 'gatewayHTTPRoute'"))
+		} else {
+			numDefined++
+		}
+	}
 	if numDefined == 0 {
 		el = append(el, field.Required(fldPath, "no HTTP01 solver type configured"))
 	}
@@ -168,11 +227,171 @@ func ValidateACMEIssuerChallengeSolverHTTP01IngressConfig(ingress *cmacme.ACMECh
 	if ingress.Class != nil && len(ingress.Name) > 0 {
 		el = append(el, field.Forbidden(fldPath, "only one of 'name' or 'class' should be specified"))
 	}
+	// an empty class would create ingresses that no ingress controller
+	// is guaranteed to pick up, so require the field to be omitted instead
+	if ingress.Class != nil && len(*ingress.Class) == 0 {
+		el = append(el, field.Required(fldPath.Child("class"), "must not be empty if specified"))
+	}
+	if len(ingress.Name) > 0 {
+		for _, msg := range utilvalidation.IsDNS1123Subdomain(ingress.Name) {
+			el = append(el, field.Invalid(fldPath.Child("name"), ingress.Name, msg))
+		}
+	}
+	if ingress.IngressSelector != nil {
+		if len(ingress.Name) > 0 {
+			el = append(el, field.Forbidden(fldPath, "only one of 'name' or 'ingressSelector' should be specified"))
+		}
+		if ingress.Class != nil {
+			el = append(el, field.Forbidden(fldPath, "only one of 'class' or 'ingressSelector' should be specified"))
+		}
+		// an empty selector would match every ingress in the namespace
+		if len(ingress.IngressSelector.MatchLabels) == 0 && len(ingress.IngressSelector.MatchExpressions) == 0 {
+			el = append(el, field.Required(fldPath.Child("ingressSelector"), "must not be empty"))
+		}
+		el = append(el, metav1validation.ValidateLabelSelector(ingress.IngressSelector, fldPath.Child("ingressSelector"))...)
+	}
 	switch ingress.ServiceType {
 	case "", corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort:
 	default:
 		el = append(el, field.Invalid(fldPath.Child("serviceType"), ingress.ServiceType, `must be empty, "ClusterIP" or "NodePort"`))
 	}
+	if len(ingress.ServiceName) > 0 {
+		if len(ingress.ServiceType) > 0 {
+			el = append(el, field.Forbidden(fldPath, "only one of 'serviceName' or 'serviceType' should be specified"))
+		}
+		for _, msg := range utilvalidation.IsDNS1035Label(ingress.ServiceName) {
+			el = append(el, field.Invalid(fldPath.Child("serviceName"), ingress.ServiceName, msg))
+		}
+	}
+	if ingress.HostNetworkPort != 0 {
+		if ingress.HostNetworkPort < 1 || ingress.HostNetworkPort > 65535 {
+			el = append(el, field.Invalid(fldPath.Child("hostNetworkPort"), ingress.HostNetworkPort, "must be between 1 and 65535, inclusive"))
+		}
+		if len(ingress.ServiceName) > 0 {
+			el = append(el, field.Forbidden(fldPath, "only one of 'serviceName' or 'hostNetworkPort' should be specified"))
+		}
+		if len(ingress.ServiceType) > 0 && ingress.ServiceType != corev1.ServiceTypeClusterIP {
+			el = append(el, field.Invalid(fldPath.Child("serviceType"), ingress.ServiceType, `must be empty or "ClusterIP" when 'hostNetworkPort' is specified`))
+		}
+	}
+	if ingress.HeadlessService {
+		if len(ingress.ServiceName) > 0 {
+			el = append(el, field.Forbidden(fldPath, "only one of 'serviceName' or 'headlessService' should be specified"))
+		}
+		if len(ingress.ServiceType) > 0 && ingress.ServiceType != corev1.ServiceTypeClusterIP {
+			el = append(el, field.Invalid(fldPath.Child("serviceType"), ingress.ServiceType, `must be empty or "ClusterIP" when 'headlessService' is specified`))
+		}
+	}
+	seen := make(map[string]struct{})
+	for i, prefix := range ingress.ExtraPathPrefixes {
+		fld := fldPath.Child("extraPathPrefixes").Index(i)
+		if _, ok := seen[prefix]; ok {
+			el = append(el, field.Duplicate(fld, prefix))
+			continue
+		}
+		seen[prefix] = struct{}{}
+		if prefix == "/" || !strings.HasPrefix(prefix, "/") || path.Clean(prefix) != prefix {
+			el = append(el, field.Invalid(fld, prefix, "must be an absolute path without a trailing '/'"))
+		}
+		if strings.Contains(prefix, ",") {
+			el = append(el, field.Invalid(fld, prefix, "must not contain ','"))
+		}
+	}
+	if tmpl := ingress.PathTemplate; len(tmpl) > 0 {
+		// the self check and solver pod find the challenge by its token
+		if !strings.HasPrefix(tmpl, "/") || !strings.HasSuffix(tmpl, "/{token}") {
+			el = append(el, field.Invalid(fldPath.Child("pathTemplate"), tmpl, "must begin with '/' and end with '/{token}'"))
+		}
+	}
+	if ingress.PodTemplate != nil {
+		fld := fldPath.Child("podTemplate", "spec", "imagePullSecrets")
+		for i, secret := range ingress.PodTemplate.Spec.ImagePullSecrets {
+			for _, msg := range utilvalidation.IsDNS1123Subdomain(secret.Name) {
+				el = append(el, field.Invalid(fld.Index(i).Child("name"), secret.Name, msg))
+			}
+		}
+	}
+	if tmpl := ingress.IngressTemplate; tmpl != nil {
+		fld := fldPath.Child("ingressTemplate")
+		// the template only applies to ingresses created by the solver
+		if len(ingress.Name) > 0 || ingress.IngressSelector != nil {
+			el = append(el, field.Forbidden(fld, "cannot be specified alongside 'name' or 'ingressSelector'"))
+		}
+		if tmpl.Spec != nil {
+			for i, rule := range tmpl.Spec.Rules {
+				if rule.HTTP == nil {
+					continue
+				}
+				for j, p := range rule.HTTP.Paths {
+					// paths within the challenge path prefix could shadow the
+					// paths added to solve challenges
+					if strings.HasPrefix(p.Path, acmeChallengePathPrefix) {
+						pathFld := fld.Child("spec", "rules").Index(i).Child("http", "paths").Index(j).Child("path")
+						el = append(el, field.Invalid(pathFld, p.Path, fmt.Sprintf("must not be within %q", acmeChallengePathPrefix)))
+					}
+				}
+			}
+		}
+	}
+	if ingress.SolverDefaultBackend {
+		fld := fldPath.Child("solverDefaultBackend")
+		// the default backend is only set on ingresses created by the solver
+		if len(ingress.Name) > 0 || ingress.IngressSelector != nil {
+			el = append(el, field.Forbidden(fld, "cannot be specified alongside 'name' or 'ingressSelector'"))
+		}
+		if tmpl := ingress.IngressTemplate; tmpl != nil && tmpl.Spec != nil && tmpl.Spec.Backend != nil {
+			el = append(el, field.Forbidden(fld, "cannot be specified alongside 'ingressTemplate.spec.backend'"))
+		}
+	}
+
+	return el
+}
+
+// selectsSingleDNSName returns true if the given solver selector restricts
+// the solver to a single DNS name.
+func selectsSingleDNSName(sel *cmacme.CertificateDNSNameSelector) bool {
+	return sel != nil && len(sel.DNSNames) == 1 && len(sel.DNSZones) == 0
+}
+
+func ValidateACMEIssuerChallengeSolverHTTP01NodePortConfig(nodePort *cmacme.ACMEChallengeSolverHTTP01NodePort, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+
+	if nodePort.Port != 0 && (nodePort.Port < minNodePort || nodePort.Port > maxNodePort) {
+		el = append(el, field.Invalid(fldPath.Child("port"), nodePort.Port, fmt.Sprintf("must be between %d and %d, inclusive", minNodePort, maxNodePort)))
+	}
+
+	return el
+}
+
+func ValidateACMEIssuerChallengeSolverHTTP01GatewayHTTPRouteConfig(route *cmacme.ACMEChallengeSolverHTTP01GatewayHTTPRoute, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+
+	if len(route.ParentRefs) == 0 {
+		el = append(el, field.Required(fldPath.Child("parentRefs"), "at least one parent Gateway must be specified"))
+	}
+	for i, ref := range route.ParentRefs {
+		if len(ref.Name) == 0 {
+			el = append(el, field.Required(fldPath.Child("parentRefs").Index(i).Child("name"), "the name of the Gateway is required"))
+		}
+	}
+	switch route.ServiceType {
+	case "", corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort:
+	default:
+		el = append(el, field.Invalid(fldPath.Child("serviceType"), route.ServiceType, `must be empty, "ClusterIP" or "NodePort"`))
+	}
+	el = append(el, metav1validation.ValidateLabels(route.Labels, fldPath.Child("labels"))...)
+
+	return el
+}
+
+func ValidateACMEIssuerChallengeSolverTLSALPN01Config(tlsALPN *cmacme.ACMEChallengeSolverTLSALPN01, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+
+	switch tlsALPN.ServiceType {
+	case "", corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
+	default:
+		el = append(el, field.Invalid(fldPath.Child("serviceType"), tlsALPN.ServiceType, `must be empty, "ClusterIP", "NodePort" or "LoadBalancer"`))
+	}
 
 	return el
 }
@@ -182,11 +401,46 @@ func ValidateCAIssuerConfig(iss *certmanager.CAIssuer, fldPath *field.Path) fiel
 	if len(iss.SecretName) == 0 {
 		el = append(el, field.Required(fldPath.Child("secretName"), ""))
 	}
+	el = append(el, ValidateNotBeforeSkew(iss.NotBeforeSkew, fldPath.Child("notBeforeSkew"))...)
+	switch iss.SignatureAlgorithm {
+	case "", certmanager.SHA256WithRSA, certmanager.SHA384WithRSA, certmanager.SHA512WithRSA,
+		certmanager.ECDSAWithSHA256, certmanager.ECDSAWithSHA384, certmanager.ECDSAWithSHA512:
+	default:
+		el = append(el, field.NotSupported(fldPath.Child("signatureAlgorithm"), iss.SignatureAlgorithm, []string{
+			string(certmanager.SHA256WithRSA), string(certmanager.SHA384WithRSA), string(certmanager.SHA512WithRSA),
+			string(certmanager.ECDSAWithSHA256), string(certmanager.ECDSAWithSHA384), string(certmanager.ECDSAWithSHA512),
+		}))
+	}
+	for i, u := range iss.IssuingCertificateURLs {
+		fldPath := fldPath.Child("issuingCertificateURLs").Index(i)
+		parsed, err := url.Parse(u)
+		if err != nil {
+			el = append(el, field.Invalid(fldPath, u, err.Error()))
+			continue
+		}
+		if !parsed.IsAbs() || parsed.Host == "" {
+			el = append(el, field.Invalid(fldPath, u, "must be an absolute URL"))
+		}
+	}
 	return el
 }
 
 func ValidateSelfSignedIssuerConfig(iss *certmanager.SelfSignedIssuer, fldPath *field.Path) field.ErrorList {
-	return nil
+	return ValidateNotBeforeSkew(iss.NotBeforeSkew, fldPath.Child("notBeforeSkew"))
+}
+
+func ValidateNotBeforeSkew(skew *metav1.Duration, fldPath *field.Path) field.ErrorList {
+	el := field.ErrorList{}
+	if skew == nil {
+		return el
+	}
+	if skew.Duration < 0 {
+		el = append(el, field.Invalid(fldPath, skew.Duration, "must not be negative"))
+	}
+	if skew.Duration > cmapiv1alpha2.MaximumNotBeforeSkew {
+		el = append(el, field.Invalid(fldPath, skew.Duration, fmt.Sprintf("must be no greater than %s", cmapiv1alpha2.MaximumNotBeforeSkew)))
+	}
+	return el
 }
 
 func ValidateVaultIssuerConfig(iss *certmanager.VaultIssuer, fldPath *field.Path) field.ErrorList {
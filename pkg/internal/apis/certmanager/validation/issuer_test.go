@@ -19,8 +19,12 @@ package validation
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilvalidation "k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 
 	cmacme "github.com/jetstack/cert-manager/pkg/internal/apis/acme"
@@ -165,6 +169,17 @@ func TestValidateACMEIssuerConfig(t *testing.T) {
 				field.Required(fldPath.Child("externalAccountBinding.keyAlgorithm"), "the keyAlgorithm field is required when using externalAccountBinding"),
 			},
 		},
+		"acme issuer with empty default http01 ingress class": {
+			spec: &cmacme.ACMEIssuer{
+				Email:                     "valid-email",
+				Server:                    "valid-server",
+				PrivateKey:                validSecretKeyRef,
+				DefaultHTTP01IngressClass: strPtr(""),
+			},
+			errs: []*field.Error{
+				field.Required(fldPath.Child("defaultHTTP01IngressClass"), "must not be empty if specified"),
+			},
+		},
 		"acme solver with missing http01 config type": {
 			spec: &cmacme.ACMEIssuer{
 				Email:      "valid-email",
@@ -194,6 +209,245 @@ func TestValidateACMEIssuerConfig(t *testing.T) {
 				},
 			},
 		},
+		"acme solver with valid http01 nodePort config": {
+			spec: &cmacme.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "valid-server",
+				PrivateKey: validSecretKeyRef,
+				Solvers: []cmacme.ACMEChallengeSolver{
+					{
+						Selector: &cmacme.CertificateDNSNameSelector{
+							DNSNames: []string{"example.com"},
+						},
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							NodePort: &cmacme.ACMEChallengeSolverHTTP01NodePort{
+								Port: 30080,
+							},
+						},
+					},
+				},
+			},
+		},
+		"acme solver with http01 nodePort port outside the node port range": {
+			spec: &cmacme.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "valid-server",
+				PrivateKey: validSecretKeyRef,
+				Solvers: []cmacme.ACMEChallengeSolver{
+					{
+						Selector: &cmacme.CertificateDNSNameSelector{
+							DNSNames: []string{"example.com"},
+						},
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							NodePort: &cmacme.ACMEChallengeSolverHTTP01NodePort{
+								Port: 8080,
+							},
+						},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("solvers").Index(0).Child("http01", "nodePort", "port"), int32(8080), "must be between 30000 and 32767, inclusive"),
+			},
+		},
+		"acme solver with fixed http01 nodePort port that could be shared between challenges": {
+			spec: &cmacme.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "valid-server",
+				PrivateKey: validSecretKeyRef,
+				Solvers: []cmacme.ACMEChallengeSolver{
+					{
+						Selector: &cmacme.CertificateDNSNameSelector{
+							DNSZones: []string{"example.com"},
+						},
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							NodePort: &cmacme.ACMEChallengeSolverHTTP01NodePort{
+								Port: 30080,
+							},
+						},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("solvers").Index(0).Child("http01", "nodePort", "port"),
+					"a fixed node port may only be used by a solver whose selector matches a single DNS name using 'dnsNames', as node ports cannot be shared between challenges"),
+			},
+		},
+		"acme solver with both http01 ingress and nodePort config": {
+			spec: &cmacme.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "valid-server",
+				PrivateKey: validSecretKeyRef,
+				Solvers: []cmacme.ACMEChallengeSolver{
+					{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress:  &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+							NodePort: &cmacme.ACMEChallengeSolverHTTP01NodePort{},
+						},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("solvers").Index(0).Child("http01"), "only one of 'ingress' or 'nodePort' should be specified"),
+			},
+		},
+		"acme solver with valid http01 gatewayHTTPRoute config": {
+			spec: &cmacme.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "valid-server",
+				PrivateKey: validSecretKeyRef,
+				Solvers: []cmacme.ACMEChallengeSolver{
+					{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							GatewayHTTPRoute: &cmacme.ACMEChallengeSolverHTTP01GatewayHTTPRoute{
+								ParentRefs: []cmacme.ACMEChallengeSolverHTTP01GatewayParentRef{
+									{Name: "gateway", Namespace: "gateway-ns", SectionName: "http"},
+								},
+								Labels: map[string]string{"acme": "true"},
+							},
+						},
+					},
+				},
+			},
+		},
+		"acme solver with http01 gatewayHTTPRoute config missing parent refs": {
+			spec: &cmacme.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "valid-server",
+				PrivateKey: validSecretKeyRef,
+				Solvers: []cmacme.ACMEChallengeSolver{
+					{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							GatewayHTTPRoute: &cmacme.ACMEChallengeSolverHTTP01GatewayHTTPRoute{
+								ServiceType: "LoadBalancer",
+							},
+						},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Required(fldPath.Child("solvers").Index(0).Child("http01", "gatewayHTTPRoute", "parentRefs"), "at least one parent Gateway must be specified"),
+				field.Invalid(fldPath.Child("solvers").Index(0).Child("http01", "gatewayHTTPRoute", "serviceType"), corev1.ServiceType("LoadBalancer"), `must be empty, "ClusterIP" or "NodePort"`),
+			},
+		},
+		"acme solver with http01 gatewayHTTPRoute parent ref missing a name": {
+			spec: &cmacme.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "valid-server",
+				PrivateKey: validSecretKeyRef,
+				Solvers: []cmacme.ACMEChallengeSolver{
+					{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							GatewayHTTPRoute: &cmacme.ACMEChallengeSolverHTTP01GatewayHTTPRoute{
+								ParentRefs: []cmacme.ACMEChallengeSolverHTTP01GatewayParentRef{{}},
+							},
+						},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Required(fldPath.Child("solvers").Index(0).Child("http01", "gatewayHTTPRoute", "parentRefs").Index(0).Child("name"), "the name of the Gateway is required"),
+			},
+		},
+		"acme solver with both http01 ingress and gatewayHTTPRoute config": {
+			spec: &cmacme.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "valid-server",
+				PrivateKey: validSecretKeyRef,
+				Solvers: []cmacme.ACMEChallengeSolver{
+					{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress:          &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+							GatewayHTTPRoute: &cmacme.ACMEChallengeSolverHTTP01GatewayHTTPRoute{},
+						},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("solvers").Index(0).Child("http01"), "only one of 'ingress', 'nodePort' or 'gatewayHTTPRoute' should be specified"),
+			},
+		},
+		"acme solver with valid tlsALPN01 config": {
+			spec: &cmacme.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "valid-server",
+				PrivateKey: validSecretKeyRef,
+				Solvers: []cmacme.ACMEChallengeSolver{
+					{
+						TLSALPN01: &cmacme.ACMEChallengeSolverTLSALPN01{
+							ServiceType: corev1.ServiceTypeLoadBalancer,
+						},
+					},
+				},
+			},
+		},
+		"acme solver with tlsALPN01 config with an invalid service type": {
+			spec: &cmacme.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "valid-server",
+				PrivateKey: validSecretKeyRef,
+				Solvers: []cmacme.ACMEChallengeSolver{
+					{
+						TLSALPN01: &cmacme.ACMEChallengeSolverTLSALPN01{
+							ServiceType: corev1.ServiceTypeExternalName,
+						},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("solvers").Index(0).Child("tlsALPN01", "serviceType"), corev1.ServiceTypeExternalName, `must be empty, "ClusterIP", "NodePort" or "LoadBalancer"`),
+			},
+		},
+		"acme solver with both http01 and tlsALPN01 config": {
+			spec: &cmacme.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "valid-server",
+				PrivateKey: validSecretKeyRef,
+				Solvers: []cmacme.ACMEChallengeSolver{
+					{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+						},
+						TLSALPN01: &cmacme.ACMEChallengeSolverTLSALPN01{},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("solvers").Index(0), "may not specify more than one solver type in a single solver"),
+			},
+		},
+		"acme solver with valid http01 strategy config": {
+			spec: &cmacme.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "valid-server",
+				PrivateKey: validSecretKeyRef,
+				Solvers: []cmacme.ACMEChallengeSolver{
+					{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Strategy: "custom-router",
+						},
+					},
+				},
+			},
+		},
+		"acme solver with both http01 ingress and strategy config": {
+			spec: &cmacme.ACMEIssuer{
+				Email:      "valid-email",
+				Server:     "valid-server",
+				PrivateKey: validSecretKeyRef,
+				Solvers: []cmacme.ACMEChallengeSolver{
+					{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress:  &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+							Strategy: "custom-router",
+						},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("solvers").Index(0).Child("http01", "strategy"), "'strategy' cannot be specified alongside 'ingress', 'nodePort' or 'gatewayHTTPRoute'"),
+			},
+		},
 		"acme issue with valid pod template ObjectMeta attributes": {
 			spec: &cmacme.ACMEIssuer{
 				Email:      "valid-email",
@@ -316,6 +570,81 @@ func TestValidateIssuerSpec(t *testing.T) {
 			},
 			errs: []*field.Error{field.Required(fldPath.Child("ca", "secretName"), "")},
 		},
+		"valid ca issuer with notBeforeSkew": {
+			spec: &cmapi.IssuerSpec{
+				IssuerConfig: cmapi.IssuerConfig{
+					CA: &cmapi.CAIssuer{
+						SecretName:    "valid",
+						NotBeforeSkew: &metav1.Duration{Duration: 5 * time.Minute},
+					},
+				},
+			},
+		},
+		"ca issuer with negative notBeforeSkew": {
+			spec: &cmapi.IssuerSpec{
+				IssuerConfig: cmapi.IssuerConfig{
+					CA: &cmapi.CAIssuer{
+						SecretName:    "valid",
+						NotBeforeSkew: &metav1.Duration{Duration: -time.Minute},
+					},
+				},
+			},
+			errs: []*field.Error{field.Invalid(fldPath.Child("ca", "notBeforeSkew"), -time.Minute, "must not be negative")},
+		},
+		"valid ca issuer with signatureAlgorithm": {
+			spec: &cmapi.IssuerSpec{
+				IssuerConfig: cmapi.IssuerConfig{
+					CA: &cmapi.CAIssuer{
+						SecretName:         "valid",
+						SignatureAlgorithm: cmapi.ECDSAWithSHA384,
+					},
+				},
+			},
+		},
+		"ca issuer with unsupported signatureAlgorithm": {
+			spec: &cmapi.IssuerSpec{
+				IssuerConfig: cmapi.IssuerConfig{
+					CA: &cmapi.CAIssuer{
+						SecretName:         "valid",
+						SignatureAlgorithm: cmapi.SignatureAlgorithm("SHA1WithRSA"),
+					},
+				},
+			},
+			errs: []*field.Error{field.NotSupported(fldPath.Child("ca", "signatureAlgorithm"), cmapi.SignatureAlgorithm("SHA1WithRSA"), []string{
+				"SHA256WithRSA", "SHA384WithRSA", "SHA512WithRSA", "ECDSAWithSHA256", "ECDSAWithSHA384", "ECDSAWithSHA512",
+			})},
+		},
+		"valid ca issuer with issuingCertificateURLs": {
+			spec: &cmapi.IssuerSpec{
+				IssuerConfig: cmapi.IssuerConfig{
+					CA: &cmapi.CAIssuer{
+						SecretName:             "valid",
+						IssuingCertificateURLs: []string{"http://pki.example.com/ca.crt"},
+					},
+				},
+			},
+		},
+		"ca issuer with relative issuingCertificateURLs": {
+			spec: &cmapi.IssuerSpec{
+				IssuerConfig: cmapi.IssuerConfig{
+					CA: &cmapi.CAIssuer{
+						SecretName:             "valid",
+						IssuingCertificateURLs: []string{"http://pki.example.com/ca.crt", "/ca.crt"},
+					},
+				},
+			},
+			errs: []*field.Error{field.Invalid(fldPath.Child("ca", "issuingCertificateURLs").Index(1), "/ca.crt", "must be an absolute URL")},
+		},
+		"self signed issuer with too large notBeforeSkew": {
+			spec: &cmapi.IssuerSpec{
+				IssuerConfig: cmapi.IssuerConfig{
+					SelfSigned: &cmapi.SelfSignedIssuer{
+						NotBeforeSkew: &metav1.Duration{Duration: 2 * time.Hour},
+					},
+				},
+			},
+			errs: []*field.Error{field.Invalid(fldPath.Child("selfSigned", "notBeforeSkew"), 2*time.Hour, "must be no greater than 1h0m0s")},
+		},
 		"valid self signed issuer": {
 			spec: &cmapi.IssuerSpec{
 				IssuerConfig: cmapi.IssuerConfig{
@@ -393,6 +722,22 @@ func TestValidateACMEIssuerHTTP01Config(t *testing.T) {
 				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{Class: strPtr("abc")},
 			},
 		},
+		"ingress class field specified but empty": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{Class: strPtr("")},
+			},
+			errs: []*field.Error{
+				field.Required(fldPath.Child("ingress", "class"), "must not be empty if specified"),
+			},
+		},
+		"invalid ingress name specified": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{Name: "Web_Ingress"},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("ingress", "name"), "Web_Ingress", utilvalidation.IsDNS1123Subdomain("Web_Ingress")[0]),
+			},
+		},
 		"neither field specified": {
 			cfg: &cmacme.ACMEChallengeSolverHTTP01{
 				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
@@ -446,6 +791,274 @@ func TestValidateACMEIssuerHTTP01Config(t *testing.T) {
 				field.Invalid(fldPath.Child("ingress", "serviceType"), corev1.ServiceType("InvalidServiceType"), `must be empty, "ClusterIP" or "NodePort"`),
 			},
 		},
+		"acme issuer with valid extra path prefixes": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					ExtraPathPrefixes: []string{"/acme-challenge", "/proxy/acme-challenge"},
+				},
+			},
+		},
+		"acme issuer with invalid extra path prefixes": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					ExtraPathPrefixes: []string{"acme-challenge", "/acme-challenge/", "/", "/a,b", "/a,b"},
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("ingress", "extraPathPrefixes").Index(0), "acme-challenge", "must be an absolute path without a trailing '/'"),
+				field.Invalid(fldPath.Child("ingress", "extraPathPrefixes").Index(1), "/acme-challenge/", "must be an absolute path without a trailing '/'"),
+				field.Invalid(fldPath.Child("ingress", "extraPathPrefixes").Index(2), "/", "must be an absolute path without a trailing '/'"),
+				field.Invalid(fldPath.Child("ingress", "extraPathPrefixes").Index(3), "/a,b", "must not contain ','"),
+				field.Duplicate(fldPath.Child("ingress", "extraPathPrefixes").Index(4), "/a,b"),
+			},
+		},
+		"acme issuer with valid image pull secrets": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					PodTemplate: &cmacme.ACMEChallengeSolverHTTP01IngressPodTemplate{
+						Spec: cmacme.ACMEChallengeSolverHTTP01IngressPodSpec{
+							ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry-credentials"}},
+						},
+					},
+				},
+			},
+		},
+		"acme issuer with invalid image pull secret name": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					PodTemplate: &cmacme.ACMEChallengeSolverHTTP01IngressPodTemplate{
+						Spec: cmacme.ACMEChallengeSolverHTTP01IngressPodSpec{
+							ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry-credentials"}, {Name: "Invalid_Name"}},
+						},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("ingress", "podTemplate", "spec", "imagePullSecrets").Index(1).Child("name"), "Invalid_Name",
+					"a DNS-1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')"),
+			},
+		},
+		"acme issuer with valid ingress template": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					IngressTemplate: &cmacme.ACMEChallengeSolverHTTP01IngressTemplate{
+						Spec: &extv1beta1.IngressSpec{
+							Rules: []extv1beta1.IngressRule{{
+								Host: "example.com",
+								IngressRuleValue: extv1beta1.IngressRuleValue{
+									HTTP: &extv1beta1.HTTPIngressRuleValue{
+										Paths: []extv1beta1.HTTPIngressPath{{Path: "/.well-known/other"}},
+									},
+								},
+							}},
+						},
+					},
+				},
+			},
+		},
+		"acme issuer with ingress template and name": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					Name:            "existing",
+					IngressTemplate: &cmacme.ACMEChallengeSolverHTTP01IngressTemplate{},
+				},
+			},
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("ingress", "ingressTemplate"), "cannot be specified alongside 'name' or 'ingressSelector'"),
+			},
+		},
+		"acme issuer with ingress template path within the challenge path prefix": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					IngressTemplate: &cmacme.ACMEChallengeSolverHTTP01IngressTemplate{
+						Spec: &extv1beta1.IngressSpec{
+							Rules: []extv1beta1.IngressRule{{
+								IngressRuleValue: extv1beta1.IngressRuleValue{
+									HTTP: &extv1beta1.HTTPIngressRuleValue{
+										Paths: []extv1beta1.HTTPIngressPath{{Path: "/.well-known/acme-challenge/abcd"}},
+									},
+								},
+							}},
+						},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("ingress", "ingressTemplate", "spec", "rules").Index(0).Child("http", "paths").Index(0).Child("path"),
+					"/.well-known/acme-challenge/abcd", `must not be within "/.well-known/acme-challenge/"`),
+			},
+		},
+		"acme issuer with solver default backend": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					SolverDefaultBackend: true,
+				},
+			},
+		},
+		"acme issuer with solver default backend and ingress name": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					Name:                 "ingress",
+					SolverDefaultBackend: true,
+				},
+			},
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("ingress", "solverDefaultBackend"), "cannot be specified alongside 'name' or 'ingressSelector'"),
+			},
+		},
+		"acme issuer with solver default backend and ingress template backend": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					IngressTemplate: &cmacme.ACMEChallengeSolverHTTP01IngressTemplate{
+						Spec: &extv1beta1.IngressSpec{
+							Backend: &extv1beta1.IngressBackend{ServiceName: "default"},
+						},
+					},
+					SolverDefaultBackend: true,
+				},
+			},
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("ingress", "solverDefaultBackend"), "cannot be specified alongside 'ingressTemplate.spec.backend'"),
+			},
+		},
+		"acme issuer with valid existing service name": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					ServiceName: "acme-solver",
+				},
+			},
+		},
+		"acme issuer with existing service name and service type": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					ServiceName: "acme-solver",
+					ServiceType: corev1.ServiceTypeNodePort,
+				},
+			},
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("ingress"), "only one of 'serviceName' or 'serviceType' should be specified"),
+			},
+		},
+		"acme issuer with invalid existing service name": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					ServiceName: "acme.solver",
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("ingress", "serviceName"), "acme.solver", utilvalidation.IsDNS1035Label("acme.solver")[0]),
+			},
+		},
+		"acme issuer with valid host network port": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					HostNetworkPort: 8089,
+					ServiceType:     corev1.ServiceTypeClusterIP,
+				},
+			},
+		},
+		"acme issuer with invalid host network port": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					HostNetworkPort: 70000,
+					ServiceName:     "acme-solver",
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("ingress", "hostNetworkPort"), int32(70000), "must be between 1 and 65535, inclusive"),
+				field.Forbidden(fldPath.Child("ingress"), "only one of 'serviceName' or 'hostNetworkPort' should be specified"),
+			},
+		},
+		"acme issuer with host network port and node port service type": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					HostNetworkPort: 8089,
+					ServiceType:     corev1.ServiceTypeNodePort,
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("ingress", "serviceType"), corev1.ServiceTypeNodePort, `must be empty or "ClusterIP" when 'hostNetworkPort' is specified`),
+			},
+		},
+		"acme issuer with valid path template": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					PathTemplate: "/cdn/{domain}/{token}",
+				},
+			},
+		},
+		"acme issuer with path template not ending in the token": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					PathTemplate: "/cdn/{token}/index.html",
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("ingress", "pathTemplate"), "/cdn/{token}/index.html", "must begin with '/' and end with '/{token}'"),
+			},
+		},
+		"acme issuer with headless service": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					HeadlessService: true,
+					ServiceType:     corev1.ServiceTypeClusterIP,
+				},
+			},
+		},
+		"acme issuer with headless service and existing service": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					HeadlessService: true,
+					ServiceName:     "acme-solver",
+				},
+			},
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("ingress"), "only one of 'serviceName' or 'headlessService' should be specified"),
+			},
+		},
+		"acme issuer with headless service and node port service type": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					HeadlessService: true,
+					ServiceType:     corev1.ServiceTypeNodePort,
+				},
+			},
+			errs: []*field.Error{
+				field.Invalid(fldPath.Child("ingress", "serviceType"), corev1.ServiceTypeNodePort, `must be empty or "ClusterIP" when 'headlessService' is specified`),
+			},
+		},
+		"acme issuer with existing ingress selector": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					IngressSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"app": "web"},
+					},
+				},
+			},
+		},
+		"acme issuer with both ingress name and ingress selector": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					Name: "web",
+					IngressSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"app": "web"},
+					},
+				},
+			},
+			errs: []*field.Error{
+				field.Forbidden(fldPath.Child("ingress"), "only one of 'name' or 'ingressSelector' should be specified"),
+			},
+		},
+		"acme issuer with empty ingress selector": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01{
+				Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+					IngressSelector: &metav1.LabelSelector{},
+				},
+			},
+			errs: []*field.Error{
+				field.Required(fldPath.Child("ingress", "ingressSelector"), "must not be empty"),
+			},
+		},
 	}
 	for n, s := range scenarios {
 		t.Run(n, func(t *testing.T) {
@@ -31,6 +31,7 @@ import (
 	corelisters "k8s.io/client-go/listers/core/v1"
 
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha2"
+	cmerrors "github.com/jetstack/cert-manager/pkg/util/errors"
 	"github.com/jetstack/cert-manager/pkg/util/pki"
 )
 
@@ -114,7 +115,11 @@ func (v *Vault) Sign(csrPEM []byte, duration time.Duration) (cert []byte, ca []b
 
 	resp, err := v.client.RawRequest(request)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to sign certificate by vault: %s", err)
+		signErr := fmt.Errorf("failed to sign certificate by vault: %s", err)
+		if isUnavailable(err) {
+			return nil, nil, cmerrors.NewIssuerUnavailable(signErr)
+		}
+		return nil, nil, signErr
 	}
 
 	defer resp.Body.Close()
@@ -348,3 +353,20 @@ func (v *Vault) requestTokenWithKubernetesAuth(client Client, kubernetesAuth *v1
 func (v *Vault) Sys() *vault.Sys {
 	return v.client.Sys()
 }
+
+// isUnavailable returns true if err indicates that Vault could not be reached,
+// or is temporarily unable to serve requests (e.g. because it is sealed).
+func isUnavailable(err error) bool {
+	if cmerrors.IsConnectionError(err) {
+		return true
+	}
+	respErr, ok := err.(*vault.ResponseError)
+	if !ok {
+		return false
+	}
+	switch respErr.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
@@ -29,6 +29,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 	"testing"
 	"time"
@@ -41,6 +42,7 @@ import (
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha2"
 	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
 	vaultfake "github.com/jetstack/cert-manager/pkg/internal/vault/fake"
+	cmerrors "github.com/jetstack/cert-manager/pkg/util/errors"
 	"github.com/jetstack/cert-manager/pkg/util/pki"
 	"github.com/jetstack/cert-manager/test/unit/gen"
 	"github.com/jetstack/cert-manager/test/unit/listers"
@@ -102,10 +104,11 @@ type testSignT struct {
 	fakeLister *listers.FakeSecretLister
 	fakeClient *vaultfake.Client
 
-	csrPEM       []byte
-	expectedErr  error
-	expectedCert string
-	expectedCA   string
+	csrPEM              []byte
+	expectedErr         error
+	expectedUnavailable bool
+	expectedCert        string
+	expectedCA          string
 }
 
 func TestSign(t *testing.T) {
@@ -143,6 +146,44 @@ func TestSign(t *testing.T) {
 			expectedCA:   "",
 		},
 
+		"a good csr but unreachable vault should return an issuer unavailable error": {
+			csrPEM: csrPEM,
+			issuer: gen.Issuer("vault-issuer",
+				gen.SetIssuerVault(v1alpha2.VaultIssuer{}),
+			),
+			fakeClient: vaultfake.NewFakeClient().WithRawRequest(nil, &url.Error{
+				Op:  "Post",
+				URL: "https://vault.example.com/v1/pki/sign/example",
+				Err: errors.New("connection refused"),
+			}),
+			expectedErr:         errors.New(`failed to sign certificate by vault: Post https://vault.example.com/v1/pki/sign/example: connection refused`),
+			expectedUnavailable: true,
+		},
+
+		"a good csr but sealed vault should return an issuer unavailable error": {
+			csrPEM: csrPEM,
+			issuer: gen.Issuer("vault-issuer",
+				gen.SetIssuerVault(v1alpha2.VaultIssuer{}),
+			),
+			fakeClient: vaultfake.NewFakeClient().WithRawRequest(nil, &vault.ResponseError{
+				StatusCode: http.StatusServiceUnavailable,
+				Errors:     []string{"Vault is sealed"},
+			}),
+			expectedUnavailable: true,
+		},
+
+		"a good csr but rejected request should not return an issuer unavailable error": {
+			csrPEM: csrPEM,
+			issuer: gen.Issuer("vault-issuer",
+				gen.SetIssuerVault(v1alpha2.VaultIssuer{}),
+			),
+			fakeClient: vaultfake.NewFakeClient().WithRawRequest(nil, &vault.ResponseError{
+				StatusCode: http.StatusBadRequest,
+				Errors:     []string{"unknown role"},
+			}),
+			expectedUnavailable: false,
+		},
+
 		"a good csr and good response should return a certificate": {
 			csrPEM: csrPEM,
 			issuer: gen.Issuer("vault-issuer",
@@ -174,6 +215,11 @@ func TestSign(t *testing.T) {
 				name, test.expectedErr, err)
 		}
 
+		if test.expectedUnavailable != cmerrors.IsIssuerUnavailable(err) {
+			t.Errorf("%s: unexpected issuer unavailable classification of error, exp=%t got=%v",
+				name, test.expectedUnavailable, err)
+		}
+
 		if test.expectedCert != string(cert) {
 			t.Errorf("unexpected certificate in response bundle, exp=%s got=%s",
 				test.expectedCert, cert)
@@ -70,12 +70,12 @@ func findHostedDomainByFqdn(fqdn string, ns []string) (string, error) {
 
 // Present creates a TXT record to fulfil the dns-01 challenge
 func (a *DNSProvider) Present(domain, fqdn, value string) error {
-	return a.setTxtRecord(fqdn, &dns01Record{value, 60})
+	return a.setTxtRecord(fqdn, value, &dns01Record{value, 60})
 }
 
 // CleanUp removes the TXT record matching the specified parameters
 func (a *DNSProvider) CleanUp(domain, fqdn, value string) error {
-	return a.setTxtRecord(fqdn, nil)
+	return a.setTxtRecord(fqdn, value, nil)
 }
 
 type dns01Record struct {
@@ -83,7 +83,11 @@ type dns01Record struct {
 	ttl   int
 }
 
-func (a *DNSProvider) setTxtRecord(fqdn string, dns01Record *dns01Record) error {
+// setTxtRecord sets the TXT record with the given name and value to
+// dns01Record, or deletes it if dns01Record is nil. Other TXT records with the
+// same name but a different value, such as those for the apex and wildcard of
+// the same domain, are left untouched.
+func (a *DNSProvider) setTxtRecord(fqdn, value string, dns01Record *dns01Record) error {
 	hostedDomain, err := a.findHostedDomainByFqdn(fqdn, a.dns01Nameservers)
 	if err != nil {
 		return errors.Wrapf(err, "failed to determine hosted domain for %q", fqdn)
@@ -99,7 +103,7 @@ func (a *DNSProvider) setTxtRecord(fqdn string, dns01Record *dns01Record) error
 		return errors.Wrapf(err, "failed to create TXT record name")
 	}
 
-	if updated, err := zoneData.setTxtRecord(recordName, dns01Record); !updated || err != nil {
+	if updated, err := zoneData.setTxtRecord(recordName, value, dns01Record); !updated || err != nil {
 		if err != nil {
 			return errors.Wrapf(err, "failed to set TXT record in %q", hostedDomain)
 		}
@@ -212,7 +216,7 @@ func (a *DNSProvider) makeRequest(req *http.Request) ([]byte, error) {
 
 type zoneData map[string]interface{}
 
-func (z zoneData) setTxtRecord(name string, dns01Record *dns01Record) (bool, error) {
+func (z zoneData) setTxtRecord(name, value string, dns01Record *dns01Record) (bool, error) {
 	zone, ok := z["zone"].(map[string]interface{})
 	if !ok {
 		return false, errors.New("failed to retrieve zone from zone data")
@@ -226,11 +230,11 @@ func (z zoneData) setTxtRecord(name string, dns01Record *dns01Record) (bool, err
 	}
 
 	if dns01Record == nil {
-		if txtRecords = deleteRecord(txtRecords, name); txtRecords == nil {
+		if txtRecords = deleteRecord(txtRecords, name, value); txtRecords == nil {
 			return false, nil
 		}
 	} else {
-		txtRecords = updateRecord(txtRecords, name, map[string]interface{}{
+		txtRecords = updateRecord(txtRecords, name, value, map[string]interface{}{
 			"name":   name,
 			"ttl":    dns01Record.ttl,
 			"active": true,
@@ -263,9 +267,9 @@ func (z zoneData) incSoaSerial() (uint64, error) {
 	return newSerial, nil
 }
 
-func deleteRecord(records []interface{}, name string) []interface{} {
+func deleteRecord(records []interface{}, name, value string) []interface{} {
 	for pos := range records {
-		if recordName, ok := records[pos].(map[string]interface{})["name"]; ok && recordName == name {
+		if isRecord(records[pos], name, value) {
 			return append(records[:pos], records[pos+1:]...)
 		}
 	}
@@ -273,9 +277,9 @@ func deleteRecord(records []interface{}, name string) []interface{} {
 	return nil
 }
 
-func updateRecord(records []interface{}, name string, record map[string]interface{}) []interface{} {
+func updateRecord(records []interface{}, name, value string, record map[string]interface{}) []interface{} {
 	for pos := range records {
-		if records[pos].(map[string]interface{})["name"] == name {
+		if isRecord(records[pos], name, value) {
 			records[pos] = record
 			return records
 		}
@@ -283,3 +287,8 @@ func updateRecord(records []interface{}, name string, record map[string]interfac
 
 	return append(records, record)
 }
+
+func isRecord(record interface{}, name, value string) bool {
+	r, ok := record.(map[string]interface{})
+	return ok && r["name"] == name && r["target"] == value
+}
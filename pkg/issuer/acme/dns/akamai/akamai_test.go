@@ -135,6 +135,42 @@ func TestCleanUp(t *testing.T) {
 	assert.EqualValues(t, expected, actual)
 }
 
+func TestPresentAndCleanUpApexAndWildcard(t *testing.T) {
+	akamai, err := NewDNSProvider("akamai.example.com", "token", "secret", "access-token", util.RecursiveNameservers)
+	assert.NoError(t, err)
+
+	// the apex and wildcard authorizations for a domain are presented using
+	// the same TXT record name with different values
+	const fqdn = "_acme-challenge.test.example.com."
+	data := sampleZoneData
+	var response []byte
+	for _, value := range []string{"apex-key", "wildcard-key"} {
+		mockTransport(t, akamai, "example.com", data, &response)
+		assert.NoError(t, akamai.Present("test.example.com", fqdn, value))
+		data = string(response)
+	}
+	assert.Equal(t, []string{"apex-key", "wildcard-key"}, txtTargets(t, response, "_acme-challenge.test"))
+
+	mockTransport(t, akamai, "example.com", data, &response)
+	assert.NoError(t, akamai.CleanUp("test.example.com", fqdn, "apex-key"))
+	assert.Equal(t, []string{"wildcard-key"}, txtTargets(t, response, "_acme-challenge.test"))
+}
+
+func txtTargets(t *testing.T, payload []byte, name string) []string {
+	var data map[string]interface{}
+	assert.NoError(t, json.Unmarshal(payload, &data))
+
+	var targets []string
+	records, _ := data["zone"].(map[string]interface{})["txt"].([]interface{})
+	for _, r := range records {
+		record := r.(map[string]interface{})
+		if record["name"] == name {
+			targets = append(targets, record["target"].(string))
+		}
+	}
+	return targets
+}
+
 func mockTransport(t *testing.T, akamai *DNSProvider, domain, data string, response *[]byte) {
 	akamai.transport = httpResponder(func(req *http.Request) (*http.Response, error) {
 		defer req.Body.Close()
@@ -13,6 +13,7 @@ package azuredns
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 
@@ -88,24 +89,58 @@ func NewDNSProviderCredentials(environment, clientID, clientSecret, subscription
 	}, nil
 }
 
-// Present creates a TXT record using the specified parameters
+// Present creates a TXT record using the specified parameters. If a TXT
+// record set with the same name already exists, the value is appended to it.
 func (c *DNSProvider) Present(domain, fqdn, value string) error {
-	return c.createRecord(fqdn, value, 60)
+	z, err := c.getHostedZoneName(fqdn)
+	if err != nil {
+		klog.Infof("Error getting hosted zone name for: %s, %v", fqdn, err)
+		return err
+	}
+	name := c.trimFqdn(fqdn, z)
+
+	values, err := c.getTxtValues(z, name)
+	if err != nil {
+		return err
+	}
+	for _, v := range values {
+		if v == value {
+			return nil
+		}
+	}
+
+	return c.updateRecord(z, name, append(values, value), 60)
 }
 
-// CleanUp removes the TXT record matching the specified parameters
+// CleanUp removes the TXT record value matching the specified parameters,
+// deleting the record set if no other values remain.
 func (c *DNSProvider) CleanUp(domain, fqdn, value string) error {
 	z, err := c.getHostedZoneName(fqdn)
 	if err != nil {
 		klog.Infof("Error getting hosted zone name for: %s, %v", fqdn, err)
 		return err
 	}
+	name := c.trimFqdn(fqdn, z)
+
+	values, err := c.getTxtValues(z, name)
+	if err != nil {
+		return err
+	}
+	var remaining []string
+	for _, v := range values {
+		if v != value {
+			remaining = append(remaining, v)
+		}
+	}
+	if len(remaining) > 0 {
+		return c.updateRecord(z, name, remaining, 60)
+	}
 
 	_, err = c.recordClient.Delete(
 		context.TODO(),
 		c.resourceGroupName,
 		z,
-		c.trimFqdn(fqdn, z),
+		name,
 		dns.TXT, "")
 
 	if err != nil {
@@ -114,32 +149,51 @@ func (c *DNSProvider) CleanUp(domain, fqdn, value string) error {
 	return nil
 }
 
-func (c *DNSProvider) createRecord(fqdn, value string, ttl int) error {
+// getTxtValues returns the values of the existing TXT record set with the
+// given name, or nil if it does not exist.
+func (c *DNSProvider) getTxtValues(zone, name string) ([]string, error) {
+	rs, err := c.recordClient.Get(context.TODO(), c.resourceGroupName, zone, name, dns.TXT)
+	if err != nil {
+		if derr, ok := err.(autorest.DetailedError); ok && derr.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if rs.RecordSetProperties == nil || rs.TxtRecords == nil {
+		return nil, nil
+	}
+
+	var values []string
+	for _, r := range *rs.TxtRecords {
+		if r.Value != nil {
+			values = append(values, strings.Join(*r.Value, ""))
+		}
+	}
+	return values, nil
+}
+
+func (c *DNSProvider) updateRecord(zone, name string, values []string, ttl int) error {
+	var txtRecords []dns.TxtRecord
+	for _, v := range values {
+		txtRecords = append(txtRecords, dns.TxtRecord{Value: &[]string{v}})
+	}
 	rparams := &dns.RecordSet{
 		RecordSetProperties: &dns.RecordSetProperties{
-			TTL: to.Int64Ptr(int64(ttl)),
-			TxtRecords: &[]dns.TxtRecord{
-				{Value: &[]string{value}},
-			},
+			TTL:        to.Int64Ptr(int64(ttl)),
+			TxtRecords: &txtRecords,
 		},
 	}
 
-	z, err := c.getHostedZoneName(fqdn)
-	if err != nil {
-		klog.Infof("Error getting hosted zone name for: %s, %v", fqdn, err)
-		return err
-	}
-
-	_, err = c.recordClient.CreateOrUpdate(
+	_, err := c.recordClient.CreateOrUpdate(
 		context.TODO(),
 		c.resourceGroupName,
-		z,
-		c.trimFqdn(fqdn, z),
+		zone,
+		name,
 		dns.TXT,
 		*rparams, "", "")
 
 	if err != nil {
-		klog.Infof("Error creating TXT: %s, %v", z, err)
+		klog.Infof("Error creating TXT: %s, %v", zone, err)
 		return err
 	}
 	return nil
@@ -9,12 +9,19 @@ this directory.
 package azuredns
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/Azure/azure-sdk-for-go/services/dns/mgmt/2017-10-01/dns"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -76,3 +83,78 @@ func TestInvalidAzureDns(t *testing.T) {
 	_, err := NewDNSProviderCredentials("invalid env", "cid", "secret", "", "", "", "", util.RecursiveNameservers)
 	assert.Error(t, err)
 }
+
+func TestAzureDnsMultipleValues(t *testing.T) {
+	api := newMockRecordSetServer(t, "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/dnsZones/example.com/TXT/_acme-challenge")
+	defer api.Close()
+
+	provider := &DNSProvider{
+		recordClient:      dns.NewRecordSetsClientWithBaseURI(api.URL, "sub"),
+		resourceGroupName: "rg",
+		zoneName:          "example.com",
+	}
+
+	// the apex and wildcard challenges for a name share the same fqdn
+	fqdn := "_acme-challenge.example.com."
+	assert.NoError(t, provider.Present("example.com", fqdn, "first"))
+	assert.NoError(t, provider.Present("*.example.com", fqdn, "second"))
+	assert.Equal(t, []string{"first", "second"}, api.values())
+
+	assert.NoError(t, provider.CleanUp("example.com", fqdn, "first"))
+	assert.Equal(t, []string{"second"}, api.values())
+
+	assert.NoError(t, provider.CleanUp("*.example.com", fqdn, "second"))
+	assert.Empty(t, api.values())
+}
+
+// mockRecordSetServer is a mock of the Azure DNS record sets API serving a
+// single TXT record set.
+type mockRecordSetServer struct {
+	*httptest.Server
+
+	lock      sync.Mutex
+	recordSet *dns.RecordSet
+}
+
+func newMockRecordSetServer(t *testing.T, recordSetPath string) *mockRecordSetServer {
+	m := &mockRecordSetServer{}
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+
+		require.Equal(t, recordSetPath, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			if m.recordSet == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(m.recordSet)
+		case http.MethodPut:
+			var rs dns.RecordSet
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&rs))
+			m.recordSet = &rs
+			json.NewEncoder(w).Encode(m.recordSet)
+		case http.MethodDelete:
+			m.recordSet = nil
+		default:
+			require.FailNowf(t, "unexpected request", "%s %s", r.Method, r.URL.Path)
+		}
+	}))
+	return m
+}
+
+// values returns the values of the stored TXT record set.
+func (m *mockRecordSetServer) values() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.recordSet == nil || m.recordSet.TxtRecords == nil {
+		return nil
+	}
+	var values []string
+	for _, r := range *m.recordSet.TxtRecords {
+		values = append(values, strings.Join(*r.Value, ""))
+	}
+	return values
+}
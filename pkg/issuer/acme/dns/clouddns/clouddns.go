@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"time"
 
 	"golang.org/x/net/context"
@@ -156,7 +157,18 @@ func (c *DNSProvider) Present(domain, fqdn, value string) error {
 		return err
 	}
 	if len(list.Rrsets) > 0 {
-		// Attempt to delete the existing records when adding our new one.
+		// Other authorizations for the same name (e.g. the apex and wildcard
+		// of a domain) may have values in the existing record set, so we
+		// replace it with one that contains both its values and our own.
+		for _, rrset := range list.Rrsets {
+			for _, data := range rrset.Rrdatas {
+				if unquoteTxt(data) == value {
+					// the record is already set to the desired value
+					return nil
+				}
+			}
+			rec.Rrdatas = append(rec.Rrdatas, rrset.Rrdatas...)
+		}
 		change.Deletions = list.Rrsets
 	}
 
@@ -191,9 +203,28 @@ func (c *DNSProvider) CleanUp(domain, fqdn, value string) error {
 	}
 
 	for _, rec := range records {
+		// only remove our value from the record set, as other authorizations
+		// for the same name may still be using the remaining values
+		var remaining []string
+		for _, data := range rec.Rrdatas {
+			if unquoteTxt(data) != value {
+				remaining = append(remaining, data)
+			}
+		}
+		if len(remaining) == len(rec.Rrdatas) {
+			continue
+		}
 		change := &dns.Change{
 			Deletions: []*dns.ResourceRecordSet{rec},
 		}
+		if len(remaining) > 0 {
+			change.Additions = []*dns.ResourceRecordSet{{
+				Name:    rec.Name,
+				Rrdatas: remaining,
+				Ttl:     rec.Ttl,
+				Type:    rec.Type,
+			}}
+		}
 		_, err = c.client.Changes.Create(c.project, zone, change).Do()
 		if err != nil {
 			return err
@@ -202,6 +233,12 @@ func (c *DNSProvider) CleanUp(domain, fqdn, value string) error {
 	return nil
 }
 
+// unquoteTxt removes the surrounding quotes that Google Cloud DNS adds to
+// TXT record data.
+func unquoteTxt(data string) string {
+	return strings.Trim(data, `"`)
+}
+
 // getHostedZone returns the managed-zone
 func (c *DNSProvider) getHostedZone(domain string) (string, error) {
 	authZone, err := util.FindZoneByFqdn(util.ToFqdn(domain), c.dns01Nameservers)
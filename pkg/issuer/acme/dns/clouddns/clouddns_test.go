@@ -9,7 +9,11 @@ this directory.
 package clouddns
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -18,7 +22,10 @@ import (
 	"google.golang.org/api/dns/v1"
 
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
+	logf "github.com/jetstack/cert-manager/pkg/logs"
+	testserver "github.com/jetstack/cert-manager/test/acme/dns/server"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -107,3 +114,96 @@ func TestLiveGoogleCloudCleanUp(t *testing.T) {
 	err = provider.CleanUp(gcloudDomain, "_acme-challenge."+gcloudDomain+".", "123d==")
 	assert.NoError(t, err)
 }
+
+func TestGoogleCloudMultipleValues(t *testing.T) {
+	dnsServer := &testserver.BasicServer{Zones: []string{"example.com."}}
+	if err := dnsServer.Run(logf.NewContext(nil, nil, t.Name())); err != nil {
+		t.Fatalf("failed to start test DNS server: %v", err)
+	}
+	defer dnsServer.Shutdown()
+
+	api := newMockManagedZoneServer(t, "my-project", "example-com", "example.com.")
+	defer api.Close()
+
+	svc, err := dns.New(http.DefaultClient)
+	require.NoError(t, err)
+	svc.BasePath = api.URL + "/dns/v1/projects/"
+	provider := &DNSProvider{
+		dns01Nameservers: []string{dnsServer.ListenAddr()},
+		project:          "my-project",
+		client:           svc,
+	}
+
+	// the apex and wildcard challenges for a name share the same fqdn
+	fqdn := "_acme-challenge.example.com."
+	assert.NoError(t, provider.Present("example.com", fqdn, "first"))
+	assert.NoError(t, provider.Present("*.example.com", fqdn, "second"))
+	assert.ElementsMatch(t, []string{"first", "second"}, api.values(fqdn))
+
+	assert.NoError(t, provider.CleanUp("example.com", fqdn, "first"))
+	assert.Equal(t, []string{"second"}, api.values(fqdn))
+
+	assert.NoError(t, provider.CleanUp("*.example.com", fqdn, "second"))
+	assert.Empty(t, api.values(fqdn))
+}
+
+// mockManagedZoneServer is a mock of the Google Cloud DNS API serving a
+// single managed zone.
+type mockManagedZoneServer struct {
+	*httptest.Server
+
+	lock   sync.Mutex
+	rrsets map[string]*dns.ResourceRecordSet
+}
+
+func newMockManagedZoneServer(t *testing.T, project, zoneName, dnsName string) *mockManagedZoneServer {
+	m := &mockManagedZoneServer{rrsets: make(map[string]*dns.ResourceRecordSet)}
+	zonesPath := "/dns/v1/projects/" + project + "/managedZones"
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case zonesPath:
+			json.NewEncoder(w).Encode(&dns.ManagedZonesListResponse{
+				ManagedZones: []*dns.ManagedZone{{Name: zoneName, DnsName: dnsName, Visibility: "public"}},
+			})
+		case zonesPath + "/" + zoneName + "/rrsets":
+			resp := &dns.ResourceRecordSetsListResponse{}
+			for name, rrset := range m.rrsets {
+				if n := r.URL.Query().Get("name"); n == "" || n == name {
+					resp.Rrsets = append(resp.Rrsets, rrset)
+				}
+			}
+			json.NewEncoder(w).Encode(resp)
+		case zonesPath + "/" + zoneName + "/changes":
+			var change dns.Change
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&change))
+			for _, rrset := range change.Deletions {
+				require.Equal(t, m.rrsets[rrset.Name], rrset, "deletions must match the existing record set")
+				delete(m.rrsets, rrset.Name)
+			}
+			for _, rrset := range change.Additions {
+				require.Nil(t, m.rrsets[rrset.Name], "additions must not overwrite an existing record set")
+				m.rrsets[rrset.Name] = rrset
+			}
+			change.Id = "1"
+			change.Status = "done"
+			json.NewEncoder(w).Encode(&change)
+		default:
+			require.FailNowf(t, "unexpected request", "%s %s", r.Method, r.URL.Path)
+		}
+	}))
+	return m
+}
+
+// values returns the data of the stored record set with the given name.
+func (m *mockManagedZoneServer) values(name string) []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if rrset, ok := m.rrsets[name]; ok {
+		return rrset.Rrdatas
+	}
+	return nil
+}
@@ -34,6 +34,9 @@ type DNSProvider struct {
 	authEmail        string
 	authKey          string
 	authToken        string
+
+	// baseURL is the CloudFlare API endpoint, which is only changed in tests
+	baseURL string
 }
 
 // NewDNSProvider returns a DNSProvider instance configured for cloudflare.
@@ -73,6 +76,7 @@ func NewDNSProviderCredentials(email, key, token string, dns01Nameservers []stri
 		authKey:          key,
 		authToken:        token,
 		dns01Nameservers: dns01Nameservers,
+		baseURL:          CloudFlareAPIURL,
 	}, nil
 }
 
@@ -83,22 +87,18 @@ func (c *DNSProvider) Present(domain, fqdn, value string) error {
 		return err
 	}
 
-	record, err := c.findTxtRecord(fqdn)
-	if err != nil && err != errNoExistingRecord {
+	// other TXT records with the same name may exist for other authorizations
+	// (e.g. the apex and wildcard of a domain), so we only check for a record
+	// with the desired value and leave the others in place
+	_, err = c.findTxtRecord(fqdn, value)
+	if err == nil {
+		// the record is already set to the desired value
+		return nil
+	}
+	if err != errNoExistingRecord {
 		// this is a real error
 		return err
 	}
-	if record != nil {
-		if record.Content == value {
-			// the record is already set to the desired value
-			return nil
-		}
-
-		_, err = c.makeRequest("DELETE", fmt.Sprintf("/zones/%s/dns_records/%s", record.ZoneID, record.ID), nil)
-		if err != nil {
-			return err
-		}
-	}
 
 	rec := cloudFlareRecord{
 		Type:    "TXT",
@@ -122,7 +122,7 @@ func (c *DNSProvider) Present(domain, fqdn, value string) error {
 
 // CleanUp removes the TXT record matching the specified parameters
 func (c *DNSProvider) CleanUp(domain, fqdn, value string) error {
-	record, err := c.findTxtRecord(fqdn)
+	record, err := c.findTxtRecord(fqdn, value)
 	// Nothing to cleanup
 	if err == errNoExistingRecord {
 		return nil
@@ -171,7 +171,8 @@ func (c *DNSProvider) getHostedZoneID(fqdn string) (string, error) {
 
 var errNoExistingRecord = errors.New("No existing record found")
 
-func (c *DNSProvider) findTxtRecord(fqdn string) (*cloudFlareRecord, error) {
+// findTxtRecord returns the TXT record with the given name and value.
+func (c *DNSProvider) findTxtRecord(fqdn, value string) (*cloudFlareRecord, error) {
 	zoneID, err := c.getHostedZoneID(fqdn)
 	if err != nil {
 		return nil, err
@@ -193,7 +194,7 @@ func (c *DNSProvider) findTxtRecord(fqdn string) (*cloudFlareRecord, error) {
 	}
 
 	for _, rec := range records {
-		if rec.Name == util.UnFqdn(fqdn) {
+		if rec.Name == util.UnFqdn(fqdn) && rec.Content == value {
 			return &rec, nil
 		}
 	}
@@ -216,7 +217,7 @@ func (c *DNSProvider) makeRequest(method, uri string, body io.Reader) (json.RawM
 		Result  json.RawMessage `json:"result"`
 	}
 
-	req, err := http.NewRequest(method, fmt.Sprintf("%s%s", CloudFlareAPIURL, uri), body)
+	req, err := http.NewRequest(method, fmt.Sprintf("%s%s", c.baseURL, uri), body)
 	if err != nil {
 		return nil, err
 	}
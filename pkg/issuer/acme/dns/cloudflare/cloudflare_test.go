@@ -9,12 +9,21 @@ this directory.
 package cloudflare
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
+	logf "github.com/jetstack/cert-manager/pkg/logs"
+	testserver "github.com/jetstack/cert-manager/test/acme/dns/server"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -104,3 +113,94 @@ func TestCloudFlareCleanUp(t *testing.T) {
 	err = provider.CleanUp(cflareDomain, "_acme-challenge."+cflareDomain+".", "123d==")
 	assert.NoError(t, err)
 }
+
+func TestCloudFlareMultipleValues(t *testing.T) {
+	dnsServer := &testserver.BasicServer{Zones: []string{"example.com."}}
+	if err := dnsServer.Run(logf.NewContext(nil, nil, t.Name())); err != nil {
+		t.Fatalf("failed to start test DNS server: %v", err)
+	}
+	defer dnsServer.Shutdown()
+
+	api := newMockZoneServer(t, "zone-id", "example.com")
+	defer api.Close()
+
+	provider, err := NewDNSProviderCredentials("123", "123", "", []string{dnsServer.ListenAddr()})
+	require.NoError(t, err)
+	provider.baseURL = api.URL
+
+	// the apex and wildcard challenges for a name share the same fqdn
+	fqdn := "_acme-challenge.example.com."
+	assert.NoError(t, provider.Present("example.com", fqdn, "first"))
+	assert.NoError(t, provider.Present("*.example.com", fqdn, "second"))
+	assert.ElementsMatch(t, []string{"first", "second"}, api.values())
+
+	assert.NoError(t, provider.CleanUp("example.com", fqdn, "first"))
+	assert.Equal(t, []string{"second"}, api.values())
+}
+
+// mockZoneServer is a mock of the CloudFlare API serving a single zone.
+type mockZoneServer struct {
+	*httptest.Server
+
+	lock    sync.Mutex
+	nextID  int
+	records []cloudFlareRecord
+}
+
+func newMockZoneServer(t *testing.T, zoneID, zoneName string) *mockZoneServer {
+	m := &mockZoneServer{nextID: 1}
+	recordsPath := "/zones/" + zoneID + "/dns_records"
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+
+		var result interface{}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/zones":
+			require.Equal(t, zoneName, r.URL.Query().Get("name"))
+			result = []map[string]string{{"id": zoneID, "name": zoneName}}
+		case r.Method == http.MethodGet && r.URL.Path == recordsPath:
+			var records []cloudFlareRecord
+			for _, rec := range m.records {
+				if rec.Name == r.URL.Query().Get("name") && rec.Type == r.URL.Query().Get("type") {
+					records = append(records, rec)
+				}
+			}
+			result = records
+		case r.Method == http.MethodPost && r.URL.Path == recordsPath:
+			var rec cloudFlareRecord
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&rec))
+			rec.ID = strconv.Itoa(m.nextID)
+			rec.ZoneID = zoneID
+			m.nextID++
+			m.records = append(m.records, rec)
+			result = rec
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, recordsPath+"/"):
+			id := strings.TrimPrefix(r.URL.Path, recordsPath+"/")
+			for i, rec := range m.records {
+				if rec.ID == id {
+					m.records = append(m.records[:i], m.records[i+1:]...)
+					break
+				}
+			}
+			result = map[string]string{"id": id}
+		default:
+			require.FailNowf(t, "unexpected request", "%s %s", r.Method, r.URL.Path)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "result": result})
+	}))
+	return m
+}
+
+// values returns the content of all stored records.
+func (m *mockZoneServer) values() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var values []string
+	for _, rec := range m.records {
+		values = append(values, rec.Content)
+	}
+	return values
+}
@@ -107,6 +107,11 @@ func (c *DNSProvider) CleanUp(domain, fqdn, value string) error {
 	}
 
 	for _, record := range records {
+		// only remove the record for this challenge, as other TXT records with
+		// the same name may be in use by other authorizations
+		if record.Type != "TXT" || record.Data != value {
+			continue
+		}
 		_, err = c.client.Domains.DeleteRecord(context.Background(), util.UnFqdn(zoneName), record.ID)
 
 		if err != nil {
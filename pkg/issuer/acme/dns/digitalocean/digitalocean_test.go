@@ -17,12 +17,23 @@ limitations under the License.
 package digitalocean
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/digitalocean/godo"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/dns/util"
+	logf "github.com/jetstack/cert-manager/pkg/logs"
+	testserver "github.com/jetstack/cert-manager/test/acme/dns/server"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -90,6 +101,97 @@ func TestDigitalOceanCleanUp(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDigitalOceanMultipleValues(t *testing.T) {
+	dnsServer := &testserver.BasicServer{Zones: []string{"example.com."}}
+	if err := dnsServer.Run(logf.NewContext(nil, nil, t.Name())); err != nil {
+		t.Fatalf("failed to start test DNS server: %v", err)
+	}
+	defer dnsServer.Shutdown()
+
+	api := newMockRecordsServer(t, "example.com")
+	defer api.Close()
+
+	provider, err := NewDNSProviderCredentials("123", []string{dnsServer.ListenAddr()})
+	require.NoError(t, err)
+	provider.client.BaseURL, _ = url.Parse(api.URL + "/")
+
+	// the apex and wildcard challenges for a name share the same fqdn
+	fqdn := "_acme-challenge.example.com."
+	assert.NoError(t, provider.Present("example.com", fqdn, "first"))
+	assert.NoError(t, provider.Present("*.example.com", fqdn, "second"))
+	assert.ElementsMatch(t, []string{"first", "second"}, api.values())
+
+	assert.NoError(t, provider.CleanUp("example.com", fqdn, "first"))
+	assert.Equal(t, []string{"second"}, api.values())
+}
+
+// mockRecordsServer is a mock of the DigitalOcean domain records API for a
+// single domain.
+type mockRecordsServer struct {
+	*httptest.Server
+
+	lock    sync.Mutex
+	nextID  int
+	records []godo.DomainRecord
+}
+
+func newMockRecordsServer(t *testing.T, domain string) *mockRecordsServer {
+	m := &mockRecordsServer{nextID: 1}
+	recordsPath := "/v2/domains/" + domain + "/records"
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == recordsPath:
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"domain_records": m.records,
+				"meta":           map[string]int{"total": len(m.records)},
+			})
+		case r.Method == http.MethodPost && r.URL.Path == recordsPath:
+			var req godo.DomainRecordEditRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			record := godo.DomainRecord{
+				ID: m.nextID,
+				// record names are stored relative to the domain
+				Name: strings.TrimSuffix(req.Name, "."+domain+"."),
+				Type: req.Type,
+				Data: req.Data,
+				TTL:  req.TTL,
+			}
+			m.nextID++
+			m.records = append(m.records, record)
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]interface{}{"domain_record": record})
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, recordsPath+"/"):
+			id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, recordsPath+"/"))
+			require.NoError(t, err)
+			for i, record := range m.records {
+				if record.ID == id {
+					m.records = append(m.records[:i], m.records[i+1:]...)
+					break
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			require.FailNowf(t, "unexpected request", "%s %s", r.Method, r.URL.Path)
+		}
+	}))
+	return m
+}
+
+// values returns the data of all stored records.
+func (m *mockRecordsServer) values() []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var values []string
+	for _, record := range m.records {
+		values = append(values, record.Data)
+	}
+	return values
+}
+
 func TestDigitalOceanSolveForProvider(t *testing.T) {
 
 }
@@ -241,6 +241,52 @@ func TestRFC2136ValidUpdatePacket(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestRFC2136MultipleValues(t *testing.T) {
+	ctx := logf.NewContext(nil, nil, t.Name())
+	server := &testserver.BasicServer{
+		Zones: []string{rfc2136TestZone},
+	}
+	if err := server.Run(ctx); err != nil {
+		t.Fatalf("failed to start test server: %v", err)
+	}
+	defer server.Shutdown()
+
+	provider, err := NewDNSProviderCredentials(server.ListenAddr(), "", "", "")
+	if err != nil {
+		t.Fatalf("Expected NewDNSProviderCredentials() to return no error but the error was -> %v", err)
+	}
+
+	// the apex and wildcard challenges for a name share the same fqdn
+	values := []string{rfc2136TestValue, "second-" + rfc2136TestValue}
+	for _, v := range values {
+		if err := provider.Present(rfc2136TestDomain, rfc2136TestFqdn, rfc2136TestZone, v); err != nil {
+			t.Fatalf("Expected Present() to return no error but the error was -> %v", err)
+		}
+	}
+	assert.ElementsMatch(t, values, lookupTXT(t, server.ListenAddr(), rfc2136TestFqdn))
+
+	if err := provider.CleanUp(rfc2136TestDomain, rfc2136TestFqdn, rfc2136TestZone, values[0]); err != nil {
+		t.Fatalf("Expected CleanUp() to return no error but the error was -> %v", err)
+	}
+	assert.ElementsMatch(t, values[1:], lookupTXT(t, server.ListenAddr(), rfc2136TestFqdn))
+}
+
+func lookupTXT(t *testing.T, nameserver, fqdn string) []string {
+	m := new(dns.Msg)
+	m.SetQuestion(fqdn, dns.TypeTXT)
+	r, _, err := new(dns.Client).Exchange(m, nameserver)
+	if err != nil {
+		t.Fatalf("failed to query test server: %v", err)
+	}
+	var values []string
+	for _, rr := range r.Answer {
+		if txt, ok := rr.(*dns.TXT); ok {
+			values = append(values, txt.Txt...)
+		}
+	}
+	return values
+}
+
 func serverHandlerHello(w dns.ResponseWriter, req *dns.Msg) {
 	m := new(dns.Msg)
 	m.SetReply(req)
@@ -178,25 +178,95 @@ func NewDNSProvider(accessKeyID, secretAccessKey, hostedZoneID, region, role str
 	}, nil
 }
 
-// Present creates a TXT record using the specified parameters
+// Present creates a TXT record using the specified parameters. If a TXT
+// record set with the same name already exists, the value is added to it so
+// that other authorizations for the same name are not affected.
 func (r *DNSProvider) Present(domain, fqdn, value string) error {
 	value = `"` + value + `"`
-	return r.changeRecord(route53.ChangeActionUpsert, fqdn, value, route53TTL)
+
+	hostedZoneID, err := r.getHostedZoneID(fqdn)
+	if err != nil {
+		return fmt.Errorf("Failed to determine Route 53 hosted zone ID: %v", err)
+	}
+
+	existing, err := r.getTXTRecordSet(hostedZoneID, fqdn)
+	if err != nil {
+		return err
+	}
+
+	var values []string
+	if existing != nil {
+		for _, rr := range existing.ResourceRecords {
+			if aws.StringValue(rr.Value) == value {
+				// the record is already set to the desired value
+				return nil
+			}
+			values = append(values, aws.StringValue(rr.Value))
+		}
+	}
+	values = append(values, value)
+
+	return r.changeRecord(hostedZoneID, route53.ChangeActionUpsert, newTXTRecordSet(fqdn, values, route53TTL))
 }
 
-// CleanUp removes the TXT record matching the specified parameters
+// CleanUp removes the TXT record value matching the specified parameters,
+// deleting the record set if no other values remain.
 func (r *DNSProvider) CleanUp(domain, fqdn, value string) error {
 	value = `"` + value + `"`
-	return r.changeRecord(route53.ChangeActionDelete, fqdn, value, route53TTL)
-}
 
-func (r *DNSProvider) changeRecord(action, fqdn, value string, ttl int) error {
 	hostedZoneID, err := r.getHostedZoneID(fqdn)
 	if err != nil {
 		return fmt.Errorf("Failed to determine Route 53 hosted zone ID: %v", err)
 	}
 
-	recordSet := newTXTRecordSet(fqdn, value, ttl)
+	existing, err := r.getTXTRecordSet(hostedZoneID, fqdn)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return nil
+	}
+
+	var remaining []string
+	for _, rr := range existing.ResourceRecords {
+		if aws.StringValue(rr.Value) != value {
+			remaining = append(remaining, aws.StringValue(rr.Value))
+		}
+	}
+	switch {
+	case len(remaining) == len(existing.ResourceRecords):
+		// nothing to clean up
+		return nil
+	case len(remaining) == 0:
+		// a delete must exactly match the existing record set
+		return r.changeRecord(hostedZoneID, route53.ChangeActionDelete, existing)
+	default:
+		return r.changeRecord(hostedZoneID, route53.ChangeActionUpsert, newTXTRecordSet(fqdn, remaining, int(aws.Int64Value(existing.TTL))))
+	}
+}
+
+// getTXTRecordSet returns the TXT record set with the given name, or nil if it
+// does not exist.
+func (r *DNSProvider) getTXTRecordSet(hostedZoneID, fqdn string) (*route53.ResourceRecordSet, error) {
+	resp, err := r.client.ListResourceRecordSets(&route53.ListResourceRecordSetsInput{
+		HostedZoneId:    aws.String(hostedZoneID),
+		StartRecordName: aws.String(fqdn),
+		StartRecordType: aws.String(route53.RRTypeTxt),
+		MaxItems:        aws.String("1"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to list Route 53 record sets: %v", err)
+	}
+
+	for _, recordSet := range resp.ResourceRecordSets {
+		if aws.StringValue(recordSet.Name) == fqdn && aws.StringValue(recordSet.Type) == route53.RRTypeTxt {
+			return recordSet, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *DNSProvider) changeRecord(hostedZoneID, action string, recordSet *route53.ResourceRecordSet) error {
 	reqParams := &route53.ChangeResourceRecordSetsInput{
 		HostedZoneId: aws.String(hostedZoneID),
 		ChangeBatch: &route53.ChangeBatch{
@@ -280,13 +350,15 @@ func (r *DNSProvider) getHostedZoneID(fqdn string) (string, error) {
 	return hostedZoneID, nil
 }
 
-func newTXTRecordSet(fqdn, value string, ttl int) *route53.ResourceRecordSet {
+func newTXTRecordSet(fqdn string, values []string, ttl int) *route53.ResourceRecordSet {
+	var records []*route53.ResourceRecord
+	for _, value := range values {
+		records = append(records, &route53.ResourceRecord{Value: aws.String(value)})
+	}
 	return &route53.ResourceRecordSet{
-		Name: aws.String(fqdn),
-		Type: aws.String(route53.RRTypeTxt),
-		TTL:  aws.Int64(int64(ttl)),
-		ResourceRecords: []*route53.ResourceRecord{
-			{Value: aws.String(value)},
-		},
+		Name:            aws.String(fqdn),
+		Type:            aws.String(route53.RRTypeTxt),
+		TTL:             aws.Int64(int64(ttl)),
+		ResourceRecords: records,
 	}
 }
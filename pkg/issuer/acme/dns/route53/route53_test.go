@@ -103,6 +103,7 @@ func TestNoRegionFromEnv(t *testing.T) {
 func TestRoute53Present(t *testing.T) {
 	mockResponses := MockResponseMap{
 		"/2013-04-01/hostedzonesbyname":         MockResponse{StatusCode: 200, Body: ListHostedZonesByNameResponse},
+		"/2013-04-01/hostedzone/ABCDEFG/rrset":  MockResponse{StatusCode: 200, Body: ListResourceRecordSetsResponse},
 		"/2013-04-01/hostedzone/ABCDEFG/rrset/": MockResponse{StatusCode: 200, Body: ChangeResourceRecordSetsResponse},
 		"/2013-04-01/change/123456":             MockResponse{StatusCode: 200, Body: GetChangeResponse},
 	}
@@ -119,6 +120,26 @@ func TestRoute53Present(t *testing.T) {
 	assert.NoError(t, err, "Expected Present to return no error")
 }
 
+func TestRoute53PresentMultipleValues(t *testing.T) {
+	ts := newMockRecordSetServer(t, "ABCDEFG")
+	defer ts.Close()
+
+	provider := makeRoute53Provider(ts.Server)
+	provider.hostedZoneID = "ABCDEFG"
+
+	// the apex and wildcard challenges for a name share the same fqdn
+	fqdn := "_acme-challenge.example.com."
+	assert.NoError(t, provider.Present("example.com", fqdn, "first"))
+	assert.NoError(t, provider.Present("*.example.com", fqdn, "second"))
+	assert.Equal(t, []string{`"first"`, `"second"`}, ts.values(fqdn))
+
+	assert.NoError(t, provider.CleanUp("example.com", fqdn, "first"))
+	assert.Equal(t, []string{`"second"`}, ts.values(fqdn))
+
+	assert.NoError(t, provider.CleanUp("*.example.com", fqdn, "second"))
+	assert.Empty(t, ts.values(fqdn))
+}
+
 func TestAssumeRole(t *testing.T) {
 	creds := &sts.Credentials{
 		AccessKeyId:     aws.String("foo"),
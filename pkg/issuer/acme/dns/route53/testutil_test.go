@@ -9,9 +9,12 @@ this directory.
 package route53
 
 import (
+	"encoding/xml"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -44,3 +47,85 @@ func newMockServer(t *testing.T, responses MockResponseMap) *httptest.Server {
 	time.Sleep(100 * time.Millisecond)
 	return ts
 }
+
+// mockRecordSetServer is a mock server for a single hosted zone that stores
+// the TXT record sets changed through it.
+type mockRecordSetServer struct {
+	*httptest.Server
+
+	lock       sync.Mutex
+	recordSets map[string][]string
+}
+
+type changeResourceRecordSetsRequest struct {
+	Changes []struct {
+		Action          string
+		Name            string   `xml:"ResourceRecordSet>Name"`
+		ResourceRecords []string `xml:"ResourceRecordSet>ResourceRecords>ResourceRecord>Value"`
+	} `xml:"ChangeBatch>Changes>Change"`
+}
+
+func newMockRecordSetServer(t *testing.T, hostedZoneID string) *mockRecordSetServer {
+	m := &mockRecordSetServer{recordSets: make(map[string][]string)}
+	m.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.lock.Lock()
+		defer m.lock.Unlock()
+
+		var body string
+		switch r.URL.Path {
+		case "/2013-04-01/hostedzone/" + hostedZoneID + "/rrset":
+			body = m.listResourceRecordSetsResponse(r.URL.Query().Get("name"))
+		case "/2013-04-01/hostedzone/" + hostedZoneID + "/rrset/":
+			var req changeResourceRecordSetsRequest
+			require.NoError(t, xml.NewDecoder(r.Body).Decode(&req))
+			for _, c := range req.Changes {
+				switch c.Action {
+				case "UPSERT":
+					m.recordSets[c.Name] = c.ResourceRecords
+				case "DELETE":
+					delete(m.recordSets, c.Name)
+				}
+			}
+			body = ChangeResourceRecordSetsResponse
+		case "/2013-04-01/change/123456":
+			body = GetChangeResponse
+		default:
+			require.FailNow(t, fmt.Sprintf("Requested path not handled by mock server: %s", r.URL.Path))
+		}
+
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte(body))
+	}))
+	return m
+}
+
+// values returns the TXT record values stored for the given name.
+func (m *mockRecordSetServer) values(name string) []string {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.recordSets[name]
+}
+
+func (m *mockRecordSetServer) listResourceRecordSetsResponse(name string) string {
+	var records []string
+	for _, v := range m.recordSets[name] {
+		records = append(records, "<ResourceRecord><Value>"+xmlEscape(v)+"</Value></ResourceRecord>")
+	}
+	recordSets := ""
+	if len(records) > 0 {
+		recordSets = "<ResourceRecordSet><Name>" + name + "</Name><Type>TXT</Type><TTL>10</TTL><ResourceRecords>" +
+			strings.Join(records, "") + "</ResourceRecords></ResourceRecordSet>"
+	}
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<ListResourceRecordSetsResponse xmlns="https://route53.amazonaws.com/doc/2013-04-01/">
+   <ResourceRecordSets>` + recordSets + `</ResourceRecordSets>
+   <IsTruncated>false</IsTruncated>
+   <MaxItems>1</MaxItems>
+</ListResourceRecordSetsResponse>`
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
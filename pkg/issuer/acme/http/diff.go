@@ -0,0 +1,163 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"fmt"
+
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+)
+
+// DiscrepancyType describes how an ingress differs from what is required to
+// solve a HTTP01 challenge.
+type DiscrepancyType string
+
+const (
+	// DiscrepancyMissingHost means the ingress has no rule for the
+	// challenged domain.
+	DiscrepancyMissingHost DiscrepancyType = "MissingHost"
+	// DiscrepancyMissingPath means no rule for the challenged domain has
+	// a path for the challenge.
+	DiscrepancyMissingPath DiscrepancyType = "MissingPath"
+	// DiscrepancyWrongBackend means the challenge path routes to a backend
+	// other than the solver service.
+	DiscrepancyWrongBackend DiscrepancyType = "WrongBackend"
+)
+
+// Discrepancy is a single difference between an ingress and what is required
+// to solve a HTTP01 challenge.
+type Discrepancy struct {
+	Type DiscrepancyType
+	// Host is the ingress rule host the discrepancy was found for.
+	Host string
+	// Path is the challenge path the discrepancy was found for, if any.
+	Path string
+	// Expected and Actual describe the expected and actual values, e.g. the
+	// backend in the form '<service>:<port>' for a WrongBackend discrepancy.
+	Expected string
+	Actual   string
+}
+
+// DiffOptions describes how a solver configures the ingress paths compared
+// by DiffSolverIngress. The zero value describes a solver with the default
+// configuration.
+type DiffOptions struct {
+	// ChallengePath is the path the key for the challenge is served on. If
+	// empty, the path from the challenge's path template is used if one is
+	// set, and the default challenge path otherwise.
+	ChallengePath string
+	// PathSuffix is appended to challenge paths, as configured by the
+	// --acme-http01-solver-path-suffix flag.
+	PathSuffix string
+	// RegexPaths is true if the solver adds challenge paths as regular
+	// expressions. Paths in either form are accepted, so this only affects
+	// the path reported for a MissingPath discrepancy.
+	RegexPaths bool
+	// ServicePort is the port of the solver service that challenge paths
+	// route to. If unset, the default solver listen port is used.
+	ServicePort intstr.IntOrString
+}
+
+// DiffSolverIngress returns the ways in which the given ingress differs from
+// what is required to route requests for the given HTTP01 challenge to the
+// named solver service. The expected path and service port are those this
+// solver would configure on the ingress itself, and challenge paths are
+// accepted in either their plain or regular expression form. An empty result
+// means the ingress routes the challenge as expected.
+func (s *Solver) DiffSolverIngress(ch *cmacme.Challenge, svcName string, ing *extv1beta1.Ingress) []Discrepancy {
+	return DiffSolverIngress(ch, svcName, ing, DiffOptions{
+		ChallengePath: s.challengePath(ch),
+		PathSuffix:    s.ACMEOptions.HTTP01SolverPathSuffix,
+		RegexPaths:    s.ACMEOptions.HTTP01SolverRegexPaths,
+		ServicePort:   s.servicePort(),
+	})
+}
+
+// DiffSolverIngress returns the ways in which the given ingress differs from
+// what is required to route requests for the given HTTP01 challenge to the
+// named solver service, for a solver configured as described by opts. It
+// does not require a Solver, so that it can be used outside of the
+// controller. Challenge paths are accepted in either their plain or regular
+// expression form. An empty result means the ingress routes the challenge as
+// expected.
+func DiffSolverIngress(ch *cmacme.Challenge, svcName string, ing *extv1beta1.Ingress, opts DiffOptions) []Discrepancy {
+	host := normalizeHost(ch.Spec.DNSName)
+	challengePath := opts.ChallengePath
+	if challengePath == "" {
+		challengePath = challengePathFor(ch, nil)
+	}
+	expected := ingressPaths(challengePath, ch.Spec.Token, svcName, nil)[0]
+	if opts.ServicePort != (intstr.IntOrString{}) {
+		expected.Backend.ServicePort = opts.ServicePort
+	}
+	expected.Path = suffixedIngressPath(challengePath, opts.PathSuffix, opts.RegexPaths)
+	plainPath := suffixedIngressPath(challengePath, opts.PathSuffix, false)
+	regexPath := suffixedIngressPath(challengePath, opts.PathSuffix, true)
+
+	var rules []extv1beta1.IngressRule
+	for _, rule := range ing.Spec.Rules {
+		if normalizeHost(rule.Host) == host {
+			rules = append(rules, rule)
+		}
+	}
+	if len(rules) == 0 {
+		return []Discrepancy{{
+			Type:     DiscrepancyMissingHost,
+			Host:     host,
+			Expected: host,
+		}}
+	}
+
+	var wrongBackends []Discrepancy
+	for _, rule := range rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, p := range rule.HTTP.Paths {
+			if p.Path != plainPath && p.Path != regexPath {
+				continue
+			}
+			if p.Backend.ServiceName == expected.Backend.ServiceName &&
+				p.Backend.ServicePort == expected.Backend.ServicePort {
+				return nil
+			}
+			wrongBackends = append(wrongBackends, Discrepancy{
+				Type:     DiscrepancyWrongBackend,
+				Host:     host,
+				Path:     p.Path,
+				Expected: backendString(expected.Backend),
+				Actual:   backendString(p.Backend),
+			})
+		}
+	}
+	if len(wrongBackends) > 0 {
+		return wrongBackends
+	}
+	return []Discrepancy{{
+		Type:     DiscrepancyMissingPath,
+		Host:     host,
+		Path:     expected.Path,
+		Expected: backendString(expected.Backend),
+	}}
+}
+
+func backendString(b extv1beta1.IngressBackend) string {
+	return fmt.Sprintf("%s:%s", b.ServiceName, b.ServicePort.String())
+}
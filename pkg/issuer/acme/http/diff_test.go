@@ -0,0 +1,218 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/controller"
+)
+
+func TestDiffSolverIngress(t *testing.T) {
+	ingressWithRule := func(host string, paths ...v1beta1.HTTPIngressPath) *v1beta1.Ingress {
+		return &v1beta1.Ingress{
+			Spec: v1beta1.IngressSpec{
+				Rules: []v1beta1.IngressRule{
+					{
+						Host: host,
+						IngressRuleValue: v1beta1.IngressRuleValue{
+							HTTP: &v1beta1.HTTPIngressRuleValue{Paths: paths},
+						},
+					},
+				},
+			},
+		}
+	}
+	challengePath := ChallengeIngressPath("abcd", "solver-svc", acmeSolverListenPort)
+	regexChallengePath := challengePath
+	regexChallengePath.Path = regexIngressPath(challengePath.Path)
+	wrongBackendPath := challengePath
+	wrongBackendPath.Backend.ServicePort = intstr.FromInt(80)
+
+	newChallenge := func(cfg *cmacme.ACMEChallengeSolverHTTP01Ingress) *cmacme.Challenge {
+		return &cmacme.Challenge{
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com.",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{Ingress: cfg},
+				},
+			},
+		}
+	}
+	namedPortPath := challengePath
+	namedPortPath.Backend.ServicePort = intstr.FromString("acme")
+	templatedPath := challengePath
+	templatedPath.Path = "/cdn/abcd"
+
+	tests := map[string]struct {
+		ingress   *v1beta1.Ingress
+		challenge *cmacme.Challenge
+		solver    func(*Solver)
+		expected  []Discrepancy
+	}{
+		"should return nothing if the challenge is routed to the solver service": {
+			ingress: ingressWithRule("example.com", challengePath),
+		},
+		"should match hosts case insensitively and accept regex paths": {
+			ingress: ingressWithRule("Example.COM", regexChallengePath),
+		},
+		"should expect the service port configured on the solver": {
+			ingress: ingressWithRule("example.com", namedPortPath),
+			solver: func(s *Solver) {
+				s.servicePortName = "acme"
+			},
+		},
+		"should report the default port if the solver uses a named port": {
+			ingress: ingressWithRule("example.com", challengePath),
+			solver: func(s *Solver) {
+				s.servicePortName = "acme"
+			},
+			expected: []Discrepancy{{
+				Type:     DiscrepancyWrongBackend,
+				Host:     "example.com",
+				Path:     "/.well-known/acme-challenge/abcd",
+				Expected: "solver-svc:acme",
+				Actual:   "solver-svc:8089",
+			}},
+		},
+		"should expect the path from the challenge's path template": {
+			ingress:   ingressWithRule("example.com", templatedPath),
+			challenge: newChallenge(&cmacme.ACMEChallengeSolverHTTP01Ingress{PathTemplate: "/cdn/{token}"}),
+		},
+		"should report a missing host": {
+			ingress: ingressWithRule("other.com", challengePath),
+			expected: []Discrepancy{{
+				Type:     DiscrepancyMissingHost,
+				Host:     "example.com",
+				Expected: "example.com",
+			}},
+		},
+		"should report a missing path": {
+			ingress: ingressWithRule("example.com"),
+			expected: []Discrepancy{{
+				Type:     DiscrepancyMissingPath,
+				Host:     "example.com",
+				Path:     "/.well-known/acme-challenge/abcd",
+				Expected: "solver-svc:8089",
+			}},
+		},
+		"should report a path routed to the wrong backend": {
+			ingress: ingressWithRule("example.com", wrongBackendPath),
+			expected: []Discrepancy{{
+				Type:     DiscrepancyWrongBackend,
+				Host:     "example.com",
+				Path:     "/.well-known/acme-challenge/abcd",
+				Expected: "solver-svc:8089",
+				Actual:   "solver-svc:80",
+			}},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := &Solver{Context: &controller.Context{}}
+			if test.solver != nil {
+				test.solver(s)
+			}
+			ch := test.challenge
+			if ch == nil {
+				ch = newChallenge(&cmacme.ACMEChallengeSolverHTTP01Ingress{})
+			}
+			diff := s.DiffSolverIngress(ch, "solver-svc", test.ingress)
+			if !reflect.DeepEqual(test.expected, diff) {
+				t.Errorf("expected discrepancies %+v but got %+v", test.expected, diff)
+			}
+		})
+	}
+}
+
+func TestDiffSolverIngressOptions(t *testing.T) {
+	ch := &cmacme.Challenge{
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Token:   "abcd",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{}},
+			},
+		},
+	}
+	ingressWithPath := func(path v1beta1.HTTPIngressPath) *v1beta1.Ingress {
+		return &v1beta1.Ingress{
+			Spec: v1beta1.IngressSpec{
+				Rules: []v1beta1.IngressRule{
+					{
+						Host: "example.com",
+						IngressRuleValue: v1beta1.IngressRuleValue{
+							HTTP: &v1beta1.HTTPIngressRuleValue{Paths: []v1beta1.HTTPIngressPath{path}},
+						},
+					},
+				},
+			},
+		}
+	}
+	challengePath := ChallengeIngressPath("abcd", "solver-svc", acmeSolverListenPort)
+	suffixedPath := challengePath
+	suffixedPath.Path += "/*"
+
+	tests := map[string]struct {
+		ingress  *v1beta1.Ingress
+		opts     DiffOptions
+		expected []Discrepancy
+	}{
+		"should expect the default path and port with zero options": {
+			ingress: ingressWithPath(challengePath),
+		},
+		"should expect the given path suffix": {
+			ingress: ingressWithPath(suffixedPath),
+			opts:    DiffOptions{PathSuffix: "/*"},
+		},
+		"should report a path without the given path suffix as missing": {
+			ingress: ingressWithPath(challengePath),
+			opts:    DiffOptions{PathSuffix: "/*"},
+			expected: []Discrepancy{{
+				Type:     DiscrepancyMissingPath,
+				Host:     "example.com",
+				Path:     "/.well-known/acme-challenge/abcd/*",
+				Expected: "solver-svc:8089",
+			}},
+		},
+		"should expect the given service port": {
+			ingress: ingressWithPath(challengePath),
+			opts:    DiffOptions{ServicePort: intstr.FromInt(80)},
+			expected: []Discrepancy{{
+				Type:     DiscrepancyWrongBackend,
+				Host:     "example.com",
+				Path:     "/.well-known/acme-challenge/abcd",
+				Expected: "solver-svc:80",
+				Actual:   "solver-svc:8089",
+			}},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			diff := DiffSolverIngress(ch, "solver-svc", test.ingress, test.opts)
+			if !reflect.DeepEqual(test.expected, diff) {
+				t.Errorf("expected discrepancies %+v but got %+v", test.expected, diff)
+			}
+		})
+	}
+}
@@ -21,35 +21,87 @@ import (
 	"crypto/tls"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	corev1listers "k8s.io/client-go/listers/core/v1"
 	extv1beta1listers "k8s.io/client-go/listers/extensions/v1beta1"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/utils/clock"
 
 	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha2"
+	cmacmelisters "github.com/jetstack/cert-manager/pkg/client/listers/acme/v1alpha2"
 	"github.com/jetstack/cert-manager/pkg/controller"
-	"github.com/jetstack/cert-manager/pkg/issuer/acme/http/solver"
 	logf "github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/metrics"
 )
 
 const (
 	// HTTP01Timeout is the max amount of time to wait for an HTTP01 challenge
-	// to succeed
+	// to succeed if a self check timeout is not configured
 	HTTP01Timeout = time.Minute * 15
+	// defaultSelfCheckInterval is the time waited between reachability tests
+	// if a self check interval is not configured
+	defaultSelfCheckInterval = time.Second * 2
 	// acmeSolverListenPort is the port acmesolver should listen on
 	acmeSolverListenPort = 8089
+	// port80ServicePort is the additional solver service port exposed when
+	// the solver is configured to route ingress paths to port 80
+	port80ServicePort = 80
+	// port80ServicePortName is the name of the additional port 80 of solver
+	// services
+	port80ServicePortName = "http-80"
+	// defaultSolverNamePrefix is the GenerateName prefix used for solver
+	// pods, services and ingresses if one is not configured
+	defaultSolverNamePrefix = "cm-acme-http-solver-"
 
 	domainLabelKey               = "acme.cert-manager.io/http-domain"
 	tokenLabelKey                = "acme.cert-manager.io/http-token"
+	challengeLabelKey            = "acme.cert-manager.io/http-challenge"
 	solverIdentificationLabelKey = "acme.cert-manager.io/http01-solver"
+	orderHashLabelKey            = "acme.cert-manager.io/order-hash"
+	// challengeUIDLabelKey identifies the challenge that owns a solver
+	// resource created outside of the challenge's namespace, where an owner
+	// reference cannot be used
+	challengeUIDLabelKey = "acme.cert-manager.io/http-challenge-uid"
+	// domainAnnotationKey records the full challenged domain on solver
+	// resources, as the value of the domain label is a hash of it so that
+	// domains longer than a label value allows are supported
+	domainAnnotationKey = "acme.cert-manager.io/http-domain"
+	// ingressExpiresAtAnnotationKey records the time, in RFC3339 format, after
+	// which a solver ingress retained after its challenge was cleaned up may
+	// be deleted
+	ingressExpiresAtAnnotationKey = "acme.cert-manager.io/http01-solver-expires-at"
+)
+
+const (
+	// CleanupStageIngress removes the solver ingress, or the challenge paths
+	// added to an existing ingress, or the solver HTTPRoute.
+	CleanupStageIngress = "ingress"
+	// CleanupStageService removes the solver service.
+	CleanupStageService = "service"
+	// CleanupStagePod removes the solver pod.
+	CleanupStagePod = "pod"
 )
 
 var (
 	challengeGvk = cmacme.SchemeGroupVersion.WithKind("Challenge")
+
+	// DefaultCleanupOrder stops routing requests to the solver before its
+	// backend is removed, so that ingress controllers never route to a
+	// service that no longer exists.
+	DefaultCleanupOrder = []string{CleanupStageIngress, CleanupStageService, CleanupStagePod}
 )
 
 // Solver is an implementation of the acme http-01 challenge solver protocol
@@ -59,26 +111,240 @@ type Solver struct {
 	podLister     corev1listers.PodLister
 	serviceLister corev1listers.ServiceLister
 	ingressLister extv1beta1listers.IngressLister
+	// ingressClient manages ingresses in the API group served by the
+	// cluster. ingressLister always lists ingresses in the same group.
+	ingressClient ingressClient
+	// challengeLister is used to find the challenges that legacy solver
+	// ingresses were created for when migrating them.
+	challengeLister cmacmelisters.ChallengeLister
 
 	testReachability reachabilityTest
 	requiredPasses   int
+
+	// namePrefix is used as the GenerateName for all solver pods, services
+	// and ingresses created by this solver. Resources are always looked up
+	// by label, so changing this does not affect existing resources.
+	namePrefix string
+
+	// naming names the services and ingresses created by this solver. If
+	// nil, namePrefix is used as their GenerateName.
+	naming NamingStrategy
+
+	// ownerChain looks up the resources that may control solver resources
+	// indirectly, keyed by their kind. It is walked by IsOwnedBy.
+	ownerChain map[schema.GroupKind]ownerLookup
+
+	// servicePortName, if set, is the name of the solver service port and
+	// causes ingress paths to reference the port by name.
+	servicePortName string
+
+	// servicePort80 additionally exposes solver services created for ingress
+	// solvers on port 80, and causes ingress paths to reference that port,
+	// for load balancers that only forward traffic to port 80 of a service.
+	servicePort80 bool
+
+	// retries is the number of times a create call for a solver resource
+	// will be retried if the apiserver returns a transient error.
+	retries int
+	// retryTimeout bounds the total time spent retrying a create call.
+	// If zero, no timeout is applied.
+	retryTimeout time.Duration
+	retryBackoff wait.Backoff
+
+	// timeout is the maximum amount of time a challenge may take to be
+	// presented and pass the self check, measured from the creation of its
+	// solver pod. If zero, challenges never time out.
+	timeout time.Duration
+
+	// ingressDeleteTimeout is the maximum amount of time cleanup will wait
+	// for deleted ingresses to be removed from the lister cache.
+	// If zero, cleanup does not wait.
+	ingressDeleteTimeout time.Duration
+
+	// ingressRetention is how long solver ingresses are retained after their
+	// challenge has been cleaned up. If zero, they are deleted immediately.
+	ingressRetention time.Duration
+
+	// selfCheckViaIngress causes the self check to be performed against the
+	// load balancer address of the solver ingress rather than the domain.
+	selfCheckViaIngress bool
+
+	// selfCheckTimeout bounds the time a single self check may spend waiting
+	// for a challenge to become reachable, and selfCheckInterval is the time
+	// waited between each of its reachability tests.
+	selfCheckTimeout  time.Duration
+	selfCheckInterval time.Duration
+
+	// allowedNamespaces, if not empty, is the set of namespaces that solver
+	// resources may be created in. deniedNamespaces is the set of namespaces
+	// that solver resources must never be created in.
+	allowedNamespaces sets.String
+	deniedNamespaces  sets.String
+
+	// maxIngresses is the maximum number of solver ingresses that may exist
+	// in a namespace at once. If zero, the number is not limited.
+	maxIngresses int
+
+	// noNewIngresses prevents solver ingresses from being created, requiring
+	// an existing ingress to be used instead.
+	noNewIngresses bool
+
+	// requireIngressOptIn prevents challenge paths from being added to
+	// existing ingresses that have not opted in using an annotation.
+	requireIngressOptIn bool
+
+	// allowForceCleanup permits CleanupAll to delete every solver ingress in
+	// a namespace.
+	allowForceCleanup bool
+
+	// reuseWildcards adds solver paths to an existing ingress with a
+	// wildcard rule matching the challenged domain instead of creating a
+	// solver ingress.
+	reuseWildcards bool
+
+	// solverNamespace is the namespace solver pods, services, ingresses and
+	// HTTPRoutes are created in. If empty, the namespace of the challenge is
+	// used.
+	solverNamespace string
+
+	// plainOwnerRefs causes solver resources to reference their challenge
+	// with a plain owner reference rather than a controller reference.
+	plainOwnerRefs bool
+
+	// inlineKeyAuthClasses are the ingress classes for which the key
+	// authorization is served by the ingress controller from an annotation on
+	// the solver ingress, instead of by a solver pod.
+	inlineKeyAuthClasses sets.String
+
+	// cleanupGrace is how long solver resources are retained after a
+	// challenge has become valid, as some ACME servers validate a challenge
+	// again shortly afterwards.
+	cleanupGrace time.Duration
+
+	// cleanupOrder is the order in which the solver's resources are cleaned
+	// up. If empty, DefaultCleanupOrder is used.
+	cleanupOrder []string
+
+	// appendPaths adds challenge paths after the existing paths of an
+	// ingress rule rather than before them.
+	appendPaths bool
+
+	// reconcileBudget bounds the time a single call to Present or Check may
+	// take. If zero, no budget is applied.
+	reconcileBudget time.Duration
+
+	// clock is used for all time based behaviour of the solver, so that
+	// retries, timeouts and self check intervals can be tested without
+	// waiting in real time.
+	clock clock.Clock
+
+	// pathFn returns the HTTP path that the key for the challenge for the
+	// given domain and token is served on, unless the challenge's solver
+	// config specifies a path template. It is used for the ingress and
+	// HTTPRoute paths, their clean up and the self check.
+	pathFn func(domain, token string) string
+
+	// backends are the instances of the registered Backends, by name.
+	backends map[string]backendInstance
+
+	// reuseIngressFn decides whether solver ingresses created for other
+	// challenges may be reused. If nil, ingresses are never reused.
+	reuseIngressFn ReuseIngressFunc
+
+	// reachable holds the UIDs of the challenges whose self check has
+	// passed, so that the time taken for a challenge to become reachable is
+	// only observed once. Challenges are removed once cleaned up.
+	reachable     map[types.UID]struct{}
+	reachableLock sync.Mutex
+
+	metrics *metrics.Metrics
 }
 
-type reachabilityTest func(ctx context.Context, url *url.URL, key string) error
+// reachabilityTest checks that the given key is served at url. If host is
+// not empty, it is sent as the Host header instead of the host in url.
+type reachabilityTest func(ctx context.Context, url *url.URL, host, key string) error
 
 // NewSolver returns a new ACME HTTP01 solver for the given Issuer and client.
 // TODO: refactor this to have fewer args
 func NewSolver(ctx *controller.Context) *Solver {
+	namePrefix := ctx.HTTP01SolverNamePrefix
+	if namePrefix == "" {
+		namePrefix = defaultSolverNamePrefix
+	}
+	selfCheckTimeout := ctx.HTTP01SelfCheckTimeout
+	if selfCheckTimeout == 0 {
+		selfCheckTimeout = HTTP01Timeout
+	}
+	selfCheckInterval := ctx.HTTP01SelfCheckInterval
+	if selfCheckInterval == 0 {
+		selfCheckInterval = defaultSelfCheckInterval
+	}
+	ingClient, ingLister := ingressAPIFor(ctx, ctx.HTTP01IngressAPIGroup)
+	if ctx.HTTP01SolverIngressQPS > 0 {
+		ingClient = &rateLimitedIngressClient{
+			client:  ingClient,
+			limiter: flowcontrol.NewTokenBucketRateLimiter(ctx.HTTP01SolverIngressQPS, ctx.HTTP01SolverIngressBurst),
+		}
+	}
+	solverClock := ctx.Clock
+	if solverClock == nil {
+		solverClock = clock.RealClock{}
+	}
+	var reuseIngressFn ReuseIngressFunc
+	if ctx.HTTP01SolverReuseIngresses {
+		reuseIngressFn = ReuseSameDomainIngress
+	}
 	return &Solver{
-		Context:          ctx,
-		podLister:        ctx.KubeSharedInformerFactory.Core().V1().Pods().Lister(),
-		serviceLister:    ctx.KubeSharedInformerFactory.Core().V1().Services().Lister(),
-		ingressLister:    ctx.KubeSharedInformerFactory.Extensions().V1beta1().Ingresses().Lister(),
-		testReachability: testReachability,
-		requiredPasses:   5,
+		Context:              ctx,
+		podLister:            ctx.KubeSharedInformerFactory.Core().V1().Pods().Lister(),
+		serviceLister:        ctx.KubeSharedInformerFactory.Core().V1().Services().Lister(),
+		ingressLister:        ingLister,
+		ingressClient:        ingClient,
+		challengeLister:      ctx.SharedInformerFactory.Acme().V1alpha2().Challenges().Lister(),
+		testReachability:     testReachability,
+		requiredPasses:       5,
+		namePrefix:           namePrefix,
+		naming:               namingStrategyFor(ctx.HTTP01SolverNamingStrategy),
+		ownerChain:           defaultOwnerChain(ctx),
+		servicePortName:      ctx.HTTP01SolverServicePortName,
+		servicePort80:        ctx.HTTP01SolverServicePort80,
+		retries:              ctx.HTTP01SolverCreateRetries,
+		retryTimeout:         ctx.HTTP01SolverCreateTimeout,
+		retryBackoff:         defaultRetryBackoff,
+		timeout:              ctx.HTTP01SolverTimeout,
+		ingressDeleteTimeout: ctx.HTTP01SolverIngressDeleteTimeout,
+		ingressRetention:     ctx.HTTP01SolverIngressRetention,
+		selfCheckViaIngress:  ctx.HTTP01SelfCheckViaIngress,
+		selfCheckTimeout:     selfCheckTimeout,
+		selfCheckInterval:    selfCheckInterval,
+		allowedNamespaces:    sets.NewString(ctx.HTTP01SolverAllowedNamespaces...),
+		deniedNamespaces:     sets.NewString(ctx.HTTP01SolverDeniedNamespaces...),
+		maxIngresses:         ctx.HTTP01SolverMaxIngresses,
+		noNewIngresses:       ctx.HTTP01DisableIngressCreation,
+		requireIngressOptIn:  ctx.HTTP01RequireIngressOptIn,
+		allowForceCleanup:    ctx.HTTP01AllowForceCleanup,
+		solverNamespace:      ctx.HTTP01SolverNamespace,
+		plainOwnerRefs:       ctx.HTTP01SolverPlainOwnerRefs,
+		inlineKeyAuthClasses: sets.NewString(ctx.HTTP01SolverInlineKeyAuthClasses...),
+		reuseWildcards:       ctx.HTTP01ReuseWildcardIngresses,
+		cleanupGrace:         ctx.HTTP01SolverCleanupGracePeriod,
+		cleanupOrder:         ctx.HTTP01SolverCleanupOrder,
+		appendPaths:          ctx.HTTP01SolverAppendPaths,
+		reconcileBudget:      ctx.HTTP01SolverReconcileBudget,
+		clock:                solverClock,
+		pathFn:               defaultPathFn,
+		reuseIngressFn:       reuseIngressFn,
+		backends:             newBackends(ctx),
+		metrics:              metrics.Default,
 	}
 }
 
+// IsSolverResource returns true if the given object was created by the
+// HTTP01 solver to solve a challenge.
+func IsSolverResource(obj metav1.Object) bool {
+	return obj.GetLabels()[solverIdentificationLabelKey] == "true"
+}
+
 func http01LogCtx(ctx context.Context) context.Context {
 	return logf.NewContext(ctx, nil, "http01")
 }
@@ -94,25 +360,236 @@ func httpDomainCfgForChallenge(ch *cmacme.Challenge) (*cmacme.ACMEChallengeSolve
 	return nil, fmt.Errorf("no HTTP01 ingress configuration found on challenge")
 }
 
+// nodePortCfgForChallenge returns the NodePort solver configuration for the
+// given challenge, or nil if the challenge is not configured to be solved by
+// exposing the solver pod using a NodePort service.
+func nodePortCfgForChallenge(ch *cmacme.Challenge) *cmacme.ACMEChallengeSolverHTTP01NodePort {
+	if ch.Spec.Solver == nil || ch.Spec.Solver.HTTP01 == nil {
+		return nil
+	}
+	return ch.Spec.Solver.HTTP01.NodePort
+}
+
 // Present will realise the resources required to solve the given HTTP01
 // challenge validation in the apiserver. If those resources already exist, it
 // will return nil (i.e. this function is idempotent).
+// If it does not complete within the solver's reconcile budget, a
+// ReconcileBudgetExceededError is returned.
 func (s *Solver) Present(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+	_, err := s.PresentIngress(ctx, issuer, ch)
+	return err
+}
+
+// PresentIngress is like Present, but also returns the change that was made
+// to the solver ingress, so that callers can give the ingress controller
+// time to pick up a new or modified ingress before checking the challenge.
+// IngressActionNone is returned if the challenge is not solved using an
+// ingress.
+func (s *Solver) PresentIngress(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) (IngressAction, error) {
+	budgetCtx, cancel := s.withReconcileBudget(ctx)
+	defer cancel()
+	action, err := s.present(budgetCtx, issuer, ch)
+	return action, s.budgetError(ctx, budgetCtx, err)
+}
+
+func (s *Solver) present(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) (IngressAction, error) {
 	ctx = http01LogCtx(ctx)
 
+	if s.timeout > 0 {
+		if _, err := s.remainingTime(ch); err != nil {
+			return IngressActionNone, err
+		}
+	}
+
+	if err := s.checkNamespaceAllowed(s.resourceNamespace(ch)); err != nil {
+		return IngressActionNone, err
+	}
+
+	// ACME servers do not offer HTTP01 challenges for wildcard domains, so
+	// this can only happen if the issuer's solvers are misconfigured
+	if ch.Spec.Wildcard {
+		return IngressActionNone, solverError(FailureReasonWildcardNotSupported, fmt.Errorf("HTTP01 challenges cannot be used to validate "+
+			"wildcard domain '*.%s', a DNS01 solver must be used instead", ch.Spec.DNSName))
+	}
+
+	if strategy := strategyForChallenge(ch); strategy != "" {
+		backend, err := s.backendFor(strategy)
+		if err != nil {
+			return IngressActionNone, err
+		}
+		return IngressActionNone, backend.Present(ctx, issuer, ch)
+	}
+
+	if err := s.validateChallengePath(ch); err != nil {
+		return IngressActionNone, err
+	}
+
+	if s.servesKeyAuthInline(ch) {
+		ing, action, ingressErr := s.ensureIngress(ctx, ch, inlineKeyAuthServiceName)
+		s.recordIngressAction(ch, ing, action)
+		return action, ingressErr
+	}
+
 	_, podErr := s.ensurePod(ctx, ch)
+	if svcName := existingServiceName(ch); svcName != "" {
+		if svcErr := s.checkExistingService(ch, svcName); svcErr != nil {
+			return IngressActionNone, utilerrors.NewAggregate([]error{podErr, svcErr})
+		}
+		ing, action, ingressErr := s.ensureIngress(ctx, ch, svcName)
+		s.recordIngressAction(ch, ing, action)
+		return action, utilerrors.NewAggregate([]error{podErr, ingressErr})
+	}
 	svc, svcErr := s.ensureService(ctx, ch)
 	if svcErr != nil {
-		return utilerrors.NewAggregate([]error{podErr, svcErr})
+		return IngressActionNone, utilerrors.NewAggregate([]error{podErr, svcErr})
+	}
+	if nodePortCfgForChallenge(ch) != nil {
+		nodePortErr := s.ensureNodePort(ctx, ch, svc)
+		return IngressActionNone, utilerrors.NewAggregate([]error{podErr, nodePortErr})
+	}
+	if gatewayCfgForChallenge(ch) != nil {
+		_, routeErr := s.ensureHTTPRoute(ctx, ch, svc.Name)
+		return IngressActionNone, utilerrors.NewAggregate([]error{podErr, routeErr})
+	}
+	ing, action, ingressErr := s.ensureIngress(ctx, ch, svc.Name)
+	s.recordIngressAction(ch, ing, action)
+	return action, utilerrors.NewAggregate([]error{podErr, svcErr, ingressErr})
+}
+
+// checkNamespaceAllowed returns an error if solver resources may not be
+// created in the given namespace.
+func (s *Solver) checkNamespaceAllowed(namespace string) error {
+	if s.deniedNamespaces.Has(namespace) {
+		return fmt.Errorf("HTTP01 solver resources may not be created in namespace %q as it is in the list of denied namespaces", namespace)
+	}
+	if s.allowedNamespaces.Len() > 0 && !s.allowedNamespaces.Has(namespace) {
+		return fmt.Errorf("HTTP01 solver resources may not be created in namespace %q as it is not in the list of allowed namespaces", namespace)
 	}
-	_, ingressErr := s.ensureIngress(ctx, ch, svc.Name)
-	return utilerrors.NewAggregate([]error{podErr, svcErr, ingressErr})
+	return nil
+}
+
+// setResourceName names the given solver service or ingress using the
+// solver's naming strategy.
+func (s *Solver) setResourceName(ch *cmacme.Challenge, obj metav1.Object) {
+	if s.naming == nil {
+		obj.SetGenerateName(s.namePrefix)
+		return
+	}
+	s.naming.SetName(ch, s.namePrefix, obj)
 }
 
+// resourceNamespace returns the namespace that solver resources for the given
+// challenge are created in.
+func (s *Solver) resourceNamespace(ch *cmacme.Challenge) string {
+	if s.solverNamespace != "" {
+		return s.solverNamespace
+	}
+	return ch.Namespace
+}
+
+// setResourceOwner sets the namespace of the given solver resource and records
+// the challenge that owns it. Owner references cannot refer to a resource in
+// another namespace, so resources created outside of the namespace of the
+// challenge are labelled with its UID instead. These are not garbage collected
+// if the challenge is deleted without being cleaned up.
+// The challenged domain is also recorded in an annotation, as the domain label
+// only holds its hash.
+func (s *Solver) setResourceOwner(ch *cmacme.Challenge, obj metav1.Object) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[domainAnnotationKey] = ch.Spec.DNSName
+	obj.SetAnnotations(annotations)
+
+	namespace := s.resourceNamespace(ch)
+	obj.SetNamespace(namespace)
+	if namespace == ch.Namespace {
+		obj.SetOwnerReferences([]metav1.OwnerReference{s.challengeOwnerRef(ch)})
+		return
+	}
+	lbls := obj.GetLabels()
+	if lbls == nil {
+		lbls = make(map[string]string)
+	}
+	lbls[challengeUIDLabelKey] = string(ch.UID)
+	obj.SetLabels(lbls)
+}
+
+// challengeOwnerRef returns the owner reference set on solver resources
+// created for the given challenge. This is a controller reference unless the
+// solver is configured to use plain owner references, so that another
+// controller may own the resource.
+func (s *Solver) challengeOwnerRef(ch *cmacme.Challenge) metav1.OwnerReference {
+	ref := *metav1.NewControllerRef(ch, challengeGvk)
+	if s.plainOwnerRefs {
+		ref.Controller = nil
+	}
+	return ref
+}
+
+// challengeRefOf returns the owner reference of the given solver resource
+// that refers to a challenge, or nil if there is none. Both controller and
+// plain owner references are considered, as the solver may have been
+// configured to use either when the resource was created.
+func challengeRefOf(obj metav1.Object) *metav1.OwnerReference {
+	refs := obj.GetOwnerReferences()
+	for i := range refs {
+		if schema.FromAPIVersionAndKind(refs[i].APIVersion, refs[i].Kind).GroupKind() == challengeGvk.GroupKind() {
+			return &refs[i]
+		}
+	}
+	return nil
+}
+
+// sharedWithOtherChallenges returns true if the given solver resource is
+// also owned by a challenge other than the given one, i.e. it is a solver
+// ingress that has been reused by or for another challenge.
+func sharedWithOtherChallenges(ch *cmacme.Challenge, obj metav1.Object) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.UID != ch.UID && schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind).GroupKind() == challengeGvk.GroupKind() {
+			return true
+		}
+	}
+	return false
+}
+
+// isResourceOwner returns true if the given solver resource is owned by the
+// given challenge, as recorded by setResourceOwner.
+func (s *Solver) isResourceOwner(ch *cmacme.Challenge, obj metav1.Object) bool {
+	if obj.GetNamespace() == ch.Namespace {
+		for _, ref := range obj.GetOwnerReferences() {
+			if ref.UID == ch.UID {
+				return true
+			}
+		}
+		return false
+	}
+	return ch.UID != "" && obj.GetLabels()[challengeUIDLabelKey] == string(ch.UID)
+}
+
+// Check runs the self check for the given challenge, first ensuring that its
+// solver resources still exist.
+// If it does not complete within the solver's reconcile budget, a
+// ReconcileBudgetExceededError is returned.
 func (s *Solver) Check(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+	budgetCtx, cancel := s.withReconcileBudget(ctx)
+	defer cancel()
+	return s.budgetError(ctx, budgetCtx, s.check(budgetCtx, issuer, ch))
+}
+
+func (s *Solver) check(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
 	ctx = logf.NewContext(http01LogCtx(ctx), nil, "selfCheck")
 	log := logf.FromContext(ctx)
 
+	if strategy := strategyForChallenge(ch); strategy != "" {
+		backend, err := s.backendFor(strategy)
+		if err != nil {
+			return err
+		}
+		return backend.Check(ctx, issuer, ch)
+	}
+
 	// HTTP Present is idempotent and the state of the system may have
 	// changed since present was called by the controllers (killed pods, drained nodes)
 	// Call present again to be certain.
@@ -127,55 +604,436 @@ func (s *Solver) Check(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *c
 		}
 	}
 
-	ctx, cancel := context.WithTimeout(ctx, HTTP01Timeout)
+	timeout := s.selfCheckTimeout
+	if s.timeout > 0 {
+		remaining, err := s.remainingTime(ch)
+		if err != nil {
+			return err
+		}
+		if remaining < timeout {
+			timeout = remaining
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 	url := s.buildChallengeUrl(ch)
+	host := ""
+	// route the self check through the solver ingress' load balancer, so
+	// that it exercises the same path through the ingress controller as the
+	// ACME server will
+	if s.selfCheckViaIngress && nodePortCfgForChallenge(ch) == nil && gatewayCfgForChallenge(ch) == nil {
+		addr, err := s.solverIngressAddress(ctx, ch)
+		if err != nil {
+			return err
+		}
+		host = url.Host
+		url.Host = addr
+	}
 	log = log.WithValues("url", url)
+	if host != "" {
+		log = log.WithValues("host", host)
+	}
 	ctx = logf.NewContext(ctx, log)
 
 	log.V(logf.DebugLevel).Info("running self check multiple times to ensure challenge has propagated", "required_passes", s.requiredPasses)
 	for i := 0; i < s.requiredPasses; i++ {
-		err := s.testReachability(ctx, url, ch.Spec.Key)
+		err := s.testReachability(ctx, url, host, ch.Spec.Key)
 		if err != nil {
+			return solverError(FailureReasonDomainNotReachable, err)
+		}
+		log.V(logf.DebugLevel).Info("reachability test passed, re-checking after interval", "interval", s.selfCheckInterval)
+		if err := s.sleep(ctx, s.selfCheckInterval); err != nil {
 			return err
 		}
-		log.V(logf.DebugLevel).Info("reachability test passed, re-checking in 2s time")
-		time.Sleep(time.Second * 2)
 	}
 
 	log.V(logf.DebugLevel).Info("self check succeeded")
+	if s.markReachable(ch) && !ch.CreationTimestamp.IsZero() {
+		s.metrics.ObserveHTTP01SolverTimeToReachable(ch, s.clock.Since(ch.CreationTimestamp.Time))
+	}
 
 	return nil
 }
 
+// markReachable records that the self check for the given challenge has
+// passed, returning true if it had not passed before.
+func (s *Solver) markReachable(ch *cmacme.Challenge) bool {
+	s.reachableLock.Lock()
+	defer s.reachableLock.Unlock()
+	if _, ok := s.reachable[ch.UID]; ok {
+		return false
+	}
+	if s.reachable == nil {
+		s.reachable = make(map[types.UID]struct{})
+	}
+	s.reachable[ch.UID] = struct{}{}
+	return true
+}
+
+// forgetReachable removes the given challenge from the set of challenges
+// whose self check has passed.
+func (s *Solver) forgetReachable(ch *cmacme.Challenge) {
+	s.reachableLock.Lock()
+	defer s.reachableLock.Unlock()
+	delete(s.reachable, ch.UID)
+}
+
+// sleep waits for the given duration using the solver's clock, returning the
+// context's error if it is cancelled first.
+func (s *Solver) sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.clock.After(d):
+		return nil
+	}
+}
+
+// TimeoutError is returned by Present and Check if a challenge has not been
+// presented and passed the self check within the solver's timeout.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s waiting for HTTP01 challenge to be presented and pass the self check", e.Timeout)
+}
+
+// IsTimeoutError returns true if the given error is a TimeoutError.
+func IsTimeoutError(err error) bool {
+	_, ok := err.(*TimeoutError)
+	return ok
+}
+
+// FailureReason is a machine readable reason for a failure of the HTTP01
+// solver, which callers may use as the reason of events and conditions so
+// that users can alert on specific failures.
+type FailureReason string
+
+const (
+	// FailureReasonIngressCreateFailed indicates that a solver ingress
+	// could not be created.
+	FailureReasonIngressCreateFailed FailureReason = "IngressCreateFailed"
+	// FailureReasonIngressNotFound indicates that the existing ingress that
+	// challenge paths should be added to, named or selected in the solver
+	// config, does not exist.
+	FailureReasonIngressNotFound FailureReason = "IngressNotFound"
+	// FailureReasonPathInjectionDenied indicates that challenge paths may not
+	// be added to the existing ingress named or selected in the solver config.
+	FailureReasonPathInjectionDenied FailureReason = "PathInjectionDenied"
+	// FailureReasonDomainNotReachable indicates that the self check could not
+	// retrieve the challenge response from the challenged domain.
+	FailureReasonDomainNotReachable FailureReason = "DomainNotReachable"
+	// FailureReasonWildcardNotSupported indicates that a HTTP01 solver was
+	// used for a wildcard domain.
+	FailureReasonWildcardNotSupported FailureReason = "WildcardNotSupported"
+)
+
+// SolverError is returned by Present and Check for failures with a known
+// FailureReason.
+type SolverError struct {
+	Reason FailureReason
+	Err    error
+}
+
+func (e *SolverError) Error() string {
+	return e.Err.Error()
+}
+
+// solverError returns err as a SolverError with the given reason, or nil if
+// err is nil.
+func solverError(reason FailureReason, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &SolverError{Reason: reason, Err: err}
+}
+
+// FailureReasonFor returns the reason for the given error returned by the
+// solver, looking inside aggregated errors, or an empty string if the reason
+// is not known.
+func FailureReasonFor(err error) FailureReason {
+	switch err := err.(type) {
+	case *SolverError:
+		return err.Reason
+	case utilerrors.Aggregate:
+		for _, err := range err.Errors() {
+			if reason := FailureReasonFor(err); reason != "" {
+				return reason
+			}
+		}
+	}
+	return ""
+}
+
+// ReconcileBudgetExceededError is returned by Present and Check if they do not
+// complete within the solver's reconcile budget. The challenge should be
+// requeued so that they are retried later.
+type ReconcileBudgetExceededError struct {
+	Budget time.Duration
+}
+
+func (e *ReconcileBudgetExceededError) Error() string {
+	return fmt.Sprintf("HTTP01 solver did not complete within its reconcile budget of %s, retrying later", e.Budget)
+}
+
+// IsReconcileBudgetExceededError returns true if the given error is a
+// ReconcileBudgetExceededError.
+func IsReconcileBudgetExceededError(err error) bool {
+	_, ok := err.(*ReconcileBudgetExceededError)
+	return ok
+}
+
+// withReconcileBudget returns a context that is cancelled once the solver's
+// reconcile budget has been used up, if one is configured.
+func (s *Solver) withReconcileBudget(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.reconcileBudget <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, s.reconcileBudget)
+}
+
+// budgetError returns a ReconcileBudgetExceededError in place of err if it
+// occurred because budgetCtx, derived from ctx by withReconcileBudget, ran out
+// of time. Errors caused by ctx itself being done are returned as they are,
+// so that a nested call leaves reporting the budget to its caller.
+func (s *Solver) budgetError(ctx, budgetCtx context.Context, err error) error {
+	if err == nil || s.reconcileBudget <= 0 || ctx.Err() != nil {
+		return err
+	}
+	if budgetCtx.Err() == context.DeadlineExceeded {
+		return &ReconcileBudgetExceededError{Budget: s.reconcileBudget}
+	}
+	return err
+}
+
+// remainingTime returns how much longer the given challenge may take before
+// it times out, or a TimeoutError if it already has. The start of the present
+// and check cycle is taken to be the time the challenge was first presented,
+// as recorded in its status.
+func (s *Solver) remainingTime(ch *cmacme.Challenge) (time.Duration, error) {
+	if ch.Status.PresentStartedAt == nil {
+		return s.timeout, nil
+	}
+	remaining := s.timeout - s.clock.Since(ch.Status.PresentStartedAt.Time)
+	if remaining <= 0 {
+		return 0, &TimeoutError{Timeout: s.timeout}
+	}
+	return remaining, nil
+}
+
+// CleanUpDeferredError is returned by CleanUp if the solver resources for a
+// valid challenge are being retained for the cleanup grace period.
+type CleanUpDeferredError struct {
+	// RetryAfter is the remaining time until the grace period ends.
+	RetryAfter time.Duration
+}
+
+func (e *CleanUpDeferredError) Error() string {
+	return fmt.Sprintf("clean up of HTTP01 solver resources deferred for %s after the challenge became valid", e.RetryAfter)
+}
+
+// cleanupDeferral returns how much longer clean up of the solver resources
+// for the given challenge should be deferred for, or zero if they can be
+// cleaned up now. Resources are only retained for valid challenges that are
+// not being deleted, measured from the validation time recorded in the
+// challenge's status.
+func (s *Solver) cleanupDeferral(ch *cmacme.Challenge) time.Duration {
+	if s.cleanupGrace <= 0 || ch.Status.State != cmacme.Valid || ch.DeletionTimestamp != nil || ch.Status.ValidatedAt == nil {
+		return 0
+	}
+	if remaining := s.cleanupGrace - s.clock.Since(ch.Status.ValidatedAt.Time); remaining > 0 {
+		return remaining
+	}
+	return 0
+}
+
 // CleanUp will ensure the created service, ingress and pod are clean/deleted of any
 // cert-manager created data.
+// If a cleanup grace period is configured, clean up of a valid challenge is
+// deferred until it has passed and a CleanUpDeferredError is returned.
 func (s *Solver) CleanUp(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
-	var errs []error
-	errs = append(errs, s.cleanupPods(ctx, ch))
-	errs = append(errs, s.cleanupServices(ctx, ch))
-	errs = append(errs, s.cleanupIngresses(ctx, ch))
-	return utilerrors.NewAggregate(errs)
+	ctx = http01LogCtx(ctx)
+
+	if remaining := s.cleanupDeferral(ch); remaining > 0 {
+		return &CleanUpDeferredError{RetryAfter: remaining}
+	}
+	if err := s.cleanUp(ctx, issuer, ch); err != nil {
+		return err
+	}
+	s.forgetReachable(ch)
+	return nil
+}
+
+func (s *Solver) cleanUp(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+	if strategy := strategyForChallenge(ch); strategy != "" {
+		backend, err := s.backendFor(strategy)
+		if err != nil {
+			return err
+		}
+		return backend.CleanUp(ctx, issuer, ch)
+	}
+
+	order := s.cleanupOrder
+	if len(order) == 0 {
+		order = DefaultCleanupOrder
+	}
+	// each stage must have completed before the next is started, so that
+	// e.g. a service is never deleted while an ingress still routes to it.
+	// Stages that have already completed are no-ops when retried.
+	for _, stage := range order {
+		if err := s.cleanupStage(ctx, ch, stage); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cleanupStage cleans up the solver resources of the given kind for the
+// given challenge.
+func (s *Solver) cleanupStage(ctx context.Context, ch *cmacme.Challenge, stage string) error {
+	switch stage {
+	case CleanupStagePod:
+		return s.cleanupPods(ctx, ch)
+	case CleanupStageService:
+		// an existing service is managed by the user and must be left in place
+		if existingServiceName(ch) != "" {
+			return nil
+		}
+		return s.cleanupServices(ctx, ch)
+	case CleanupStageIngress:
+		switch {
+		case nodePortCfgForChallenge(ch) != nil:
+			// solvers using a NodePort service do not create or modify any ingresses
+			return nil
+		case gatewayCfgForChallenge(ch) != nil:
+			return s.cleanupHTTPRoutes(ctx, ch)
+		default:
+			err := s.cleanupIngresses(ctx, ch)
+			s.metrics.ObserveHTTP01SolverOperation(ch, metrics.HTTP01SolverOperationCleanup, err)
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown HTTP01 solver cleanup stage %q", stage)
+	}
+}
+
+// ValidateCleanupOrder returns an error unless the given cleanup order
+// contains each cleanup stage exactly once.
+func ValidateCleanupOrder(order []string) error {
+	if len(order) != len(DefaultCleanupOrder) {
+		return fmt.Errorf("cleanup order must contain each of %s exactly once", strings.Join(DefaultCleanupOrder, ", "))
+	}
+	stages := sets.NewString(DefaultCleanupOrder...)
+	seen := sets.NewString()
+	for _, stage := range order {
+		if !stages.Has(stage) {
+			return fmt.Errorf("unknown cleanup stage %q", stage)
+		}
+		if seen.Has(stage) {
+			return fmt.Errorf("cleanup stage %q is listed more than once", stage)
+		}
+		seen.Insert(stage)
+	}
+	return nil
+}
+
+// PresentedURL returns the URL that the given challenge is served at once it
+// has been presented.
+func (s *Solver) PresentedURL(ch *cmacme.Challenge) string {
+	return s.buildChallengeUrl(ch).String()
+}
+
+// SolverService returns the name and port of the service that requests for
+// the given challenge are routed to, in the form '<name>:<port>'.
+func (s *Solver) SolverService(ctx context.Context, ch *cmacme.Challenge) (string, error) {
+	port := s.servicePort()
+	if svcName := existingServiceName(ch); svcName != "" {
+		return fmt.Sprintf("%s:%s", svcName, port.String()), nil
+	}
+	svcs, err := s.getServicesForChallenge(ctx, ch)
+	if err != nil {
+		return "", err
+	}
+	if len(svcs) != 1 {
+		return "", fmt.Errorf("expected exactly one solver service for challenge but found %d", len(svcs))
+	}
+	return fmt.Sprintf("%s:%s", svcs[0].Name, port.String()), nil
 }
 
 func (s *Solver) buildChallengeUrl(ch *cmacme.Challenge) *url.URL {
 	url := &url.URL{}
 	url.Scheme = "http"
 	url.Host = ch.Spec.DNSName
-	url.Path = fmt.Sprintf("%s/%s", solver.HTTPChallengePath, ch.Spec.Token)
+	url.Path = s.challengePath(ch)
 
 	return url
 }
 
+// lookupIPAddr is used to resolve the addresses of the host being checked.
+// It is a variable so that it can be overridden in tests.
+var lookupIPAddr = net.DefaultResolver.LookupIPAddr
+
+// hasRouteTo returns true if the controller has a route to the given IP
+// address. Connecting a UDP socket does not send any packets, but fails if
+// there is no route to the address, e.g. for IPv6 addresses when the
+// controller only has IPv4 connectivity. It is a variable so that it can be
+// overridden in tests.
+var hasRouteTo = func(ip net.IP) bool {
+	conn, err := net.Dial("udp", net.JoinHostPort(ip.String(), "80"))
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
 // testReachability will attempt to connect to the 'domain' with 'path' and
-// check if the returned body equals 'key'
-func testReachability(ctx context.Context, url *url.URL, key string) error {
+// check if the returned body equals 'key'. If 'host' is set, it will be used
+// as the Host header of the request.
+// The ACME server may connect to any of the addresses the domain resolves
+// to, so the check is performed against every IPv4 and IPv6 address and only
+// succeeds if the key is served on all of them. Addresses the controller has
+// no route to, e.g. the IPv6 addresses of a dual-stack domain when the
+// controller only has IPv4 connectivity, cannot be checked and are skipped.
+func testReachability(ctx context.Context, url *url.URL, host, key string) error {
 	log := logf.FromContext(ctx)
+
+	addrs, err := lookupIPAddr(ctx, url.Hostname())
+	if err != nil {
+		log.V(logf.DebugLevel).Info("failed to resolve addresses for self check", "error", err)
+		return fmt.Errorf("failed to resolve addresses of '%s': %v", url.Hostname(), err)
+	}
+
+	var errs []error
+	checked := 0
+	for _, addr := range addrs {
+		if !hasRouteTo(addr.IP) {
+			log.V(logf.DebugLevel).Info("skipping self check against address with no route", "address", addr.String())
+			continue
+		}
+		checked++
+		if err := testReachabilityOfAddress(ctx, url, host, key, addr.String()); err != nil {
+			errs = append(errs, fmt.Errorf("self check against address %s failed: %v", addr.String(), err))
+		}
+	}
+	if len(addrs) > 0 && checked == 0 {
+		return fmt.Errorf("no route to any of the addresses of '%s'", url.Hostname())
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+// testReachabilityOfAddress performs the reachability check described by
+// testReachability, connecting to the given IP address in place of any
+// addresses the host in 'url' would otherwise resolve to.
+func testReachabilityOfAddress(ctx context.Context, url *url.URL, host, key, addr string) error {
+	log := logf.FromContext(ctx).WithValues("address", addr)
 	log.V(logf.DebugLevel).Info("performing HTTP01 reachability check")
 
 	req := &http.Request{
 		Method: http.MethodGet,
 		URL:    url,
+		Host:   host,
 	}
 	req = req.WithContext(ctx)
 
@@ -185,8 +1043,18 @@ func testReachability(ctx context.Context, url *url.URL, key string) error {
 	// certificate after all).
 	// TODO(dmo): figure out if we need to add a more specific timeout for
 	// individual checks
+	dialer := &net.Dialer{}
 	transport := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
+		// only connections to the host being checked are pinned to addr, so
+		// that redirects to other hosts and proxies are still dialled normally
+		DialContext: func(ctx context.Context, network, address string) (net.Conn, error) {
+			dialHost, port, err := net.SplitHostPort(address)
+			if err == nil && dialHost == url.Hostname() {
+				address = net.JoinHostPort(addr, port)
+			}
+			return dialer.DialContext(ctx, network, address)
+		},
 		// we're only doing 1 request, make the code around this
 		// simpler by disabling keepalives
 		DisableKeepAlives: true,
@@ -18,19 +18,38 @@ package http
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	coretesting "k8s.io/client-go/testing"
+	fakeclock "k8s.io/utils/clock/testing"
 
 	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+	"github.com/jetstack/cert-manager/pkg/metrics"
 )
 
 // countReachabilityTestCalls is a wrapper function that allows us to count the number
 // of calls to a reachabilityTest.
 func countReachabilityTestCalls(counter *int, t reachabilityTest) reachabilityTest {
-	return func(ctx context.Context, url *url.URL, key string) error {
+	return func(ctx context.Context, url *url.URL, host, key string) error {
 		*counter++
-		return t(ctx, url, key)
+		return t(ctx, url, host, key)
 	}
 }
 
@@ -44,14 +63,14 @@ func TestCheck(t *testing.T) {
 	tests := []testT{
 		{
 			name: "should pass",
-			reachabilityTest: func(context.Context, *url.URL, string) error {
+			reachabilityTest: func(context.Context, *url.URL, string, string) error {
 				return nil
 			},
 			expectedErr: false,
 		},
 		{
 			name: "should error",
-			reachabilityTest: func(context.Context, *url.URL, string) error {
+			reachabilityTest: func(context.Context, *url.URL, string, string) error {
 				return fmt.Errorf("failed")
 			},
 			expectedErr: true,
@@ -66,9 +85,15 @@ func TestCheck(t *testing.T) {
 			if test.challenge == nil {
 				test.challenge = &cmacme.Challenge{}
 			}
+			clk, stop := autoStepClock(defaultSelfCheckInterval)
+			defer stop()
 			s := Solver{
-				testReachability: countReachabilityTestCalls(&calls, test.reachabilityTest),
-				requiredPasses:   requiredCallsForPass,
+				testReachability:  countReachabilityTestCalls(&calls, test.reachabilityTest),
+				requiredPasses:    requiredCallsForPass,
+				selfCheckTimeout:  HTTP01Timeout,
+				selfCheckInterval: defaultSelfCheckInterval,
+				clock:             clk,
+				metrics:           metrics.Default,
 			}
 
 			err := s.Check(context.Background(), nil, test.challenge)
@@ -87,3 +112,1030 @@ func TestCheck(t *testing.T) {
 		})
 	}
 }
+
+// autoStepClock returns a fake clock that is stepped by the given duration
+// whenever something is waiting on it, and a function that stops stepping it.
+func autoStepClock(step time.Duration) (*fakeclock.FakeClock, func()) {
+	clk := fakeclock.NewFakeClock(time.Now())
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Millisecond):
+				if clk.HasWaiters() {
+					clk.Step(step)
+				}
+			}
+		}
+	}()
+	return clk, func() { close(done) }
+}
+
+func TestCheckSelfCheckTimeout(t *testing.T) {
+	const timeout = time.Minute
+	const interval = 10 * time.Second
+	clk, stop := autoStepClock(interval)
+	defer stop()
+	var calls []time.Time
+	s := Solver{
+		testReachability: func(ctx context.Context, _ *url.URL, _, _ string) error {
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				t.Fatalf("expected reachability test context to have a deadline")
+			}
+			if remaining := time.Until(deadline); remaining > timeout {
+				t.Errorf("expected reachability test deadline to be within %s but it is %s away", timeout, remaining)
+			}
+			calls = append(calls, clk.Now())
+			return nil
+		},
+		requiredPasses:    3,
+		selfCheckTimeout:  timeout,
+		selfCheckInterval: interval,
+		clock:             clk,
+		metrics:           metrics.Default,
+	}
+
+	if err := s.Check(context.Background(), nil, &cmacme.Challenge{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 reachability tests but got %d", len(calls))
+	}
+	for i := 1; i < len(calls); i++ {
+		if d := calls[i].Sub(calls[i-1]); d != interval {
+			t.Errorf("expected reachability tests to be %s apart but got %s", interval, d)
+		}
+	}
+}
+
+func TestCheckObservesTimeToReachableOnce(t *testing.T) {
+	const interval = 10 * time.Second
+	clk, stop := autoStepClock(interval)
+	defer stop()
+	timeToReachable := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "time_to_reachable_seconds",
+		Help:    "Time to reachable.",
+		Buckets: []float64{60},
+	}, []string{"namespace", "issuer_name", "issuer_kind"})
+	s := Solver{
+		testReachability: func(context.Context, *url.URL, string, string) error {
+			return nil
+		},
+		requiredPasses:    1,
+		selfCheckTimeout:  HTTP01Timeout,
+		selfCheckInterval: interval,
+		clock:             clk,
+		metrics:           &metrics.Metrics{HTTP01SolverTimeToReachableSeconds: timeToReachable},
+	}
+	ch := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         defaultTestNamespace,
+			UID:               "test-uid",
+			CreationTimestamp: metav1.NewTime(clk.Now()),
+		},
+	}
+	check := func() {
+		t.Helper()
+		if err := s.Check(context.Background(), nil, ch); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// the time is only observed the first time the challenge is reachable
+	check()
+	check()
+	// and again once the challenge has been cleaned up
+	s.forgetReachable(ch)
+	check()
+
+	expected := `
+# HELP time_to_reachable_seconds Time to reachable.
+# TYPE time_to_reachable_seconds histogram
+time_to_reachable_seconds_bucket{issuer_kind="",issuer_name="",namespace="default-unit-test-ns",le="60"} 2
+time_to_reachable_seconds_bucket{issuer_kind="",issuer_name="",namespace="default-unit-test-ns",le="+Inf"} 2
+time_to_reachable_seconds_sum{issuer_kind="",issuer_name="",namespace="default-unit-test-ns"} 40
+time_to_reachable_seconds_count{issuer_kind="",issuer_name="",namespace="default-unit-test-ns"} 2
+`
+	if err := testutil.CollectAndCompare(timeToReachable, strings.NewReader(expected)); err != nil {
+		t.Errorf("unexpected time to reachable observations: %v", err)
+	}
+}
+
+func TestCheckCancelledDuringInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := Solver{
+		testReachability: func(context.Context, *url.URL, string, string) error {
+			// the clock is never stepped, so the check can only return once
+			// the context is cancelled
+			cancel()
+			return nil
+		},
+		requiredPasses:    2,
+		selfCheckTimeout:  HTTP01Timeout,
+		selfCheckInterval: defaultSelfCheckInterval,
+		clock:             fakeclock.NewFakeClock(time.Now()),
+		metrics:           metrics.Default,
+	}
+
+	if err := s.Check(ctx, nil, &cmacme.Challenge{}); err != context.Canceled {
+		t.Errorf("expected %v but got: %v", context.Canceled, err)
+	}
+}
+
+func TestCheckReconcileBudget(t *testing.T) {
+	const budget = 10 * time.Millisecond
+	blockUntilDone := func(ctx context.Context, _ *url.URL, _, _ string) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	tests := map[string]struct {
+		budget          time.Duration
+		cancelParent    bool
+		reachability    reachabilityTest
+		expectBudgetErr bool
+	}{
+		"returns a budget error if the self check outlasts the budget": {
+			budget:          budget,
+			reachability:    blockUntilDone,
+			expectBudgetErr: true,
+		},
+		"returns the original error if the parent context is cancelled": {
+			budget:       budget,
+			cancelParent: true,
+			reachability: blockUntilDone,
+		},
+		"returns the original error if the self check fails within the budget": {
+			budget: time.Minute,
+			reachability: func(context.Context, *url.URL, string, string) error {
+				return fmt.Errorf("failed")
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := Solver{
+				testReachability:  test.reachability,
+				requiredPasses:    1,
+				selfCheckTimeout:  HTTP01Timeout,
+				selfCheckInterval: defaultSelfCheckInterval,
+				reconcileBudget:   test.budget,
+				clock:             fakeclock.NewFakeClock(time.Now()),
+				metrics:           metrics.Default,
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if test.cancelParent {
+				cancel()
+			}
+
+			err := s.Check(ctx, nil, &cmacme.Challenge{})
+			if err == nil {
+				t.Fatalf("expected an error but got none")
+			}
+			if IsReconcileBudgetExceededError(err) != test.expectBudgetErr {
+				t.Errorf("expected reconcile budget exceeded error to be %t but got: %v", test.expectBudgetErr, err)
+			}
+		})
+	}
+}
+
+func TestRemainingTime(t *testing.T) {
+	const timeout = 5 * time.Minute
+	now := time.Now()
+	presentStartedAt := func(started time.Time) *cmacme.Challenge {
+		ts := metav1.NewTime(started)
+		return &cmacme.Challenge{Status: cmacme.ChallengeStatus{PresentStartedAt: &ts}}
+	}
+
+	tests := map[string]struct {
+		challenge *cmacme.Challenge
+		timedOut  bool
+		remaining time.Duration
+	}{
+		"should return the full timeout if the challenge has not been presented yet": {
+			challenge: &cmacme.Challenge{},
+			remaining: timeout,
+		},
+		"should return the time remaining since the challenge was first presented": {
+			challenge: presentStartedAt(now.Add(-time.Minute)),
+			remaining: 4 * time.Minute,
+		},
+		"should return a timeout error if the challenge was first presented too long ago": {
+			challenge: presentStartedAt(now.Add(-10 * time.Minute)),
+			timedOut:  true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := &Solver{clock: fakeclock.NewFakeClock(now), timeout: timeout}
+
+			remaining, err := s.remainingTime(tc.challenge)
+			if tc.timedOut {
+				if !IsTimeoutError(err) {
+					t.Errorf("expected a timeout error but got: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if remaining != tc.remaining {
+				t.Errorf("expected remaining time %s but got %s", tc.remaining, remaining)
+			}
+		})
+	}
+}
+
+func TestPresentTimeout(t *testing.T) {
+	now := time.Now()
+	startedAt := metav1.NewTime(now.Add(-10 * time.Minute))
+	f := solverFixture{
+		Challenge: &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+				UID:       "test-uid",
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+					},
+				},
+			},
+			Status: cmacme.ChallengeStatus{PresentStartedAt: &startedAt},
+		},
+		Builder: &test.Builder{Clock: fakeclock.NewFakeClock(now)},
+		PreFn: func(t *testing.T, s *solverFixture) {
+			s.Solver.timeout = 5 * time.Minute
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	err := f.Solver.Present(context.TODO(), nil, f.Challenge)
+	if !IsTimeoutError(err) {
+		t.Fatalf("expected a timeout error but got: %v", err)
+	}
+	pods, err := f.Builder.FakeKubeClient().CoreV1().Pods(defaultTestNamespace).List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("error listing pods: %v", err)
+	}
+	if len(pods.Items) != 0 {
+		t.Errorf("expected no solver pod to be created but got %d", len(pods.Items))
+	}
+}
+
+func TestReachabilityHostOverride(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "example.com" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, "key")
+	}))
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatalf("error parsing test server URL: %v", err)
+	}
+	serverURL.Path = ChallengePath("token")
+
+	if err := testReachability(context.Background(), serverURL, "example.com", "key"); err != nil {
+		t.Errorf("expected reachability test with host override to pass, but got: %v", err)
+	}
+	if err := testReachability(context.Background(), serverURL, "", "key"); err == nil {
+		t.Errorf("expected reachability test without host override to fail, but it passed")
+	}
+}
+
+func TestPresentNamespaceFilter(t *testing.T) {
+	chal := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Token:   "abcd",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+				},
+			},
+		},
+	}
+	setNamespaces := func(allowed, denied []string) func(*testing.T, *solverFixture) {
+		return func(t *testing.T, s *solverFixture) {
+			s.Solver.allowedNamespaces = sets.NewString(allowed...)
+			s.Solver.deniedNamespaces = sets.NewString(denied...)
+		}
+	}
+	setSolverNamespace := func(solverNamespace string, allowed, denied []string) func(*testing.T, *solverFixture) {
+		return func(t *testing.T, s *solverFixture) {
+			setNamespaces(allowed, denied)(t, s)
+			s.Solver.solverNamespace = solverNamespace
+		}
+	}
+	expectPods := func(expected int) func(*testing.T, *solverFixture, ...interface{}) {
+		return func(t *testing.T, s *solverFixture, args ...interface{}) {
+			pods, err := s.Builder.FakeKubeClient().CoreV1().Pods("").List(metav1.ListOptions{})
+			if err != nil {
+				t.Errorf("error listing pods: %v", err)
+				return
+			}
+			if len(pods.Items) != expected {
+				t.Errorf("expected %d solver pods to be created but got %d", expected, len(pods.Items))
+			}
+		}
+	}
+
+	tests := map[string]solverFixture{
+		"should present in any namespace if no namespaces are configured": {
+			Challenge: chal,
+			CheckFn:   expectPods(1),
+		},
+		"should present in an allowed namespace": {
+			Challenge: chal,
+			PreFn:     setNamespaces([]string{defaultTestNamespace}, nil),
+			CheckFn:   expectPods(1),
+		},
+		"should refuse to present in a namespace that is not allowed": {
+			Challenge: chal,
+			PreFn:     setNamespaces([]string{"other"}, nil),
+			CheckFn:   expectPods(0),
+			Err:       true,
+		},
+		"should refuse to present in a denied namespace": {
+			Challenge: chal,
+			PreFn:     setNamespaces(nil, []string{defaultTestNamespace}),
+			CheckFn:   expectPods(0),
+			Err:       true,
+		},
+		"should refuse to present if the solver namespace is not allowed": {
+			Challenge: chal,
+			PreFn:     setSolverNamespace("solvers", []string{defaultTestNamespace}, nil),
+			CheckFn:   expectPods(0),
+			Err:       true,
+		},
+		"should refuse to present if the solver namespace is denied": {
+			Challenge: chal,
+			PreFn:     setSolverNamespace("solvers", nil, []string{"solvers"}),
+			CheckFn:   expectPods(0),
+			Err:       true,
+		},
+		"should present in an allowed solver namespace for a challenge in a denied namespace": {
+			Challenge: chal,
+			PreFn:     setSolverNamespace("solvers", []string{"solvers"}, []string{defaultTestNamespace}),
+			CheckFn:   expectPods(1),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			err := test.Solver.Present(context.TODO(), nil, test.Challenge)
+			if err != nil && !test.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && test.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+			test.Finish(t, err)
+		})
+	}
+}
+
+func TestPresentSolverNamespace(t *testing.T) {
+	const solverNamespace = "solver-ns"
+	chal := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+			UID:       "test-uid",
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Token:   "abcd",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+				},
+			},
+		},
+	}
+	f := solverFixture{
+		Challenge: chal,
+		PreFn: func(t *testing.T, s *solverFixture) {
+			s.Solver.solverNamespace = solverNamespace
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	if err := f.Solver.Present(context.TODO(), nil, chal); err != nil {
+		t.Fatalf("unexpected error presenting challenge: %v", err)
+	}
+	f.Builder.Sync()
+
+	cl := f.Builder.FakeKubeClient()
+	pods, err := cl.CoreV1().Pods(solverNamespace).List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("error listing pods: %v", err)
+	}
+	svcs, err := cl.CoreV1().Services(solverNamespace).List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("error listing services: %v", err)
+	}
+	ings, err := cl.ExtensionsV1beta1().Ingresses(solverNamespace).List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("error listing ingresses: %v", err)
+	}
+	if len(pods.Items) != 1 || len(svcs.Items) != 1 || len(ings.Items) != 1 {
+		t.Fatalf("expected a pod, service and ingress in the solver namespace but got %d, %d and %d",
+			len(pods.Items), len(svcs.Items), len(ings.Items))
+	}
+	for _, obj := range []metav1.Object{&pods.Items[0], &svcs.Items[0], &ings.Items[0]} {
+		if len(obj.GetOwnerReferences()) != 0 {
+			t.Errorf("expected %s to have no owner references but got %v", obj.GetName(), obj.GetOwnerReferences())
+		}
+		if uid := obj.GetLabels()[challengeUIDLabelKey]; uid != "test-uid" {
+			t.Errorf("expected %s to be labelled with the challenge UID but got %q", obj.GetName(), uid)
+		}
+	}
+
+	// a challenge with the same name in another namespace must not match
+	other := chal.DeepCopy()
+	other.Namespace = "other-ns"
+	other.UID = "other-uid"
+	if found, err := f.Solver.getIngressesForChallenge(context.TODO(), other); err != nil || len(found) != 0 {
+		t.Errorf("expected no ingresses for a different challenge but got %d (err: %v)", len(found), err)
+	}
+
+	if err := f.Solver.CleanUp(context.TODO(), nil, chal); err != nil {
+		t.Fatalf("unexpected error cleaning up challenge: %v", err)
+	}
+	f.Builder.Sync()
+	pods, _ = cl.CoreV1().Pods(solverNamespace).List(metav1.ListOptions{})
+	svcs, _ = cl.CoreV1().Services(solverNamespace).List(metav1.ListOptions{})
+	ings, _ = cl.ExtensionsV1beta1().Ingresses(solverNamespace).List(metav1.ListOptions{})
+	if len(pods.Items) != 0 || len(svcs.Items) != 0 || len(ings.Items) != 0 {
+		t.Errorf("expected solver resources to be cleaned up but got %d pods, %d services and %d ingresses",
+			len(pods.Items), len(svcs.Items), len(ings.Items))
+	}
+}
+
+func TestPresentInlineKeyAuth(t *testing.T) {
+	const snippetAnnotation = "nginx.ingress.kubernetes.io/configuration-snippet"
+	nginx := "nginx"
+	traefik := "traefik"
+	tests := map[string]struct {
+		class        *string
+		key          string
+		expectInline bool
+	}{
+		"ingress with a class configured for inline key authorizations serves the key inline": {
+			class:        &nginx,
+			expectInline: true,
+		},
+		"key that is not a well formed key authorization falls back to a solver pod": {
+			class: &nginx,
+			key:   "abcd.key\";\nreturn 200 $http_authorization;\n#",
+		},
+		"ingress without a class falls back to a solver pod": {},
+		"ingress with a class not configured for inline key authorizations falls back to a solver pod": {
+			class: &traefik,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			key := test.key
+			if key == "" {
+				key = "abcd.key"
+			}
+			chal := &cmacme.Challenge{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testchal",
+					Namespace: defaultTestNamespace,
+					UID:       "test-uid",
+				},
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Token:   "abcd",
+					Key:     key,
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{Class: test.class},
+						},
+					},
+				},
+			}
+			f := solverFixture{
+				Challenge: chal,
+				PreFn: func(t *testing.T, s *solverFixture) {
+					s.Solver.inlineKeyAuthClasses = sets.NewString("nginx")
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			if err := f.Solver.Present(context.TODO(), nil, chal); err != nil {
+				t.Fatalf("unexpected error presenting challenge: %v", err)
+			}
+
+			cl := f.Builder.FakeKubeClient()
+			pods, err := cl.CoreV1().Pods(defaultTestNamespace).List(metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("error listing pods: %v", err)
+			}
+			svcs, err := cl.CoreV1().Services(defaultTestNamespace).List(metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("error listing services: %v", err)
+			}
+			ings, err := cl.ExtensionsV1beta1().Ingresses(defaultTestNamespace).List(metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("error listing ingresses: %v", err)
+			}
+			if len(ings.Items) != 1 {
+				t.Fatalf("expected one ingress but got %d", len(ings.Items))
+			}
+			snippet, hasSnippet := ings.Items[0].Annotations[snippetAnnotation]
+
+			if !test.expectInline {
+				if len(pods.Items) != 1 || len(svcs.Items) != 1 {
+					t.Errorf("expected a solver pod and service but got %d and %d", len(pods.Items), len(svcs.Items))
+				}
+				if hasSnippet {
+					t.Errorf("expected ingress to have no %q annotation but got %q", snippetAnnotation, snippet)
+				}
+				return
+			}
+			if len(pods.Items) != 0 || len(svcs.Items) != 0 {
+				t.Errorf("expected no solver pod or service but got %d and %d", len(pods.Items), len(svcs.Items))
+			}
+			if !strings.Contains(snippet, `return 200 "abcd.key";`) {
+				t.Errorf("expected ingress %q annotation to return the key authorization but got %q", snippetAnnotation, snippet)
+			}
+			if svcName := ingressServiceName(&ings.Items[0], chal.Spec.DNSName); svcName != inlineKeyAuthServiceName {
+				t.Errorf("expected ingress to route to %q but got %q", inlineKeyAuthServiceName, svcName)
+			}
+		})
+	}
+}
+
+func TestCleanUpGracePeriod(t *testing.T) {
+	chal := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+			UID:       "test-uid",
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Token:   "abcd",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+				},
+			},
+		},
+	}
+	clk := fakeclock.NewFakeClock(time.Now())
+	f := solverFixture{
+		Challenge: chal,
+		Builder:   &test.Builder{Clock: clk},
+		PreFn: func(t *testing.T, s *solverFixture) {
+			s.Solver.cleanupGrace = time.Minute
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	if err := f.Solver.Present(context.TODO(), nil, chal); err != nil {
+		t.Fatalf("unexpected error presenting challenge: %v", err)
+	}
+	f.Builder.Sync()
+
+	valid := chal.DeepCopy()
+	valid.Status.State = cmacme.Valid
+	valid.Status.ValidatedAt = &metav1.Time{Time: clk.Now()}
+	expectDeferred := func(expected time.Duration) {
+		t.Helper()
+		err := f.Solver.CleanUp(context.TODO(), nil, valid)
+		deferred, ok := err.(*CleanUpDeferredError)
+		if !ok {
+			t.Fatalf("expected clean up to be deferred but got: %v", err)
+		}
+		if deferred.RetryAfter != expected {
+			t.Errorf("expected clean up to be deferred for %s but got %s", expected, deferred.RetryAfter)
+		}
+	}
+	expectDeferred(time.Minute)
+	clk.Step(40 * time.Second)
+	expectDeferred(20 * time.Second)
+
+	cl := f.Builder.FakeKubeClient()
+	pods, _ := cl.CoreV1().Pods(defaultTestNamespace).List(metav1.ListOptions{})
+	if len(pods.Items) != 1 {
+		t.Errorf("expected the solver pod to be retained during the grace period but got %d pods", len(pods.Items))
+	}
+
+	// challenges that are not valid, are being deleted or have no recorded
+	// validation time are never retained
+	deleting := valid.DeepCopy()
+	deleting.DeletionTimestamp = &metav1.Time{Time: clk.Now()}
+	invalid := chal.DeepCopy()
+	invalid.Status.State = cmacme.Invalid
+	unrecorded := valid.DeepCopy()
+	unrecorded.Status.ValidatedAt = nil
+	for _, ch := range []*cmacme.Challenge{deleting, invalid, unrecorded} {
+		if d := f.Solver.cleanupDeferral(ch); d != 0 {
+			t.Errorf("expected clean up not to be deferred for challenge in state %q but got %s", ch.Status.State, d)
+		}
+	}
+
+	clk.Step(20 * time.Second)
+	if err := f.Solver.CleanUp(context.TODO(), nil, valid); err != nil {
+		t.Fatalf("unexpected error cleaning up challenge: %v", err)
+	}
+	f.Builder.Sync()
+	pods, _ = cl.CoreV1().Pods(defaultTestNamespace).List(metav1.ListOptions{})
+	if len(pods.Items) != 0 {
+		t.Errorf("expected the solver pod to be cleaned up after the grace period but got %d pods", len(pods.Items))
+	}
+}
+
+func TestCleanUpOrder(t *testing.T) {
+	chal := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+			UID:       "test-uid",
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Token:   "abcd",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+				},
+			},
+		},
+	}
+	tests := map[string]struct {
+		order             []string
+		failService       bool
+		expectDeletes     []string
+		expectErr         bool
+		expectPodRetained bool
+	}{
+		"default order removes the ingress before its backend": {
+			expectDeletes: []string{"ingresses", "services", "pods"},
+		},
+		"custom order is followed": {
+			order:         []string{CleanupStagePod, CleanupStageService, CleanupStageIngress},
+			expectDeletes: []string{"pods", "services", "ingresses"},
+		},
+		"later stages are not started if a stage fails": {
+			failService:       true,
+			expectDeletes:     []string{"ingresses", "services"},
+			expectErr:         true,
+			expectPodRetained: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := solverFixture{
+				Challenge: chal,
+				PreFn: func(t *testing.T, s *solverFixture) {
+					s.Solver.cleanupOrder = test.order
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			if err := f.Solver.Present(context.TODO(), nil, chal); err != nil {
+				t.Fatalf("unexpected error presenting challenge: %v", err)
+			}
+			f.Builder.Sync()
+
+			cl := f.Builder.FakeKubeClient()
+			if test.failService {
+				cl.PrependReactor("delete", "services", func(action coretesting.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.New("simulated error")
+				})
+			}
+			cl.ClearActions()
+			err := f.Solver.CleanUp(context.TODO(), nil, chal)
+			if err != nil != test.expectErr {
+				t.Fatalf("expected error %t but got: %v", test.expectErr, err)
+			}
+
+			var deletes []string
+			for _, action := range cl.Actions() {
+				if verb := action.GetVerb(); verb != "delete" && verb != "delete-collection" {
+					continue
+				}
+				resource := action.GetResource().Resource
+				if len(deletes) == 0 || deletes[len(deletes)-1] != resource {
+					deletes = append(deletes, resource)
+				}
+			}
+			if !reflect.DeepEqual(deletes, test.expectDeletes) {
+				t.Errorf("expected resources to be deleted in order %v but got %v", test.expectDeletes, deletes)
+			}
+			if test.expectPodRetained {
+				pods, _ := cl.CoreV1().Pods(defaultTestNamespace).List(metav1.ListOptions{})
+				if len(pods.Items) != 1 {
+					t.Errorf("expected the solver pod to be retained but got %d pods", len(pods.Items))
+				}
+			}
+		})
+	}
+}
+
+func TestValidateCleanupOrder(t *testing.T) {
+	tests := map[string]struct {
+		order     []string
+		expectErr bool
+	}{
+		"default order is valid": {
+			order: DefaultCleanupOrder,
+		},
+		"any permutation is valid": {
+			order: []string{CleanupStagePod, CleanupStageIngress, CleanupStageService},
+		},
+		"missing stage is invalid": {
+			order:     []string{CleanupStageIngress, CleanupStageService},
+			expectErr: true,
+		},
+		"duplicate stage is invalid": {
+			order:     []string{CleanupStageIngress, CleanupStageIngress, CleanupStagePod},
+			expectErr: true,
+		},
+		"unknown stage is invalid": {
+			order:     []string{CleanupStageIngress, CleanupStageService, "secret"},
+			expectErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := ValidateCleanupOrder(test.order)
+			if err != nil != test.expectErr {
+				t.Errorf("expected error %t but got: %v", test.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestFailureReasons(t *testing.T) {
+	newChallenge := func(ingressCfg *cmacme.ACMEChallengeSolverHTTP01Ingress) *cmacme.Challenge {
+		return &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: ingressCfg,
+					},
+				},
+			},
+		}
+	}
+	wildcard := newChallenge(&cmacme.ACMEChallengeSolverHTTP01Ingress{})
+	wildcard.Spec.Wildcard = true
+	denied := &extv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "denied",
+			Namespace:   defaultTestNamespace,
+			Annotations: map[string]string{cmacme.IngressDisablePathInjectionAnnotationKey: "true"},
+		},
+	}
+
+	tests := map[string]struct {
+		challenge *cmacme.Challenge
+		preFn     func(*testing.T, *solverFixture)
+		expected  FailureReason
+	}{
+		"wildcard domains are not supported": {
+			challenge: wildcard,
+			expected:  FailureReasonWildcardNotSupported,
+		},
+		"named existing ingress does not exist": {
+			challenge: newChallenge(&cmacme.ACMEChallengeSolverHTTP01Ingress{Name: "missing"}),
+			expected:  FailureReasonIngressNotFound,
+		},
+		"existing ingress denies path injection": {
+			challenge: newChallenge(&cmacme.ACMEChallengeSolverHTTP01Ingress{Name: denied.Name}),
+			preFn: func(t *testing.T, s *solverFixture) {
+				if _, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Create(denied); err != nil {
+					t.Fatalf("error creating ingress: %v", err)
+				}
+			},
+			expected: FailureReasonPathInjectionDenied,
+		},
+		"solver ingress cannot be created": {
+			challenge: newChallenge(&cmacme.ACMEChallengeSolverHTTP01Ingress{}),
+			preFn: func(t *testing.T, s *solverFixture) {
+				s.Builder.FakeKubeClient().PrependReactor("create", "ingresses", func(action coretesting.Action) (bool, runtime.Object, error) {
+					return true, nil, errors.New("simulated error")
+				})
+			},
+			expected: FailureReasonIngressCreateFailed,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := solverFixture{Challenge: test.challenge, PreFn: test.preFn}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			err := f.Solver.Present(context.TODO(), nil, test.challenge)
+			if err == nil {
+				t.Fatalf("expected error presenting challenge")
+			}
+			if reason := FailureReasonFor(err); reason != test.expected {
+				t.Errorf("expected failure reason %q but got %q for error: %v", test.expected, reason, err)
+			}
+		})
+	}
+
+	t.Run("domain is not reachable", func(t *testing.T) {
+		s := Solver{
+			testReachability: func(context.Context, *url.URL, string, string) error {
+				return fmt.Errorf("failed")
+			},
+			requiredPasses:    1,
+			selfCheckTimeout:  HTTP01Timeout,
+			selfCheckInterval: defaultSelfCheckInterval,
+			clock:             fakeclock.NewFakeClock(time.Now()),
+			metrics:           metrics.Default,
+		}
+		err := s.Check(context.Background(), nil, &cmacme.Challenge{})
+		if reason := FailureReasonFor(err); reason != FailureReasonDomainNotReachable {
+			t.Errorf("expected failure reason %q but got %q for error: %v", FailureReasonDomainNotReachable, reason, err)
+		}
+	})
+
+	t.Run("errors without a reason", func(t *testing.T) {
+		if reason := FailureReasonFor(errors.New("some error")); reason != "" {
+			t.Errorf("expected no failure reason but got %q", reason)
+		}
+	})
+}
+
+func TestReachabilityAllAddresses(t *testing.T) {
+	// listen on all addresses so that the server can be reached on any
+	// loopback address
+	listener, err := net.Listen("tcp", "0.0.0.0:0")
+	if err != nil {
+		t.Fatalf("error creating test listener: %v", err)
+	}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// simulate broken routing on one of the addresses
+		if strings.HasPrefix(r.Context().Value(http.LocalAddrContextKey).(net.Addr).String(), "127.0.0.3:") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, "key")
+	}))
+	server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	defer server.Close()
+
+	_, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		t.Fatalf("error parsing test listener address: %v", err)
+	}
+	challengeURL := &url.URL{
+		Scheme: "http",
+		Host:   net.JoinHostPort("example.com", port),
+		Path:   ChallengePath("token"),
+	}
+
+	ipv4Only := func(ip net.IP) bool { return ip.To4() != nil }
+	tests := map[string]struct {
+		addrs         []string
+		hasRouteTo    func(net.IP) bool
+		expectedError string
+	}{
+		"should pass if the key is served on every address": {
+			addrs: []string{"127.0.0.1", "127.0.0.2"},
+		},
+		"should fail and report the address if the key is not served on one address": {
+			addrs:         []string{"127.0.0.1", "127.0.0.3"},
+			expectedError: "self check against address 127.0.0.3 failed: wrong status code '404', expected '200'",
+		},
+		"should skip the IPv6 addresses of a dual-stack domain from an IPv4 only controller": {
+			addrs:      []string{"127.0.0.1", "2001:db8::1"},
+			hasRouteTo: ipv4Only,
+		},
+		"should still fail if the key is not served on a routable address of a dual-stack domain": {
+			addrs:         []string{"127.0.0.3", "2001:db8::1"},
+			hasRouteTo:    ipv4Only,
+			expectedError: "self check against address 127.0.0.3 failed: wrong status code '404', expected '200'",
+		},
+		"should fail if there is no route to any address": {
+			addrs:         []string{"2001:db8::1", "2001:db8::2"},
+			hasRouteTo:    ipv4Only,
+			expectedError: "no route to any of the addresses of 'example.com'",
+		},
+	}
+	defaultHasRouteTo := hasRouteTo
+	defer func() {
+		lookupIPAddr = net.DefaultResolver.LookupIPAddr
+		hasRouteTo = defaultHasRouteTo
+	}()
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			hasRouteTo = defaultHasRouteTo
+			if test.hasRouteTo != nil {
+				hasRouteTo = test.hasRouteTo
+			}
+			lookupIPAddr = func(_ context.Context, host string) ([]net.IPAddr, error) {
+				if host != "example.com" {
+					t.Errorf("expected addresses of %q to be resolved but got %q", "example.com", host)
+				}
+				var addrs []net.IPAddr
+				for _, a := range test.addrs {
+					addrs = append(addrs, net.IPAddr{IP: net.ParseIP(a)})
+				}
+				return addrs, nil
+			}
+
+			err := testReachability(context.Background(), challengeURL, "", "key")
+			if test.expectedError == "" && err != nil {
+				t.Errorf("expected reachability test to pass, but got: %v", err)
+			}
+			if test.expectedError != "" && (err == nil || err.Error() != test.expectedError) {
+				t.Errorf("expected error %q but got: %v", test.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestPresentLongDomain(t *testing.T) {
+	// 70 characters, longer than the 63 allowed in a label value
+	domain := strings.Repeat("a", 58) + ".example.com"
+	f := solverFixture{
+		Challenge: &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: domain,
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+					},
+				},
+			},
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	for key, value := range podLabels(f.Challenge) {
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			t.Errorf("invalid value %q for label %q: %v", value, key, errs)
+		}
+	}
+
+	if err := f.Solver.Present(context.TODO(), nil, f.Challenge); err != nil {
+		t.Fatalf("unexpected error presenting challenge: %v", err)
+	}
+	f.Builder.Sync()
+
+	pods, err := f.Solver.getPodsForChallenge(context.TODO(), f.Challenge)
+	if err != nil {
+		t.Fatalf("error getting pods: %v", err)
+	}
+	services, err := f.Solver.getServicesForChallenge(context.TODO(), f.Challenge)
+	if err != nil {
+		t.Fatalf("error getting services: %v", err)
+	}
+	ingresses, err := f.Solver.getIngressesForChallenge(context.TODO(), f.Challenge)
+	if err != nil {
+		t.Fatalf("error getting ingresses: %v", err)
+	}
+	if len(pods) != 1 || len(services) != 1 || len(ingresses) != 1 {
+		t.Fatalf("expected to find 1 pod, service and ingress by label but got %d, %d and %d", len(pods), len(services), len(ingresses))
+	}
+	for _, obj := range []metav1.Object{pods[0], services[0], ingresses[0]} {
+		if got := obj.GetAnnotations()[domainAnnotationKey]; got != domain {
+			t.Errorf("expected %s to record domain %q in an annotation but got %q", obj.GetName(), domain, got)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"fmt"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	logf "github.com/jetstack/cert-manager/pkg/logs"
+)
+
+// There is no typed client for the Gateway API available, so HTTPRoute
+// resources are managed as unstructured objects using the dynamic client.
+var (
+	httpRouteGVR = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "httproutes"}
+	httpRouteGVK = schema.GroupVersionKind{Group: "gateway.networking.k8s.io", Version: "v1", Kind: "HTTPRoute"}
+)
+
+// gatewayCfgForChallenge returns the Gateway API HTTPRoute solver
+// configuration for the given challenge, or nil if the challenge is not
+// configured to be solved using an HTTPRoute.
+func gatewayCfgForChallenge(ch *cmacme.Challenge) *cmacme.ACMEChallengeSolverHTTP01GatewayHTTPRoute {
+	if ch.Spec.Solver == nil || ch.Spec.Solver.HTTP01 == nil {
+		return nil
+	}
+	return ch.Spec.Solver.HTTP01.GatewayHTTPRoute
+}
+
+// ensureHTTPRoute will ensure the HTTPRoute required to solve the given
+// challenge exists and routes the challenge path to the named service.
+func (s *Solver) ensureHTTPRoute(ctx context.Context, ch *cmacme.Challenge, svcName string) (*unstructured.Unstructured, error) {
+	log := logf.FromContext(ctx).WithName("ensureHTTPRoute")
+
+	log.V(logf.DebugLevel).Info("checking for existing HTTP01 solver HTTPRoutes")
+	existingRoutes, err := s.getHTTPRoutesForChallenge(ctx, ch)
+	if err != nil {
+		return nil, err
+	}
+	if len(existingRoutes) > 1 {
+		log.Info("multiple challenge solver HTTPRoutes found for challenge. cleaning up all existing HTTPRoutes.")
+		if err := s.cleanupHTTPRoutes(ctx, ch); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("multiple existing challenge solver HTTPRoutes found and cleaned up. retrying challenge sync")
+	}
+
+	expected, err := s.buildHTTPRoute(ch, svcName)
+	if err != nil {
+		return nil, err
+	}
+	if len(existingRoutes) == 1 {
+		route := existingRoutes[0]
+		if httpRouteSpecMatches(route, expected) {
+			logf.WithRelatedResource(log, route).Info("found one existing HTTP01 solver HTTPRoute")
+			return route, nil
+		}
+		logf.WithRelatedResource(log, route).Info("updating modified HTTP01 solver HTTPRoute")
+		route = route.DeepCopy()
+		spec, ok := route.Object["spec"].(map[string]interface{})
+		if !ok {
+			spec = make(map[string]interface{})
+			route.Object["spec"] = spec
+		}
+		expectedSpec := expected.Object["spec"].(map[string]interface{})
+		for _, field := range httpRouteSpecFields {
+			spec[field] = expectedSpec[field]
+		}
+		return s.DynamicClient.Resource(httpRouteGVR).Namespace(route.GetNamespace()).Update(route, metav1.UpdateOptions{})
+	}
+
+	log.Info("creating HTTP01 challenge solver HTTPRoute")
+	var created *unstructured.Unstructured
+	err = s.retryCreate(ctx, func() error {
+		var err error
+		created, err = s.DynamicClient.Resource(httpRouteGVR).Namespace(expected.GetNamespace()).Create(expected, metav1.CreateOptions{})
+		return err
+	}, func() (bool, error) {
+		existing, err := s.getHTTPRoutesForChallenge(ctx, ch)
+		if err != nil || len(existing) == 0 {
+			return false, err
+		}
+		created = existing[0]
+		return true, nil
+	})
+	return created, err
+}
+
+// httpRouteSpecFields are the fields of the HTTPRoute spec set by the solver.
+// Other fields are left to their server defaults, and are neither compared
+// nor overwritten when an existing HTTPRoute is updated.
+var httpRouteSpecFields = []string{"parentRefs", "hostnames", "rules"}
+
+// httpRouteSpecMatches returns true if the fields of the spec of the existing
+// HTTPRoute that are set by the solver match those of the expected HTTPRoute.
+// The apiserver sets defaults within these fields too, such as the group and
+// kind of parent and backend references, so values only present in the
+// existing HTTPRoute are ignored.
+func httpRouteSpecMatches(existing, expected *unstructured.Unstructured) bool {
+	existingSpec, _ := existing.Object["spec"].(map[string]interface{})
+	expectedSpec, _ := expected.Object["spec"].(map[string]interface{})
+	for _, field := range httpRouteSpecFields {
+		if !unstructuredContains(existingSpec[field], expectedSpec[field]) {
+			return false
+		}
+	}
+	return true
+}
+
+// unstructuredContains returns true if the unstructured value obj contains
+// every value set in expected. Lists must be of the same length, with each
+// element containing the corresponding expected element.
+func unstructuredContains(obj, expected interface{}) bool {
+	switch expected := expected.(type) {
+	case map[string]interface{}:
+		m, ok := obj.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, v := range expected {
+			if !unstructuredContains(m[k], v) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		if obj == nil && len(expected) == 0 {
+			return true
+		}
+		l, ok := obj.([]interface{})
+		if !ok || len(l) != len(expected) {
+			return false
+		}
+		for i := range expected {
+			if !unstructuredContains(l[i], expected[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return apiequality.Semantic.DeepEqual(obj, expected)
+	}
+}
+
+// getHTTPRoutesForChallenge returns a list of HTTPRoutes that were created
+// to solve the given challenge
+func (s *Solver) getHTTPRoutesForChallenge(ctx context.Context, ch *cmacme.Challenge) ([]*unstructured.Unstructured, error) {
+	log := logf.FromContext(ctx)
+
+	if s.DynamicClient == nil {
+		return nil, fmt.Errorf("no dynamic client configured to manage HTTPRoute resources")
+	}
+	var relevantRoutes []*unstructured.Unstructured
+	for _, selector := range solverSelectors(ch) {
+		routeList, err := s.DynamicClient.Resource(httpRouteGVR).Namespace(s.resourceNamespace(ch)).List(metav1.ListOptions{
+			LabelSelector: selector.String(),
+		})
+		if err != nil {
+			return nil, err
+		}
+		for i := range routeList.Items {
+			route := &routeList.Items[i]
+			if !s.isResourceOwner(ch, route) {
+				logf.WithRelatedResource(log, route).Info("found existing solver HTTPRoute for this challenge resource, however " +
+					"it does not have an appropriate OwnerReference referencing this challenge. Skipping it altogether.")
+				continue
+			}
+			relevantRoutes = append(relevantRoutes, route)
+		}
+	}
+
+	return relevantRoutes, nil
+}
+
+// buildHTTPRoute will build the HTTPRoute that attaches to the configured
+// Gateways and routes the challenge path for the challenged domain to the
+// named solver service. It will not create it in the API server.
+func (s *Solver) buildHTTPRoute(ch *cmacme.Challenge, svcName string) (*unstructured.Unstructured, error) {
+	gatewayCfg := gatewayCfgForChallenge(ch)
+	if gatewayCfg == nil {
+		return nil, fmt.Errorf("no HTTP01 Gateway API HTTPRoute configuration found on challenge")
+	}
+
+	routeLabels := make(map[string]string)
+	for k, v := range gatewayCfg.Labels {
+		routeLabels[k] = v
+	}
+	// the labels used to look up solver resources always take precedence
+	for k, v := range podLabels(ch) {
+		routeLabels[k] = v
+	}
+
+	var parentRefs []interface{}
+	for _, ref := range gatewayCfg.ParentRefs {
+		parentRef := map[string]interface{}{
+			"name": ref.Name,
+		}
+		if ref.Namespace != "" {
+			parentRef["namespace"] = ref.Namespace
+		}
+		if ref.SectionName != "" {
+			parentRef["sectionName"] = ref.SectionName
+		}
+		parentRefs = append(parentRefs, parentRef)
+	}
+
+	// backendRefs can only reference a service port by number, so a named
+	// service port is referenced by the number it is exposed on
+	port := s.servicePort()
+	if port.Type == intstr.String {
+		port = intstr.FromInt(acmeSolverListenPort)
+	}
+
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(httpRouteGVK)
+	route.SetGenerateName(s.namePrefix)
+	route.SetLabels(routeLabels)
+	s.setResourceOwner(ch, route)
+	route.Object["spec"] = map[string]interface{}{
+		"parentRefs": parentRefs,
+		"hostnames":  []interface{}{normalizeHost(ch.Spec.DNSName)},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"matches": []interface{}{
+					map[string]interface{}{
+						"path": map[string]interface{}{
+							"type":  "Exact",
+							"value": s.challengePath(ch),
+						},
+					},
+				},
+				"backendRefs": []interface{}{
+					map[string]interface{}{
+						"name": svcName,
+						"port": int64(port.IntValue()),
+					},
+				},
+			},
+		},
+	}
+	return route, nil
+}
+
+// cleanupHTTPRoutes will delete the HTTPRoutes created to solve the given
+// challenge.
+func (s *Solver) cleanupHTTPRoutes(ctx context.Context, ch *cmacme.Challenge) error {
+	log := logf.FromContext(ctx, "cleanupHTTPRoutes")
+
+	routes, err := s.getHTTPRoutesForChallenge(ctx, ch)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, route := range routes {
+		log := logf.WithRelatedResource(log, route).V(logf.DebugLevel)
+		log.Info("deleting HTTPRoute resource")
+
+		err := s.DynamicClient.Resource(httpRouteGVR).Namespace(route.GetNamespace()).Delete(route.GetName(), nil)
+		if k8sErrors.IsNotFound(err) {
+			log.Info("HTTPRoute resource has already been deleted")
+			continue
+		}
+		if err != nil {
+			log.Info("failed to delete HTTPRoute resource", "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		log.Info("successfully deleted HTTPRoute resource")
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/diff"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+)
+
+func TestGatewayHTTPRoute(t *testing.T) {
+	chal := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "Example.com",
+			Token:   "abcd",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					GatewayHTTPRoute: &cmacme.ACMEChallengeSolverHTTP01GatewayHTTPRoute{
+						ParentRefs: []cmacme.ACMEChallengeSolverHTTP01GatewayParentRef{
+							{Name: "gateway", Namespace: "gateway-ns", SectionName: "http"},
+						},
+						Labels: map[string]string{"acme": "true"},
+					},
+				},
+			},
+		},
+	}
+	listRoutes := func(t *testing.T, s *solverFixture) []unstructured.Unstructured {
+		routes, err := s.Builder.FakeDynamicClient().Resource(httpRouteGVR).Namespace(defaultTestNamespace).List(metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("error listing HTTPRoutes: %v", err)
+		}
+		return routes.Items
+	}
+
+	f := solverFixture{Challenge: chal}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	if err := f.Solver.Present(context.TODO(), nil, chal); err != nil {
+		t.Fatalf("unexpected error presenting challenge: %v", err)
+	}
+	f.Builder.Sync()
+	// presenting again must not create another HTTPRoute
+	if err := f.Solver.Present(context.TODO(), nil, chal); err != nil {
+		t.Fatalf("unexpected error presenting challenge: %v", err)
+	}
+
+	routes := listRoutes(t, &f)
+	if len(routes) != 1 {
+		t.Fatalf("expected 1 HTTPRoute to be created but got %d", len(routes))
+	}
+	svcs, err := f.Solver.getServicesForChallenge(context.TODO(), chal)
+	if err != nil || len(svcs) != 1 {
+		t.Fatalf("expected one solver service but got %d: %v", len(svcs), err)
+	}
+	route := routes[0]
+	if route.GetLabels()["acme"] != "true" {
+		t.Errorf("expected custom labels to be added to the HTTPRoute but got %v", route.GetLabels())
+	}
+	expectedSpec := map[string]interface{}{
+		"parentRefs": []interface{}{
+			map[string]interface{}{"name": "gateway", "namespace": "gateway-ns", "sectionName": "http"},
+		},
+		"hostnames": []interface{}{"example.com"},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"matches": []interface{}{
+					map[string]interface{}{
+						"path": map[string]interface{}{"type": "Exact", "value": "/.well-known/acme-challenge/abcd"},
+					},
+				},
+				"backendRefs": []interface{}{
+					map[string]interface{}{"name": svcs[0].Name, "port": int64(acmeSolverListenPort)},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(expectedSpec, route.Object["spec"]) {
+		t.Errorf("unexpected HTTPRoute spec: %v", diff.ObjectDiff(expectedSpec, route.Object["spec"]))
+	}
+
+	// a modified HTTPRoute is repaired when the challenge is presented
+	route.Object["spec"] = map[string]interface{}{"hostnames": []interface{}{"other.com"}}
+	if _, err := f.Builder.FakeDynamicClient().Resource(httpRouteGVR).Namespace(defaultTestNamespace).Update(&route, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error modifying HTTPRoute: %v", err)
+	}
+	if err := f.Solver.Present(context.TODO(), nil, chal); err != nil {
+		t.Fatalf("unexpected error presenting challenge: %v", err)
+	}
+	routes = listRoutes(t, &f)
+	if len(routes) != 1 || !reflect.DeepEqual(expectedSpec, routes[0].Object["spec"]) {
+		t.Errorf("expected the modified HTTPRoute to be repaired")
+	}
+
+	// fields defaulted by the apiserver are not considered a modification,
+	// and are retained
+	route = routes[0]
+	defaultedSpec := map[string]interface{}{
+		"parentRefs": []interface{}{
+			map[string]interface{}{"group": "gateway.networking.k8s.io", "kind": "Gateway", "name": "gateway", "namespace": "gateway-ns", "sectionName": "http"},
+		},
+		"hostnames": []interface{}{"example.com"},
+		"rules": []interface{}{
+			map[string]interface{}{
+				"matches": []interface{}{
+					map[string]interface{}{
+						"path": map[string]interface{}{"type": "Exact", "value": "/.well-known/acme-challenge/abcd"},
+					},
+				},
+				"backendRefs": []interface{}{
+					map[string]interface{}{"group": "", "kind": "Service", "name": svcs[0].Name, "port": int64(acmeSolverListenPort), "weight": int64(1)},
+				},
+			},
+		},
+		"useDefaultGateways": "None",
+	}
+	route.Object["spec"] = defaultedSpec
+	if _, err := f.Builder.FakeDynamicClient().Resource(httpRouteGVR).Namespace(defaultTestNamespace).Update(&route, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("error defaulting HTTPRoute: %v", err)
+	}
+	if err := f.Solver.Present(context.TODO(), nil, chal); err != nil {
+		t.Fatalf("unexpected error presenting challenge: %v", err)
+	}
+	routes = listRoutes(t, &f)
+	if len(routes) != 1 || !reflect.DeepEqual(defaultedSpec, routes[0].Object["spec"]) {
+		t.Errorf("expected the server defaulted HTTPRoute to be left unchanged: %v", diff.ObjectDiff(defaultedSpec, routes[0].Object["spec"]))
+	}
+
+	if err := f.Solver.CleanUp(context.TODO(), nil, chal); err != nil {
+		t.Fatalf("unexpected error cleaning up challenge: %v", err)
+	}
+	if routes := listRoutes(t, &f); len(routes) != 0 {
+		t.Errorf("expected HTTPRoutes to be cleaned up but %d remain", len(routes))
+	}
+}
+
+func TestBuildHTTPRouteBackendPort(t *testing.T) {
+	chal := &cmacme.Challenge{
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Token:   "abcd",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					GatewayHTTPRoute: &cmacme.ACMEChallengeSolverHTTP01GatewayHTTPRoute{},
+				},
+			},
+		},
+	}
+	tests := map[string]struct {
+		solver       *Solver
+		expectedPort int64
+	}{
+		"should reference the solver listen port by default": {
+			solver:       &Solver{},
+			expectedPort: acmeSolverListenPort,
+		},
+		"should reference a named service port by number": {
+			solver:       &Solver{servicePortName: "http"},
+			expectedPort: acmeSolverListenPort,
+		},
+		"should reference port 80 if the solver exposes services on port 80": {
+			solver:       &Solver{servicePort80: true},
+			expectedPort: port80ServicePort,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			route, err := test.solver.buildHTTPRoute(chal, "fakeservice")
+			if err != nil {
+				t.Fatalf("unexpected error building HTTPRoute: %v", err)
+			}
+			rules, _, _ := unstructured.NestedSlice(route.Object, "spec", "rules")
+			backendRef := rules[0].(map[string]interface{})["backendRefs"].([]interface{})[0].(map[string]interface{})
+			if backendRef["port"] != test.expectedPort {
+				t.Errorf("expected backendRef port %d but got %v", test.expectedPort, backendRef["port"])
+			}
+		})
+	}
+}
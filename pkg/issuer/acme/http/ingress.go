@@ -18,259 +18,1644 @@ package http
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash/adler32"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
 	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
 	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha2"
 	"github.com/jetstack/cert-manager/pkg/issuer/acme/http/solver"
 	logf "github.com/jetstack/cert-manager/pkg/logs"
+	"github.com/jetstack/cert-manager/pkg/metrics"
 )
 
+// ingressDeletePollInterval is how often the lister cache is checked when
+// waiting for deleted ingresses to be removed.
+const ingressDeletePollInterval = 100 * time.Millisecond
+
+// maxIngressUpdateConflicts is the number of times adding challenge paths to,
+// or removing them from, an existing ingress is retried if it is modified
+// concurrently.
+const maxIngressUpdateConflicts = 5
+
+// ErrIngressStillDeleting is returned when cleaning up a challenge if the
+// deleted solver ingresses have not been removed within the configured
+// timeout. Callers should requeue the challenge and retry the cleanup.
+var ErrIngressStillDeleting = errors.New("deleted HTTP01 solver ingresses have not yet been removed")
+
+// ErrIngressLimitReached is returned when presenting a challenge if the
+// namespace already contains the maximum number of solver ingresses. Callers
+// should requeue the challenge and retry once earlier challenges have been
+// cleaned up.
+var ErrIngressLimitReached = errors.New("maximum number of HTTP01 solver ingresses in namespace reached")
+
+// IsIngressLimitReached returns true if the given error, or any of the errors
+// aggregated in it, is ErrIngressLimitReached.
+func IsIngressLimitReached(err error) bool {
+	if agg, ok := err.(utilerrors.Aggregate); ok {
+		for _, err := range agg.Errors() {
+			if IsIngressLimitReached(err) {
+				return true
+			}
+		}
+		return false
+	}
+	return err == ErrIngressLimitReached
+}
+
 // getIngressesForChallenge returns a list of Ingresses that were created to solve
 // http challenges for the given domain
 func (s *Solver) getIngressesForChallenge(ctx context.Context, ch *cmacme.Challenge) ([]*extv1beta1.Ingress, error) {
 	log := logf.FromContext(ctx)
 
-	podLabels := podLabels(ch)
-	selector := labels.NewSelector()
-	for key, val := range podLabels {
-		req, err := labels.NewRequirement(key, selection.Equals, []string{val})
+	log.V(logf.DebugLevel).Info("checking for existing HTTP01 solver ingresses")
+	var ingressList []*extv1beta1.Ingress
+	for _, selector := range solverSelectors(ch) {
+		ingresses, err := s.ingressLister.Ingresses(s.resourceNamespace(ch)).List(selector)
 		if err != nil {
 			return nil, err
 		}
-		selector = selector.Add(*req)
+		ingressList = append(ingressList, ingresses...)
 	}
 
-	log.V(logf.DebugLevel).Info("checking for existing HTTP01 solver ingresses")
-	ingressList, err := s.ingressLister.Ingresses(ch.Namespace).List(selector)
-	if err != nil {
-		return nil, err
+	return s.filterIngressesForChallenge(ctx, ch, ingressList), nil
+}
+
+// listIngressesForChallenge is like getIngressesForChallenge, but queries the
+// apiserver directly rather than the lister's cache, so that ingresses that
+// have only just been created are also returned.
+func (s *Solver) listIngressesForChallenge(ctx context.Context, ch *cmacme.Challenge) ([]*extv1beta1.Ingress, error) {
+	var ingresses []*extv1beta1.Ingress
+	for _, selector := range solverSelectors(ch) {
+		ingressList, err := s.ingressClient.Ingresses(ctx, s.resourceNamespace(ch)).List(metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return nil, err
+		}
+		for i := range ingressList.Items {
+			ingresses = append(ingresses, &ingressList.Items[i])
+		}
 	}
+	return s.filterIngressesForChallenge(ctx, ch, ingresses), nil
+}
+
+// filterIngressesForChallenge returns the ingresses in the given list that
+// were created by the solver for the given challenge.
+func (s *Solver) filterIngressesForChallenge(ctx context.Context, ch *cmacme.Challenge, ingressList []*extv1beta1.Ingress) []*extv1beta1.Ingress {
+	log := logf.FromContext(ctx)
 
+	orderHash := orderHashForChallenge(ch)
 	var relevantIngresses []*extv1beta1.Ingress
 	for _, ingress := range ingressList {
-		if !metav1.IsControlledBy(ingress, ch) {
+		if !s.isResourceOwner(ch, ingress) {
 			logf.WithRelatedResource(log, ingress).Info("found existing solver ingress for this challenge resource, however " +
 				"it does not have an appropriate OwnerReference referencing this challenge. Skipping it altogether.")
 			continue
 		}
-		relevantIngresses = append(relevantIngresses, ingress)
+		// ingresses created before the order hash label was introduced, or
+		// for challenges without a known order, do not have the label and
+		// are always considered to belong to the challenge
+		if h, ok := ingress.Labels[orderHashLabelKey]; ok && orderHash != "" && h != orderHash {
+			logf.WithRelatedResource(log, ingress).Info("found existing solver ingress for this challenge resource, however " +
+				"it was created for a different ACME order. Skipping it altogether.")
+			continue
+		}
+		relevantIngresses = append(relevantIngresses, ingress)
+	}
+
+	return relevantIngresses
+}
+
+// orderHashForChallenge returns a hash of the URL of the ACME order the given
+// challenge was created for, for use as a label value, or an empty string if
+// the order URL is not known.
+func orderHashForChallenge(ch *cmacme.Challenge) string {
+	orderURL := ch.Annotations[cmacme.ACMEOrderURLAnnotationKey]
+	if orderURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("%d", adler32.Checksum([]byte(orderURL)))
+}
+
+// existingIngressName returns the name of the existing ingress that solver
+// paths should be added to, either as named in the solver config or by
+// selecting it using the configured ingressSelector. If neither is set and
+// reuse of wildcard ingresses is enabled, an ingress with a wildcard rule
+// matching the challenged domain is used. Otherwise an empty string is
+// returned and a solver ingress should be created instead.
+// Once an ingress has been selected for the challenge and recorded in its
+// status, that ingress is always used, so that the paths are cleaned up from
+// the ingress they were added to even if the selection has changed since.
+func (s *Solver) existingIngressName(ch *cmacme.Challenge, httpDomainCfg *cmacme.ACMEChallengeSolverHTTP01Ingress) (string, error) {
+	if httpDomainCfg.Name == "" && ch.Status.SolverIngress != "" {
+		return ch.Status.SolverIngress, nil
+	}
+	if httpDomainCfg.Name == "" && httpDomainCfg.IngressSelector == nil && s.reuseWildcards {
+		return s.wildcardIngressName(ch, httpDomainCfg)
+	}
+	if httpDomainCfg.Name != "" || httpDomainCfg.IngressSelector == nil {
+		return httpDomainCfg.Name, nil
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(httpDomainCfg.IngressSelector)
+	if err != nil {
+		return "", err
+	}
+	ingresses, err := s.ingressLister.Ingresses(s.resourceNamespace(ch)).List(selector)
+	if err != nil {
+		return "", err
+	}
+	switch len(ingresses) {
+	case 0:
+		return "", k8sErrors.NewNotFound(extv1beta1.Resource("ingresses"), selector.String())
+	case 1:
+		return ingresses[0].Name, nil
+	default:
+		return "", fmt.Errorf("expected exactly one ingress to match selector %q but found %d", selector.String(), len(ingresses))
+	}
+}
+
+// wildcardIngressName returns the name of an ingress that is not managed by
+// the solver and has a wildcard rule routing the challenged domain, or an
+// empty string if there is none. If a class is configured on the solver, only
+// ingresses with that class are considered. If several ingresses match, the
+// first by name is used so that cleanup finds the same ingress.
+func (s *Solver) wildcardIngressName(ch *cmacme.Challenge, httpDomainCfg *cmacme.ACMEChallengeSolverHTTP01Ingress) (string, error) {
+	ingresses, err := s.ingressLister.Ingresses(s.resourceNamespace(ch)).List(labels.Everything())
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, ing := range ingresses {
+		if IsSolverResource(ing) {
+			continue
+		}
+		if httpDomainCfg.Class != nil && ing.Annotations[cmapi.IngressClassAnnotationKey] != *httpDomainCfg.Class {
+			continue
+		}
+		for _, rule := range ing.Spec.Rules {
+			if wildcardHostMatches(rule.Host, ch.Spec.DNSName) {
+				names = append(names, ing.Name)
+				break
+			}
+		}
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+	sort.Strings(names)
+	return names[0], nil
+}
+
+// wildcardHostMatches returns true if the given ingress rule host is a
+// wildcard that matches the given domain. As with ingress rules, the wildcard
+// only matches a single DNS label.
+func wildcardHostMatches(host, domain string) bool {
+	host = normalizeHost(host)
+	if !strings.HasPrefix(host, "*.") {
+		return false
+	}
+	label := strings.TrimSuffix(normalizeHost(domain), host[1:])
+	if len(label) == len(normalizeHost(domain)) {
+		return false
+	}
+	return label != "" && label != "*" && !strings.Contains(label, ".")
+}
+
+// getIngress returns the named ingress, reading it from the informer cache
+// and falling back to a live Get if it is not found there, e.g. because it
+// was created moments ago. Ingresses read from the cache may lag behind the
+// apiserver by the informer's watch latency, so an Update based on the
+// returned ingress may fail with a conflict and must be retried on a later
+// sync. The returned ingress must be copied before it is modified.
+func (s *Solver) getIngress(ctx context.Context, namespace, name string) (*extv1beta1.Ingress, error) {
+	ing, err := s.ingressLister.Ingresses(namespace).Get(name)
+	if k8sErrors.IsNotFound(err) {
+		return s.ingressClient.Ingresses(ctx, namespace).Get(name, metav1.GetOptions{})
+	}
+	return ing, err
+}
+
+// FindToken returns the solver ingress that routes requests for the HTTP01
+// challenge with the given token, along with the host of the rule the
+// token's path was found on, so that ACME server logs can be correlated with
+// the state of the cluster. Only ingresses labelled as solver ingresses are
+// searched, and paths must be exactly the challenge path for the token in
+// one of the forms this solver adds it in. The given namespace is that of the
+// challenge, and is overridden by the solver namespace if configured.
+func (s *Solver) FindToken(namespace, token string) (*extv1beta1.Ingress, string, error) {
+	if token == "" {
+		return nil, "", fmt.Errorf("token must not be empty")
+	}
+	if s.solverNamespace != "" {
+		namespace = s.solverNamespace
+	}
+	ingresses, err := s.listSolverIngresses(namespace)
+	if err != nil {
+		return nil, "", err
+	}
+	// search in a stable order, in case the token is found on more than one
+	// ingress
+	sort.Slice(ingresses, func(i, j int) bool {
+		return ingresses[i].Name < ingresses[j].Name
+	})
+	for _, ing := range ingresses {
+		for _, rule := range ing.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+			ch := &cmacme.Challenge{Spec: cmacme.ChallengeSpec{DNSName: rule.Host, Token: token}}
+			forms := sets.NewString(s.challengePathForms(challengePathFor(ch, s.pathFn))...)
+			for _, path := range rule.HTTP.Paths {
+				if forms.Has(path.Path) {
+					return ing, rule.Host, nil
+				}
+			}
+		}
+	}
+	return nil, "", fmt.Errorf("no solver ingress in namespace %q routes HTTP01 challenge token %q", namespace, token)
+}
+
+// IsChallengePresented returns true if the given ingress routes every path
+// needed to solve the given challenge to the named solver service, on a rule
+// whose host matches the challenged domain. Paths are compared in the form the
+// solver adds them in, including the service port, so that watchers can check
+// an ingress without re-implementing the solver's matching rules. It does not
+// make any API calls.
+func (s *Solver) IsChallengePresented(ch *cmacme.Challenge, svcName string, ing *extv1beta1.Ingress) bool {
+	httpDomainCfg, err := httpDomainCfgForChallenge(ch)
+	if err != nil || ing == nil {
+		return false
+	}
+	for _, want := range s.ingressPaths(ch, svcName, httpDomainCfg.ExtraPathPrefixes) {
+		if !ingressRoutesDomainPath(ing, ch.Spec.DNSName, want) {
+			return false
+		}
+	}
+	return true
+}
+
+// ingressRoutesDomainPath returns true if a rule of the given ingress whose
+// host matches the domain, either exactly or as a wildcard, has the given
+// path routing to the same service and port.
+func ingressRoutesDomainPath(ing *extv1beta1.Ingress, domain string, want extv1beta1.HTTPIngressPath) bool {
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		if normalizeHost(rule.Host) != normalizeHost(domain) && !wildcardHostMatches(rule.Host, domain) {
+			continue
+		}
+		for _, p := range rule.HTTP.Paths {
+			if p.Path == want.Path && p.Backend.ServiceName == want.Backend.ServiceName &&
+				p.Backend.ServicePort == want.Backend.ServicePort {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ActiveChallenge describes a solver ingress created for an in-flight HTTP01
+// challenge.
+type ActiveChallenge struct {
+	// Namespace is the namespace of the solver ingress.
+	Namespace string `json:"namespace"`
+	// Ingress is the name of the solver ingress.
+	Ingress string `json:"ingress"`
+	// Challenge is the name of the challenge that owns the solver ingress.
+	Challenge string `json:"challenge,omitempty"`
+	// Domains are the hosts of the ingress rules.
+	Domains []string `json:"domains"`
+	// Addresses are the load balancer addresses the ingress has been
+	// programmed with. It is empty until the ingress controller has
+	// processed the ingress.
+	Addresses []string `json:"addresses,omitempty"`
+}
+
+// ActiveChallenges returns the solver ingresses of all in-flight HTTP01
+// challenges, ordered by namespace and name. Existing ingresses that solver
+// paths have been added to are not included, as they carry no solver labels.
+func (s *Solver) ActiveChallenges() ([]ActiveChallenge, error) {
+	ingresses, err := s.listSolverIngresses(s.solverNamespace)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(ingresses, func(i, j int) bool {
+		if ingresses[i].Namespace != ingresses[j].Namespace {
+			return ingresses[i].Namespace < ingresses[j].Namespace
+		}
+		return ingresses[i].Name < ingresses[j].Name
+	})
+
+	active := make([]ActiveChallenge, 0, len(ingresses))
+	for _, ing := range ingresses {
+		// retained ingresses belong to challenges that have been cleaned up
+		if _, ok := ing.Annotations[ingressExpiresAtAnnotationKey]; ok {
+			continue
+		}
+		a := ActiveChallenge{
+			Namespace: ing.Namespace,
+			Ingress:   ing.Name,
+		}
+		if ref := challengeRefOf(ing); ref != nil {
+			a.Challenge = ref.Name
+		}
+		for _, rule := range ing.Spec.Rules {
+			a.Domains = append(a.Domains, rule.Host)
+		}
+		for _, lb := range ing.Status.LoadBalancer.Ingress {
+			if lb.IP != "" {
+				a.Addresses = append(a.Addresses, lb.IP)
+			} else if lb.Hostname != "" {
+				a.Addresses = append(a.Addresses, lb.Hostname)
+			}
+		}
+		active = append(active, a)
+	}
+	return active, nil
+}
+
+// solverIngressAddress returns the load balancer address of the ingress that
+// routes the solver path for the given challenge. An error is returned if the
+// ingress has not yet been programmed with a load balancer address.
+func (s *Solver) solverIngressAddress(ctx context.Context, ch *cmacme.Challenge) (string, error) {
+	ing, err := s.solverIngress(ctx, ch)
+	if err != nil {
+		return "", err
+	}
+
+	addr := ingressAddress(ing)
+	if addr == "" {
+		return "", fmt.Errorf("solver ingress %q has not yet been assigned a load balancer address", ing.Name)
+	}
+	// IPv6 addresses must be bracketed to be used as a URL host
+	if strings.Contains(addr, ":") {
+		return "[" + addr + "]", nil
+	}
+	return addr, nil
+}
+
+// SolverAddress returns the load balancer IP address or hostname assigned by
+// the ingress controller to the ingress routing the given challenge, which is
+// where the ACME server will connect to if the challenged domain resolves
+// correctly. An empty string is returned if the challenge is not solved using
+// an ingress, or the ingress has not yet been assigned an address.
+// Challenges solved using a NodePort service or a Gateway API HTTPRoute are
+// not routed by an ingress controller, so no address is returned for them.
+func (s *Solver) SolverAddress(ctx context.Context, ch *cmacme.Challenge) (string, error) {
+	if nodePortCfgForChallenge(ch) != nil || gatewayCfgForChallenge(ch) != nil {
+		return "", nil
+	}
+	if _, err := httpDomainCfgForChallenge(ch); err != nil {
+		return "", nil
+	}
+	ing, err := s.solverIngress(ctx, ch)
+	if err != nil {
+		return "", err
+	}
+	return ingressAddress(ing), nil
+}
+
+// solverIngress returns the ingress that routes the solver path for the given
+// challenge, read from the lister. This is either the existing ingress named
+// on the solver or the ingress created by the solver.
+func (s *Solver) solverIngress(ctx context.Context, ch *cmacme.Challenge) (*extv1beta1.Ingress, error) {
+	httpDomainCfg, err := httpDomainCfgForChallenge(ch)
+	if err != nil {
+		return nil, err
+	}
+
+	existingIngressName, err := s.existingIngressName(ch, httpDomainCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if existingIngressName != "" {
+		return s.getIngress(ctx, s.resourceNamespace(ch), existingIngressName)
+	}
+	ingresses, err := s.getIngressesForChallenge(ctx, ch)
+	if err != nil {
+		return nil, err
+	}
+	if len(ingresses) != 1 {
+		return nil, fmt.Errorf("expected exactly one solver ingress for challenge but found %d", len(ingresses))
+	}
+	return ingresses[0], nil
+}
+
+// ingressAddress returns the first load balancer IP address or hostname in the
+// status of the given ingress, or an empty string if it has not been assigned
+// one.
+func ingressAddress(ing *extv1beta1.Ingress) string {
+	for _, lb := range ing.Status.LoadBalancer.Ingress {
+		if lb.IP != "" {
+			return lb.IP
+		}
+		if lb.Hostname != "" {
+			return lb.Hostname
+		}
+	}
+	return ""
+}
+
+// IngressAction describes the change ensureIngress made in order to route a
+// challenge to its solver service.
+type IngressAction string
+
+const (
+	// IngressActionNone means the ingress already routed the challenge and
+	// was not modified.
+	IngressActionNone IngressAction = "None"
+	// IngressActionCreated means a new solver ingress was created.
+	IngressActionCreated IngressAction = "Created"
+	// IngressActionPathAdded means the challenge paths were added to an
+	// existing ingress.
+	IngressActionPathAdded IngressAction = "PathAdded"
+	// IngressActionRepaired means the rules of an existing solver ingress
+	// had been modified and were repaired.
+	IngressActionRepaired IngressAction = "Repaired"
+	// IngressActionReused means an existing solver ingress for another
+	// challenge was updated to solve the challenge instead.
+	IngressActionReused IngressAction = "Reused"
+)
+
+// ensureIngress will ensure the ingress required to solve this challenge
+// exists, or if an existing ingress is specified on the secret will ensure
+// that the ingress has an appropriate challenge path configured. The returned
+// IngressAction describes whether the ingress was created or modified.
+func (s *Solver) ensureIngress(ctx context.Context, ch *cmacme.Challenge, svcName string) (*extv1beta1.Ingress, IngressAction, error) {
+	log := logf.FromContext(ctx).WithName("ensureIngress")
+	if err := validateIngressHost(ch.Spec.DNSName); err != nil {
+		return nil, IngressActionNone, err
+	}
+	httpDomainCfg, err := httpDomainCfgForChallenge(ch)
+	if err != nil {
+		return nil, IngressActionNone, err
+	}
+	existingIngressName, err := s.existingIngressName(ch, httpDomainCfg)
+	if k8sErrors.IsNotFound(err) {
+		return nil, IngressActionNone, solverError(FailureReasonIngressNotFound, err)
+	}
+	if err != nil {
+		return nil, IngressActionNone, err
+	}
+	if existingIngressName != "" {
+		log := logf.WithRelatedResourceName(log, existingIngressName, s.resourceNamespace(ch), "Ingress")
+		ctx := logf.NewContext(ctx, log)
+		log.Info("adding solver paths to existing ingress resource")
+		if httpDomainCfg.Name == "" {
+			ch.Status.SolverIngress = existingIngressName
+		}
+		ing, action, err := s.addChallengePathToIngress(ctx, ch, svcName)
+		if action != IngressActionNone || err != nil {
+			s.metrics.ObserveHTTP01SolverOperation(ch, metrics.HTTP01SolverOperationAddPath, err)
+		}
+		return ing, action, err
+	}
+	existingIngresses, err := s.getIngressesForChallenge(ctx, ch)
+	if err != nil {
+		return nil, IngressActionNone, err
+	}
+	if len(existingIngresses) == 1 && ingressServiceName(existingIngresses[0], ch.Spec.DNSName) != svcName && ingressServiceName(existingIngresses[0], ch.Spec.DNSName) != "" {
+		log.Info("service name changed. cleaning up all existing ingresses.")
+		err := s.cleanupIngresses(ctx, ch)
+		if err != nil {
+			return nil, IngressActionNone, err
+		}
+		return nil, IngressActionNone, fmt.Errorf("service name changed, existing challenge solver ingresses found and cleaned up. retrying challenge sync")
+	}
+	if len(existingIngresses) == 1 {
+		logf.WithRelatedResource(log, existingIngresses[0]).Info("found one existing HTTP01 solver ingress")
+		return s.reconcileIngress(ctx, ch, existingIngresses[0], svcName)
+	}
+	if len(existingIngresses) > 1 {
+		log.Info("multiple challenge solver ingresses found for challenge. cleaning up all existing ingresses.")
+		err := s.cleanupIngresses(ctx, ch)
+		if err != nil {
+			return nil, IngressActionNone, err
+		}
+		return nil, IngressActionNone, fmt.Errorf("multiple existing challenge solver ingresses found and cleaned up. retrying challenge sync")
+	}
+
+	reusable, err := s.reusableIngress(ch)
+	if err != nil {
+		return nil, IngressActionNone, err
+	}
+	if reusable != nil {
+		logf.WithRelatedResource(log, reusable).Info("reusing existing HTTP01 solver ingress")
+		ing, err := s.adoptIngress(ctx, ch, reusable, svcName)
+		if err != nil {
+			return nil, IngressActionNone, err
+		}
+		return ing, IngressActionReused, nil
+	}
+
+	if s.noNewIngresses {
+		return nil, IngressActionNone, fmt.Errorf("creation of HTTP01 solver ingresses is disabled. An existing ingress must be " +
+			"specified using the 'name' or 'ingressSelector' field of the HTTP01 ingress solver configuration")
+	}
+
+	if err := s.checkIngressLimit(s.resourceNamespace(ch)); err != nil {
+		log.Info("waiting for existing solver ingresses to be cleaned up before creating ingress", "limit", s.maxIngresses)
+		return nil, IngressActionNone, err
+	}
+
+	log.Info("creating HTTP01 challenge solver ingress")
+	ing, err := s.createIngress(ctx, ch, svcName)
+	s.metrics.ObserveHTTP01SolverOperation(ch, metrics.HTTP01SolverOperationCreateIngress, err)
+	if err != nil {
+		return nil, IngressActionNone, solverError(FailureReasonIngressCreateFailed, err)
+	}
+	return ing, IngressActionCreated, nil
+}
+
+// recordIngressAction emits an event on the challenge when presenting it
+// created a solver ingress or added paths to an existing ingress. Repairs
+// are recorded by reconcileIngress.
+func (s *Solver) recordIngressAction(ch *cmacme.Challenge, ing *extv1beta1.Ingress, action IngressAction) {
+	switch action {
+	case IngressActionCreated:
+		s.Recorder.Eventf(ch, corev1.EventTypeNormal, "CreatedIngress", "Created HTTP01 solver ingress %q", ing.Name)
+	case IngressActionPathAdded:
+		s.Recorder.Eventf(ch, corev1.EventTypeNormal, "AddedIngressPath", "Added HTTP01 challenge paths to ingress %q", ing.Name)
+	case IngressActionReused:
+		s.Recorder.Eventf(ch, corev1.EventTypeNormal, "ReusedIngress", "Reused HTTP01 solver ingress %q", ing.Name)
+	}
+}
+
+// ReuseIngressFunc returns true if the given solver ingress, created for
+// another challenge, may be reused to solve the given challenge instead of
+// creating a new solver ingress, e.g. to avoid creating an ingress for each
+// attempt at an order for the same domain.
+type ReuseIngressFunc func(ch *cmacme.Challenge, ing *extv1beta1.Ingress) bool
+
+// SetReuseIngressFunc sets the predicate used to decide whether solver
+// ingresses created for other challenges may be reused. If fn is nil, which
+// is the default, ingresses are never reused and the solver ingresses of
+// other challenges are not considered at all.
+func (s *Solver) SetReuseIngressFunc(fn ReuseIngressFunc) {
+	s.reuseIngressFn = fn
+}
+
+// ReuseSameDomainIngress is the ReuseIngressFunc used when solver ingress
+// reuse is enabled. It allows a solver ingress to be reused if it was
+// created for a challenge for the same domain with the same ingress class.
+func ReuseSameDomainIngress(ch *cmacme.Challenge, ing *extv1beta1.Ingress) bool {
+	if ing.Annotations[domainAnnotationKey] != ch.Spec.DNSName || ingressServiceName(ing, ch.Spec.DNSName) == "" {
+		return false
+	}
+	httpDomainCfg, err := httpDomainCfgForChallenge(ch)
+	if err != nil {
+		return false
+	}
+	class, ok := ing.Annotations[cmapi.IngressClassAnnotationKey]
+	if httpDomainCfg.Class == nil {
+		return !ok
+	}
+	return ok && class == *httpDomainCfg.Class
+}
+
+// reusableIngress returns an existing solver ingress created for another
+// challenge that the solver's reuse predicate allows to be used to solve the
+// given challenge, or nil if there is none. Ingresses that have expired are
+// never reused. Ingresses are considered in order of name, so that the same
+// ingress is chosen on every attempt. Ingresses are not reused if solver
+// resources are created outside the namespace of the challenge, as their
+// ownership is then recorded by a label that can only name one challenge.
+func (s *Solver) reusableIngress(ch *cmacme.Challenge) (*extv1beta1.Ingress, error) {
+	if s.reuseIngressFn == nil || s.resourceNamespace(ch) != ch.Namespace {
+		return nil, nil
+	}
+	ingresses, err := s.listSolverIngresses(s.resourceNamespace(ch))
+	if err != nil {
+		return nil, err
+	}
+	sorted := make([]*extv1beta1.Ingress, len(ingresses))
+	copy(sorted, ingresses)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	for _, ing := range sorted {
+		if _, ok := ing.Annotations[ingressExpiresAtAnnotationKey]; ok {
+			continue
+		}
+		if s.isResourceOwner(ch, ing) {
+			continue
+		}
+		if s.reuseIngressFn(ch, ing) {
+			return ing, nil
+		}
+	}
+	return nil, nil
+}
+
+// adoptIngress updates the given solver ingress, created for another
+// challenge, so that it also solves the given challenge. Its labels are
+// replaced with those of a solver ingress built for the challenge, so that it
+// is found for the challenge, but it is shared rather than taken over: an
+// owner reference to the challenge is added alongside the existing ones, and
+// the challenge paths are added alongside the paths of the other challenges.
+// It is only deleted once no other challenge owns it, see cleanupIngresses.
+func (s *Solver) adoptIngress(ctx context.Context, ch *cmacme.Challenge, ing *extv1beta1.Ingress, svcName string) (*extv1beta1.Ingress, error) {
+	expected, err := s.buildIngressResource(ch, svcName)
+	if err != nil {
+		return nil, err
+	}
+	ing = ing.DeepCopy()
+	ing.Labels = expected.Labels
+	if ing.Annotations == nil {
+		ing.Annotations = make(map[string]string)
+	}
+	for k, v := range expected.Annotations {
+		ing.Annotations[k] = v
+	}
+	ing.OwnerReferences = addChallengeOwnerRef(ing.OwnerReferences, s.challengeOwnerRef(ch))
+	ing.Spec.Rules = mergeIngressRules(expected.Spec.Rules, ing.Spec.Rules)
+	return s.ingressClient.Ingresses(ctx, ing.Namespace).Update(ing)
+}
+
+// addChallengeOwnerRef appends the given challenge owner reference to refs.
+// A resource may only have one controller reference, so it is added as a
+// plain owner reference if refs already contains one.
+func addChallengeOwnerRef(refs []metav1.OwnerReference, ref metav1.OwnerReference) []metav1.OwnerReference {
+	out := make([]metav1.OwnerReference, 0, len(refs)+1)
+	for _, r := range refs {
+		if r.UID == ref.UID {
+			continue
+		}
+		if r.Controller != nil && *r.Controller {
+			ref.Controller = nil
+		}
+		out = append(out, r)
+	}
+	return append(out, ref)
+}
+
+// mergeIngressRules returns the existing rules with the paths of the given
+// rules added before the existing paths of the rule for the same host, so
+// that the paths of the existing rules are retained. Existing paths that are
+// replaced by the given paths are removed.
+func mergeIngressRules(rules, existing []extv1beta1.IngressRule) []extv1beta1.IngressRule {
+	merged := make([]extv1beta1.IngressRule, 0, len(existing)+len(rules))
+	for _, rule := range existing {
+		merged = append(merged, *rule.DeepCopy())
+	}
+	for _, rule := range rules {
+		i := 0
+		for ; i < len(merged); i++ {
+			if normalizeHost(merged[i].Host) == normalizeHost(rule.Host) && merged[i].HTTP != nil {
+				break
+			}
+		}
+		if i == len(merged) || rule.HTTP == nil {
+			merged = append(merged, *rule.DeepCopy())
+			continue
+		}
+		added := sets.NewString()
+		paths := make([]extv1beta1.HTTPIngressPath, 0, len(rule.HTTP.Paths)+len(merged[i].HTTP.Paths))
+		for _, path := range rule.HTTP.Paths {
+			added.Insert(path.Path)
+			paths = append(paths, path)
+		}
+		for _, path := range merged[i].HTTP.Paths {
+			if !added.Has(path.Path) {
+				paths = append(paths, path)
+			}
+		}
+		merged[i].HTTP.Paths = paths
+	}
+	return merged
+}
+
+// checkIngressLimit returns ErrIngressLimitReached if the given namespace
+// already contains the maximum number of solver ingresses. Ingresses retained
+// after their challenge has been cleaned up are not counted, as they only
+// remain until they expire. Ingresses are counted using the lister, so
+// ingresses created moments ago may not yet be included and the limit may
+// briefly be exceeded.
+func (s *Solver) checkIngressLimit(namespace string) error {
+	if s.maxIngresses <= 0 {
+		return nil
+	}
+	ingresses, err := s.listSolverIngresses(namespace)
+	if err != nil {
+		return err
+	}
+	active := 0
+	for _, ing := range ingresses {
+		if _, ok := ing.Annotations[ingressExpiresAtAnnotationKey]; !ok {
+			active++
+		}
+	}
+	if active >= s.maxIngresses {
+		return ErrIngressLimitReached
+	}
+	return nil
+}
+
+// ingressServiceName returns the name of the service that the given solver
+// ingress routes the challenge paths for the given domain to, or an empty
+// string if it has no such paths. Rules added by an ingress template may come
+// before the rule for the challenged domain, so that rule is looked up by
+// host. The challenge paths always come first in that rule.
+func ingressServiceName(ing *extv1beta1.Ingress, domain string) string {
+	domain = normalizeHost(domain)
+	for _, rule := range ing.Spec.Rules {
+		if normalizeHost(rule.Host) != domain || rule.HTTP == nil || len(rule.HTTP.Paths) == 0 {
+			continue
+		}
+		return rule.HTTP.Paths[0].Backend.ServiceName
+	}
+	return ""
+}
+
+// reconcileIngress repairs the rules of an existing solver ingress if they
+// have been modified since it was created, e.g. by a user or another
+// controller, so that they no longer route the challenge paths to the solver.
+func (s *Solver) reconcileIngress(ctx context.Context, ch *cmacme.Challenge, ing *extv1beta1.Ingress, svcName string) (*extv1beta1.Ingress, IngressAction, error) {
+	log := logf.WithRelatedResource(logf.FromContext(ctx), ing)
+
+	expected, err := s.buildIngressResource(ch, svcName)
+	if err != nil {
+		return nil, IngressActionNone, err
+	}
+	// the paths of the other challenges sharing a reused ingress must be
+	// retained when it is repaired
+	expectedRules := expected.Spec.Rules
+	if sharedWithOtherChallenges(ch, ing) {
+		expectedRules = mergeIngressRules(expected.Spec.Rules, ing.Spec.Rules)
+	}
+	if apiequality.Semantic.DeepEqual(ing.Spec.Rules, expectedRules) {
+		return ing, IngressActionNone, nil
+	}
+
+	log.Info("existing HTTP01 solver ingress has been modified, repairing its rules")
+	ing = ing.DeepCopy()
+	ing.Spec.Rules = expectedRules
+	updated, err := s.ingressClient.Ingresses(ctx, ing.Namespace).Update(ing)
+	if err != nil {
+		return nil, IngressActionNone, err
+	}
+	s.Recorder.Eventf(ch, corev1.EventTypeNormal, "RepairedIngress", "Repaired modified rules on HTTP01 solver ingress %q", ing.Name)
+	return updated, IngressActionRepaired, nil
+}
+
+// createIngress will create a challenge solving pod for the given certificate,
+// domain, token and key.
+func (s *Solver) createIngress(ctx context.Context, ch *cmacme.Challenge, svcName string) (*extv1beta1.Ingress, error) {
+	ing, err := s.buildIngressResource(ch, svcName)
+	if err != nil {
+		return nil, err
+	}
+	// an ingress for any other host can never serve the challenge, so fail
+	// early rather than waiting for the self check to time out
+	if err := checkSolverIngressHost(ing, ch.Spec.DNSName, svcName); err != nil {
+		return nil, err
+	}
+	var created *extv1beta1.Ingress
+	err = s.retryCreate(ctx, func() error {
+		var err error
+		created, err = s.ingressClient.Ingresses(ctx, ing.Namespace).Create(ing)
+		return err
+	}, func() (bool, error) {
+		existing, err := s.listIngressesForChallenge(ctx, ch)
+		if err != nil || len(existing) == 0 {
+			return false, err
+		}
+		created = existing[0]
+		return true, nil
+	})
+	if k8sErrors.IsAlreadyExists(err) {
+		// an earlier attempt may have succeeded despite returning an error,
+		// in which case the ingress it created can be used. It is unlikely to
+		// be in the lister's cache yet, so fall back to the apiserver.
+		existing, listErr := s.getIngressesForChallenge(ctx, ch)
+		if listErr == nil && len(existing) == 0 {
+			existing, listErr = s.listIngressesForChallenge(ctx, ch)
+		}
+		if listErr == nil && len(existing) == 1 {
+			logf.WithRelatedResource(logf.FromContext(ctx), existing[0]).Info("solver ingress already exists, using existing ingress")
+			return existing[0], nil
+		}
+	}
+	return created, err
+}
+
+// knownBackendProtocolAnnotations are the annotations that instruct the
+// ingress controllers for well known ingress classes to talk to the solver
+// backend over plain HTTP, as some can be configured to default to HTTPS.
+var knownBackendProtocolAnnotations = map[string]map[string]string{
+	"nginx": {"nginx.ingress.kubernetes.io/backend-protocol": "HTTP"},
+	"alb":   {"alb.ingress.kubernetes.io/backend-protocol": "HTTP"},
+}
+
+// backendProtocolAnnotations returns the annotations to set on a solver
+// ingress with the given class so the solver pod is reached over plain HTTP.
+// Ingresses without a class are assumed to be served by ingress-nginx. These
+// annotations are only set on ingresses created by the solver, as on an
+// existing ingress they would apply to every backend it routes to.
+func backendProtocolAnnotations(class *string) map[string]string {
+	if class == nil {
+		return knownBackendProtocolAnnotations["nginx"]
+	}
+	return knownBackendProtocolAnnotations[*class]
+}
+
+// inlineKeyAuthServiceName is the backend service of solver ingresses that
+// serve the key authorization inline. No such service is created, as requests
+// for the challenge paths are answered by the ingress controller itself.
+const inlineKeyAuthServiceName = "cm-acme-http-solver-inline"
+
+// keyAuthorizationRegexp matches a well formed ACME key authorization, i.e.
+// a token and a key thumbprint, both base64url encoded, joined by a dot.
+var keyAuthorizationRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+$`)
+
+// inlineKeyAuthAnnotations returns the annotations that instruct
+// ingress-nginx to respond to every request routed by a solver ingress with
+// the given key authorization. The key is written into nginx configuration
+// as is, so it must match keyAuthorizationRegexp.
+func inlineKeyAuthAnnotations(key string) map[string]string {
+	return map[string]string{
+		"nginx.ingress.kubernetes.io/configuration-snippet": fmt.Sprintf("default_type text/plain;\nreturn 200 %q;\n", key),
+	}
+}
+
+// servesKeyAuthInline returns true if the key authorization for the given
+// challenge is served by the ingress controller from an annotation on the
+// solver ingress, in which case no solver pod or service is needed. This is
+// only done if the solver creates its own ingress with a class that has
+// explicitly been configured for it, as it relies on snippet annotations
+// that may be disabled. As the key is written into the ingress controller's
+// configuration, it is also only done for well formed key authorizations.
+// Otherwise the pod based solver is used.
+func (s *Solver) servesKeyAuthInline(ch *cmacme.Challenge) bool {
+	if s.inlineKeyAuthClasses.Len() == 0 || existingServiceName(ch) != "" || s.reuseWildcards {
+		return false
+	}
+	if !keyAuthorizationRegexp.MatchString(ch.Spec.Key) {
+		return false
+	}
+	httpDomainCfg, err := httpDomainCfgForChallenge(ch)
+	if err != nil || httpDomainCfg.Name != "" || httpDomainCfg.IngressSelector != nil || httpDomainCfg.SolverDefaultBackend {
+		return false
+	}
+	return httpDomainCfg.Class != nil && s.inlineKeyAuthClasses.Has(*httpDomainCfg.Class)
+}
+
+func (s *Solver) buildIngressResource(ch *cmacme.Challenge, svcName string) (*extv1beta1.Ingress, error) {
+	httpDomainCfg, err := httpDomainCfgForChallenge(ch)
+	if err != nil {
+		return nil, err
+	}
+	var ingClass *string
+	if httpDomainCfg.Class != nil {
+		ingClass = httpDomainCfg.Class
+	}
+
+	podLabels := podLabels(ch)
+	if orderHash := orderHashForChallenge(ch); orderHash != "" {
+		podLabels[orderHashLabelKey] = orderHash
+	}
+	// TODO: add additional annotations to help workaround problematic ingress controller behaviours
+	ingAnnotations := make(map[string]string)
+	ingAnnotations["nginx.ingress.kubernetes.io/whitelist-source-range"] = "0.0.0.0/0,::/0"
+
+	if ingClass != nil {
+		ingAnnotations[cmapi.IngressClassAnnotationKey] = *ingClass
+	}
+	for k, v := range backendProtocolAnnotations(ingClass) {
+		ingAnnotations[k] = v
+	}
+
+	ingPathsToAdd := s.ingressPaths(ch, svcName, httpDomainCfg.ExtraPathPrefixes)
+
+	ing := &extv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      podLabels,
+			Annotations: ingAnnotations,
+		},
+		Spec: extv1beta1.IngressSpec{
+			Rules: []extv1beta1.IngressRule{
+				{
+					Host: normalizeHost(ch.Spec.DNSName),
+					IngressRuleValue: extv1beta1.IngressRuleValue{
+						HTTP: &extv1beta1.HTTPIngressRuleValue{
+							Paths: ingPathsToAdd,
+						},
+					},
+				},
+			},
+		},
+	}
+	if tmpl := httpDomainCfg.IngressTemplate; tmpl != nil {
+		mergeIngressTemplate(ing, tmpl)
+		// the template must never prevent the challenge from being solved
+		for _, path := range ingPathsToAdd {
+			if !ingressRoutesPath(ing, normalizeHost(ch.Spec.DNSName), path) {
+				return nil, fmt.Errorf("ingress template removes challenge path %q from solver ingress", path.Path)
+			}
+		}
+	}
+	// the key authorization must be served regardless of the annotations
+	// set by the template
+	if s.servesKeyAuthInline(ch) {
+		for k, v := range inlineKeyAuthAnnotations(ch.Spec.Key) {
+			ing.Annotations[k] = v
+		}
+	}
+	// the default backend is set after the template has been merged, as the
+	// template's spec is used as the base of the ingress spec
+	if httpDomainCfg.SolverDefaultBackend {
+		ing.Spec.Backend = &extv1beta1.IngressBackend{
+			ServiceName: svcName,
+			ServicePort: s.servicePort(),
+		}
+	}
+	s.setResourceName(ch, ing)
+	s.setResourceOwner(ch, ing)
+	return ing, nil
+}
+
+// mergeIngressTemplate merges the given template into a solver ingress built
+// by buildIngressResource, whose only rule is the one for the challenged
+// domain. The template's spec is used as the base of the ingress spec, with
+// the challenge paths added to the template's rule for the same host, taking
+// precedence over template paths with the same value. Template annotations
+// override the in-built annotations, but in-built labels cannot be
+// overridden as they are used to find the ingress.
+func mergeIngressTemplate(ing *extv1beta1.Ingress, tmpl *cmacme.ACMEChallengeSolverHTTP01IngressTemplate) {
+	for k, v := range tmpl.Labels {
+		if _, ok := ing.Labels[k]; !ok {
+			ing.Labels[k] = v
+		}
+	}
+	for k, v := range tmpl.Annotations {
+		ing.Annotations[k] = v
+	}
+	if tmpl.Spec == nil {
+		return
+	}
+
+	challengeRule := ing.Spec.Rules[0]
+	spec := tmpl.Spec.DeepCopy()
+	for i, rule := range spec.Rules {
+		if normalizeHost(rule.Host) != challengeRule.Host {
+			continue
+		}
+		paths := append([]extv1beta1.HTTPIngressPath{}, challengeRule.HTTP.Paths...)
+		if rule.HTTP != nil {
+			for _, path := range rule.HTTP.Paths {
+				if !hasIngressPath(challengeRule.HTTP.Paths, path.Path) {
+					paths = append(paths, path)
+				}
+			}
+		}
+		spec.Rules[i].Host = challengeRule.Host
+		spec.Rules[i].HTTP = &extv1beta1.HTTPIngressRuleValue{Paths: paths}
+		ing.Spec = *spec
+		return
+	}
+	spec.Rules = append(spec.Rules, challengeRule)
+	ing.Spec = *spec
+}
+
+// hasIngressPath returns true if any of the given paths has the given value.
+func hasIngressPath(paths []extv1beta1.HTTPIngressPath, value string) bool {
+	for _, path := range paths {
+		if path.Path == value {
+			return true
+		}
+	}
+	return false
+}
+
+// ingressRoutesPath returns true if a rule of the given ingress for the given
+// host contains the given path.
+func ingressRoutesPath(ing *extv1beta1.Ingress, host string, path extv1beta1.HTTPIngressPath) bool {
+	for _, rule := range ing.Spec.Rules {
+		if rule.Host != host || rule.HTTP == nil {
+			continue
+		}
+		for _, p := range rule.HTTP.Paths {
+			if apiequality.Semantic.DeepEqual(p, path) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkSolverIngressHost returns an error if any rule of the given ingress
+// that routes to the named solver service is for a host other than the
+// normalized domain. Rules that do not route to the solver may have been
+// added by an ingress template.
+func checkSolverIngressHost(ing *extv1beta1.Ingress, domain, svcName string) error {
+	domain = normalizeHost(domain)
+	for _, rule := range ing.Spec.Rules {
+		if rule.Host != domain && ruleRoutesToService(rule, svcName) {
+			return fmt.Errorf("solver ingress host %q does not match challenged domain %q", rule.Host, domain)
+		}
+	}
+	return nil
+}
+
+// ruleRoutesToService returns true if any path of the given rule routes to
+// the named service.
+func ruleRoutesToService(rule extv1beta1.IngressRule, svcName string) bool {
+	if rule.HTTP == nil {
+		return false
+	}
+	for _, path := range rule.HTTP.Paths {
+		if path.Backend.ServiceName == svcName {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Solver) addChallengePathToIngress(ctx context.Context, ch *cmacme.Challenge, svcName string) (*extv1beta1.Ingress, IngressAction, error) {
+	log := logf.FromContext(ctx)
+
+	httpDomainCfg, err := httpDomainCfgForChallenge(ch)
+	if err != nil {
+		return nil, IngressActionNone, err
+	}
+	ingressName, err := s.existingIngressName(ch, httpDomainCfg)
+	if err != nil {
+		return nil, IngressActionNone, err
+	}
+
+	ing, err := s.getIngress(ctx, s.resourceNamespace(ch), ingressName)
+	if k8sErrors.IsNotFound(err) {
+		return nil, IngressActionNone, solverError(FailureReasonIngressNotFound, err)
+	}
+	if err != nil {
+		return nil, IngressActionNone, err
+	}
+
+	ingPathsToAdd := s.ingressPaths(ch, svcName, httpDomainCfg.ExtraPathPrefixes)
+	// the ingress may be edited by others at the same time. Updates are
+	// made conditional on the resource version the paths were added to, and
+	// on a conflict the paths are added again to the latest version of the
+	// ingress, so that concurrent changes are never overwritten.
+	for attempt := 1; ; attempt++ {
+		if err := s.checkPathInjectionAllowed(ing); err != nil {
+			return nil, IngressActionNone, solverError(FailureReasonPathInjectionDenied, err)
+		}
+		ing = ing.DeepCopy()
+		// ingress resource is already up to date
+		if !s.addIngressPaths(ing, ch, ingPathsToAdd) {
+			return ing, IngressActionNone, nil
+		}
+		updated, action, err := s.updateIngressPaths(ctx, ing, svcName)
+		if !k8sErrors.IsConflict(err) || attempt > maxIngressUpdateConflicts {
+			return updated, action, err
+		}
+		logf.WithRelatedResource(log, ing).V(logf.DebugLevel).Info("ingress was modified while adding challenge paths, retrying with the latest version", "attempt", attempt)
+		ing, err = s.ingressClient.Ingresses(ctx, ing.Namespace).Get(ing.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, IngressActionNone, err
+		}
+	}
+}
+
+// checkPathInjectionAllowed returns an error if challenge paths may not be
+// added to the given existing ingress.
+func (s *Solver) checkPathInjectionAllowed(ing *extv1beta1.Ingress) error {
+	if ing.Annotations[cmacme.IngressDisablePathInjectionAnnotationKey] == "true" {
+		return fmt.Errorf("refusing to add challenge paths to ingress %s/%s as it has the %q annotation set to \"true\"",
+			ing.Namespace, ing.Name, cmacme.IngressDisablePathInjectionAnnotationKey)
+	}
+	if s.requireIngressOptIn && !ingressOptedIn(ing) {
+		return fmt.Errorf("refusing to add challenge paths to ingress %s/%s as it has not opted in. "+
+			"Check that the issuer names the correct ingress and, if so, set the %q annotation on it to \"true\"",
+			ing.Namespace, ing.Name, cmacme.IngressAllowPathInjectionAnnotationKey)
+	}
+	return nil
+}
+
+// addIngressPaths adds the given challenge paths to the rule for the domain
+// of the given challenge on an existing ingress, adding a rule for the domain
+// if there is none, and returns true if the ingress was modified.
+func (s *Solver) addIngressPaths(ing *extv1beta1.Ingress, ch *cmacme.Challenge, ingPathsToAdd []extv1beta1.HTTPIngressPath) bool {
+	// check for an existing Rule for the given domain on the ingress resource.
+	// Hosts are compared in their normalized form, as DNS names are case
+	// insensitive and may be written with a trailing dot.
+	domain := normalizeHost(ch.Spec.DNSName)
+	for i, rule := range ing.Spec.Rules {
+		if normalizeHost(rule.Host) == domain {
+			if rule.HTTP == nil {
+				ing.Spec.Rules[i].HTTP = &extv1beta1.HTTPIngressRuleValue{}
+				rule.HTTP = ing.Spec.Rules[i].HTTP
+			}
+			paths, removed := s.removeStaleChallengePaths(ch, ing.Namespace, rule.HTTP.Paths, ingPathsToAdd)
+			paths, modified := mergeIngressPaths(paths, ingPathsToAdd, s.appendPaths)
+			rule.HTTP.Paths = paths
+			return modified || removed
+		}
+	}
+
+	// if one doesn't exist, create a new IngressRule
+	ing.Spec.Rules = append(ing.Spec.Rules, extv1beta1.IngressRule{
+		Host: domain,
+		IngressRuleValue: extv1beta1.IngressRuleValue{
+			HTTP: &extv1beta1.HTTPIngressRuleValue{
+				Paths: ingPathsToAdd,
+			},
+		},
+	})
+	return true
+}
+
+// ingressOptedIn returns true if the given existing ingress has opted in to
+// having challenge paths added to it, either explicitly or by asking for its
+// own certificate to be solved in place.
+func ingressOptedIn(ing *extv1beta1.Ingress) bool {
+	return ing.Annotations[cmacme.IngressAllowPathInjectionAnnotationKey] == "true" ||
+		ing.Annotations[cmacme.IngressEditInPlaceAnnotationKey] == "true"
+}
+
+// updateIngressPaths updates an existing ingress that challenge paths routing
+// to the named solver service have been added to.
+// The update is made with the resource version the ingress was read at, so
+// the apiserver rejects it with a conflict rather than overwriting changes
+// made since.
+func (s *Solver) updateIngressPaths(ctx context.Context, ing *extv1beta1.Ingress, svcName string) (*extv1beta1.Ingress, IngressAction, error) {
+	if err := s.checkIngressBackendService(ing.Namespace, svcName); err != nil {
+		return nil, IngressActionNone, err
+	}
+	updated, err := s.ingressClient.Ingresses(ctx, ing.Namespace).Update(ing)
+	if err != nil {
+		return nil, IngressActionNone, err
+	}
+	return updated, IngressActionPathAdded, nil
+}
+
+// removeStaleChallengePaths removes challenge paths left behind by earlier
+// challenges from the given paths, such as those for the previous token when
+// an order is retried. A path is stale if it is under one of the challenge
+// path prefixes for the given challenge, is not one of the current
+// challenge's paths and routes to a service that no longer exists. Paths
+// routing to a service that still exists are retained, as they may be serving
+// another challenge for the same host.
+// The returned bool is true if any path was removed.
+func (s *Solver) removeStaleChallengePaths(ch *cmacme.Challenge, namespace string, existing, current []extv1beta1.HTTPIngressPath) ([]extv1beta1.HTTPIngressPath, bool) {
+	prefixes := s.challengePathPrefixes(ch)
+	var paths []extv1beta1.HTTPIngressPath
+	removed := false
+	for _, p := range existing {
+		if !isChallengeIngressPath(p.Path, prefixes) || hasIngressPath(current, p.Path) {
+			paths = append(paths, p)
+			continue
+		}
+		_, err := s.serviceLister.Services(namespace).Get(p.Backend.ServiceName)
+		if !k8sErrors.IsNotFound(err) {
+			paths = append(paths, p)
+			continue
+		}
+		removed = true
+	}
+	return paths, removed
+}
+
+// routesToSolverService returns true if the given ingress backend routes to a
+// service created by the solver, or to the existing service configured for the
+// given challenge. A backend routing to a service that no longer exists is
+// also treated as a solver backend, as solver services may be cleaned up
+// before the paths routing to them.
+func (s *Solver) routesToSolverService(ch *cmacme.Challenge, namespace string, backend extv1beta1.IngressBackend) bool {
+	if name := existingServiceName(ch); name != "" && backend.ServiceName == name {
+		return true
+	}
+	svc, err := s.serviceLister.Services(namespace).Get(backend.ServiceName)
+	if k8sErrors.IsNotFound(err) {
+		return true
+	}
+	if err != nil {
+		return false
+	}
+	return IsSolverResource(svc)
+}
+
+// challengePathPrefixes returns the prefixes of the paths that the keys for
+// challenges for the same domain as the given challenge may be served on: the
+// ACME challenge path prefix, and the prefix of the challenge's own path if it
+// has been customised using a path template or the solver's path function.
+func (s *Solver) challengePathPrefixes(ch *cmacme.Challenge) []string {
+	prefixes := []string{solver.HTTPChallengePath + "/"}
+	path := s.challengePath(ch)
+	if !strings.HasSuffix(path, "/"+ch.Spec.Token) {
+		return prefixes
+	}
+	if prefix := strings.TrimSuffix(path, ch.Spec.Token); prefix != prefixes[0] {
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes
+}
+
+// isChallengeIngressPath returns true if the given ingress path is under one
+// of the given challenge path prefixes, in either its plain or regular
+// expression form. Paths with the configured path suffix are also under the
+// prefix.
+func isChallengeIngressPath(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) || strings.HasPrefix(path, "^"+regexp.QuoteMeta(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeIngressPaths adds the given challenge paths to the existing list of
+// paths on an ingress rule. If a path already exists it is overwritten so that
+// ingress controllers are not confused by duplicates, otherwise it is
+// prepended, so that it takes precedence over catch-all paths with ingress
+// controllers that match paths in order, or appended if appendNew is true.
+// The returned bool is false if existing was already up to date.
+func mergeIngressPaths(existing, toAdd []extv1beta1.HTTPIngressPath, appendNew bool) ([]extv1beta1.HTTPIngressPath, bool) {
+	modified := false
+	var prepend []extv1beta1.HTTPIngressPath
+	for _, ingPathToAdd := range toAdd {
+		found := false
+		for i, p := range existing {
+			if p.Path != ingPathToAdd.Path {
+				continue
+			}
+			found = true
+			if p.Backend.ServiceName != ingPathToAdd.Backend.ServiceName ||
+				p.Backend.ServicePort != ingPathToAdd.Backend.ServicePort {
+				existing[i] = ingPathToAdd
+				modified = true
+			}
+			break
+		}
+		if !found {
+			prepend = append(prepend, ingPathToAdd)
+			modified = true
+		}
 	}
+	if appendNew {
+		return append(existing, prepend...), modified
+	}
+	return append(prepend, existing...), modified
+}
 
-	return relevantIngresses, nil
+// IngressCleanupResult describes the outcome of cleaning up the ingresses
+// created by the solver for a challenge. It does not count existing ingresses
+// that only had challenge paths removed from them.
+type IngressCleanupResult struct {
+	// Deleted is the number of solver ingresses deleted.
+	Deleted int
+	// Remaining is the number of solver ingresses that could not be deleted
+	// and will need to be retried.
+	Remaining int
+	// Expired is the number of solver ingresses marked as expired to be
+	// retained, rather than deleted.
+	Expired int
 }
 
-// ensureIngress will ensure the ingress required to solve this challenge
-// exists, or if an existing ingress is specified on the secret will ensure
-// that the ingress has an appropriate challenge path configured
-func (s *Solver) ensureIngress(ctx context.Context, ch *cmacme.Challenge, svcName string) (ing *extv1beta1.Ingress, err error) {
-	log := logf.FromContext(ctx).WithName("ensureIngress")
-	httpDomainCfg, err := httpDomainCfgForChallenge(ch)
+// cleanupIngresses will remove the rules added by cert-manager to an existing
+// ingress, or delete the ingress if an existing ingress name is not specified
+// on the certificate.
+func (s *Solver) cleanupIngresses(ctx context.Context, ch *cmacme.Challenge) error {
+	_, err := s.CleanupIngresses(ctx, ch)
+	return err
+}
+
+// expireIngresses marks the solver ingresses for the given challenge as
+// expired instead of deleting them, so that they are retained as a record of
+// how the challenge was solved. Their owner references are removed so that
+// they are not garbage collected along with the challenge, and they are
+// deleted by DeleteExpiredIngresses once the retention period has passed.
+func (s *Solver) expireIngresses(ctx context.Context, ch *cmacme.Challenge) (IngressCleanupResult, error) {
+	log := logf.FromContext(ctx, "expireIngresses")
+
+	var result IngressCleanupResult
+	ingresses, err := s.getIngressesForChallenge(ctx, ch)
 	if err != nil {
-		return nil, err
+		return result, err
 	}
-	if httpDomainCfg.Name != "" {
-		log := logf.WithRelatedResourceName(log, httpDomainCfg.Name, ch.Namespace, "Ingress")
-		ctx := logf.NewContext(ctx, log)
-		log.Info("adding solver paths to existing ingress resource")
-		return s.addChallengePathToIngress(ctx, ch, svcName)
-	}
-	existingIngresses, err := s.getIngressesForChallenge(ctx, ch)
+	ingresses, err = s.releaseSharedIngresses(ctx, ch, ingresses)
 	if err != nil {
-		return nil, err
-	}
-	if len(existingIngresses) == 1 && ingressServiceName(existingIngresses[0]) == svcName {
-		logf.WithRelatedResource(log, existingIngresses[0]).Info("found one existing HTTP01 solver ingress")
-		return existingIngresses[0], nil
+		return result, err
 	}
-	if len(existingIngresses) == 1 && ingressServiceName(existingIngresses[0]) != svcName {
-		log.Info("service name changed. cleaning up all existing ingresses.")
-		err := s.cleanupIngresses(ctx, ch)
-		if err != nil {
-			return nil, err
+	expiresAt := s.clock.Now().Add(s.ingressRetention).UTC().Format(time.RFC3339)
+	var errs []error
+	for _, ing := range ingresses {
+		// ingresses created outside of the challenge's namespace are still
+		// found by their label once expired, and keep their original expiry
+		if _, ok := ing.Annotations[ingressExpiresAtAnnotationKey]; ok {
+			continue
+		}
+		log := logf.WithRelatedResource(log, ing).V(logf.DebugLevel)
+
+		ing = ing.DeepCopy()
+		if ing.Annotations == nil {
+			ing.Annotations = make(map[string]string)
+		}
+		ing.Annotations[ingressExpiresAtAnnotationKey] = expiresAt
+		ing.OwnerReferences = nil
+
+		log.Info("marking ingress resource as expired", "expiresAt", expiresAt)
+		_, err := s.ingressClient.Ingresses(ctx, ing.Namespace).Update(ing)
+		if k8sErrors.IsNotFound(err) {
+			log.Info("ingress resource has already been deleted")
+			continue
 		}
-		return nil, fmt.Errorf("service name changed, existing challenge solver ingresses found and cleaned up. retrying challenge sync")
-	}
-	if len(existingIngresses) > 1 {
-		log.Info("multiple challenge solver ingresses found for challenge. cleaning up all existing ingresses.")
-		err := s.cleanupIngresses(ctx, ch)
 		if err != nil {
-			return nil, err
+			log.Info("failed to mark ingress resource as expired", "error", err)
+			errs = append(errs, domainIngressError(ch, ing, "expiring", err))
+			result.Remaining++
+			continue
 		}
-		return nil, fmt.Errorf("multiple existing challenge solver ingresses found and cleaned up. retrying challenge sync")
+		result.Expired++
 	}
-
-	log.Info("creating HTTP01 challenge solver ingress")
-	return s.createIngress(ch, svcName)
+	return result, utilerrors.NewAggregate(errs)
 }
 
-func ingressServiceName(ing *extv1beta1.Ingress) string {
-	return ing.Spec.Rules[0].IngressRuleValue.HTTP.Paths[0].Backend.ServiceName
-}
+// releaseSharedIngresses releases the given challenge's ownership of the
+// solver ingresses it shares with other challenges, after one of them was
+// reused, by removing its owner reference and challenge paths. Shared
+// ingresses are only found by their owner references once they have been
+// adopted by another challenge, so these are also released. The ingresses in
+// the given list that are not shared are returned, to be cleaned up as usual.
+func (s *Solver) releaseSharedIngresses(ctx context.Context, ch *cmacme.Challenge, ingresses []*extv1beta1.Ingress) ([]*extv1beta1.Ingress, error) {
+	log := logf.FromContext(ctx)
 
-// createIngress will create a challenge solving pod for the given certificate,
-// domain, token and key.
-func (s *Solver) createIngress(ch *cmacme.Challenge, svcName string) (*extv1beta1.Ingress, error) {
-	ing, err := buildIngressResource(ch, svcName)
-	if err != nil {
-		return nil, err
+	var unshared []*extv1beta1.Ingress
+	shared := make(map[types.UID]*extv1beta1.Ingress)
+	for _, ing := range ingresses {
+		if sharedWithOtherChallenges(ch, ing) {
+			shared[ing.UID] = ing
+			continue
+		}
+		unshared = append(unshared, ing)
+	}
+	if s.resourceNamespace(ch) == ch.Namespace {
+		all, err := s.listSolverIngresses(ch.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, ing := range all {
+			if s.isResourceOwner(ch, ing) && sharedWithOtherChallenges(ch, ing) {
+				shared[ing.UID] = ing
+			}
+		}
 	}
-	return s.Client.ExtensionsV1beta1().Ingresses(ch.Namespace).Create(ing)
-}
 
-func buildIngressResource(ch *cmacme.Challenge, svcName string) (*extv1beta1.Ingress, error) {
 	httpDomainCfg, err := httpDomainCfgForChallenge(ch)
 	if err != nil {
-		return nil, err
-	}
-	var ingClass *string
-	if httpDomainCfg.Class != nil {
-		ingClass = httpDomainCfg.Class
+		httpDomainCfg = &cmacme.ACMEChallengeSolverHTTP01Ingress{}
 	}
+	var errs []error
+	for _, ing := range shared {
+		log := logf.WithRelatedResource(log, ing)
 
-	podLabels := podLabels(ch)
-	// TODO: add additional annotations to help workaround problematic ingress controller behaviours
-	ingAnnotations := make(map[string]string)
-	ingAnnotations["nginx.ingress.kubernetes.io/whitelist-source-range"] = "0.0.0.0/0,::/0"
+		ing = ing.DeepCopy()
+		var refs []metav1.OwnerReference
+		for _, ref := range ing.OwnerReferences {
+			if ref.UID != ch.UID {
+				refs = append(refs, ref)
+			}
+		}
+		ing.OwnerReferences = refs
+		ing.Spec.Rules = s.removeChallengePaths(log, ch, httpDomainCfg, ing)
 
-	if ingClass != nil {
-		ingAnnotations[cmapi.IngressClassAnnotationKey] = *ingClass
+		log.V(logf.DebugLevel).Info("releasing ingress resource shared with other challenges")
+		_, err := s.ingressClient.Ingresses(ctx, ing.Namespace).Update(ing)
+		if k8sErrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, domainIngressError(ch, ing, "releasing", err))
+		}
 	}
-
-	ingPathToAdd := ingressPath(ch.Spec.Token, svcName)
-
-	return &extv1beta1.Ingress{
-		ObjectMeta: metav1.ObjectMeta{
-			GenerateName:    "cm-acme-http-solver-",
-			Namespace:       ch.Namespace,
-			Labels:          podLabels,
-			Annotations:     ingAnnotations,
-			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(ch, challengeGvk)},
-		},
-		Spec: extv1beta1.IngressSpec{
-			Rules: []extv1beta1.IngressRule{
-				{
-					Host: ch.Spec.DNSName,
-					IngressRuleValue: extv1beta1.IngressRuleValue{
-						HTTP: &extv1beta1.HTTPIngressRuleValue{
-							Paths: []extv1beta1.HTTPIngressPath{ingPathToAdd},
-						},
-					},
-				},
-			},
-		},
-	}, nil
+	return unshared, utilerrors.NewAggregate(errs)
 }
 
-func (s *Solver) addChallengePathToIngress(ctx context.Context, ch *cmacme.Challenge, svcName string) (*extv1beta1.Ingress, error) {
-	httpDomainCfg, err := httpDomainCfgForChallenge(ch)
-	if err != nil {
-		return nil, err
-	}
-	ingressName := httpDomainCfg.Name
+// DeleteExpiredIngresses deletes the solver ingresses that were retained
+// after their challenge was cleaned up and whose retention period has passed.
+func (s *Solver) DeleteExpiredIngresses(ctx context.Context) error {
+	log := logf.FromContext(http01LogCtx(ctx), "deleteExpiredIngresses")
 
-	ing, err := s.ingressLister.Ingresses(ch.Namespace).Get(ingressName)
+	ingresses, err := s.listSolverIngresses(s.solverNamespace)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	now := s.clock.Now()
+	var errs []error
+	for _, ing := range ingresses {
+		value, ok := ing.Annotations[ingressExpiresAtAnnotationKey]
+		if !ok {
+			continue
+		}
+		log := logf.WithRelatedResource(log, ing)
+		expiresAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			log.Error(err, "invalid expiry time on retained ingress resource, skipping it")
+			continue
+		}
+		if now.Before(expiresAt) {
+			continue
+		}
 
-	ingPathToAdd := ingressPath(ch.Spec.Token, svcName)
-	// check for an existing Rule for the given domain on the ingress resource
-	for _, rule := range ing.Spec.Rules {
-		if rule.Host == ch.Spec.DNSName {
-			if rule.HTTP == nil {
-				rule.HTTP = &extv1beta1.HTTPIngressRuleValue{}
-			}
-			for i, p := range rule.HTTP.Paths {
-				// if an existing path exists on this rule for the challenge path,
-				// we overwrite it else we'll confuse ingress controllers
-				if p.Path == ingPathToAdd.Path {
-					// ingress resource is already up to date
-					if p.Backend.ServiceName == ingPathToAdd.Backend.ServiceName &&
-						p.Backend.ServicePort == ingPathToAdd.Backend.ServicePort {
-						return ing, nil
-					}
-					rule.HTTP.Paths[i] = ingPathToAdd
-					return s.Client.ExtensionsV1beta1().Ingresses(ing.Namespace).Update(ing)
-				}
-			}
-			rule.HTTP.Paths = append([]extv1beta1.HTTPIngressPath{ingPathToAdd}, rule.HTTP.Paths...)
-			return s.Client.ExtensionsV1beta1().Ingresses(ing.Namespace).Update(ing)
+		log.V(logf.DebugLevel).Info("deleting expired ingress resource", "expiresAt", value)
+		err = s.ingressClient.Ingresses(ctx, ing.Namespace).Delete(ing.Name, nil)
+		if err != nil && !k8sErrors.IsNotFound(err) {
+			errs = append(errs, fmt.Errorf("error deleting expired ingress %s/%s: %v", ing.Namespace, ing.Name, err))
 		}
 	}
-
-	// if one doesn't exist, create a new IngressRule
-	ing.Spec.Rules = append(ing.Spec.Rules, extv1beta1.IngressRule{
-		Host: ch.Spec.DNSName,
-		IngressRuleValue: extv1beta1.IngressRuleValue{
-			HTTP: &extv1beta1.HTTPIngressRuleValue{
-				Paths: []extv1beta1.HTTPIngressPath{ingPathToAdd},
-			},
-		},
-	})
-	return s.Client.ExtensionsV1beta1().Ingresses(ing.Namespace).Update(ing)
+	return utilerrors.NewAggregate(errs)
 }
 
-// cleanupIngresses will remove the rules added by cert-manager to an existing
-// ingress, or delete the ingress if an existing ingress name is not specified
-// on the certificate.
-func (s *Solver) cleanupIngresses(ctx context.Context, ch *cmacme.Challenge) error {
-	log := logf.FromContext(ctx, "cleanupPods")
+// CleanupIngresses behaves like cleanupIngresses, additionally reporting how
+// many solver ingresses were deleted and how many remain, so that callers
+// can tell a partially successful cleanup from one that made no progress.
+func (s *Solver) CleanupIngresses(ctx context.Context, ch *cmacme.Challenge) (IngressCleanupResult, error) {
+	log := logf.FromContext(ctx, "cleanupIngresses")
 
+	var result IngressCleanupResult
+	var existingIngressName string
 	httpDomainCfg, err := httpDomainCfgForChallenge(ch)
 	if err != nil {
-		return err
+		// the ingress config may have been removed since the challenge was
+		// presented. Any ingresses the solver created for it must still be
+		// cleaned up, and are found by their labels.
+		log.Info("no HTTP01 ingress config found for challenge, cleaning up solver ingresses", "reason", err.Error())
+	} else {
+		existingIngressName, err = s.existingIngressName(ch, httpDomainCfg)
+		if k8sErrors.IsNotFound(err) {
+			log.Error(err, "selected ingress resource not found, skipping cleanup")
+			return result, nil
+		}
+		if err != nil {
+			return result, err
+		}
 	}
-	existingIngressName := httpDomainCfg.Name
 
 	// if the 'ingress' field on the domain config is not set, we need to delete
 	// the ingress resources that cert-manager has created to solve the challenge
 	if existingIngressName == "" {
-		ingresses, err := s.getIngressesForChallenge(ctx, ch)
+		if s.ingressRetention > 0 {
+			return s.expireIngresses(ctx, ch)
+		}
+		// the apiserver is queried rather than the lister's cache so that
+		// ingresses created just before cleanup are not missed
+		ingresses, err := s.listIngressesForChallenge(ctx, ch)
 		if err != nil {
-			return err
+			return result, err
+		}
+		ingresses, err = s.releaseSharedIngresses(ctx, ch, ingresses)
+		if err != nil {
+			return result, err
 		}
 		var errs []error
+		var deleted []*extv1beta1.Ingress
 		for _, ingress := range ingresses {
 			log := logf.WithRelatedResource(log, ingress).V(logf.DebugLevel)
 
 			log.Info("deleting ingress resource")
-			err := s.Client.ExtensionsV1beta1().Ingresses(ingress.Namespace).Delete(ingress.Name, nil)
+			// the UID precondition ensures an ingress that has been replaced
+			// since it was listed is not deleted without being checked for
+			// ownership first
+			err := s.ingressClient.Ingresses(ctx, ingress.Namespace).Delete(ingress.Name, &metav1.DeleteOptions{
+				Preconditions: metav1.NewUIDPreconditions(string(ingress.UID)),
+			})
+			if k8sErrors.IsNotFound(err) {
+				log.Info("ingress resource has already been deleted")
+				continue
+			}
 			if err != nil {
 				log.Info("failed to delete ingress resource", "error", err)
-				errs = append(errs, err)
+				errs = append(errs, domainIngressError(ch, ingress, "deleting", err))
+				result.Remaining++
 				continue
 			}
 			log.Info("successfully deleted ingress resource")
+			deleted = append(deleted, ingress)
+			result.Deleted++
 		}
-		return utilerrors.NewAggregate(errs)
+		if len(errs) > 0 {
+			return result, utilerrors.NewAggregate(errs)
+		}
+		return result, s.waitForIngressesDeleted(ctx, deleted)
 	}
 
 	// otherwise, we need to remove any cert-manager added rules from the ingress resource
-	ing, err := s.Client.ExtensionsV1beta1().Ingresses(ch.Namespace).Get(existingIngressName, metav1.GetOptions{})
+	log = logf.WithRelatedResourceName(log, existingIngressName, s.resourceNamespace(ch), "Ingress")
+	log.Info("attempting to clean up automatically added solver paths on ingress resource")
+	ing, err := s.patchIngressRules(ctx, s.resourceNamespace(ch), existingIngressName, func(ing *extv1beta1.Ingress) []extv1beta1.IngressRule {
+		return s.removeChallengePaths(log, ch, httpDomainCfg, ing)
+	})
 	if k8sErrors.IsNotFound(err) {
 		log.Error(err, "named ingress resource not found, skipping cleanup")
-		return nil
+		return result, nil
 	}
 	if err != nil {
-		return err
+		return result, domainIngressError(ch, ing, "removing challenge paths from", err)
 	}
-	log = logf.WithRelatedResource(log, ing)
 
-	log.Info("attempting to clean up automatically added solver paths on ingress resource")
-	ingPathToDel := solverPathFn(ch.Spec.Token)
+	log.Info("cleaned up all challenge solver paths on ingress resource")
+
+	return result, nil
+}
+
+// CleanupChallengeIngresses cleans up the solver ingresses for all of the
+// given challenges, e.g. the challenges for each domain of a certificate.
+// Challenges whose paths were added to the same existing ingress have their
+// paths removed in a single update of that ingress, rather than one update
+// per challenge. All other challenges are cleaned up individually as by
+// cleanupIngresses. Errors for each ingress and challenge are aggregated.
+func (s *Solver) CleanupChallengeIngresses(ctx context.Context, chs []*cmacme.Challenge) error {
+	log := logf.FromContext(ctx, "cleanupChallengeIngresses")
+
+	type ingressKey struct{ namespace, name string }
+	var keys []ingressKey
+	shared := make(map[ingressKey][]*cmacme.Challenge)
+	var errs []error
+	for _, ch := range chs {
+		httpDomainCfg, err := httpDomainCfgForChallenge(ch)
+		var existingIngressName string
+		if err == nil {
+			existingIngressName, err = s.existingIngressName(ch, httpDomainCfg)
+		}
+		// challenges for solver ingresses, or whose existing ingress cannot
+		// be determined, are handled individually
+		if err != nil || existingIngressName == "" {
+			if err := s.cleanupIngresses(ctx, ch); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		key := ingressKey{s.resourceNamespace(ch), existingIngressName}
+		if _, ok := shared[key]; !ok {
+			keys = append(keys, key)
+		}
+		shared[key] = append(shared[key], ch)
+	}
+
+	for _, key := range keys {
+		log := logf.WithRelatedResourceName(log, key.name, key.namespace, "Ingress")
+		log.Info("attempting to clean up automatically added solver paths on ingress resource", "challenges", len(shared[key]))
+		ing, err := s.patchIngressRules(ctx, key.namespace, key.name, func(ing *extv1beta1.Ingress) []extv1beta1.IngressRule {
+			for _, ch := range shared[key] {
+				httpDomainCfg, _ := httpDomainCfgForChallenge(ch)
+				ing.Spec.Rules = s.removeChallengePaths(log, ch, httpDomainCfg, ing)
+			}
+			return ing.Spec.Rules
+		})
+		if k8sErrors.IsNotFound(err) {
+			log.Error(err, "named ingress resource not found, skipping cleanup")
+			continue
+		}
+		if err != nil {
+			for _, ch := range shared[key] {
+				errs = append(errs, domainIngressError(ch, ing, "removing challenge paths from", err))
+			}
+			continue
+		}
+		log.Info("cleaned up all challenge solver paths on ingress resource")
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// removeChallengePaths returns the rules of the given ingress with the solver
+// paths for the given challenge removed. Rules left without any paths are
+// dropped.
+func (s *Solver) removeChallengePaths(log logr.Logger, ch *cmacme.Challenge, httpDomainCfg *cmacme.ACMEChallengeSolverHTTP01Ingress, ing *extv1beta1.Ingress) []extv1beta1.IngressRule {
+	ingPathsToDel := make(map[string]struct{})
+	// match both the plain and regex forms of each path, so that paths are
+	// still cleaned up if the regex paths option has changed since they
+	// were added. The configured path suffix is matched in the same way.
+	for _, p := range ingressPaths(s.challengePath(ch), ch.Spec.Token, "", httpDomainCfg.ExtraPathPrefixes) {
+		for _, path := range s.challengePathForms(p.Path) {
+			ingPathsToDel[path] = struct{}{}
+		}
+	}
+	// the same host may legally appear in more than one rule, so every rule
+	// is checked and the ingress is only updated once all have been handled
+	domain := normalizeHost(ch.Spec.DNSName)
 	var ingRules []extv1beta1.IngressRule
 	for _, rule := range ing.Spec.Rules {
 		// always retain rules that are not for the same DNSName
-		if rule.Host != ch.Spec.DNSName {
+		if normalizeHost(rule.Host) != domain {
 			ingRules = append(ingRules, rule)
 			continue
 		}
@@ -281,14 +1666,19 @@ func (s *Solver) cleanupIngresses(ctx context.Context, ch *cmacme.Challenge) err
 			continue
 		}
 
-		// check the rule for paths. If we find the ingress path we need to
-		// delete here, delete it
-		for i, path := range rule.HTTP.Paths {
-			if path.Path == ingPathToDel {
+		// check the rule for paths. If we find any of the ingress paths we
+		// need to delete here, delete them. Paths that route to a service
+		// other than a solver service were configured by the user and are
+		// retained even if they match.
+		var paths []extv1beta1.HTTPIngressPath
+		for _, path := range rule.HTTP.Paths {
+			if _, ok := ingPathsToDel[path.Path]; ok && s.routesToSolverService(ch, ing.Namespace, path.Backend) {
 				log.Info("deleting challenge solver path on ingress resource", "host", rule.Host, "path", path.Path)
-				rule.HTTP.Paths = append(rule.HTTP.Paths[:i], rule.HTTP.Paths[i+1:]...)
+				continue
 			}
+			paths = append(paths, path)
 		}
+		rule.HTTP.Paths = paths
 
 		// if there are still paths level on this rule, we should retain it
 		if len(rule.HTTP.Paths) > 0 {
@@ -296,30 +1686,297 @@ func (s *Solver) cleanupIngresses(ctx context.Context, ch *cmacme.Challenge) err
 		}
 	}
 
-	ing.Spec.Rules = ingRules
+	return ingRules
+}
+
+// patchIngressRules replaces the rules of the named ingress with those
+// returned by rulesFn for a copy of its latest version, returning the ingress
+// the rules were computed from. Only the rules are patched, so that changes
+// made to the rest of the ingress, e.g. annotations added by other
+// controllers, are not overwritten. The patch is conditional on the resource
+// version the rules were computed from, and on a conflict they are computed
+// again from the latest version, so that paths added by other challenges or
+// rules edited by users in the meantime are never overwritten.
+func (s *Solver) patchIngressRules(ctx context.Context, namespace, name string, rulesFn func(*extv1beta1.Ingress) []extv1beta1.IngressRule) (*extv1beta1.Ingress, error) {
+	log := logf.FromContext(ctx)
+
+	client := s.ingressClient.Ingresses(ctx, namespace)
+	for attempt := 1; ; attempt++ {
+		ing, err := client.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return &extv1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}, err
+		}
+		patch, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"resourceVersion": ing.ResourceVersion,
+			},
+			"spec": map[string]interface{}{
+				"rules": rulesFn(ing.DeepCopy()),
+			},
+		})
+		if err != nil {
+			return ing, err
+		}
+		_, err = client.Patch(name, types.MergePatchType, patch)
+		if !k8sErrors.IsConflict(err) || attempt > maxIngressUpdateConflicts {
+			return ing, err
+		}
+		logf.WithRelatedResource(log, ing).V(logf.DebugLevel).Info("ingress was modified while removing challenge paths, retrying with the latest version", "attempt", attempt)
+	}
+}
+
+// domainIngressError annotates an error that occurred while acting on the
+// given ingress for a challenge with the challenged domain and the name of the
+// ingress, so that the failing domain can be identified once the errors for
+// the many challenges of a certificate have been aggregated.
+func domainIngressError(ch *cmacme.Challenge, ing *extv1beta1.Ingress, action string, err error) error {
+	return fmt.Errorf("domain %q: error %s ingress %s/%s: %v", ch.Spec.DNSName, action, ing.Namespace, ing.Name, err)
+}
+
+// waitForIngressesDeleted blocks until the given ingresses have been removed
+// from the lister cache, returning ErrIngressStillDeleting if they are still
+// present once the configured ingressDeleteTimeout has been exceeded.
+// If no timeout is configured, it returns immediately.
+func (s *Solver) waitForIngressesDeleted(ctx context.Context, ingresses []*extv1beta1.Ingress) error {
+	if s.ingressDeleteTimeout <= 0 || len(ingresses) == 0 {
+		return nil
+	}
+
+	log := logf.FromContext(ctx)
+	log.V(logf.DebugLevel).Info("waiting for deleted ingresses to be removed", "timeout", s.ingressDeleteTimeout)
+	deadline := s.clock.Now().Add(s.ingressDeleteTimeout)
+	for {
+		deleted, err := s.ingressesDeleted(ingresses)
+		if err != nil || deleted {
+			return err
+		}
+		if !s.clock.Now().Before(deadline) {
+			return ErrIngressStillDeleting
+		}
+		if err := s.sleep(ctx, ingressDeletePollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// ingressesDeleted returns true if none of the given ingresses are present in
+// the lister cache.
+func (s *Solver) ingressesDeleted(ingresses []*extv1beta1.Ingress) (bool, error) {
+	for _, ing := range ingresses {
+		existing, err := s.ingressLister.Ingresses(ing.Namespace).Get(ing.Name)
+		if k8sErrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		// an ingress with the same name but a different UID has been
+		// created since, so the one we deleted has been removed
+		if existing.UID != ing.UID {
+			continue
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// ErrForceCleanupDisabled is returned by CleanupAll if the solver has not
+// been configured to allow force cleanups.
+var ErrForceCleanupDisabled = errors.New("force cleanup of HTTP01 solver ingresses is not enabled")
+
+// CleanupAll deletes every HTTP01 solver ingress in the given namespace,
+// regardless of the challenge that owns it. It is intended to be used by
+// operators recovering from a bad rollout and must never be called as part
+// of normal challenge processing, so it returns ErrForceCleanupDisabled
+// unless force cleanups have been explicitly enabled.
+func (s *Solver) CleanupAll(ctx context.Context, namespace string) error {
+	log := logf.FromContext(ctx, "cleanupAll")
+
+	if !s.allowForceCleanup {
+		return ErrForceCleanupDisabled
+	}
 
-	_, err = s.Client.ExtensionsV1beta1().Ingresses(ing.Namespace).Update(ing)
+	ingresses, err := s.listSolverIngresses(namespace)
 	if err != nil {
 		return err
 	}
+	var errs []error
+	for _, ingress := range ingresses {
+		log := logf.WithRelatedResource(log, ingress)
 
-	log.Info("cleaned up all challenge solver paths on ingress resource")
+		log.Info("force deleting solver ingress resource")
+		err := s.ingressClient.Ingresses(ctx, ingress.Namespace).Delete(ingress.Name, nil)
+		if k8sErrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			log.Error(err, "failed to delete solver ingress resource")
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// CleanupAllDryRun returns the names of the ingresses that CleanupAll would
+// delete in the given namespace, without deleting them.
+func (s *Solver) CleanupAllDryRun(ctx context.Context, namespace string) ([]string, error) {
+	ingresses, err := s.listSolverIngresses(namespace)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(ingresses))
+	for _, ingress := range ingresses {
+		names = append(names, ingress.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// listSolverIngresses returns all ingresses in the given namespace that were
+// created by the HTTP01 solver. If namespace is empty, ingresses in all
+// namespaces are returned.
+func (s *Solver) listSolverIngresses(namespace string) ([]*extv1beta1.Ingress, error) {
+	selector := labels.SelectorFromSet(labels.Set{solverIdentificationLabelKey: "true"})
+	return s.ingressLister.Ingresses(namespace).List(selector)
+}
+
+// normalizeHost returns the given DNS name in the canonical form used as an
+// ingress rule host, i.e. lower case and without a trailing dot.
+func normalizeHost(host string) string {
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
 
+// validateIngressHost returns an error if the given domain cannot be used as
+// an ingress rule host, e.g. because it was copied from a URL and contains a
+// scheme, port or path. This gives a clearer error than the apiserver would
+// when the ingress is created or updated.
+func validateIngressHost(domain string) error {
+	host := normalizeHost(domain)
+	if strings.ContainsAny(host, ":/") {
+		return fmt.Errorf("domain %q must be a bare DNS hostname without a scheme, port or path. "+
+			"Check the commonName and dnsNames fields of the Certificate", domain)
+	}
+	if errs := validation.IsDNS1123Subdomain(host); len(errs) > 0 {
+		return fmt.Errorf("domain %q is not a valid DNS hostname: %s. "+
+			"Check the commonName and dnsNames fields of the Certificate", domain, strings.Join(errs, ", "))
+	}
 	return nil
 }
 
-// ingressPath returns the ingress HTTPIngressPath object needed to solve this
-// challenge.
-func ingressPath(token, serviceName string) extv1beta1.HTTPIngressPath {
+// ingressPaths returns the ingress HTTPIngressPath objects needed to solve
+// this challenge. The first entry is always the given challenge path,
+// followed by one entry for each of the given extra path prefixes.
+func ingressPaths(challengePath, token, serviceName string, extraPrefixes []string) []extv1beta1.HTTPIngressPath {
+	ingPath := ChallengeIngressPath(token, serviceName, acmeSolverListenPort)
+	ingPath.Path = challengePath
+	paths := []extv1beta1.HTTPIngressPath{ingPath}
+	for _, prefix := range extraPrefixes {
+		ingPath.Path = fmt.Sprintf("%s/%s", prefix, token)
+		paths = append(paths, ingPath)
+	}
+	return paths
+}
+
+// ingressPaths returns the ingress paths needed to solve this challenge,
+// referencing the solver service port as configured on the solver, and
+// escaped and anchored as regular expressions if the solver has been
+// configured for ingress controllers that treat paths as regexes.
+// Cleanup matches paths by path alone, so the port representation does not
+// affect which paths are removed.
+func (s *Solver) ingressPaths(ch *cmacme.Challenge, serviceName string, extraPrefixes []string) []extv1beta1.HTTPIngressPath {
+	paths := ingressPaths(s.challengePath(ch), ch.Spec.Token, serviceName, extraPrefixes)
+	for i := range paths {
+		paths[i].Backend.ServicePort = s.servicePort()
+		paths[i].Path = suffixedIngressPath(paths[i].Path, s.ACMEOptions.HTTP01SolverPathSuffix, s.ACMEOptions.HTTP01SolverRegexPaths)
+	}
+	return paths
+}
+
+// challengePath returns the HTTP path that the key for the given challenge is
+// served on by this solver, using the path template from the challenge's
+// solver config if one is set and the solver's path function otherwise.
+func (s *Solver) challengePath(ch *cmacme.Challenge) string {
+	return challengePathFor(ch, s.pathFn)
+}
+
+// challengePathFor returns the HTTP path that the key for the given
+// challenge is served on, using the path template from the challenge's
+// solver config if one is set and the given path function otherwise. If
+// pathFn is nil, the default challenge path is used.
+func challengePathFor(ch *cmacme.Challenge, pathFn func(domain, token string) string) string {
+	if cfg, err := httpDomainCfgForChallenge(ch); err == nil && cfg.PathTemplate != "" {
+		return expandPathTemplate(cfg.PathTemplate, ch.Spec.DNSName, ch.Spec.Token)
+	}
+	if pathFn == nil {
+		return defaultPathFn(ch.Spec.DNSName, ch.Spec.Token)
+	}
+	return pathFn(ch.Spec.DNSName, ch.Spec.Token)
+}
+
+// validateChallengePath returns an error if the path that the key for the
+// given challenge would be served on does not end in its token, as the self
+// check and the solver pod rely on finding the token there.
+func (s *Solver) validateChallengePath(ch *cmacme.Challenge) error {
+	path := s.challengePath(ch)
+	if !strings.HasPrefix(path, "/") || !strings.HasSuffix(path, "/"+ch.Spec.Token) {
+		return fmt.Errorf("HTTP01 challenge path %q for domain %q must be absolute and end in the challenge token", path, ch.Spec.DNSName)
+	}
+	return nil
+}
+
+// expandPathTemplate replaces the '{domain}' and '{token}' placeholders in
+// the given path template.
+func expandPathTemplate(tmpl, domain, token string) string {
+	return strings.NewReplacer("{domain}", domain, "{token}", token).Replace(tmpl)
+}
+
+// defaultPathFn serves the key for every challenge on its canonical path.
+func defaultPathFn(_, token string) string {
+	return ChallengePath(token)
+}
+
+// challengePathForms returns every form the given plain challenge path may
+// have been added to an ingress in by this solver, with or without the
+// configured path suffix, and as plain text or a regular expression.
+func (s *Solver) challengePathForms(path string) []string {
+	forms := []string{path, regexIngressPath(path)}
+	if suffix := s.ACMEOptions.HTTP01SolverPathSuffix; suffix != "" {
+		forms = append(forms, suffixedIngressPath(path, suffix, false), suffixedIngressPath(path, suffix, true))
+	}
+	return forms
+}
+
+// regexIngressPath returns a regular expression that matches exactly the
+// given plain text path.
+func regexIngressPath(path string) string {
+	return suffixedIngressPath(path, "", true)
+}
+
+// suffixedIngressPath appends the given suffix verbatim to the plain text
+// path. If regex is true, the path is escaped and anchored as a regular
+// expression, with the suffix added before the end of line anchor.
+func suffixedIngressPath(path, suffix string, regex bool) string {
+	if regex {
+		return "^" + regexp.QuoteMeta(path) + suffix + "$"
+	}
+	return path + suffix
+}
+
+// ChallengePath returns the HTTP path that the key for the HTTP01 challenge
+// with the given token is served on.
+func ChallengePath(token string) string {
+	return fmt.Sprintf("%s/%s", solver.HTTPChallengePath, token)
+}
+
+// ChallengeIngressPath returns the HTTPIngressPath that routes requests for
+// the HTTP01 challenge with the given token to the named solver service.
+func ChallengeIngressPath(token, serviceName string, port int) extv1beta1.HTTPIngressPath {
 	return extv1beta1.HTTPIngressPath{
-		Path: solverPathFn(token),
+		Path: ChallengePath(token),
 		Backend: extv1beta1.IngressBackend{
 			ServiceName: serviceName,
-			ServicePort: intstr.FromInt(acmeSolverListenPort),
+			ServicePort: intstr.FromInt(port),
 		},
 	}
 }
-
-var solverPathFn = func(token string) string {
-	return fmt.Sprintf("%s/%s", solver.HTTPChallengePath, token)
-}
@@ -18,20 +18,32 @@ package http
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/diff"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	extv1beta1listers "k8s.io/client-go/listers/extensions/v1beta1"
 	coretesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	fakeclock "k8s.io/utils/clock/testing"
 
 	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	cmapi "github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/controller"
 	"github.com/jetstack/cert-manager/pkg/controller/test"
 )
 
@@ -50,7 +62,7 @@ func TestGetIngressesForChallenge(t *testing.T) {
 				},
 			},
 			PreFn: func(t *testing.T, s *solverFixture) {
-				ing, err := s.Solver.createIngress(s.Challenge, "fakeservice")
+				ing, err := s.Solver.createIngress(context.TODO(), s.Challenge, "fakeservice")
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
@@ -71,6 +83,43 @@ func TestGetIngressesForChallenge(t *testing.T) {
 				}
 			},
 		},
+		"should return an ingress created with a custom name prefix": {
+			Challenge: &cmacme.Challenge{
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+						},
+					},
+				},
+			},
+			PreFn: func(t *testing.T, s *solverFixture) {
+				s.Solver.namePrefix = "team-a-solver-"
+				ing, err := s.Solver.createIngress(context.TODO(), s.Challenge, "fakeservice")
+				if err != nil {
+					t.Errorf("error preparing test: %v", err)
+				}
+				if ing.GenerateName != "team-a-solver-" {
+					t.Errorf("expected ingress GenerateName to be %q but got %q", "team-a-solver-", ing.GenerateName)
+				}
+
+				s.testResources[createdIngressKey] = ing
+				s.Builder.Sync()
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				createdIngress := s.testResources[createdIngressKey].(*v1beta1.Ingress)
+				resp := args[0].([]*v1beta1.Ingress)
+				if len(resp) != 1 {
+					t.Errorf("expected one ingress to be returned, but got %d", len(resp))
+					t.Fail()
+					return
+				}
+				if !reflect.DeepEqual(resp[0], createdIngress) {
+					t.Errorf("Expected %v to equal %v", resp[0], createdIngress)
+				}
+			},
+		},
 		"should not return an ingress for the same certificate but different domain": {
 			Challenge: &cmacme.Challenge{
 				Spec: cmacme.ChallengeSpec{
@@ -85,7 +134,7 @@ func TestGetIngressesForChallenge(t *testing.T) {
 			PreFn: func(t *testing.T, s *solverFixture) {
 				differentChallenge := s.Challenge.DeepCopy()
 				differentChallenge.Spec.DNSName = "notexample.com"
-				_, err := s.Solver.createIngress(differentChallenge, "fakeservice")
+				_, err := s.Solver.createIngress(context.TODO(), differentChallenge, "fakeservice")
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
@@ -101,6 +150,130 @@ func TestGetIngressesForChallenge(t *testing.T) {
 				}
 			},
 		},
+		"should not select an ingress for a different challenge with the same domain and token": {
+			Challenge: &cmacme.Challenge{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "first-challenge",
+					Namespace: defaultTestNamespace,
+					UID:       "first-challenge-uid",
+				},
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Token:   "abcd",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+						},
+					},
+				},
+			},
+			PreFn: func(t *testing.T, s *solverFixture) {
+				differentChallenge := s.Challenge.DeepCopy()
+				differentChallenge.Name = "second-challenge"
+				differentChallenge.UID = "second-challenge-uid"
+				_, err := s.Solver.createIngress(context.TODO(), differentChallenge, "fakeservice")
+				if err != nil {
+					t.Errorf("error preparing test: %v", err)
+				}
+
+				s.Builder.Sync()
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				resp := args[0].([]*v1beta1.Ingress)
+				if len(resp) != 0 {
+					t.Errorf("expected zero ingresses to be returned, but got %d", len(resp))
+					t.Fail()
+					return
+				}
+				// the label selectors alone should not match the other
+				// challenge's ingress, before any owner reference filtering
+				for _, selector := range solverSelectors(s.Challenge) {
+					ingresses, err := s.Solver.ingressLister.Ingresses(s.Challenge.Namespace).List(selector)
+					if err != nil {
+						t.Errorf("error listing ingresses: %v", err)
+						return
+					}
+					if len(ingresses) != 0 {
+						t.Errorf("expected label selector %q to match zero ingresses, but it matched %d", selector, len(ingresses))
+					}
+				}
+			},
+		},
+		"should label the ingress with the order hash and return it for the same order": {
+			Challenge: &cmacme.Challenge{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "testchal",
+					Namespace:   defaultTestNamespace,
+					Annotations: map[string]string{cmacme.ACMEOrderURLAnnotationKey: "https://acme.example.com/order/1"},
+				},
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Token:   "abcd",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+						},
+					},
+				},
+			},
+			PreFn: func(t *testing.T, s *solverFixture) {
+				ing, err := s.Solver.createIngress(context.TODO(), s.Challenge, "fakeservice")
+				if err != nil {
+					t.Errorf("error preparing test: %v", err)
+				}
+				if ing.Labels[orderHashLabelKey] != orderHashForChallenge(s.Challenge) {
+					t.Errorf("expected ingress to have order hash label %q but got labels %v", orderHashForChallenge(s.Challenge), ing.Labels)
+				}
+
+				s.testResources[createdIngressKey] = ing
+				s.Builder.Sync()
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				createdIngress := s.testResources[createdIngressKey].(*v1beta1.Ingress)
+				resp := args[0].([]*v1beta1.Ingress)
+				if len(resp) != 1 {
+					t.Errorf("expected one ingress to be returned, but got %d", len(resp))
+					return
+				}
+				if !reflect.DeepEqual(resp[0], createdIngress) {
+					t.Errorf("Expected %v to equal %v", resp[0], createdIngress)
+				}
+			},
+		},
+		"should not return an ingress created for a different order": {
+			Challenge: &cmacme.Challenge{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        "testchal",
+					Namespace:   defaultTestNamespace,
+					Annotations: map[string]string{cmacme.ACMEOrderURLAnnotationKey: "https://acme.example.com/order/2"},
+				},
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Token:   "abcd",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+						},
+					},
+				},
+			},
+			PreFn: func(t *testing.T, s *solverFixture) {
+				staleChallenge := s.Challenge.DeepCopy()
+				staleChallenge.Annotations[cmacme.ACMEOrderURLAnnotationKey] = "https://acme.example.com/order/1"
+				_, err := s.Solver.createIngress(context.TODO(), staleChallenge, "fakeservice")
+				if err != nil {
+					t.Errorf("error preparing test: %v", err)
+				}
+
+				s.Builder.Sync()
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				resp := args[0].([]*v1beta1.Ingress)
+				if len(resp) != 0 {
+					t.Errorf("expected zero ingresses to be returned, but got %d", len(resp))
+				}
+			},
+		},
 	}
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -135,7 +308,7 @@ func TestCleanupIngresses(t *testing.T) {
 				},
 			},
 			PreFn: func(t *testing.T, s *solverFixture) {
-				ing, err := s.Solver.createIngress(s.Challenge, "fakeservice")
+				ing, err := s.Solver.createIngress(context.TODO(), s.Challenge, "fakeservice")
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
@@ -170,7 +343,7 @@ func TestCleanupIngresses(t *testing.T) {
 			PreFn: func(t *testing.T, s *solverFixture) {
 				differentChallenge := s.Challenge.DeepCopy()
 				differentChallenge.Spec.DNSName = "notexample.com"
-				ing, err := s.Solver.createIngress(differentChallenge, "fakeservice")
+				ing, err := s.Solver.createIngress(context.TODO(), differentChallenge, "fakeservice")
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
@@ -187,6 +360,88 @@ func TestCleanupIngresses(t *testing.T) {
 				}
 			},
 		},
+		"should not overwrite annotations added to an existing ingress since it was cached": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{
+					&v1beta1.Ingress{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "testingress",
+							Namespace: defaultTestNamespace,
+						},
+						Spec: v1beta1.IngressSpec{
+							Rules: []v1beta1.IngressRule{
+								{
+									Host: "example.com",
+									IngressRuleValue: v1beta1.IngressRuleValue{
+										HTTP: &v1beta1.HTTPIngressRuleValue{
+											Paths: []v1beta1.HTTPIngressPath{
+												ChallengeIngressPath("abcd", "solversvc", acmeSolverListenPort),
+												{
+													Path: "/",
+													Backend: v1beta1.IngressBackend{
+														ServiceName: "testsvc",
+														ServicePort: intstr.FromInt(8080),
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Challenge: &cmacme.Challenge{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testchal",
+					Namespace: defaultTestNamespace,
+				},
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Token:   "abcd",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+								Name: "testingress",
+							},
+						},
+					},
+				},
+			},
+			PreFn: func(t *testing.T, s *solverFixture) {
+				// annotate the ingress behind a stale lister, as another
+				// controller would between the ingress being read and updated
+				ing, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Get("testingress", metav1.GetOptions{})
+				if err != nil {
+					t.Errorf("error preparing test: %v", err)
+					return
+				}
+				indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+				if err := indexer.Add(ing.DeepCopy()); err != nil {
+					t.Errorf("error preparing test: %v", err)
+				}
+				s.Solver.ingressLister = extv1beta1listers.NewIngressLister(indexer)
+				ing.Annotations = map[string]string{"example.com/owner": "other-controller"}
+				if _, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Update(ing); err != nil {
+					t.Errorf("error preparing test: %v", err)
+				}
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				ing, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Get("testingress", metav1.GetOptions{})
+				if err != nil {
+					t.Errorf("error getting ingress: %v", err)
+					return
+				}
+				if ing.Annotations["example.com/owner"] != "other-controller" {
+					t.Errorf("expected unrelated annotation to be preserved but got annotations %v", ing.Annotations)
+				}
+				paths := ing.Spec.Rules[0].HTTP.Paths
+				if len(paths) != 1 || paths[0].Path != "/" {
+					t.Errorf("expected only the challenge path to be removed but got paths %v", paths)
+				}
+			},
+		},
 		"should clean up an ingress with a single challenge path inserted": {
 			Builder: &test.Builder{
 				KubeObjects: []runtime.Object{
@@ -258,7 +513,7 @@ func TestCleanupIngresses(t *testing.T) {
 				}
 			},
 		},
-		"should clean up an ingress with a single challenge path inserted without removing second HTTP rule": {
+		"should clean up a challenge path inserted as a regex": {
 			Builder: &test.Builder{
 				KubeObjects: []runtime.Object{
 					&v1beta1.Ingress{
@@ -278,7 +533,7 @@ func TestCleanupIngresses(t *testing.T) {
 										HTTP: &v1beta1.HTTPIngressRuleValue{
 											Paths: []v1beta1.HTTPIngressPath{
 												{
-													Path: "/.well-known/acme-challenge/abcd",
+													Path: `^/\.well-known/acme-challenge/abcd$`,
 													Backend: v1beta1.IngressBackend{
 														ServiceName: "solversvc",
 														ServicePort: intstr.FromInt(8081),
@@ -288,22 +543,6 @@ func TestCleanupIngresses(t *testing.T) {
 										},
 									},
 								},
-								{
-									Host: "a.example.com",
-									IngressRuleValue: v1beta1.IngressRuleValue{
-										HTTP: &v1beta1.HTTPIngressRuleValue{
-											Paths: []v1beta1.HTTPIngressPath{
-												{
-													Path: "/",
-													Backend: v1beta1.IngressBackend{
-														ServiceName: "real-backend-svc",
-														ServicePort: intstr.FromInt(8081),
-													},
-												},
-											},
-										},
-									},
-								},
 							},
 						},
 					},
@@ -330,7 +569,7 @@ func TestCleanupIngresses(t *testing.T) {
 			},
 			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
 				expectedIng := s.KubeObjects[0].(*v1beta1.Ingress).DeepCopy()
-				expectedIng.Spec.Rules = []v1beta1.IngressRule{expectedIng.Spec.Rules[1]}
+				expectedIng.Spec.Rules = nil
 
 				actualIng, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(s.Challenge.Namespace).Get(expectedIng.Name, metav1.GetOptions{})
 				if apierrors.IsNotFound(err) {
@@ -345,94 +584,4404 @@ func TestCleanupIngresses(t *testing.T) {
 				}
 			},
 		},
-		"should return an error if a delete fails": {
-			Challenge: &cmacme.Challenge{
-				Spec: cmacme.ChallengeSpec{
-					DNSName: "example.com",
-					Token:   "abcd",
-					Solver: &cmacme.ACMEChallengeSolver{
-						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
-							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
-								Class: strPtr("nginx"),
-							},
+		"should clean up an ingress with a single challenge path inserted without removing second HTTP rule": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{
+					&v1beta1.Ingress{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "testingress",
+							Namespace: defaultTestNamespace,
 						},
-					},
-				},
-			},
-			Err: true,
-			PreFn: func(t *testing.T, s *solverFixture) {
-				s.Builder.FakeKubeClient().PrependReactor("delete", "ingresses", func(action coretesting.Action) (handled bool, ret runtime.Object, err error) {
-					return true, nil, fmt.Errorf("simulated error")
-				})
-				ing, err := s.Solver.createIngress(s.Challenge, "fakeservice")
-				if err != nil {
-					t.Errorf("error preparing test: %v", err)
-				}
-				s.testResources[createdIngressKey] = ing
-			},
-		},
-	}
-
-	for name, test := range tests {
-		t.Run(name, func(t *testing.T) {
-			test.Setup(t)
-			err := test.Solver.cleanupIngresses(context.TODO(), test.Challenge)
-			if err != nil && !test.Err {
-				t.Errorf("Expected function to not error, but got: %v", err)
-			}
-			if err == nil && test.Err {
-				t.Errorf("Expected function to get an error, but got: %v", err)
-			}
-			test.Finish(t)
-		})
-	}
-}
-
-func TestEnsureIngress(t *testing.T) {
-	tests := map[string]solverFixture{
-		"should clean up if service name changes": {
+						Spec: v1beta1.IngressSpec{
+							Backend: &v1beta1.IngressBackend{
+								ServiceName: "testsvc",
+								ServicePort: intstr.FromInt(8080),
+							},
+							Rules: []v1beta1.IngressRule{
+								{
+									Host: "example.com",
+									IngressRuleValue: v1beta1.IngressRuleValue{
+										HTTP: &v1beta1.HTTPIngressRuleValue{
+											Paths: []v1beta1.HTTPIngressPath{
+												{
+													Path: "/.well-known/acme-challenge/abcd",
+													Backend: v1beta1.IngressBackend{
+														ServiceName: "solversvc",
+														ServicePort: intstr.FromInt(8081),
+													},
+												},
+											},
+										},
+									},
+								},
+								{
+									Host: "a.example.com",
+									IngressRuleValue: v1beta1.IngressRuleValue{
+										HTTP: &v1beta1.HTTPIngressRuleValue{
+											Paths: []v1beta1.HTTPIngressPath{
+												{
+													Path: "/",
+													Backend: v1beta1.IngressBackend{
+														ServiceName: "real-backend-svc",
+														ServicePort: intstr.FromInt(8081),
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
 			Challenge: &cmacme.Challenge{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testchal",
+					Namespace: defaultTestNamespace,
+				},
 				Spec: cmacme.ChallengeSpec{
 					DNSName: "example.com",
+					Token:   "abcd",
 					Solver: &cmacme.ACMEChallengeSolver{
 						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
-							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+								Name: "testingress",
+							},
 						},
 					},
 				},
 			},
-			Err: true,
 			PreFn: func(t *testing.T, s *solverFixture) {
-				_, err := s.Solver.createIngress(s.Challenge, "anotherfakeservice")
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				expectedIng := s.KubeObjects[0].(*v1beta1.Ingress).DeepCopy()
+				expectedIng.Spec.Rules = []v1beta1.IngressRule{expectedIng.Spec.Rules[1]}
+
+				actualIng, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(s.Challenge.Namespace).Get(expectedIng.Name, metav1.GetOptions{})
+				if apierrors.IsNotFound(err) {
+					t.Errorf("expected ingress resource %q to not be deleted, but it was deleted", expectedIng.Name)
+				}
 				if err != nil {
-					t.Errorf("error preparing test: %v", err)
+					t.Errorf("error getting ingress resource: %v", err)
 				}
-				s.Builder.Sync()
+
+				if !reflect.DeepEqual(expectedIng, actualIng) {
+					t.Errorf("expected did not match actual: %v", diff.ObjectDiff(expectedIng, actualIng))
+				}
+			},
+		},
+		"should clean up all challenge paths including extra path prefixes": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{
+					&v1beta1.Ingress{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "testingress",
+							Namespace: defaultTestNamespace,
+						},
+						Spec: v1beta1.IngressSpec{
+							Rules: []v1beta1.IngressRule{
+								{
+									Host: "example.com",
+									IngressRuleValue: v1beta1.IngressRuleValue{
+										HTTP: &v1beta1.HTTPIngressRuleValue{
+											Paths: []v1beta1.HTTPIngressPath{
+												{
+													Path: "/.well-known/acme-challenge/abcd",
+													Backend: v1beta1.IngressBackend{
+														ServiceName: "solversvc",
+														ServicePort: intstr.FromInt(8089),
+													},
+												},
+												{
+													Path: "/acme-challenge/abcd",
+													Backend: v1beta1.IngressBackend{
+														ServiceName: "solversvc",
+														ServicePort: intstr.FromInt(8089),
+													},
+												},
+												{
+													Path: "/",
+													Backend: v1beta1.IngressBackend{
+														ServiceName: "real-backend-svc",
+														ServicePort: intstr.FromInt(8080),
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Challenge: &cmacme.Challenge{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testchal",
+					Namespace: defaultTestNamespace,
+				},
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Token:   "abcd",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+								Name:              "testingress",
+								ExtraPathPrefixes: []string{"/acme-challenge"},
+							},
+						},
+					},
+				},
 			},
 			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
-				ingresses, err := s.Solver.ingressLister.List(labels.NewSelector())
+				expectedIng := s.KubeObjects[0].(*v1beta1.Ingress).DeepCopy()
+				expectedIng.Spec.Rules[0].HTTP.Paths = expectedIng.Spec.Rules[0].HTTP.Paths[2:]
+
+				actualIng, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(s.Challenge.Namespace).Get(expectedIng.Name, metav1.GetOptions{})
 				if err != nil {
-					t.Errorf("error listing ingresses: %v", err)
-					t.Fail()
-					return
+					t.Errorf("error getting ingress resource: %v", err)
 				}
-				if len(ingresses) != 0 {
-					t.Errorf("expected ingresses to have been cleaned up, but there were %d ingresses left", len(ingresses))
+
+				if !reflect.DeepEqual(expectedIng, actualIng) {
+					t.Errorf("expected did not match actual: %v", diff.ObjectDiff(expectedIng, actualIng))
 				}
 			},
 		},
+		"should clean up challenge paths from every rule with the same host": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{
+					&v1beta1.Ingress{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "testingress",
+							Namespace: defaultTestNamespace,
+						},
+						Spec: v1beta1.IngressSpec{
+							Rules: []v1beta1.IngressRule{
+								{
+									Host: "example.com",
+									IngressRuleValue: v1beta1.IngressRuleValue{
+										HTTP: &v1beta1.HTTPIngressRuleValue{
+											Paths: []v1beta1.HTTPIngressPath{
+												{
+													Path: "/.well-known/acme-challenge/abcd",
+													Backend: v1beta1.IngressBackend{
+														ServiceName: "solversvc",
+														ServicePort: intstr.FromInt(8081),
+													},
+												},
+												{
+													Path: "/",
+													Backend: v1beta1.IngressBackend{
+														ServiceName: "testsvc",
+														ServicePort: intstr.FromInt(8080),
+													},
+												},
+											},
+										},
+									},
+								},
+								{
+									Host: "example.com",
+									IngressRuleValue: v1beta1.IngressRuleValue{
+										HTTP: &v1beta1.HTTPIngressRuleValue{
+											Paths: []v1beta1.HTTPIngressPath{
+												{
+													Path: "/.well-known/acme-challenge/abcd",
+													Backend: v1beta1.IngressBackend{
+														ServiceName: "solversvc",
+														ServicePort: intstr.FromInt(8081),
+													},
+												},
+												{
+													Path: "/api",
+													Backend: v1beta1.IngressBackend{
+														ServiceName: "apisvc",
+														ServicePort: intstr.FromInt(8080),
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Challenge: &cmacme.Challenge{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testchal",
+					Namespace: defaultTestNamespace,
+				},
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Token:   "abcd",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+								Name: "testingress",
+							},
+						},
+					},
+				},
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				expectedIng := s.KubeObjects[0].(*v1beta1.Ingress).DeepCopy()
+				expectedIng.Spec.Rules[0].HTTP.Paths = expectedIng.Spec.Rules[0].HTTP.Paths[1:]
+				expectedIng.Spec.Rules[1].HTTP.Paths = expectedIng.Spec.Rules[1].HTTP.Paths[1:]
+
+				actualIng, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(s.Challenge.Namespace).Get(expectedIng.Name, metav1.GetOptions{})
+				if err != nil {
+					t.Errorf("error getting ingress resource: %v", err)
+				}
+
+				if !reflect.DeepEqual(expectedIng, actualIng) {
+					t.Errorf("expected did not match actual: %v", diff.ObjectDiff(expectedIng, actualIng))
+				}
+			},
+		},
+		"should clean up challenge paths from rules with a mixed case host and trailing dot": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{
+					&v1beta1.Ingress{
+						ObjectMeta: metav1.ObjectMeta{
+							Name:      "testingress",
+							Namespace: defaultTestNamespace,
+						},
+						Spec: v1beta1.IngressSpec{
+							Rules: []v1beta1.IngressRule{
+								{
+									Host: "Example.COM.",
+									IngressRuleValue: v1beta1.IngressRuleValue{
+										HTTP: &v1beta1.HTTPIngressRuleValue{
+											Paths: []v1beta1.HTTPIngressPath{
+												{
+													Path: "/.well-known/acme-challenge/abcd",
+													Backend: v1beta1.IngressBackend{
+														ServiceName: "solversvc",
+														ServicePort: intstr.FromInt(8081),
+													},
+												},
+												{
+													Path: "/",
+													Backend: v1beta1.IngressBackend{
+														ServiceName: "testsvc",
+														ServicePort: intstr.FromInt(8080),
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Challenge: &cmacme.Challenge{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testchal",
+					Namespace: defaultTestNamespace,
+				},
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Token:   "abcd",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+								Name: "testingress",
+							},
+						},
+					},
+				},
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				expectedIng := s.KubeObjects[0].(*v1beta1.Ingress).DeepCopy()
+				expectedIng.Spec.Rules[0].HTTP.Paths = expectedIng.Spec.Rules[0].HTTP.Paths[1:]
+
+				actualIng, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(s.Challenge.Namespace).Get(expectedIng.Name, metav1.GetOptions{})
+				if err != nil {
+					t.Errorf("error getting ingress resource: %v", err)
+				}
+
+				if !reflect.DeepEqual(expectedIng, actualIng) {
+					t.Errorf("expected did not match actual: %v", diff.ObjectDiff(expectedIng, actualIng))
+				}
+			},
+		},
+		"should not return an error if the ingress has already been deleted": {
+			Challenge: &cmacme.Challenge{
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Token:   "abcd",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+								Class: strPtr("nginx"),
+							},
+						},
+					},
+				},
+			},
+			PreFn: func(t *testing.T, s *solverFixture) {
+				s.Builder.FakeKubeClient().PrependReactor("delete", "ingresses", func(action coretesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, nil, apierrors.NewNotFound(schema.GroupResource{Group: "extensions", Resource: "ingresses"}, "")
+				})
+				_, err := s.Solver.createIngress(context.TODO(), s.Challenge, "fakeservice")
+				if err != nil {
+					t.Errorf("error preparing test: %v", err)
+				}
+				s.Builder.Sync()
+			},
+		},
+		"should return an error if a delete fails": {
+			Challenge: &cmacme.Challenge{
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Token:   "abcd",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+								Class: strPtr("nginx"),
+							},
+						},
+					},
+				},
+			},
+			Err: true,
+			PreFn: func(t *testing.T, s *solverFixture) {
+				s.Builder.FakeKubeClient().PrependReactor("delete", "ingresses", func(action coretesting.Action) (handled bool, ret runtime.Object, err error) {
+					return true, nil, fmt.Errorf("simulated error")
+				})
+				ing, err := s.Solver.createIngress(context.TODO(), s.Challenge, "fakeservice")
+				if err != nil {
+					t.Errorf("error preparing test: %v", err)
+				}
+				s.testResources[createdIngressKey] = ing
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			err := test.Solver.cleanupIngresses(context.TODO(), test.Challenge)
+			if err != nil && !test.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && test.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+			test.Finish(t)
+		})
+	}
+}
+
+func TestCleanupIngressesErrorDomain(t *testing.T) {
+	f := solverFixture{
+		Challenge: &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+					},
+				},
+			},
+		},
+		PreFn: func(t *testing.T, s *solverFixture) {
+			s.Builder.FakeKubeClient().PrependReactor("delete", "ingresses", func(action coretesting.Action) (handled bool, ret runtime.Object, err error) {
+				return true, nil, fmt.Errorf("simulated error")
+			})
+			var names []string
+			for i := 0; i < 2; i++ {
+				ing, err := s.Solver.createIngress(context.TODO(), s.Challenge, "fakeservice")
+				if err != nil {
+					t.Fatalf("error preparing test: %v", err)
+				}
+				names = append(names, ing.Name)
+			}
+			s.testResources["ingressNames"] = names
+			s.Builder.Sync()
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	err := f.Solver.cleanupIngresses(context.TODO(), f.Challenge)
+	if err == nil {
+		t.Fatalf("expected an error cleaning up ingresses")
+	}
+	for _, name := range f.testResources["ingressNames"].([]string) {
+		expected := fmt.Sprintf(`domain "example.com": error deleting ingress %s/%s: simulated error`, defaultTestNamespace, name)
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected aggregated error to contain %q but got: %v", expected, err)
+		}
+	}
+}
+
+func TestCleanupIngressesRetention(t *testing.T) {
+	const retention = time.Hour
+	clk := fakeclock.NewFakeClock(time.Now())
+	f := solverFixture{
+		Challenge: &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+					},
+				},
+			},
+		},
+		PreFn: func(t *testing.T, s *solverFixture) {
+			s.Solver.ingressRetention = retention
+			s.Solver.clock = clk
+			ing, err := s.Solver.createIngress(context.TODO(), s.Challenge, "fakeservice")
+			if err != nil {
+				t.Fatalf("error preparing test: %v", err)
+			}
+			s.testResources["ingressName"] = ing.Name
+			s.Builder.Sync()
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	getIngress := func() (*v1beta1.Ingress, error) {
+		name := f.testResources["ingressName"].(string)
+		return f.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Get(name, metav1.GetOptions{})
+	}
+
+	result, err := f.Solver.CleanupIngresses(context.TODO(), f.Challenge)
+	if err != nil {
+		t.Fatalf("unexpected error cleaning up ingresses: %v", err)
+	}
+	if result.Expired != 1 || result.Deleted != 0 {
+		t.Errorf("expected 1 ingress to be expired and none deleted but got %+v", result)
+	}
+	ing, err := getIngress()
+	if err != nil {
+		t.Fatalf("expected ingress to be retained but got: %v", err)
+	}
+	expected := clk.Now().Add(retention).UTC().Format(time.RFC3339)
+	if got := ing.Annotations[ingressExpiresAtAnnotationKey]; got != expected {
+		t.Errorf("expected ingress to expire at %q but got %q", expected, got)
+	}
+	if len(ing.OwnerReferences) != 0 {
+		t.Errorf("expected owner references to be removed but got %+v", ing.OwnerReferences)
+	}
+	f.Builder.Sync()
+
+	if err := f.Solver.DeleteExpiredIngresses(context.TODO()); err != nil {
+		t.Fatalf("unexpected error deleting expired ingresses: %v", err)
+	}
+	if _, err := getIngress(); err != nil {
+		t.Fatalf("expected ingress to be retained until it expires but got: %v", err)
+	}
+
+	clk.Step(retention)
+	if err := f.Solver.DeleteExpiredIngresses(context.TODO()); err != nil {
+		t.Fatalf("unexpected error deleting expired ingresses: %v", err)
+	}
+	if _, err := getIngress(); !apierrors.IsNotFound(err) {
+		t.Errorf("expected expired ingress to be deleted but got: %v", err)
+	}
+}
+
+func TestCleanupIngressesUserChallengePath(t *testing.T) {
+	userService := fakeSolverService()
+	userService.Name = "user-svc"
+	userService.Labels = nil
+	path := func(p, svcName string) v1beta1.HTTPIngressPath {
+		return v1beta1.HTTPIngressPath{
+			Path: p,
+			Backend: v1beta1.IngressBackend{
+				ServiceName: svcName,
+				ServicePort: intstr.FromInt(acmeSolverListenPort),
+			},
+		}
+	}
+	tests := map[string]struct {
+		token    string
+		paths    []v1beta1.HTTPIngressPath
+		expected []string
+	}{
+		"should retain a user's own challenge path when removing the solver path": {
+			token: "abcd",
+			paths: []v1beta1.HTTPIngressPath{
+				path("/.well-known/acme-challenge/abcd", "fakeservice"),
+				path("/.well-known/acme-challenge/static", "user-svc"),
+			},
+			expected: []string{"/.well-known/acme-challenge/static"},
+		},
+		"should retain a user's own challenge path that matches the challenge token": {
+			token: "static",
+			paths: []v1beta1.HTTPIngressPath{
+				path("/.well-known/acme-challenge/static", "user-svc"),
+			},
+			expected: []string{"/.well-known/acme-challenge/static"},
+		},
+		"should remove a solver path routing to a service that has been deleted": {
+			token: "abcd",
+			paths: []v1beta1.HTTPIngressPath{
+				path("/.well-known/acme-challenge/abcd", "deleted-svc"),
+				path("/.well-known/acme-challenge/static", "user-svc"),
+			},
+			expected: []string{"/.well-known/acme-challenge/static"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := solverFixture{
+				Builder: &test.Builder{
+					KubeObjects: []runtime.Object{
+						fakeSolverService(),
+						userService,
+						&v1beta1.Ingress{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "testingress",
+								Namespace: defaultTestNamespace,
+							},
+							Spec: v1beta1.IngressSpec{
+								Rules: []v1beta1.IngressRule{
+									{
+										Host: "example.com",
+										IngressRuleValue: v1beta1.IngressRuleValue{
+											HTTP: &v1beta1.HTTPIngressRuleValue{Paths: tc.paths},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				Challenge: &cmacme.Challenge{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testchal",
+						Namespace: defaultTestNamespace,
+					},
+					Spec: cmacme.ChallengeSpec{
+						DNSName: "example.com",
+						Token:   tc.token,
+						Solver: &cmacme.ACMEChallengeSolver{
+							HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+								Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+									Name: "testingress",
+								},
+							},
+						},
+					},
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			if err := f.Solver.cleanupIngresses(context.TODO(), f.Challenge); err != nil {
+				t.Fatalf("unexpected error cleaning up ingress: %v", err)
+			}
+			ing, err := f.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Get("testingress", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting ingress: %v", err)
+			}
+			var paths []string
+			for _, p := range ing.Spec.Rules[0].HTTP.Paths {
+				paths = append(paths, p.Path)
+			}
+			if !reflect.DeepEqual(tc.expected, paths) {
+				t.Errorf("expected paths %v but got %v", tc.expected, paths)
+			}
+		})
+	}
+}
+
+// deleteOptionsRecorder wraps an ingressClient and records the options
+// passed to each Delete call, which the fake clientset does not retain.
+type deleteOptionsRecorder struct {
+	ingressClient
+	options map[string]*metav1.DeleteOptions
+}
+
+func (r *deleteOptionsRecorder) Ingresses(ctx context.Context, namespace string) ingressInterface {
+	return &deleteOptionsRecorderIngresses{ingressInterface: r.ingressClient.Ingresses(ctx, namespace), recorder: r}
+}
+
+type deleteOptionsRecorderIngresses struct {
+	ingressInterface
+	recorder *deleteOptionsRecorder
+}
+
+func (r *deleteOptionsRecorderIngresses) Delete(name string, options *metav1.DeleteOptions) error {
+	r.recorder.options[name] = options
+	return r.ingressInterface.Delete(name, options)
+}
+
+func TestCleanupIngressesUIDPreconditions(t *testing.T) {
+	const recorderKey = "recorder"
+	newChallenge := func() *cmacme.Challenge {
+		return &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-challenge",
+				Namespace: defaultTestNamespace,
+				UID:       "test-uid",
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+					},
+				},
+			},
+		}
+	}
+	// createIngress creates an ingress for the challenge with the given name
+	// and UID, and removes its owner reference if owned is false
+	createIngress := func(t *testing.T, s *solverFixture, name string, uid types.UID, owned bool) {
+		ing, err := s.Solver.buildIngressResource(s.Challenge, "fakeservice")
+		if err != nil {
+			t.Fatalf("error preparing test: %v", err)
+		}
+		ing.GenerateName = ""
+		ing.Name = name
+		ing.UID = uid
+		if !owned {
+			ing.OwnerReferences = nil
+		}
+		if _, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Create(ing); err != nil {
+			t.Fatalf("error preparing test: %v", err)
+		}
+	}
+	createIngresses := func(t *testing.T, s *solverFixture) {
+		createIngress(t, s, "solver-ingress-1", "uid-1", true)
+		createIngress(t, s, "solver-ingress-2", "uid-2", true)
+		recorder := &deleteOptionsRecorder{ingressClient: s.Solver.ingressClient, options: map[string]*metav1.DeleteOptions{}}
+		s.Solver.ingressClient = recorder
+		s.testResources[recorderKey] = recorder
+	}
+	expectDeleted := func(t *testing.T, s *solverFixture, expected map[string]types.UID) {
+		options := s.testResources[recorderKey].(*deleteOptionsRecorder).options
+		if len(options) != len(expected) {
+			t.Errorf("expected %d ingresses to be deleted but got %d", len(expected), len(options))
+		}
+		for name, uid := range expected {
+			opts, ok := options[name]
+			if !ok {
+				t.Errorf("expected ingress %q to be deleted", name)
+				continue
+			}
+			if opts == nil || opts.Preconditions == nil || opts.Preconditions.UID == nil || *opts.Preconditions.UID != uid {
+				t.Errorf("expected ingress %q to be deleted with UID precondition %q but got options %+v", name, uid, opts)
+			}
+		}
+	}
+	expectIngresses := func(t *testing.T, s *solverFixture, expected int) {
+		ingresses, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).List(metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("error listing ingresses: %v", err)
+		}
+		if len(ingresses.Items) != expected {
+			t.Errorf("expected %d ingresses to remain but got %d", expected, len(ingresses.Items))
+		}
+	}
+	solverIngresses := map[string]types.UID{"solver-ingress-1": "uid-1", "solver-ingress-2": "uid-2"}
+
+	tests := map[string]solverFixture{
+		"should delete each solver ingress with a UID precondition": {
+			Challenge: newChallenge(),
+			PreFn:     createIngresses,
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				expectDeleted(t, s, solverIngresses)
+				expectIngresses(t, s, 0)
+			},
+		},
+		"should delete solver ingresses that are not yet in the lister's cache": {
+			Challenge: newChallenge(),
+			PreFn: func(t *testing.T, s *solverFixture) {
+				createIngresses(t, s)
+				s.Solver.ingressLister = extv1beta1listers.NewIngressLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}))
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				expectDeleted(t, s, solverIngresses)
+				expectIngresses(t, s, 0)
+			},
+		},
+		"should not delete an ingress not owned by the challenge": {
+			Challenge: newChallenge(),
+			PreFn: func(t *testing.T, s *solverFixture) {
+				createIngress(t, s, "foreign-ingress", "foreign-uid", false)
+				createIngresses(t, s)
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				expectDeleted(t, s, solverIngresses)
+				expectIngresses(t, s, 1)
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			result, err := test.Solver.CleanupIngresses(context.TODO(), test.Challenge)
+			if err != nil {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if result.Deleted != 2 || result.Remaining != 0 {
+				t.Errorf("unexpected cleanup result %+v", result)
+			}
+			test.Finish(t)
+		})
+	}
+}
+
+func TestCleanupIngressesResult(t *testing.T) {
+	f := solverFixture{
+		Challenge: &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-challenge",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+					},
+				},
+			},
+		},
+		PreFn: func(t *testing.T, s *solverFixture) {
+			var failName string
+			for i := 0; i < 3; i++ {
+				ing, err := s.Solver.createIngress(context.TODO(), s.Challenge, "fakeservice")
+				if err != nil {
+					t.Fatalf("error preparing test: %v", err)
+				}
+				failName = ing.Name
+			}
+			s.Builder.FakeKubeClient().PrependReactor("delete", "ingresses", func(action coretesting.Action) (bool, runtime.Object, error) {
+				if action.(coretesting.DeleteAction).GetName() == failName {
+					return true, nil, fmt.Errorf("simulated error")
+				}
+				return false, nil, nil
+			})
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	result, err := f.Solver.CleanupIngresses(context.TODO(), f.Challenge)
+	if err == nil {
+		t.Errorf("expected an error from the failed delete")
+	}
+	if expected := (IngressCleanupResult{Deleted: 2, Remaining: 1}); result != expected {
+		t.Errorf("expected result %+v but got %+v", expected, result)
+	}
+}
+
+func TestCleanupIngressesWaitForDeletion(t *testing.T) {
+	const createdIngressKey = "createdIngress"
+	newChallenge := func() *cmacme.Challenge {
+		return &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test-challenge",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+					},
+				},
+			},
+		}
+	}
+	ingressGVR := schema.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "ingresses"}
+	// slowDelete returns a PreFn that creates a solver ingress and simulates
+	// the apiserver taking 'delay' to remove it once deleted.
+	// If delay is zero, the ingress is never removed.
+	slowDelete := func(timeout, delay time.Duration) func(*testing.T, *solverFixture) {
+		return func(t *testing.T, s *solverFixture) {
+			s.Solver.ingressDeleteTimeout = timeout
+			ing, err := s.Solver.buildIngressResource(s.Challenge, "fakeservice")
+			if err != nil {
+				t.Errorf("error preparing test: %v", err)
+			}
+			// the fake clientset does not support GenerateName
+			ing.Name = "cm-acme-http-solver-abcde"
+			ing, err = s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(ing.Namespace).Create(ing)
+			if err != nil {
+				t.Errorf("error preparing test: %v", err)
+			}
+			s.testResources[createdIngressKey] = ing
+			tracker := s.Builder.FakeKubeClient().Tracker()
+			s.Builder.FakeKubeClient().PrependReactor("delete", "ingresses", func(action coretesting.Action) (bool, runtime.Object, error) {
+				if delay > 0 {
+					go func() {
+						time.Sleep(delay)
+						tracker.Delete(ingressGVR, ing.Namespace, ing.Name)
+					}()
+				}
+				return true, nil, nil
+			})
+		}
+	}
+
+	tests := map[string]solverFixture{
+		"should wait for a slow ingress deletion to complete": {
+			Challenge: newChallenge(),
+			PreFn:     slowDelete(5*time.Second, 300*time.Millisecond),
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				err, _ := args[0].(error)
+				if err != nil {
+					t.Errorf("expected no error but got: %v", err)
+				}
+				ingresses, err := s.Solver.ingressLister.List(labels.Everything())
+				if err != nil {
+					t.Errorf("error listing ingresses: %v", err)
+				}
+				if len(ingresses) != 0 {
+					t.Errorf("expected ingress to have been removed, but %d remain", len(ingresses))
+				}
+			},
+		},
+		"should return ErrIngressStillDeleting if the ingress is not removed in time": {
+			Challenge: newChallenge(),
+			PreFn:     slowDelete(300*time.Millisecond, 0),
+			Err:       true,
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				err, _ := args[0].(error)
+				if err != ErrIngressStillDeleting {
+					t.Errorf("expected ErrIngressStillDeleting but got: %v", err)
+				}
+			},
+		},
+		"should not wait if no timeout is configured": {
+			Challenge: newChallenge(),
+			PreFn:     slowDelete(0, 0),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			err := test.Solver.cleanupIngresses(context.TODO(), test.Challenge)
+			if err != nil && !test.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && test.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+			test.Finish(t, err)
+		})
+	}
+}
+
+func TestWaitForIngressesDeletedCancelled(t *testing.T) {
+	ing := &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "cm-acme-http-solver-abcde",
+			Namespace: defaultTestNamespace,
+		},
+	}
+	f := solverFixture{
+		Builder: &test.Builder{
+			KubeObjects: []runtime.Object{ing},
+		},
+		Challenge: &cmacme.Challenge{},
+		PreFn: func(t *testing.T, s *solverFixture) {
+			s.Solver.ingressDeleteTimeout = time.Hour
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+	// the ingress is never removed, so this only returns once cancelled
+	if err := f.Solver.waitForIngressesDeleted(ctx, []*v1beta1.Ingress{ing}); err != context.Canceled {
+		t.Errorf("expected context.Canceled but got: %v", err)
+	}
+}
+
+func TestEnsureIngress(t *testing.T) {
+	const createdIngressKey = "createdIngress"
+	driftChallenge := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Token:   "abcd",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+				},
+			},
+		},
+	}
+	// modifyIngress returns a PreFn that creates a solver ingress and then
+	// applies the given modification to it, simulating drift
+	modifyIngress := func(modify func(*v1beta1.Ingress)) func(*testing.T, *solverFixture) {
+		return func(t *testing.T, s *solverFixture) {
+			ing, err := s.Solver.createIngress(context.TODO(), s.Challenge, "fakeservice")
+			if err != nil {
+				t.Errorf("error preparing test: %v", err)
+				return
+			}
+			if modify != nil {
+				modify(ing)
+				ing, err = s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(ing.Namespace).Update(ing)
+				if err != nil {
+					t.Errorf("error preparing test: %v", err)
+					return
+				}
+			}
+			s.testResources[createdIngressKey] = ing
+			s.Builder.Sync()
+		}
+	}
+	expectRepaired := func(repaired bool) func(*testing.T, *solverFixture, ...interface{}) {
+		return func(t *testing.T, s *solverFixture, args ...interface{}) {
+			createdIngress := s.testResources[createdIngressKey].(*v1beta1.Ingress)
+			ing, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(createdIngress.Namespace).Get(createdIngress.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Errorf("error getting ingress: %v", err)
+				return
+			}
+			expected, err := s.Solver.buildIngressResource(s.Challenge, "fakeservice")
+			if err != nil {
+				t.Errorf("error building expected ingress: %v", err)
+				return
+			}
+			if !reflect.DeepEqual(expected.Spec.Rules, ing.Spec.Rules) {
+				t.Errorf("expected ingress rules to match: %v", diff.ObjectDiff(expected.Spec.Rules, ing.Spec.Rules))
+			}
+			expectedEvent := fmt.Sprintf("Normal RepairedIngress Repaired modified rules on HTTP01 solver ingress %q", ing.Name)
+			var events []string
+			if repaired {
+				events = []string{expectedEvent}
+			}
+			if !reflect.DeepEqual(events, s.Builder.Events()) {
+				t.Errorf("expected events %v but got %v", events, s.Builder.Events())
+			}
+		}
+	}
+
+	tests := map[string]solverFixture{
+		"should not modify an existing solver ingress that has not drifted": {
+			Challenge: driftChallenge,
+			PreFn:     modifyIngress(nil),
+			CheckFn:   expectRepaired(false),
+		},
+		"should repair a solver ingress whose challenge path has been changed": {
+			Challenge: driftChallenge,
+			PreFn: modifyIngress(func(ing *v1beta1.Ingress) {
+				ing.Spec.Rules[0].HTTP.Paths[0].Path = "/mangled"
+			}),
+			CheckFn: expectRepaired(true),
+		},
+		"should repair a solver ingress whose paths have been removed": {
+			Challenge: driftChallenge,
+			PreFn: modifyIngress(func(ing *v1beta1.Ingress) {
+				ing.Spec.Rules[0].HTTP = nil
+			}),
+			CheckFn: expectRepaired(true),
+		},
+		"should repair a solver ingress whose host has been changed": {
+			Challenge: driftChallenge,
+			PreFn: modifyIngress(func(ing *v1beta1.Ingress) {
+				ing.Spec.Rules[0].Host = "other.example.com"
+			}),
+			CheckFn: expectRepaired(true),
+		},
+		"should clean up if service name changes": {
+			Challenge: &cmacme.Challenge{
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+						},
+					},
+				},
+			},
+			Err: true,
+			PreFn: func(t *testing.T, s *solverFixture) {
+				_, err := s.Solver.createIngress(context.TODO(), s.Challenge, "anotherfakeservice")
+				if err != nil {
+					t.Errorf("error preparing test: %v", err)
+				}
+				s.Builder.Sync()
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				ingresses, err := s.Solver.ingressLister.List(labels.NewSelector())
+				if err != nil {
+					t.Errorf("error listing ingresses: %v", err)
+					t.Fail()
+					return
+				}
+				if len(ingresses) != 0 {
+					t.Errorf("expected ingresses to have been cleaned up, but there were %d ingresses left", len(ingresses))
+				}
+			},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			resp, _, err := test.Solver.ensureIngress(context.TODO(), test.Challenge, "fakeservice")
+			if err != nil && !test.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && test.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+			test.Finish(t, resp, err)
+		})
+	}
+}
+
+func TestCreateIngressHostMatchesDomain(t *testing.T) {
+	newChallenge := func(dnsName string) *cmacme.Challenge {
+		return &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: dnsName,
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+					},
+				},
+			},
+		}
+	}
+	expectIngressHost := func(expected string) func(*testing.T, *solverFixture, ...interface{}) {
+		return func(t *testing.T, s *solverFixture, args ...interface{}) {
+			ingresses, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).List(metav1.ListOptions{})
+			if err != nil {
+				t.Errorf("error listing ingresses: %v", err)
+				return
+			}
+			if len(ingresses.Items) != 1 {
+				t.Errorf("expected 1 ingress to exist but there were %d", len(ingresses.Items))
+				return
+			}
+			if host := ingresses.Items[0].Spec.Rules[0].Host; host != expected {
+				t.Errorf("expected ingress host %q but got %q", expected, host)
+			}
+		}
+	}
+	tests := map[string]solverFixture{
+		"should create an ingress for a normalized domain": {
+			Challenge: newChallenge("www.example.com"),
+			CheckFn:   expectIngressHost("www.example.com"),
+		},
+		"should create an ingress with the normalized host for a mixed case domain with a trailing dot": {
+			Challenge: newChallenge("WWW.example.com."),
+			CheckFn:   expectIngressHost("www.example.com"),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			resp, err := test.Solver.createIngress(context.TODO(), test.Challenge, "fakeservice")
+			if err != nil && !test.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && test.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+			test.Finish(t, resp, err)
+		})
+	}
+}
+
+func TestCheckSolverIngressHost(t *testing.T) {
+	rule := func(host, svcName string) v1beta1.IngressRule {
+		return v1beta1.IngressRule{
+			Host: host,
+			IngressRuleValue: v1beta1.IngressRuleValue{
+				HTTP: &v1beta1.HTTPIngressRuleValue{
+					Paths: []v1beta1.HTTPIngressPath{
+						{
+							Path: "/.well-known/acme-challenge/abcd",
+							Backend: v1beta1.IngressBackend{
+								ServiceName: svcName,
+								ServicePort: intstr.FromInt(acmeSolverListenPort),
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	tests := map[string]struct {
+		rules     []v1beta1.IngressRule
+		domain    string
+		expectErr bool
+	}{
+		"should accept a rule for the normalized domain": {
+			rules:  []v1beta1.IngressRule{rule("www.example.com", "fakeservice")},
+			domain: "WWW.example.com.",
+		},
+		"should reject a solver rule for another host": {
+			rules:     []v1beta1.IngressRule{rule("example.com", "fakeservice")},
+			domain:    "www.example.com",
+			expectErr: true,
+		},
+		"should ignore rules for another host that do not route to the solver": {
+			rules: []v1beta1.IngressRule{
+				rule("other.example.com", "real-backend-svc"),
+				rule("www.example.com", "fakeservice"),
+			},
+			domain: "www.example.com",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ing := &v1beta1.Ingress{Spec: v1beta1.IngressSpec{Rules: test.rules}}
+			err := checkSolverIngressHost(ing, test.domain, "fakeservice")
+			if err != nil && !test.expectErr {
+				t.Errorf("expected no error but got: %v", err)
+			}
+			if err == nil && test.expectErr {
+				t.Errorf("expected an error but got none")
+			}
+		})
+	}
+}
+
+func TestAddChallengePathToIngressExtraPaths(t *testing.T) {
+	const existingPath = "/"
+	test := solverFixture{
+		Builder: &test.Builder{
+			KubeObjects: []runtime.Object{
+				fakeSolverService(),
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testingress",
+						Namespace: defaultTestNamespace,
+					},
+					Spec: v1beta1.IngressSpec{
+						Rules: []v1beta1.IngressRule{
+							{
+								Host: "example.com",
+								IngressRuleValue: v1beta1.IngressRuleValue{
+									HTTP: &v1beta1.HTTPIngressRuleValue{
+										Paths: []v1beta1.HTTPIngressPath{
+											{
+												Path: existingPath,
+												Backend: v1beta1.IngressBackend{
+													ServiceName: "real-backend-svc",
+													ServicePort: intstr.FromInt(8080),
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Challenge: &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+							Name:              "testingress",
+							ExtraPathPrefixes: []string{"/acme-challenge"},
+						},
+					},
+				},
+			},
+		},
+		CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+			ing := args[0].(*v1beta1.Ingress)
+			var paths []string
+			for _, p := range ing.Spec.Rules[0].HTTP.Paths {
+				paths = append(paths, p.Path)
+			}
+			expected := []string{"/.well-known/acme-challenge/abcd", "/acme-challenge/abcd", existingPath}
+			if !reflect.DeepEqual(expected, paths) {
+				t.Errorf("expected paths %v but got %v", expected, paths)
+			}
+		},
+	}
+	test.Setup(t)
+	ing, _, err := test.Solver.addChallengePathToIngress(context.TODO(), test.Challenge, "fakeservice")
+	if err != nil {
+		t.Errorf("Expected function to not error, but got: %v", err)
+	}
+	test.Finish(t, ing, err)
+}
+
+func TestAddChallengePathToIngressCatchAllPath(t *testing.T) {
+	tests := map[string]struct {
+		appendPaths bool
+		expected    []string
+	}{
+		"should place the challenge path before a catch-all path": {
+			expected: []string{"/.well-known/acme-challenge/abcd", "/"},
+		},
+		"should place the challenge path after a catch-all path if configured to append": {
+			appendPaths: true,
+			expected:    []string{"/", "/.well-known/acme-challenge/abcd"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := solverFixture{
+				Builder: &test.Builder{
+					KubeObjects: []runtime.Object{
+						fakeSolverService(),
+						&v1beta1.Ingress{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "testingress",
+								Namespace: defaultTestNamespace,
+							},
+							Spec: v1beta1.IngressSpec{
+								Rules: []v1beta1.IngressRule{
+									{
+										Host: "example.com",
+										IngressRuleValue: v1beta1.IngressRuleValue{
+											HTTP: &v1beta1.HTTPIngressRuleValue{
+												Paths: []v1beta1.HTTPIngressPath{
+													{
+														Path: "/",
+														Backend: v1beta1.IngressBackend{
+															ServiceName: "real-backend-svc",
+															ServicePort: intstr.FromInt(8080),
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				Challenge: &cmacme.Challenge{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testchal",
+						Namespace: defaultTestNamespace,
+					},
+					Spec: cmacme.ChallengeSpec{
+						DNSName: "example.com",
+						Token:   "abcd",
+						Solver: &cmacme.ACMEChallengeSolver{
+							HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+								Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+									Name: "testingress",
+								},
+							},
+						},
+					},
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+			f.Solver.appendPaths = tc.appendPaths
+
+			paths := func(ing *v1beta1.Ingress) []string {
+				var paths []string
+				for _, p := range ing.Spec.Rules[0].HTTP.Paths {
+					paths = append(paths, p.Path)
+				}
+				return paths
+			}
+
+			ing, _, err := f.Solver.addChallengePathToIngress(context.TODO(), f.Challenge, "fakeservice")
+			if err != nil {
+				t.Fatalf("unexpected error adding challenge path: %v", err)
+			}
+			if got := paths(ing); !reflect.DeepEqual(tc.expected, got) {
+				t.Errorf("expected paths %v but got %v", tc.expected, got)
+			}
+
+			// the challenge path is removed by exact match wherever it was
+			// placed, leaving the catch-all path in place
+			if err := f.Solver.cleanupIngresses(context.TODO(), f.Challenge); err != nil {
+				t.Fatalf("unexpected error cleaning up ingress: %v", err)
+			}
+			ing, err = f.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Get("testingress", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting ingress: %v", err)
+			}
+			if got := paths(ing); !reflect.DeepEqual([]string{"/"}, got) {
+				t.Errorf("expected only the catch-all path to remain after clean up but got %v", got)
+			}
+		})
+	}
+}
+
+func TestAddChallengePathToIngressStalePaths(t *testing.T) {
+	tests := map[string]struct {
+		pathTemplate string
+		prefix       string
+	}{
+		"stale paths under the ACME challenge path are removed": {
+			prefix: "/.well-known/acme-challenge/",
+		},
+		"stale paths under a custom challenge path are removed": {
+			pathTemplate: "/custom/acme/{token}",
+			prefix:       "/custom/acme/",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			testAddChallengePathToIngressStalePaths(t, tc.pathTemplate, tc.prefix)
+		})
+	}
+}
+
+func testAddChallengePathToIngressStalePaths(t *testing.T, pathTemplate, prefix string) {
+	liveService := fakeSolverService()
+	liveService.Name = "live-solver-svc"
+	challengePath := func(token, svcName string) v1beta1.HTTPIngressPath {
+		return v1beta1.HTTPIngressPath{
+			Path: prefix + token,
+			Backend: v1beta1.IngressBackend{
+				ServiceName: svcName,
+				ServicePort: intstr.FromInt(acmeSolverListenPort),
+			},
+		}
+	}
+	test := solverFixture{
+		Builder: &test.Builder{
+			KubeObjects: []runtime.Object{
+				fakeSolverService(),
+				liveService,
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testingress",
+						Namespace: defaultTestNamespace,
+					},
+					Spec: v1beta1.IngressSpec{
+						Rules: []v1beta1.IngressRule{
+							{
+								Host: "example.com",
+								IngressRuleValue: v1beta1.IngressRuleValue{
+									HTTP: &v1beta1.HTTPIngressRuleValue{
+										Paths: []v1beta1.HTTPIngressPath{
+											challengePath("old", "deleted-solver-svc"),
+											challengePath("other", "live-solver-svc"),
+											{
+												Path: "/",
+												Backend: v1beta1.IngressBackend{
+													ServiceName: "real-backend-svc",
+													ServicePort: intstr.FromInt(8080),
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Challenge: &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+							Name:         "testingress",
+							PathTemplate: pathTemplate,
+						},
+					},
+				},
+			},
+		},
+		CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+			ing := args[0].(*v1beta1.Ingress)
+			var paths []string
+			for _, p := range ing.Spec.Rules[0].HTTP.Paths {
+				paths = append(paths, p.Path)
+			}
+			expected := []string{prefix + "abcd", prefix + "other", "/"}
+			if !reflect.DeepEqual(expected, paths) {
+				t.Errorf("expected paths %v but got %v", expected, paths)
+			}
+		},
+	}
+	test.Setup(t)
+	ing, _, err := test.Solver.addChallengePathToIngress(context.TODO(), test.Challenge, "fakeservice")
+	if err != nil {
+		t.Errorf("Expected function to not error, but got: %v", err)
+	}
+	test.Finish(t, ing, err)
+}
+
+func TestAddChallengePathToIngressMissingService(t *testing.T) {
+	wrongPortService := fakeSolverService()
+	wrongPortService.Spec.Ports[0].Port = 8080
+	tests := map[string][]runtime.Object{
+		"should not route to a service that does not exist":     nil,
+		"should not route to a service without the solver port": {wrongPortService},
+	}
+	for name, objs := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := solverFixture{
+				Builder: &test.Builder{
+					KubeObjects: append([]runtime.Object{
+						&v1beta1.Ingress{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "testingress",
+								Namespace: defaultTestNamespace,
+							},
+						},
+					}, objs...),
+				},
+				Challenge: &cmacme.Challenge{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testchal",
+						Namespace: defaultTestNamespace,
+					},
+					Spec: cmacme.ChallengeSpec{
+						DNSName: "example.com",
+						Token:   "abcd",
+						Solver: &cmacme.ACMEChallengeSolver{
+							HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+								Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+									Name: "testingress",
+								},
+							},
+						},
+					},
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			if _, _, err := f.Solver.addChallengePathToIngress(context.TODO(), f.Challenge, "fakeservice"); err == nil {
+				t.Errorf("expected an error adding a challenge path routing to an unusable service")
+			}
+			ing, err := f.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Get("testingress", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting ingress: %v", err)
+			}
+			if len(ing.Spec.Rules) != 0 {
+				t.Errorf("expected ingress to not be updated but got rules %+v", ing.Spec.Rules)
+			}
+		})
+	}
+}
+
+func TestAddChallengePathToIngressPathInjectionDisabled(t *testing.T) {
+	f := solverFixture{
+		Builder: &test.Builder{
+			KubeObjects: []runtime.Object{
+				fakeSolverService(),
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testingress",
+						Namespace: defaultTestNamespace,
+						Annotations: map[string]string{
+							cmacme.IngressDisablePathInjectionAnnotationKey: "true",
+						},
+					},
+				},
+			},
+		},
+		Challenge: &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+							Name: "testingress",
+						},
+					},
+				},
+			},
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	_, _, err := f.Solver.addChallengePathToIngress(context.TODO(), f.Challenge, "fakeservice")
+	if err == nil || !strings.Contains(err.Error(), cmacme.IngressDisablePathInjectionAnnotationKey) {
+		t.Errorf("expected an error naming the %q annotation but got: %v", cmacme.IngressDisablePathInjectionAnnotationKey, err)
+	}
+	ing, err := f.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Get("testingress", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting ingress: %v", err)
+	}
+	if len(ing.Spec.Rules) != 0 {
+		t.Errorf("expected ingress to not be updated but got rules %+v", ing.Spec.Rules)
+	}
+}
+
+func TestAddChallengePathToIngressConflict(t *testing.T) {
+	ingressGVR := schema.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "ingresses"}
+	conflict := apierrors.NewConflict(schema.GroupResource{Group: "extensions", Resource: "ingresses"}, "testingress", fmt.Errorf("the object has been modified"))
+	tests := map[string]struct {
+		// conflicts is the number of updates that fail with a conflict
+		conflicts int
+		expectErr bool
+	}{
+		"should re-apply challenge paths to the latest ingress after a conflict": {
+			conflicts: 1,
+		},
+		"should give up if the ingress keeps being modified": {
+			conflicts: maxIngressUpdateConflicts + 1,
+			expectErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			updates := 0
+			f := solverFixture{
+				Builder: &test.Builder{
+					KubeObjects: []runtime.Object{
+						fakeSolverService(),
+						&v1beta1.Ingress{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "testingress",
+								Namespace: defaultTestNamespace,
+							},
+						},
+					},
+				},
+				Challenge: &cmacme.Challenge{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testchal",
+						Namespace: defaultTestNamespace,
+					},
+					Spec: cmacme.ChallengeSpec{
+						DNSName: "example.com",
+						Token:   "abcd",
+						Solver: &cmacme.ACMEChallengeSolver{
+							HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+								Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+									Name: "testingress",
+								},
+							},
+						},
+					},
+				},
+				PreFn: func(t *testing.T, s *solverFixture) {
+					tracker := s.Builder.FakeKubeClient().Tracker()
+					s.Builder.FakeKubeClient().PrependReactor("update", "ingresses", func(action coretesting.Action) (bool, runtime.Object, error) {
+						updates++
+						if updates > tc.conflicts {
+							return false, nil, nil
+						}
+						// simulate another editor adding a rule before our
+						// update is applied
+						obj, err := tracker.Get(ingressGVR, defaultTestNamespace, "testingress")
+						if err != nil {
+							return true, nil, err
+						}
+						ing := obj.(*v1beta1.Ingress).DeepCopy()
+						ing.Spec.Rules = append(ing.Spec.Rules, v1beta1.IngressRule{Host: fmt.Sprintf("concurrent-%d.example.com", updates)})
+						if err := tracker.Update(ingressGVR, ing, defaultTestNamespace); err != nil {
+							return true, nil, err
+						}
+						return true, nil, conflict
+					})
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			_, _, err := f.Solver.addChallengePathToIngress(context.TODO(), f.Challenge, "fakeservice")
+			if tc.expectErr {
+				if !apierrors.IsConflict(err) {
+					t.Errorf("expected a conflict error but got: %v", err)
+				}
+				if updates != maxIngressUpdateConflicts+1 {
+					t.Errorf("expected %d update attempts but got %d", maxIngressUpdateConflicts+1, updates)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error adding challenge path: %v", err)
+			}
+			ing, err := f.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Get("testingress", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting ingress: %v", err)
+			}
+			var hosts []string
+			for _, rule := range ing.Spec.Rules {
+				hosts = append(hosts, rule.Host)
+			}
+			if expected := []string{"concurrent-1.example.com", "example.com"}; !reflect.DeepEqual(hosts, expected) {
+				t.Errorf("expected concurrent change to be retained alongside the challenge rule, expected hosts %v but got %v", expected, hosts)
+			}
+		})
+	}
+}
+
+func TestAddChallengePathToIngressOptIn(t *testing.T) {
+	tests := map[string]struct {
+		requireOptIn bool
+		annotations  map[string]string
+		expectErr    bool
+	}{
+		"should modify an ingress that has not opted in if opt in is not required": {},
+		"should refuse to modify an ingress that has not opted in": {
+			requireOptIn: true,
+			expectErr:    true,
+		},
+		"should refuse to modify an ingress with the allow annotation set to false": {
+			requireOptIn: true,
+			annotations:  map[string]string{cmacme.IngressAllowPathInjectionAnnotationKey: "false"},
+			expectErr:    true,
+		},
+		"should modify an ingress with the allow annotation": {
+			requireOptIn: true,
+			annotations:  map[string]string{cmacme.IngressAllowPathInjectionAnnotationKey: "true"},
+		},
+		"should modify an ingress with the edit in place annotation": {
+			requireOptIn: true,
+			annotations:  map[string]string{cmacme.IngressEditInPlaceAnnotationKey: "true"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := solverFixture{
+				Builder: &test.Builder{
+					KubeObjects: []runtime.Object{
+						fakeSolverService(),
+						&v1beta1.Ingress{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:        "testingress",
+								Namespace:   defaultTestNamespace,
+								Annotations: tc.annotations,
+							},
+						},
+					},
+				},
+				Challenge: &cmacme.Challenge{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testchal",
+						Namespace: defaultTestNamespace,
+					},
+					Spec: cmacme.ChallengeSpec{
+						DNSName: "example.com",
+						Token:   "abcd",
+						Solver: &cmacme.ACMEChallengeSolver{
+							HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+								Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+									Name: "testingress",
+								},
+							},
+						},
+					},
+				},
+				PreFn: func(t *testing.T, s *solverFixture) {
+					s.Solver.requireIngressOptIn = tc.requireOptIn
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			_, _, err := f.Solver.addChallengePathToIngress(context.TODO(), f.Challenge, "fakeservice")
+			if tc.expectErr {
+				if err == nil || !strings.Contains(err.Error(), cmacme.IngressAllowPathInjectionAnnotationKey) {
+					t.Errorf("expected an error naming the %q annotation but got: %v", cmacme.IngressAllowPathInjectionAnnotationKey, err)
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error adding challenge path: %v", err)
+			}
+			ing, err := f.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Get("testingress", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting ingress: %v", err)
+			}
+			if modified := len(ing.Spec.Rules) != 0; modified == tc.expectErr {
+				t.Errorf("expected ingress to be modified %t but got rules %+v", !tc.expectErr, ing.Spec.Rules)
+			}
+		})
+	}
+}
+
+func TestIsChallengePresented(t *testing.T) {
+	ch := &cmacme.Challenge{
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "www.example.com",
+			Token:   "abcd",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+						ExtraPathPrefixes: []string{"/extra"},
+					},
+				},
+			},
+		},
+	}
+	ingressWithRule := func(host string, paths ...v1beta1.HTTPIngressPath) *v1beta1.Ingress {
+		return &v1beta1.Ingress{
+			Spec: v1beta1.IngressSpec{
+				Rules: []v1beta1.IngressRule{
+					{
+						Host: host,
+						IngressRuleValue: v1beta1.IngressRuleValue{
+							HTTP: &v1beta1.HTTPIngressRuleValue{Paths: paths},
+						},
+					},
+				},
+			},
+		}
+	}
+	path := func(path, svcName string, port intstr.IntOrString) v1beta1.HTTPIngressPath {
+		return v1beta1.HTTPIngressPath{
+			Path: path,
+			Backend: v1beta1.IngressBackend{
+				ServiceName: svcName,
+				ServicePort: port,
+			},
+		}
+	}
+	solverPort := intstr.FromInt(acmeSolverListenPort)
+	tests := map[string]struct {
+		ing      *v1beta1.Ingress
+		expected bool
+	}{
+		"should match an ingress routing all challenge paths to the service": {
+			ing: ingressWithRule("www.example.com",
+				path("/", "app", intstr.FromInt(80)),
+				path(ChallengePath("abcd"), "solver", solverPort),
+				path("/extra/abcd", "solver", solverPort)),
+			expected: true,
+		},
+		"should match a rule host in a different case with a trailing dot": {
+			ing: ingressWithRule("WWW.example.com.",
+				path(ChallengePath("abcd"), "solver", solverPort),
+				path("/extra/abcd", "solver", solverPort)),
+			expected: true,
+		},
+		"should match a wildcard rule host": {
+			ing: ingressWithRule("*.example.com",
+				path(ChallengePath("abcd"), "solver", solverPort),
+				path("/extra/abcd", "solver", solverPort)),
+			expected: true,
+		},
+		"should not match if a path is missing": {
+			ing: ingressWithRule("www.example.com",
+				path(ChallengePath("abcd"), "solver", solverPort)),
+		},
+		"should not match a different host": {
+			ing: ingressWithRule("example.com",
+				path(ChallengePath("abcd"), "solver", solverPort),
+				path("/extra/abcd", "solver", solverPort)),
+		},
+		"should not match a different service": {
+			ing: ingressWithRule("www.example.com",
+				path(ChallengePath("abcd"), "other", solverPort),
+				path("/extra/abcd", "other", solverPort)),
+		},
+		"should not match a different service port": {
+			ing: ingressWithRule("www.example.com",
+				path(ChallengePath("abcd"), "solver", intstr.FromInt(80)),
+				path("/extra/abcd", "solver", intstr.FromInt(80))),
+		},
+		"should not match a nil ingress": {},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := &Solver{Context: &controller.Context{}}
+			if got := s.IsChallengePresented(ch, "solver", tc.ing); got != tc.expected {
+				t.Errorf("expected %t but got %t", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestFindToken(t *testing.T) {
+	f := solverFixture{
+		Builder: &test.Builder{
+			KubeObjects: []runtime.Object{
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "existing",
+						Namespace: defaultTestNamespace,
+					},
+					Spec: v1beta1.IngressSpec{
+						Rules: []v1beta1.IngressRule{
+							{
+								Host: "other.example.com",
+								IngressRuleValue: v1beta1.IngressRuleValue{
+									HTTP: &v1beta1.HTTPIngressRuleValue{
+										Paths: []v1beta1.HTTPIngressPath{
+											{
+												Path: ChallengePath("existing-token"),
+												Backend: v1beta1.IngressBackend{
+													ServiceName: "fakeservice",
+													ServicePort: intstr.FromInt(acmeSolverListenPort),
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Challenge: &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "solver-token",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+					},
+				},
+			},
+		},
+		PreFn: func(t *testing.T, s *solverFixture) {
+			ing, err := s.Solver.createIngress(context.TODO(), s.Challenge, "fakeservice")
+			if err != nil {
+				t.Fatalf("error preparing test: %v", err)
+			}
+			s.testResources["solverIngress"] = ing.Name
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	tests := map[string]struct {
+		token   string
+		ingress string
+		host    string
+		err     bool
+	}{
+		"should find a token on a solver ingress": {
+			token:   "solver-token",
+			ingress: f.testResources["solverIngress"].(string),
+			host:    "example.com",
+		},
+		"should not find a token on an ingress that is not a solver ingress": {
+			token: "existing-token",
+			err:   true,
+		},
+		"should not find a token that is only part of a challenge path": {
+			token: "solver-tok",
+			err:   true,
+		},
+		"should return an error for an unknown token": {
+			token: "unknown-token",
+			err:   true,
+		},
+		"should return an error for an empty token": {
+			token: "",
+			err:   true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ing, host, err := f.Solver.FindToken(defaultTestNamespace, tc.token)
+			if tc.err {
+				if err == nil {
+					t.Errorf("expected an error but found ingress %v", ing)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ing.Name != tc.ingress || host != tc.host {
+				t.Errorf("expected token on ingress %q with host %q but got %q with host %q", tc.ingress, tc.host, ing.Name, host)
+			}
+		})
+	}
+}
+
+func TestActiveChallenges(t *testing.T) {
+	f := solverFixture{
+		Builder: &test.Builder{
+			KubeObjects: []runtime.Object{
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "existing",
+						Namespace: defaultTestNamespace,
+					},
+				},
+			},
+		},
+		Challenge: &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "solver-token",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+					},
+				},
+			},
+		},
+		PreFn: func(t *testing.T, s *solverFixture) {
+			ing, err := s.Solver.createIngress(context.TODO(), s.Challenge, "fakeservice")
+			if err != nil {
+				t.Fatalf("error preparing test: %v", err)
+			}
+			s.testResources["solverIngress"] = ing.Name
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	active, err := f.Solver.ActiveChallenges()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []ActiveChallenge{
+		{
+			Namespace: defaultTestNamespace,
+			Ingress:   f.testResources["solverIngress"].(string),
+			Challenge: "testchal",
+			Domains:   []string{"example.com"},
+		},
+	}
+	if !reflect.DeepEqual(expected, active) {
+		t.Errorf("expected active challenges %+v but got %+v", expected, active)
+	}
+}
+
+func TestIngressMissingSolverConfig(t *testing.T) {
+	tests := map[string]*cmacme.ACMEChallengeSolver{
+		"no solver":                     nil,
+		"solver without HTTP01 config":  {DNS01: &cmacme.ACMEChallengeSolverDNS01{}},
+		"HTTP01 solver without ingress": {HTTP01: &cmacme.ACMEChallengeSolverHTTP01{}},
+	}
+	for name, solver := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := solverFixture{
+				Challenge: &cmacme.Challenge{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testchal",
+						Namespace: defaultTestNamespace,
+						UID:       "test-uid",
+					},
+					Spec: cmacme.ChallengeSpec{
+						DNSName: "example.com",
+						Token:   "abcd",
+						Solver:  solver,
+					},
+				},
+				// the challenge was presented before its ingress config was
+				// removed
+				PreFn: func(t *testing.T, s *solverFixture) {
+					presented := s.Challenge.DeepCopy()
+					presented.Spec.Solver = &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+						},
+					}
+					if _, err := s.Solver.createIngress(context.TODO(), presented, "fakeservice"); err != nil {
+						t.Fatalf("error preparing test: %v", err)
+					}
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			if _, _, err := f.Solver.ensureIngress(context.TODO(), f.Challenge, "fakeservice"); err == nil {
+				t.Errorf("expected an error ensuring an ingress without an HTTP01 ingress config")
+			}
+			result, err := f.Solver.CleanupIngresses(context.TODO(), f.Challenge)
+			if err != nil {
+				t.Fatalf("unexpected error cleaning up ingresses without an HTTP01 ingress config: %v", err)
+			}
+			if result.Deleted != 1 {
+				t.Errorf("expected the solver ingress to be cleaned up but got result %+v", result)
+			}
+		})
+	}
+}
+
+func TestAddChallengePathToIngressUnchanged(t *testing.T) {
+	f := solverFixture{
+		Builder: &test.Builder{
+			KubeObjects: []runtime.Object{
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testingress",
+						Namespace: defaultTestNamespace,
+					},
+					Spec: v1beta1.IngressSpec{
+						Rules: []v1beta1.IngressRule{
+							{
+								Host: "example.com",
+								IngressRuleValue: v1beta1.IngressRuleValue{
+									HTTP: &v1beta1.HTTPIngressRuleValue{
+										Paths: []v1beta1.HTTPIngressPath{
+											ChallengeIngressPath("abcd", "fakeservice", acmeSolverListenPort),
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Challenge: &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+							Name: "testingress",
+						},
+					},
+				},
+			},
+		},
+		PreFn: func(t *testing.T, s *solverFixture) {
+			s.Builder.FakeKubeClient().PrependReactor("update", "ingresses", func(action coretesting.Action) (bool, runtime.Object, error) {
+				t.Errorf("ingress should not be updated if the challenge path is already present")
+				return false, nil, nil
+			})
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	ing, action, err := f.Solver.addChallengePathToIngress(context.TODO(), f.Challenge, "fakeservice")
+	if err != nil {
+		t.Fatalf("unexpected error adding challenge path: %v", err)
+	}
+	if action != IngressActionNone {
+		t.Errorf("expected action %q but got %q", IngressActionNone, action)
+	}
+	if ing == nil || ing.Name != "testingress" {
+		t.Errorf("expected the unchanged ingress to be returned but got %v", ing)
+	}
+}
+
+func TestAddChallengePathToIngressNormalizedHost(t *testing.T) {
+	test := solverFixture{
+		Builder: &test.Builder{
+			KubeObjects: []runtime.Object{
+				fakeSolverService(),
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testingress",
+						Namespace: defaultTestNamespace,
+					},
+					Spec: v1beta1.IngressSpec{
+						Rules: []v1beta1.IngressRule{
+							{
+								Host: "Example.com.",
+								IngressRuleValue: v1beta1.IngressRuleValue{
+									HTTP: &v1beta1.HTTPIngressRuleValue{
+										Paths: []v1beta1.HTTPIngressPath{
+											{
+												Path: "/",
+												Backend: v1beta1.IngressBackend{
+													ServiceName: "real-backend-svc",
+													ServicePort: intstr.FromInt(8080),
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Challenge: &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "EXAMPLE.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+							Name: "testingress",
+						},
+					},
+				},
+			},
+		},
+		CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+			ing := args[0].(*v1beta1.Ingress)
+			if len(ing.Spec.Rules) != 1 {
+				t.Fatalf("expected the existing rule to be reused but got %d rules", len(ing.Spec.Rules))
+			}
+			var paths []string
+			for _, p := range ing.Spec.Rules[0].HTTP.Paths {
+				paths = append(paths, p.Path)
+			}
+			expected := []string{"/.well-known/acme-challenge/abcd", "/"}
+			if !reflect.DeepEqual(expected, paths) {
+				t.Errorf("expected paths %v but got %v", expected, paths)
+			}
+		},
+	}
+	test.Setup(t)
+	ing, _, err := test.Solver.addChallengePathToIngress(context.TODO(), test.Challenge, "fakeservice")
+	if err != nil {
+		t.Errorf("Expected function to not error, but got: %v", err)
+	}
+	test.Finish(t, ing, err)
+}
+
+func TestAddChallengePathToIngressRegexPaths(t *testing.T) {
+	test := solverFixture{
+		Builder: &test.Builder{
+			KubeObjects: []runtime.Object{
+				fakeSolverService(),
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testingress",
+						Namespace: defaultTestNamespace,
+					},
+					Spec: v1beta1.IngressSpec{
+						Rules: []v1beta1.IngressRule{
+							{
+								Host: "example.com",
+								IngressRuleValue: v1beta1.IngressRuleValue{
+									HTTP: &v1beta1.HTTPIngressRuleValue{
+										Paths: []v1beta1.HTTPIngressPath{
+											{
+												Path: "/",
+												Backend: v1beta1.IngressBackend{
+													ServiceName: "real-backend-svc",
+													ServicePort: intstr.FromInt(8080),
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Challenge: &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "ab.cd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+							Name:              "testingress",
+							ExtraPathPrefixes: []string{"/acme-challenge"},
+						},
+					},
+				},
+			},
+		},
+		PreFn: func(t *testing.T, s *solverFixture) {
+			s.Solver.ACMEOptions.HTTP01SolverRegexPaths = true
+		},
+		CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+			ing := args[0].(*v1beta1.Ingress)
+			var paths []string
+			for _, p := range ing.Spec.Rules[0].HTTP.Paths {
+				paths = append(paths, p.Path)
+			}
+			expected := []string{`^/\.well-known/acme-challenge/ab\.cd$`, `^/acme-challenge/ab\.cd$`, "/"}
+			if !reflect.DeepEqual(expected, paths) {
+				t.Errorf("expected paths %v but got %v", expected, paths)
+			}
+		},
+	}
+	test.Setup(t)
+	ing, _, err := test.Solver.addChallengePathToIngress(context.TODO(), test.Challenge, "fakeservice")
+	if err != nil {
+		t.Errorf("Expected function to not error, but got: %v", err)
+	}
+	test.Finish(t, ing, err)
+}
+
+func TestChallengePathSuffix(t *testing.T) {
+	tests := map[string]struct {
+		suffix   string
+		regex    bool
+		expected []string
+	}{
+		"should append the suffix to plain paths": {
+			suffix:   "/*",
+			expected: []string{"/.well-known/acme-challenge/abcd/*", "/"},
+		},
+		"should add the suffix before the anchor of regex paths": {
+			suffix:   "(/|$)(.*)",
+			regex:    true,
+			expected: []string{`^/\.well-known/acme-challenge/abcd(/|$)(.*)$`, "/"},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := solverFixture{
+				Builder: &test.Builder{
+					KubeObjects: []runtime.Object{
+						fakeSolverService(),
+						&v1beta1.Ingress{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "testingress",
+								Namespace: defaultTestNamespace,
+							},
+							Spec: v1beta1.IngressSpec{
+								Rules: []v1beta1.IngressRule{
+									{
+										Host: "example.com",
+										IngressRuleValue: v1beta1.IngressRuleValue{
+											HTTP: &v1beta1.HTTPIngressRuleValue{
+												Paths: []v1beta1.HTTPIngressPath{
+													{
+														Path: "/",
+														Backend: v1beta1.IngressBackend{
+															ServiceName: "real-backend-svc",
+															ServicePort: intstr.FromInt(8080),
+														},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+				Challenge: &cmacme.Challenge{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testchal",
+						Namespace: defaultTestNamespace,
+					},
+					Spec: cmacme.ChallengeSpec{
+						DNSName: "example.com",
+						Token:   "abcd",
+						Solver: &cmacme.ACMEChallengeSolver{
+							HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+								Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+									Name: "testingress",
+								},
+							},
+						},
+					},
+				},
+				PreFn: func(t *testing.T, s *solverFixture) {
+					s.Solver.ACMEOptions.HTTP01SolverPathSuffix = tc.suffix
+					s.Solver.ACMEOptions.HTTP01SolverRegexPaths = tc.regex
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+			paths := func(ing *v1beta1.Ingress) []string {
+				var paths []string
+				for _, p := range ing.Spec.Rules[0].HTTP.Paths {
+					paths = append(paths, p.Path)
+				}
+				return paths
+			}
+
+			ing, _, err := f.Solver.addChallengePathToIngress(context.TODO(), f.Challenge, "fakeservice")
+			if err != nil {
+				t.Fatalf("unexpected error adding challenge path: %v", err)
+			}
+			if !reflect.DeepEqual(tc.expected, paths(ing)) {
+				t.Errorf("expected paths %v but got %v", tc.expected, paths(ing))
+			}
+			f.Builder.Sync()
+
+			if err := f.Solver.cleanupIngresses(context.TODO(), f.Challenge); err != nil {
+				t.Fatalf("unexpected error cleaning up challenge paths: %v", err)
+			}
+			ing, err = f.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Get("testingress", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting ingress: %v", err)
+			}
+			if expected := []string{"/"}; !reflect.DeepEqual(expected, paths(ing)) {
+				t.Errorf("expected suffixed challenge path to be cleaned up leaving %v but got %v", expected, paths(ing))
+			}
+		})
+	}
+}
+
+func TestBuildIngressResourceTemplate(t *testing.T) {
+	backend := func(name string) v1beta1.IngressBackend {
+		return v1beta1.IngressBackend{ServiceName: name, ServicePort: intstr.FromInt(80)}
+	}
+	challengePath := v1beta1.HTTPIngressPath{
+		Path:    "/.well-known/acme-challenge/abcd",
+		Backend: v1beta1.IngressBackend{ServiceName: "fakeservice", ServicePort: intstr.FromInt(acmeSolverListenPort)},
+	}
+	tmpl := &cmacme.ACMEChallengeSolverHTTP01IngressTemplate{
+		ACMEChallengeSolverHTTP01IngressObjectMeta: cmacme.ACMEChallengeSolverHTTP01IngressObjectMeta{
+			Labels: map[string]string{
+				"team":                   "platform",
+				challengeLabelKey:        "overridden",
+				"acme.example.com/other": "label",
+			},
+			Annotations: map[string]string{
+				"nginx.ingress.kubernetes.io/whitelist-source-range": "10.0.0.0/8",
+			},
+		},
+		Spec: &v1beta1.IngressSpec{
+			Backend: &v1beta1.IngressBackend{ServiceName: "default-backend", ServicePort: intstr.FromInt(80)},
+			TLS:     []v1beta1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: "example-tls"}},
+			Rules: []v1beta1.IngressRule{
+				{
+					Host: "other.example.com",
+					IngressRuleValue: v1beta1.IngressRuleValue{
+						HTTP: &v1beta1.HTTPIngressRuleValue{
+							Paths: []v1beta1.HTTPIngressPath{{Path: "/", Backend: backend("other")}},
+						},
+					},
+				},
+				{
+					Host: "EXAMPLE.com",
+					IngressRuleValue: v1beta1.IngressRuleValue{
+						HTTP: &v1beta1.HTTPIngressRuleValue{
+							Paths: []v1beta1.HTTPIngressPath{
+								{Path: "/", Backend: backend("app")},
+								{Path: challengePath.Path, Backend: backend("app")},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	f := solverFixture{
+		Challenge: &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+							IngressTemplate: tmpl,
+						},
+					},
+				},
+			},
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	ing, err := f.Solver.createIngress(context.TODO(), f.Challenge, "fakeservice")
+	if err != nil {
+		t.Fatalf("unexpected error creating ingress: %v", err)
+	}
+
+	if ing.Labels["team"] != "platform" || ing.Labels["acme.example.com/other"] != "label" {
+		t.Errorf("expected template labels to be added but got %v", ing.Labels)
+	}
+	if ing.Labels[challengeLabelKey] == "overridden" {
+		t.Errorf("expected in-built label %q not to be overridden", challengeLabelKey)
+	}
+	if v := ing.Annotations["nginx.ingress.kubernetes.io/whitelist-source-range"]; v != "10.0.0.0/8" {
+		t.Errorf("expected template annotation to override in-built annotation but got %q", v)
+	}
+	if !reflect.DeepEqual(ing.Spec.Backend, tmpl.Spec.Backend) || !reflect.DeepEqual(ing.Spec.TLS, tmpl.Spec.TLS) {
+		t.Errorf("expected template backend and TLS to be retained but got %v and %v", ing.Spec.Backend, ing.Spec.TLS)
+	}
+	expectedRules := []v1beta1.IngressRule{
+		tmpl.Spec.Rules[0],
+		{
+			Host: "example.com",
+			IngressRuleValue: v1beta1.IngressRuleValue{
+				HTTP: &v1beta1.HTTPIngressRuleValue{
+					Paths: []v1beta1.HTTPIngressPath{challengePath, {Path: "/", Backend: backend("app")}},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(ing.Spec.Rules, expectedRules) {
+		t.Errorf("unexpected rules on ingress\nexp=%+v\ngot=%+v", expectedRules, ing.Spec.Rules)
+	}
+	if tmpl.Spec.Rules[1].HTTP.Paths[0].Path != "/" || len(tmpl.Spec.Rules[1].HTTP.Paths) != 2 {
+		t.Errorf("expected the template not to be modified but got %+v", tmpl.Spec.Rules[1])
+	}
+
+	// without a rule for the challenged domain, one is added to the template
+	tmpl.Spec.Rules = tmpl.Spec.Rules[:1]
+	ing, err = f.Solver.buildIngressResource(f.Challenge, "fakeservice")
+	if err != nil {
+		t.Fatalf("unexpected error building ingress: %v", err)
+	}
+	if len(ing.Spec.Rules) != 2 || ing.Spec.Rules[1].Host != "example.com" || !reflect.DeepEqual(ing.Spec.Rules[1].HTTP.Paths, []v1beta1.HTTPIngressPath{challengePath}) {
+		t.Errorf("expected a rule for the challenge to be added to the template rules but got %+v", ing.Spec.Rules)
+	}
+}
+
+func TestBuildIngressResourceSolverDefaultBackend(t *testing.T) {
+	challengePath := v1beta1.HTTPIngressPath{
+		Path:    "/.well-known/acme-challenge/abcd",
+		Backend: v1beta1.IngressBackend{ServiceName: "fakeservice", ServicePort: intstr.FromInt(acmeSolverListenPort)},
+	}
+	tests := map[string]struct {
+		cfg     *cmacme.ACMEChallengeSolverHTTP01Ingress
+		backend *v1beta1.IngressBackend
+	}{
+		"should not set a default backend by default": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+		},
+		"should set the default backend to the solver service": {
+			cfg:     &cmacme.ACMEChallengeSolverHTTP01Ingress{SolverDefaultBackend: true},
+			backend: &challengePath.Backend,
+		},
+		"should set the default backend alongside an ingress template": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+				SolverDefaultBackend: true,
+				IngressTemplate: &cmacme.ACMEChallengeSolverHTTP01IngressTemplate{
+					Spec: &v1beta1.IngressSpec{
+						TLS: []v1beta1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: "example-tls"}},
+					},
+				},
+			},
+			backend: &challengePath.Backend,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := solverFixture{
+				Challenge: &cmacme.Challenge{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testchal",
+						Namespace: defaultTestNamespace,
+					},
+					Spec: cmacme.ChallengeSpec{
+						DNSName: "example.com",
+						Token:   "abcd",
+						Solver: &cmacme.ACMEChallengeSolver{
+							HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+								Ingress: tc.cfg,
+							},
+						},
+					},
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			ing, err := f.Solver.buildIngressResource(f.Challenge, "fakeservice")
+			if err != nil {
+				t.Fatalf("unexpected error building ingress: %v", err)
+			}
+			if !reflect.DeepEqual(tc.backend, ing.Spec.Backend) {
+				t.Errorf("expected default backend %+v but got %+v", tc.backend, ing.Spec.Backend)
+			}
+			expectedRules := []v1beta1.IngressRule{
+				{
+					Host: "example.com",
+					IngressRuleValue: v1beta1.IngressRuleValue{
+						HTTP: &v1beta1.HTTPIngressRuleValue{
+							Paths: []v1beta1.HTTPIngressPath{challengePath},
+						},
+					},
+				},
+			}
+			if !reflect.DeepEqual(expectedRules, ing.Spec.Rules) {
+				t.Errorf("unexpected rules on ingress\nexp=%+v\ngot=%+v", expectedRules, ing.Spec.Rules)
+			}
+		})
+	}
+}
+
+func TestSolverPathFn(t *testing.T) {
+	tests := map[string]struct {
+		pathFn       func(string, string) string
+		pathTemplate string
+		expected     string
+	}{
+		"should use the default challenge path": {
+			expected: "/.well-known/acme-challenge/abcd",
+		},
+		"should use the path function of the solver": {
+			pathFn:   func(domain, token string) string { return "/acme/" + domain + "/" + token },
+			expected: "/acme/example.com/abcd",
+		},
+		"should use the path template of the challenge over the path function": {
+			pathFn:       func(_, token string) string { return "/acme/" + token },
+			pathTemplate: "/cdn/{domain}/challenge/{token}",
+			expected:     "/cdn/example.com/challenge/abcd",
+		},
+	}
+	for name, tc := range tests {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			// each solver has its own path function, so these may run in
+			// parallel
+			t.Parallel()
+			f := solverFixture{
+				Builder: &test.Builder{
+					KubeObjects: []runtime.Object{
+						fakeSolverService(),
+						&v1beta1.Ingress{
+							ObjectMeta: metav1.ObjectMeta{
+								Name:      "testingress",
+								Namespace: defaultTestNamespace,
+							},
+							Spec: v1beta1.IngressSpec{
+								Rules: []v1beta1.IngressRule{{Host: "example.com"}},
+							},
+						},
+					},
+				},
+				Challenge: &cmacme.Challenge{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testchal",
+						Namespace: defaultTestNamespace,
+					},
+					Spec: cmacme.ChallengeSpec{
+						DNSName: "example.com",
+						Token:   "abcd",
+						Solver: &cmacme.ACMEChallengeSolver{
+							HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+								Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+									Name:         "testingress",
+									PathTemplate: tc.pathTemplate,
+								},
+							},
+						},
+					},
+				},
+				PreFn: func(t *testing.T, s *solverFixture) {
+					if tc.pathFn != nil {
+						s.Solver.pathFn = tc.pathFn
+					}
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			if err := f.Solver.validateChallengePath(f.Challenge); err != nil {
+				t.Fatalf("unexpected error validating challenge path: %v", err)
+			}
+			ing, _, err := f.Solver.addChallengePathToIngress(context.TODO(), f.Challenge, "fakeservice")
+			if err != nil {
+				t.Fatalf("unexpected error adding challenge path: %v", err)
+			}
+			if paths := ing.Spec.Rules[0].HTTP.Paths; len(paths) != 1 || paths[0].Path != tc.expected {
+				t.Errorf("expected challenge path %q but got %+v", tc.expected, paths)
+			}
+			if u := f.Solver.buildChallengeUrl(f.Challenge); u.Path != tc.expected {
+				t.Errorf("expected self check path %q but got %q", tc.expected, u.Path)
+			}
+			f.Builder.Sync()
+
+			if err := f.Solver.cleanupIngresses(context.TODO(), f.Challenge); err != nil {
+				t.Fatalf("unexpected error cleaning up challenge paths: %v", err)
+			}
+			ing, err = f.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Get("testingress", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting ingress: %v", err)
+			}
+			for _, rule := range ing.Spec.Rules {
+				if rule.HTTP != nil && len(rule.HTTP.Paths) != 0 {
+					t.Errorf("expected challenge path to be cleaned up but got %+v", rule.HTTP.Paths)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateChallengePath(t *testing.T) {
+	tests := map[string]struct {
+		pathFn    func(string, string) string
+		expectErr bool
+	}{
+		"should accept a path ending in the token": {
+			pathFn: func(_, token string) string { return "/acme/" + token },
+		},
+		"should reject a path that does not end in the token": {
+			pathFn:    func(_, token string) string { return "/acme/" + token + "/index.html" },
+			expectErr: true,
+		},
+		"should reject a relative path": {
+			pathFn:    func(_, token string) string { return "acme/" + token },
+			expectErr: true,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := &Solver{pathFn: tc.pathFn}
+			ch := &cmacme.Challenge{
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Token:   "abcd",
+				},
+			}
+			err := s.validateChallengePath(ch)
+			if tc.expectErr != (err != nil) {
+				t.Errorf("expected error %t but got: %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestBuildIngressResourceBackendProtocol(t *testing.T) {
+	tests := map[string]struct {
+		class    *string
+		expected map[string]string
+		absent   []string
+	}{
+		"should default to an HTTP backend for ingress-nginx if no class is set": {
+			expected: map[string]string{"nginx.ingress.kubernetes.io/backend-protocol": "HTTP"},
+		},
+		"should set the backend protocol annotation for a known class": {
+			class:    strPtr("alb"),
+			expected: map[string]string{"alb.ingress.kubernetes.io/backend-protocol": "HTTP"},
+			absent:   []string{"nginx.ingress.kubernetes.io/backend-protocol"},
+		},
+		"should not set a backend protocol annotation for an unknown class": {
+			class: strPtr("custom"),
+			absent: []string{
+				"nginx.ingress.kubernetes.io/backend-protocol",
+				"alb.ingress.kubernetes.io/backend-protocol",
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ch := &cmacme.Challenge{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testchal",
+					Namespace: defaultTestNamespace,
+				},
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Token:   "abcd",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+								Class: tc.class,
+							},
+						},
+					},
+				},
+			}
+			f := solverFixture{Challenge: ch}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			ing, err := f.Solver.buildIngressResource(ch, "fakeservice")
+			if err != nil {
+				t.Fatalf("unexpected error building ingress: %v", err)
+			}
+			for k, v := range tc.expected {
+				if ing.Annotations[k] != v {
+					t.Errorf("expected annotation %s=%q but got annotations %v", k, v, ing.Annotations)
+				}
+			}
+			for _, k := range tc.absent {
+				if _, ok := ing.Annotations[k]; ok {
+					t.Errorf("expected annotation %s to not be set but got annotations %v", k, ing.Annotations)
+				}
+			}
+		})
+	}
+}
+
+func TestExistingIngressCacheNotMutated(t *testing.T) {
+	existingIngress := func(paths ...v1beta1.HTTPIngressPath) *v1beta1.Ingress {
+		return &v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testingress",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: v1beta1.IngressSpec{
+				Rules: []v1beta1.IngressRule{
+					{
+						Host: "example.com",
+						IngressRuleValue: v1beta1.IngressRuleValue{
+							HTTP: &v1beta1.HTTPIngressRuleValue{Paths: paths},
+						},
+					},
+				},
+			},
+		}
+	}
+	backendPath := v1beta1.HTTPIngressPath{
+		Path: "/",
+		Backend: v1beta1.IngressBackend{
+			ServiceName: "real-backend-svc",
+			ServicePort: intstr.FromInt(8080),
+		},
+	}
+	chal := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Token:   "abcd",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+						Name: "testingress",
+					},
+				},
+			},
+		},
+	}
+	failUpdates := func(t *testing.T, s *solverFixture) {
+		for _, verb := range []string{"update", "patch"} {
+			s.Builder.FakeKubeClient().PrependReactor(verb, "ingresses", func(action coretesting.Action) (bool, runtime.Object, error) {
+				return true, nil, fmt.Errorf("simulated update error")
+			})
+		}
+	}
+
+	tests := map[string]struct {
+		ingress *v1beta1.Ingress
+		fn      func(*Solver) error
+	}{
+		"adding the challenge path should not modify the cached ingress": {
+			ingress: existingIngress(backendPath),
+			fn: func(s *Solver) error {
+				_, _, err := s.addChallengePathToIngress(context.TODO(), chal, "fakeservice")
+				return err
+			},
+		},
+		"removing the challenge path should not modify the cached ingress": {
+			ingress: existingIngress(ChallengeIngressPath("abcd", "fakeservice", acmeSolverListenPort), backendPath),
+			fn: func(s *Solver) error {
+				return s.cleanupIngresses(context.TODO(), chal)
+			},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := solverFixture{
+				Builder: &test.Builder{
+					KubeObjects: []runtime.Object{tc.ingress},
+				},
+				Challenge: chal,
+				PreFn:     failUpdates,
+			}
+			f.Setup(t)
+			if err := tc.fn(f.Solver); err == nil {
+				t.Errorf("expected the simulated update error to be returned")
+			}
+			cached, err := f.Solver.ingressLister.Ingresses(defaultTestNamespace).Get("testingress")
+			if err != nil {
+				t.Fatalf("error getting ingress from lister: %v", err)
+			}
+			if !reflect.DeepEqual(cached.Spec, tc.ingress.Spec) {
+				t.Errorf("cached ingress was modified: %v", diff.ObjectDiff(tc.ingress.Spec, cached.Spec))
+			}
+			f.Finish(t)
+		})
+	}
+}
+
+func TestNamedServicePort(t *testing.T) {
+	f := solverFixture{
+		Builder: &test.Builder{
+			KubeObjects: []runtime.Object{
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testingress",
+						Namespace: defaultTestNamespace,
+					},
+				},
+			},
+		},
+		Challenge: &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+							Name: "testingress",
+						},
+					},
+				},
+			},
+		},
+		PreFn: func(t *testing.T, s *solverFixture) {
+			s.Solver.servicePortName = "acme-http"
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	if err := f.Solver.Present(context.TODO(), nil, f.Challenge); err != nil {
+		t.Fatalf("unexpected error presenting challenge: %v", err)
+	}
+	f.Builder.Sync()
+
+	svcs, err := f.Solver.getServicesForChallenge(context.TODO(), f.Challenge)
+	if err != nil || len(svcs) != 1 {
+		t.Fatalf("expected one solver service but got %d: %v", len(svcs), err)
+	}
+	if name := svcs[0].Spec.Ports[0].Name; name != "acme-http" {
+		t.Errorf("expected solver service port to be named %q but got %q", "acme-http", name)
+	}
+	ing, err := f.Solver.ingressLister.Ingresses(defaultTestNamespace).Get("testingress")
+	if err != nil {
+		t.Fatalf("error getting ingress: %v", err)
+	}
+	if port := ing.Spec.Rules[0].HTTP.Paths[0].Backend.ServicePort; port != intstr.FromString("acme-http") {
+		t.Errorf("expected ingress path to reference the service port by name but got %v", port)
+	}
+
+	if err := f.Solver.CleanUp(context.TODO(), nil, f.Challenge); err != nil {
+		t.Fatalf("unexpected error cleaning up challenge: %v", err)
+	}
+	f.Builder.Sync()
+	ing, err = f.Solver.ingressLister.Ingresses(defaultTestNamespace).Get("testingress")
+	if err != nil {
+		t.Fatalf("error getting ingress: %v", err)
+	}
+	if len(ing.Spec.Rules) != 0 {
+		t.Errorf("expected challenge path to be cleaned up but got rules %v", ing.Spec.Rules)
+	}
+}
+
+func TestChallengeIngressPath(t *testing.T) {
+	expected := v1beta1.HTTPIngressPath{
+		Path: "/.well-known/acme-challenge/abcd",
+		Backend: v1beta1.IngressBackend{
+			ServiceName: "fakeservice",
+			ServicePort: intstr.FromInt(8089),
+		},
+	}
+	if path := ChallengePath("abcd"); path != expected.Path {
+		t.Errorf("expected path %q but got %q", expected.Path, path)
+	}
+	if ingPath := ChallengeIngressPath("abcd", "fakeservice", 8089); !reflect.DeepEqual(expected, ingPath) {
+		t.Errorf("expected did not match actual: %v", diff.ObjectDiff(expected, ingPath))
+	}
+}
+
+func TestSolverIngressAddress(t *testing.T) {
+	namedIngress := func(lbs ...corev1.LoadBalancerIngress) *v1beta1.Ingress {
+		return &v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testingress",
+				Namespace: defaultTestNamespace,
+			},
+			Status: v1beta1.IngressStatus{
+				LoadBalancer: corev1.LoadBalancerStatus{Ingress: lbs},
+			},
+		}
+	}
+	namedChallenge := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Token:   "abcd",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+						Name: "testingress",
+					},
+				},
+			},
+		},
+	}
+	expectAddress := func(expected string) func(*testing.T, *solverFixture, ...interface{}) {
+		return func(t *testing.T, s *solverFixture, args ...interface{}) {
+			if addr := args[0].(string); addr != expected {
+				t.Errorf("expected address %q but got %q", expected, addr)
+			}
+		}
+	}
+
+	tests := map[string]solverFixture{
+		"should return the load balancer IP of a named ingress": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{namedIngress(corev1.LoadBalancerIngress{IP: "10.0.0.1"})},
+			},
+			Challenge: namedChallenge,
+			CheckFn:   expectAddress("10.0.0.1"),
+		},
+		"should bracket an IPv6 load balancer IP": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{namedIngress(corev1.LoadBalancerIngress{IP: "fd00::1"})},
+			},
+			Challenge: namedChallenge,
+			CheckFn:   expectAddress("[fd00::1]"),
+		},
+		"should return the load balancer hostname of a named ingress": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{namedIngress(corev1.LoadBalancerIngress{Hostname: "lb.example.com"})},
+			},
+			Challenge: namedChallenge,
+			CheckFn:   expectAddress("lb.example.com"),
+		},
+		"should error if the named ingress has no load balancer address": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{namedIngress()},
+			},
+			Challenge: namedChallenge,
+			Err:       true,
+		},
+		"should error if the solver ingress has no load balancer address": {
+			Challenge: &cmacme.Challenge{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testchal",
+					Namespace: defaultTestNamespace,
+				},
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Token:   "abcd",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+						},
+					},
+				},
+			},
+			PreFn: func(t *testing.T, s *solverFixture) {
+				_, err := s.Solver.createIngress(context.TODO(), s.Challenge, "fakeservice")
+				if err != nil {
+					t.Errorf("error preparing test: %v", err)
+				}
+				s.Builder.Sync()
+			},
+			Err: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			addr, err := test.Solver.solverIngressAddress(context.TODO(), test.Challenge)
+			if err != nil && !test.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && test.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+			test.Finish(t, addr, err)
+		})
+	}
+}
+
+func TestSolverAddress(t *testing.T) {
+	challenge := func(ingress *cmacme.ACMEChallengeSolverHTTP01Ingress) *cmacme.Challenge {
+		return &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: ingress,
+					},
+				},
+			},
+		}
+	}
+	namedIngress := func(lbs ...corev1.LoadBalancerIngress) *v1beta1.Ingress {
+		return &v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testingress",
+				Namespace: defaultTestNamespace,
+			},
+			Status: v1beta1.IngressStatus{
+				LoadBalancer: corev1.LoadBalancerStatus{Ingress: lbs},
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		objects   []runtime.Object
+		challenge *cmacme.Challenge
+		expected  string
+	}{
+		"should return an IPv6 load balancer IP without brackets": {
+			objects:   []runtime.Object{namedIngress(corev1.LoadBalancerIngress{IP: "fd00::1"})},
+			challenge: challenge(&cmacme.ACMEChallengeSolverHTTP01Ingress{Name: "testingress"}),
+			expected:  "fd00::1",
+		},
+		"should return an empty address if none has been assigned": {
+			objects:   []runtime.Object{namedIngress()},
+			challenge: challenge(&cmacme.ACMEChallengeSolverHTTP01Ingress{Name: "testingress"}),
+		},
+		"should return an empty address if the challenge is not solved using an ingress": {
+			challenge: challenge(nil),
+		},
+		"should return an empty address if the challenge is solved using a NodePort service": {
+			objects: []runtime.Object{namedIngress(corev1.LoadBalancerIngress{IP: "1.2.3.4"})},
+			challenge: func() *cmacme.Challenge {
+				ch := challenge(&cmacme.ACMEChallengeSolverHTTP01Ingress{Name: "testingress"})
+				ch.Spec.Solver.HTTP01.NodePort = &cmacme.ACMEChallengeSolverHTTP01NodePort{}
+				return ch
+			}(),
+		},
+		"should return an empty address if the challenge is solved using an HTTPRoute": {
+			objects: []runtime.Object{namedIngress(corev1.LoadBalancerIngress{IP: "1.2.3.4"})},
+			challenge: func() *cmacme.Challenge {
+				ch := challenge(&cmacme.ACMEChallengeSolverHTTP01Ingress{Name: "testingress"})
+				ch.Spec.Solver.HTTP01.GatewayHTTPRoute = &cmacme.ACMEChallengeSolverHTTP01GatewayHTTPRoute{}
+				return ch
+			}(),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := solverFixture{
+				Builder:   &test.Builder{KubeObjects: tc.objects},
+				Challenge: tc.challenge,
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			addr, err := f.Solver.SolverAddress(context.TODO(), f.Challenge)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if addr != tc.expected {
+				t.Errorf("expected address %q but got %q", tc.expected, addr)
+			}
+		})
+	}
+}
+
+func TestGetIngress(t *testing.T) {
+	ing := &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testingress",
+			Namespace: defaultTestNamespace,
+		},
+	}
+	expectIngress := func(t *testing.T, s *solverFixture, args ...interface{}) {
+		got, _ := args[0].(*v1beta1.Ingress)
+		if got == nil || got.Name != ing.Name {
+			t.Errorf("expected ingress %q to be returned but got %v", ing.Name, got)
+		}
+	}
+
+	tests := map[string]solverFixture{
+		"should return an ingress from the informer cache": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{ing},
+			},
+			CheckFn: expectIngress,
+		},
+		"should fall through to a live get if the ingress is not in the informer cache": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{ing},
+			},
+			PreFn: func(t *testing.T, s *solverFixture) {
+				// simulate an ingress that has not yet been observed by the informer
+				s.Solver.ingressLister = extv1beta1listers.NewIngressLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}))
+			},
+			CheckFn: expectIngress,
+		},
+		"should return a not found error if the ingress does not exist": {
+			Err: true,
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				if err, _ := args[1].(error); !apierrors.IsNotFound(err) {
+					t.Errorf("expected a not found error but got: %v", err)
+				}
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			resp, err := test.Solver.getIngress(context.TODO(), defaultTestNamespace, ing.Name)
+			if err != nil && !test.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && test.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+			test.Finish(t, resp, err)
+		})
+	}
+}
+
+func TestEnsureIngressExistingIngress(t *testing.T) {
+	webIngress := func(name string) *v1beta1.Ingress {
+		return &v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: defaultTestNamespace,
+				Labels:    map[string]string{"app": "web"},
+			},
+		}
+	}
+	newChallenge := func(cfg cmacme.ACMEChallengeSolverHTTP01Ingress) *cmacme.Challenge {
+		return &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cfg,
+					},
+				},
+			},
+		}
+	}
+	webSelector := &metav1.LabelSelector{
+		MatchLabels: map[string]string{"app": "web"},
+	}
+	expectIngresses := func(expected int) func(*testing.T, *solverFixture, ...interface{}) {
+		return func(t *testing.T, s *solverFixture, args ...interface{}) {
+			ingresses, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).List(metav1.ListOptions{})
+			if err != nil {
+				t.Errorf("error listing ingresses: %v", err)
+				return
+			}
+			if len(ingresses.Items) != expected {
+				t.Errorf("expected %d ingresses to exist but got %d", expected, len(ingresses.Items))
+			}
+		}
+	}
+
+	tests := map[string]solverFixture{
+		"should add the challenge path to the ingress matching the selector": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{webIngress("web"), fakeSolverService()},
+			},
+			Challenge: newChallenge(cmacme.ACMEChallengeSolverHTTP01Ingress{IngressSelector: webSelector}),
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				ing, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Get("web", metav1.GetOptions{})
+				if err != nil {
+					t.Errorf("error getting ingress: %v", err)
+					return
+				}
+				if len(ing.Spec.Rules) != 1 || ing.Spec.Rules[0].Host != "example.com" {
+					t.Errorf("expected a rule for the challenged domain to be added, but got %v", ing.Spec.Rules)
+					return
+				}
+				if path := ing.Spec.Rules[0].HTTP.Paths[0].Path; path != ChallengePath("abcd") {
+					t.Errorf("expected challenge path %q to be added but got %q", ChallengePath("abcd"), path)
+				}
+				if s.Challenge.Status.SolverIngress != "web" {
+					t.Errorf("expected selected ingress %q to be recorded on the challenge but got %q", "web", s.Challenge.Status.SolverIngress)
+				}
+				expectIngresses(1)(t, s)
+			},
+		},
+		"should use the ingress recorded on the challenge even if the selector now matches others": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{webIngress("web"), webIngress("web2"), fakeSolverService()},
+			},
+			Challenge: func() *cmacme.Challenge {
+				ch := newChallenge(cmacme.ACMEChallengeSolverHTTP01Ingress{IngressSelector: webSelector})
+				ch.Status.SolverIngress = "web2"
+				return ch
+			}(),
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				ing, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Get("web2", metav1.GetOptions{})
+				if err != nil {
+					t.Errorf("error getting ingress: %v", err)
+					return
+				}
+				if len(ing.Spec.Rules) != 1 {
+					t.Errorf("expected a rule for the challenged domain to be added to the recorded ingress, but got %v", ing.Spec.Rules)
+				}
+			},
+		},
+		"should error if no ingress matches the selector": {
+			Challenge: newChallenge(cmacme.ACMEChallengeSolverHTTP01Ingress{IngressSelector: webSelector}),
+			CheckFn:   expectIngresses(0),
+			Err:       true,
+		},
+		"should error if more than one ingress matches the selector": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{webIngress("web"), webIngress("web2")},
+			},
+			Challenge: newChallenge(cmacme.ACMEChallengeSolverHTTP01Ingress{IngressSelector: webSelector}),
+			CheckFn:   expectIngresses(2),
+			Err:       true,
+		},
+		"should error if ingress creation is disabled and no existing ingress is specified": {
+			Challenge: newChallenge(cmacme.ACMEChallengeSolverHTTP01Ingress{}),
+			PreFn: func(t *testing.T, s *solverFixture) {
+				s.Solver.noNewIngresses = true
+			},
+			CheckFn: expectIngresses(0),
+			Err:     true,
+		},
+		"should add the challenge path to a named ingress if ingress creation is disabled": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{webIngress("web"), fakeSolverService()},
+			},
+			Challenge: newChallenge(cmacme.ACMEChallengeSolverHTTP01Ingress{Name: "web"}),
+			PreFn: func(t *testing.T, s *solverFixture) {
+				s.Solver.noNewIngresses = true
+			},
+			CheckFn: expectIngresses(1),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			resp, _, err := test.Solver.ensureIngress(context.TODO(), test.Challenge, "fakeservice")
+			if err != nil && !test.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && test.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+			test.Finish(t, resp, err)
+		})
+	}
+}
+
+func TestEnsureIngressLimit(t *testing.T) {
+	solverIngress := func(name string) *v1beta1.Ingress {
+		return &v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: defaultTestNamespace,
+				Labels:    map[string]string{solverIdentificationLabelKey: "true"},
+			},
+		}
+	}
+	otherIngress := &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: defaultTestNamespace,
+		},
+	}
+	expiringIngress := solverIngress("solver2")
+	expiringIngress.Annotations = map[string]string{ingressExpiresAtAnnotationKey: time.Now().Add(time.Hour).Format(time.RFC3339)}
+	chal := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Token:   "abcd",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+				},
+			},
+		},
+	}
+	expectCreated := func(created bool) func(*testing.T, *solverFixture, ...interface{}) {
+		return func(t *testing.T, s *solverFixture, args ...interface{}) {
+			ingresses, err := s.Solver.getIngressesForChallenge(context.TODO(), s.Challenge)
+			if err != nil {
+				t.Errorf("error listing ingresses: %v", err)
+				return
+			}
+			if created != (len(ingresses) == 1) {
+				t.Errorf("expected solver ingress created to be %t but found %d ingresses", created, len(ingresses))
+			}
+		}
+	}
+
+	tests := map[string]solverFixture{
+		"should create an ingress if the namespace is below the limit": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{solverIngress("solver1"), otherIngress},
+			},
+			Challenge: chal,
+			PreFn: func(t *testing.T, s *solverFixture) {
+				s.Solver.maxIngresses = 2
+			},
+			CheckFn: expectCreated(true),
+		},
+		"should not create an ingress if the namespace has reached the limit": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{solverIngress("solver1"), solverIngress("solver2")},
+			},
+			Challenge: chal,
+			PreFn: func(t *testing.T, s *solverFixture) {
+				s.Solver.maxIngresses = 2
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				if err, _ := args[1].(error); err != ErrIngressLimitReached {
+					t.Errorf("expected ErrIngressLimitReached but got: %v", err)
+				}
+				expectCreated(false)(t, s)
+			},
+			Err: true,
+		},
+		"should not count retained ingresses that are expiring towards the limit": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{solverIngress("solver1"), expiringIngress},
+			},
+			Challenge: chal,
+			PreFn: func(t *testing.T, s *solverFixture) {
+				s.Solver.maxIngresses = 2
+			},
+			CheckFn: expectCreated(true),
+		},
+		"should not limit ingresses if no limit is set": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{solverIngress("solver1"), solverIngress("solver2")},
+			},
+			Challenge: chal,
+			CheckFn:   expectCreated(true),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			tc.Setup(t)
+			resp, _, err := tc.Solver.ensureIngress(context.TODO(), tc.Challenge, "fakeservice")
+			if err != nil && !tc.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && tc.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+			tc.Finish(t, resp, err)
+		})
+	}
+}
+
+func TestEnsureIngressAction(t *testing.T) {
+	newChallenge := func(cfg cmacme.ACMEChallengeSolverHTTP01Ingress) *cmacme.Challenge {
+		return &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cfg,
+					},
+				},
+			},
+		}
+	}
+	ensure := func(t *testing.T, s *solverFixture, expected IngressAction) {
+		_, action, err := s.Solver.ensureIngress(context.TODO(), s.Challenge, "fakeservice")
+		if err != nil {
+			t.Fatalf("unexpected error ensuring ingress: %v", err)
+		}
+		if action != expected {
+			t.Errorf("expected action %q but got %q", expected, action)
+		}
+		s.Builder.Sync()
+	}
+
+	t.Run("solver ingress", func(t *testing.T) {
+		f := solverFixture{Challenge: newChallenge(cmacme.ACMEChallengeSolverHTTP01Ingress{})}
+		f.Setup(t)
+		defer f.Finish(t)
+
+		ensure(t, &f, IngressActionCreated)
+		ensure(t, &f, IngressActionNone)
+
+		ingresses, err := f.Solver.getIngressesForChallenge(context.TODO(), f.Challenge)
+		if err != nil || len(ingresses) != 1 {
+			t.Fatalf("expected one solver ingress but got %d: %v", len(ingresses), err)
+		}
+		ing := ingresses[0].DeepCopy()
+		ing.Spec.Rules[0].Host = "other.com"
+		if _, err := f.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(ing.Namespace).Update(ing); err != nil {
+			t.Fatalf("error modifying ingress: %v", err)
+		}
+		f.Builder.Sync()
+		ensure(t, &f, IngressActionRepaired)
+	})
+
+	t.Run("existing ingress", func(t *testing.T) {
+		f := solverFixture{
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{
+					&v1beta1.Ingress{
+						ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: defaultTestNamespace},
+					},
+					fakeSolverService(),
+				},
+			},
+			Challenge: newChallenge(cmacme.ACMEChallengeSolverHTTP01Ingress{Name: "web"}),
+		}
+		f.Setup(t)
+		defer f.Finish(t)
+
+		ensure(t, &f, IngressActionPathAdded)
+		ensure(t, &f, IngressActionNone)
+	})
+}
+
+func TestWildcardHostMatches(t *testing.T) {
+	tests := []struct {
+		host, domain string
+		expected     bool
+	}{
+		{host: "*.example.com", domain: "foo.example.com", expected: true},
+		{host: "*.Example.com.", domain: "FOO.example.com", expected: true},
+		{host: "*.example.com", domain: "example.com"},
+		{host: "*.example.com", domain: "foo.bar.example.com"},
+		{host: "*.example.com", domain: "fooexample.com"},
+		{host: "foo.example.com", domain: "foo.example.com"},
+		{host: "", domain: "foo.example.com"},
+	}
+	for _, tc := range tests {
+		if got := wildcardHostMatches(tc.host, tc.domain); got != tc.expected {
+			t.Errorf("expected wildcardHostMatches(%q, %q) to be %t but got %t", tc.host, tc.domain, tc.expected, got)
+		}
+	}
+}
+
+func TestEnsureIngressWildcardIngress(t *testing.T) {
+	wildcardIngress := func(name, class string) *v1beta1.Ingress {
+		return &v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   defaultTestNamespace,
+				Annotations: map[string]string{cmapi.IngressClassAnnotationKey: class},
+			},
+			Spec: v1beta1.IngressSpec{
+				Rules: []v1beta1.IngressRule{{Host: "*.example.com"}},
+			},
+		}
+	}
+	tests := map[string]struct {
+		disabled bool
+		class    *string
+		expected IngressAction
+		ingress  string
+	}{
+		"should add the challenge path to the matching wildcard ingress": {
+			expected: IngressActionPathAdded,
+			ingress:  "a-wildcard",
+		},
+		"should only use a wildcard ingress with the configured class": {
+			class:    strPtr("other"),
+			expected: IngressActionPathAdded,
+			ingress:  "b-wildcard",
+		},
+		"should create a solver ingress if no wildcard ingress has the configured class": {
+			class:    strPtr("missing"),
+			expected: IngressActionCreated,
+		},
+		"should create a solver ingress if wildcard ingresses are not reused": {
+			disabled: true,
+			expected: IngressActionCreated,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := solverFixture{
+				Builder: &test.Builder{
+					KubeObjects: []runtime.Object{
+						wildcardIngress("b-wildcard", "other"),
+						wildcardIngress("a-wildcard", "nginx"),
+						fakeSolverService(),
+					},
+				},
+				Challenge: &cmacme.Challenge{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testchal",
+						Namespace: defaultTestNamespace,
+					},
+					Spec: cmacme.ChallengeSpec{
+						DNSName: "foo.example.com",
+						Token:   "abcd",
+						Solver: &cmacme.ACMEChallengeSolver{
+							HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+								Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+									Class: tc.class,
+								},
+							},
+						},
+					},
+				},
+				PreFn: func(t *testing.T, s *solverFixture) {
+					s.Solver.reuseWildcards = !tc.disabled
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			ing, action, err := f.Solver.ensureIngress(context.TODO(), f.Challenge, "fakeservice")
+			if err != nil {
+				t.Fatalf("unexpected error ensuring ingress: %v", err)
+			}
+			if action != tc.expected {
+				t.Errorf("expected action %q but got %q", tc.expected, action)
+			}
+			if tc.ingress == "" {
+				return
+			}
+			if ing.Name != tc.ingress {
+				t.Errorf("expected challenge path to be added to ingress %q but got %q", tc.ingress, ing.Name)
+			}
+			if len(ing.Spec.Rules) != 2 || ing.Spec.Rules[1].Host != "foo.example.com" {
+				t.Errorf("expected a rule for the challenged domain to be added but got %+v", ing.Spec.Rules)
+			}
+			f.Builder.Sync()
+
+			if err := f.Solver.cleanupIngresses(context.TODO(), f.Challenge); err != nil {
+				t.Fatalf("unexpected error cleaning up ingresses: %v", err)
+			}
+			ing, err = f.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Get(tc.ingress, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting ingress: %v", err)
+			}
+			if len(ing.Spec.Rules) != 1 || ing.Spec.Rules[0].Host != "*.example.com" {
+				t.Errorf("expected only the wildcard rule to remain but got %+v", ing.Spec.Rules)
+			}
+		})
+	}
+}
+
+func TestEnsureIngressInvalidHost(t *testing.T) {
+	tests := map[string]string{
+		"should reject a URL with a scheme": "http://example.com",
+		"should reject a host with a port":  "example.com:8080",
+		"should reject a host with a path":  "example.com/foo",
+		"should reject an invalid hostname": "exa mple.com",
+	}
+	for name, domain := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := solverFixture{
+				Challenge: &cmacme.Challenge{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testchal",
+						Namespace: defaultTestNamespace,
+					},
+					Spec: cmacme.ChallengeSpec{
+						DNSName: domain,
+						Token:   "abcd",
+						Solver: &cmacme.ACMEChallengeSolver{
+							HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+								Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+							},
+						},
+					},
+				},
+				CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+					ingresses, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).List(metav1.ListOptions{})
+					if err != nil {
+						t.Errorf("error listing ingresses: %v", err)
+						return
+					}
+					if len(ingresses.Items) != 0 {
+						t.Errorf("expected no ingress to be created but got %d", len(ingresses.Items))
+					}
+				},
+			}
+			f.Setup(t)
+			_, _, err := f.Solver.ensureIngress(context.TODO(), f.Challenge, "fakeservice")
+			if err == nil || !strings.Contains(err.Error(), "Certificate") {
+				t.Errorf("expected an error referencing the Certificate but got: %v", err)
+			}
+			f.Finish(t)
+		})
+	}
+}
+
+func TestCleanupAll(t *testing.T) {
+	solverIngress := func(namespace, name string) *v1beta1.Ingress {
+		return &v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Labels:    map[string]string{solverIdentificationLabelKey: "true"},
+			},
+		}
+	}
+	otherIngress := &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: defaultTestNamespace,
+		},
+	}
+	newFixture := func() *solverFixture {
+		return &solverFixture{
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{
+					solverIngress(defaultTestNamespace, "solver2"),
+					solverIngress(defaultTestNamespace, "solver1"),
+					solverIngress("other-namespace", "solver3"),
+					otherIngress,
+				},
+			},
+			Challenge: &cmacme.Challenge{},
+		}
+	}
+	remainingIngresses := func(t *testing.T, s *solverFixture) []string {
+		ingresses, err := s.Solver.ingressLister.List(labels.Everything())
+		if err != nil {
+			t.Fatalf("error listing ingresses: %v", err)
+		}
+		var names []string
+		for _, ing := range ingresses {
+			names = append(names, ing.Namespace+"/"+ing.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	t.Run("should refuse to clean up if force cleanup is not enabled", func(t *testing.T) {
+		f := newFixture()
+		f.Setup(t)
+		defer f.Finish(t)
+
+		if err := f.Solver.CleanupAll(context.TODO(), defaultTestNamespace); err != ErrForceCleanupDisabled {
+			t.Errorf("expected ErrForceCleanupDisabled but got: %v", err)
+		}
+		f.Builder.Sync()
+		if remaining := remainingIngresses(t, f); len(remaining) != 4 {
+			t.Errorf("expected no ingresses to be deleted but %v remain", remaining)
+		}
+	})
+
+	t.Run("should list the solver ingresses that would be deleted", func(t *testing.T) {
+		f := newFixture()
+		f.Setup(t)
+		defer f.Finish(t)
+
+		names, err := f.Solver.CleanupAllDryRun(context.TODO(), defaultTestNamespace)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if expected := []string{"solver1", "solver2"}; !reflect.DeepEqual(names, expected) {
+			t.Errorf("expected %v but got %v", expected, names)
+		}
+		f.Builder.Sync()
+		if remaining := remainingIngresses(t, f); len(remaining) != 4 {
+			t.Errorf("expected no ingresses to be deleted by a dry run but %v remain", remaining)
+		}
+	})
+
+	t.Run("should delete all solver ingresses in the namespace regardless of owner", func(t *testing.T) {
+		f := newFixture()
+		f.Setup(t)
+		defer f.Finish(t)
+		f.Solver.allowForceCleanup = true
+
+		if err := f.Solver.CleanupAll(context.TODO(), defaultTestNamespace); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		f.Builder.Sync()
+		expected := []string{defaultTestNamespace + "/web", "other-namespace/solver3"}
+		if remaining := remainingIngresses(t, f); !reflect.DeepEqual(remaining, expected) {
+			t.Errorf("expected %v to remain but got %v", expected, remaining)
+		}
+	})
+}
+
+func TestGetIngressesForChallengeOwnerReferenceModes(t *testing.T) {
+	tests := map[string]struct {
+		plainOwnerRefs   bool
+		userController   bool
+		expectController bool
+	}{
+		"controller owner reference": {
+			expectController: true,
+		},
+		"plain owner reference": {
+			plainOwnerRefs: true,
+		},
+		"plain owner reference alongside another controller": {
+			plainOwnerRefs: true,
+			userController: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := solverFixture{
+				Challenge: &cmacme.Challenge{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testchal",
+						Namespace: defaultTestNamespace,
+						UID:       "chal-uid",
+					},
+					Spec: cmacme.ChallengeSpec{
+						DNSName: "example.com",
+						Solver: &cmacme.ACMEChallengeSolver{
+							HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+								Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+							},
+						},
+					},
+				},
+				PreFn: func(t *testing.T, s *solverFixture) {
+					s.Solver.plainOwnerRefs = test.plainOwnerRefs
+					ing, err := s.Solver.createIngress(context.TODO(), s.Challenge, "fakeservice")
+					if err != nil {
+						t.Fatalf("error preparing test: %v", err)
+					}
+					if len(ing.OwnerReferences) != 1 {
+						t.Fatalf("expected one owner reference but got %v", ing.OwnerReferences)
+					}
+					ref := ing.OwnerReferences[0]
+					if isController := ref.Controller != nil && *ref.Controller; isController != test.expectController {
+						t.Errorf("expected owner reference controller to be %t but got %t", test.expectController, isController)
+					}
+					if test.userController {
+						isController := true
+						ing.OwnerReferences = append(ing.OwnerReferences, metav1.OwnerReference{
+							APIVersion: "example.com/v1",
+							Kind:       "Router",
+							Name:       "user-router",
+							UID:        "router-uid",
+							Controller: &isController,
+						})
+						if _, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(ing.Namespace).Update(ing); err != nil {
+							t.Fatalf("error preparing test: %v", err)
+						}
+					}
+					s.Builder.Sync()
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			ingresses, err := f.Solver.getIngressesForChallenge(context.TODO(), f.Challenge)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(ingresses) != 1 {
+				t.Fatalf("expected one ingress to be returned but got %d", len(ingresses))
+			}
+
+			owned, err := f.Solver.IsOwnedBy(ingresses[0], f.Challenge)
+			if err != nil {
+				t.Fatalf("unexpected error checking owner: %v", err)
+			}
+			if !owned {
+				t.Errorf("expected ingress to be owned by the challenge")
+			}
+
+			other := f.Challenge.DeepCopy()
+			other.UID = "other-chal-uid"
+			ingresses, err = f.Solver.getIngressesForChallenge(context.TODO(), other)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(ingresses) != 0 {
+				t.Errorf("expected no ingresses to be returned for another challenge but got %d", len(ingresses))
+			}
+		})
+	}
+}
+
+func TestCleanupChallengeIngresses(t *testing.T) {
+	challengeFor := func(name, domain, token, ingressName string) *cmacme.Challenge {
+		return &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: defaultTestNamespace,
+				UID:       types.UID(name + "-uid"),
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: domain,
+				Token:   token,
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+							Name: ingressName,
+						},
+					},
+				},
+			},
+		}
+	}
+	ruleFor := func(host string, paths ...v1beta1.HTTPIngressPath) v1beta1.IngressRule {
+		return v1beta1.IngressRule{
+			Host: host,
+			IngressRuleValue: v1beta1.IngressRuleValue{
+				HTTP: &v1beta1.HTTPIngressRuleValue{Paths: paths},
+			},
+		}
+	}
+	appPath := v1beta1.HTTPIngressPath{
+		Path: "/",
+		Backend: v1beta1.IngressBackend{
+			ServiceName: "app",
+			ServicePort: intstr.FromInt(80),
+		},
+	}
+
+	shared := []*cmacme.Challenge{
+		challengeFor("apexchal", "example.com", "token1", "testingress"),
+		challengeFor("wwwchal", "www.example.com", "token2", "testingress"),
+	}
+	own := challengeFor("otherchal", "other.example.com", "token3", "")
+	f := solverFixture{
+		Builder: &test.Builder{
+			KubeObjects: []runtime.Object{
+				fakeSolverService(),
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "testingress",
+						Namespace: defaultTestNamespace,
+					},
+					Spec: v1beta1.IngressSpec{
+						Rules: []v1beta1.IngressRule{
+							ruleFor("example.com", ChallengeIngressPath("token1", "fakeservice", acmeSolverListenPort), appPath),
+							ruleFor("www.example.com", ChallengeIngressPath("token2", "fakeservice", acmeSolverListenPort)),
+						},
+					},
+				},
+			},
+		},
+		Challenge: own,
+		PreFn: func(t *testing.T, s *solverFixture) {
+			if _, err := s.Solver.createIngress(context.TODO(), own, "fakeservice"); err != nil {
+				t.Fatalf("error preparing test: %v", err)
+			}
+			s.Builder.Sync()
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	actions := len(f.Builder.FakeKubeClient().Actions())
+	if err := f.Solver.CleanupChallengeIngresses(context.TODO(), append(shared, own)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	patches := 0
+	for _, action := range f.Builder.FakeKubeClient().Actions()[actions:] {
+		if action.Matches("patch", "ingresses") {
+			patches++
+		}
+	}
+	if patches != 1 {
+		t.Errorf("expected the shared ingress to be patched once but got %d patches", patches)
+	}
+
+	ing, err := f.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Get("testingress", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting ingress: %v", err)
+	}
+	expectedRules := []v1beta1.IngressRule{ruleFor("example.com", appPath)}
+	if !reflect.DeepEqual(ing.Spec.Rules, expectedRules) {
+		t.Errorf("expected rules %+v but got %+v", expectedRules, ing.Spec.Rules)
+	}
+
+	ingresses, err := f.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).List(metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(podLabels(own)).String(),
+	})
+	if err != nil {
+		t.Fatalf("error listing ingresses: %v", err)
+	}
+	if len(ingresses.Items) != 0 {
+		t.Errorf("expected the solver ingress for %q to be deleted but found %d", own.Name, len(ingresses.Items))
+	}
+}
+
+func TestEnsureIngressReuse(t *testing.T) {
+	challengeFor := func(name, token string) *cmacme.Challenge {
+		return &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: defaultTestNamespace,
+				UID:       types.UID(name + "-uid"),
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   token,
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+					},
+				},
+			},
+		}
+	}
+	sameDomain := func(ch *cmacme.Challenge, ing *v1beta1.Ingress) bool {
+		return ing.Annotations[domainAnnotationKey] == ch.Spec.DNSName
+	}
+
+	tests := map[string]struct {
+		reuseIngressFn  ReuseIngressFunc
+		expectedAction  IngressAction
+		expectIngresses int
+	}{
+		"default predicate creates a new ingress": {
+			expectedAction:  IngressActionCreated,
+			expectIngresses: 2,
+		},
+		"predicate matching the ingress of an earlier challenge reuses it": {
+			reuseIngressFn:  sameDomain,
+			expectedAction:  IngressActionReused,
+			expectIngresses: 1,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			oldChal := challengeFor("oldchal", "oldtoken")
+			f := solverFixture{
+				Challenge: challengeFor("newchal", "newtoken"),
+				PreFn: func(t *testing.T, s *solverFixture) {
+					s.Solver.SetReuseIngressFunc(test.reuseIngressFn)
+					if _, err := s.Solver.createIngress(context.TODO(), oldChal, "oldservice"); err != nil {
+						t.Fatalf("error preparing test: %v", err)
+					}
+					s.Builder.Sync()
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			ing, action, err := f.Solver.ensureIngress(context.TODO(), f.Challenge, "newservice")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if action != test.expectedAction {
+				t.Errorf("expected action %q but got %q", test.expectedAction, action)
+			}
+			if svcName := ingressServiceName(ing, f.Challenge.Spec.DNSName); svcName != "newservice" {
+				t.Errorf("expected ingress to route to %q but got %q", "newservice", svcName)
+			}
+			f.Builder.Sync()
+
+			all, err := f.Solver.listSolverIngresses(defaultTestNamespace)
+			if err != nil {
+				t.Fatalf("error listing ingresses: %v", err)
+			}
+			if len(all) != test.expectIngresses {
+				t.Errorf("expected %d solver ingresses but got %d", test.expectIngresses, len(all))
+			}
+
+			found, err := f.Solver.getIngressesForChallenge(context.TODO(), f.Challenge)
+			if err != nil || len(found) != 1 {
+				t.Errorf("expected one ingress for the challenge but got %d (err: %v)", len(found), err)
+			}
+			found, err = f.Solver.getIngressesForChallenge(context.TODO(), oldChal)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if reused := len(found) == 0; reused != (test.expectedAction == IngressActionReused) {
+				t.Errorf("expected the earlier challenge to have %d ingresses but got %d", test.expectIngresses-1, len(found))
+			}
+			if test.expectedAction != IngressActionReused {
+				return
+			}
+			if !f.Solver.isResourceOwner(oldChal, ing) || !f.Solver.isResourceOwner(f.Challenge, ing) {
+				t.Errorf("expected the reused ingress to be owned by both challenges but got %v", ing.OwnerReferences)
+			}
+			controllers := 0
+			for _, ref := range ing.OwnerReferences {
+				if ref.Controller != nil && *ref.Controller {
+					controllers++
+				}
+			}
+			if controllers != 1 {
+				t.Errorf("expected one controller reference but got %d", controllers)
+			}
+			if !ingressHasPath(ing, "example.com", ChallengePath("oldtoken")) || !ingressHasPath(ing, "example.com", ChallengePath("newtoken")) {
+				t.Errorf("expected the reused ingress to route the paths of both challenges but got %v", ing.Spec.Rules)
+			}
+		})
+	}
+}
+
+func TestCleanupSharedIngress(t *testing.T) {
+	challengeFor := func(name, token string) *cmacme.Challenge {
+		return &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: defaultTestNamespace,
+				UID:       types.UID(name + "-uid"),
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   token,
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+					},
+				},
+			},
+		}
+	}
+	oldChal := challengeFor("oldchal", "oldtoken")
+	f := solverFixture{
+		Challenge: challengeFor("newchal", "newtoken"),
+		PreFn: func(t *testing.T, s *solverFixture) {
+			s.Solver.SetReuseIngressFunc(ReuseSameDomainIngress)
+			if _, err := s.Solver.createIngress(context.TODO(), oldChal, "oldservice"); err != nil {
+				t.Fatalf("error preparing test: %v", err)
+			}
+			s.Builder.Sync()
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	if _, action, err := f.Solver.ensureIngress(context.TODO(), f.Challenge, "newservice"); err != nil || action != IngressActionReused {
+		t.Fatalf("expected the ingress to be reused but got action %q (err: %v)", action, err)
+	}
+	f.Builder.Sync()
+
+	// the challenge the ingress was created for is cleaned up first, and
+	// must not delete the ingress still used by the other challenge
+	if _, err := f.Solver.CleanupIngresses(context.TODO(), oldChal); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f.Builder.Sync()
+	ingresses, err := f.Solver.listSolverIngresses(defaultTestNamespace)
+	if err != nil {
+		t.Fatalf("error listing ingresses: %v", err)
+	}
+	if len(ingresses) != 1 {
+		t.Fatalf("expected the shared ingress to be retained but got %d ingresses", len(ingresses))
+	}
+	ing := ingresses[0]
+	if f.Solver.isResourceOwner(oldChal, ing) || !f.Solver.isResourceOwner(f.Challenge, ing) {
+		t.Errorf("expected only the remaining challenge to own the ingress but got %v", ing.OwnerReferences)
+	}
+	if ingressHasPath(ing, "example.com", ChallengePath("oldtoken")) || !ingressHasPath(ing, "example.com", ChallengePath("newtoken")) {
+		t.Errorf("expected only the paths of the remaining challenge to be retained but got %v", ing.Spec.Rules)
+	}
+
+	if _, err := f.Solver.CleanupIngresses(context.TODO(), f.Challenge); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f.Builder.Sync()
+	ingresses, err = f.Solver.listSolverIngresses(defaultTestNamespace)
+	if err != nil {
+		t.Fatalf("error listing ingresses: %v", err)
+	}
+	if len(ingresses) != 0 {
+		t.Errorf("expected the ingress to be deleted once no challenge shares it but got %d ingresses", len(ingresses))
+	}
+}
+
+func TestReuseSameDomainIngress(t *testing.T) {
+	class := "nginx"
+	otherClass := "traefik"
+	challengeFor := func(domain string, class *string) *cmacme.Challenge {
+		return &cmacme.Challenge{
+			Spec: cmacme.ChallengeSpec{
+				DNSName: domain,
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{Class: class},
+					},
+				},
+			},
+		}
+	}
+	ingressFor := func(domain string, class *string) *v1beta1.Ingress {
+		ing := &v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{domainAnnotationKey: domain},
+			},
+			Spec: v1beta1.IngressSpec{
+				Rules: []v1beta1.IngressRule{
+					{
+						Host: domain,
+						IngressRuleValue: v1beta1.IngressRuleValue{
+							HTTP: &v1beta1.HTTPIngressRuleValue{
+								Paths: []v1beta1.HTTPIngressPath{ChallengeIngressPath("abcd", "cm-acme-http-solver-abcdef", acmeSolverListenPort)},
+							},
+						},
+					},
+				},
+			},
+		}
+		if class != nil {
+			ing.Annotations[cmapi.IngressClassAnnotationKey] = *class
+		}
+		return ing
+	}
+
+	tests := map[string]struct {
+		ch     *cmacme.Challenge
+		ing    *v1beta1.Ingress
+		expect bool
+	}{
+		"same domain and no class": {
+			ch:     challengeFor("example.com", nil),
+			ing:    ingressFor("example.com", nil),
+			expect: true,
+		},
+		"same domain and class": {
+			ch:     challengeFor("example.com", &class),
+			ing:    ingressFor("example.com", &class),
+			expect: true,
+		},
+		"different domain": {
+			ch:  challengeFor("example.com", nil),
+			ing: ingressFor("other.example.com", nil),
+		},
+		"different class": {
+			ch:  challengeFor("example.com", &class),
+			ing: ingressFor("example.com", &otherClass),
+		},
+		"class only set on the ingress": {
+			ch:  challengeFor("example.com", nil),
+			ing: ingressFor("example.com", &class),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := ReuseSameDomainIngress(test.ch, test.ing); got != test.expect {
+				t.Errorf("expected %v but got %v", test.expect, got)
+			}
+		})
+	}
+}
+
+func TestCleanupIngressesConflict(t *testing.T) {
+	ingressGVR := schema.GroupVersionResource{Group: "extensions", Version: "v1beta1", Resource: "ingresses"}
+	conflict := apierrors.NewConflict(schema.GroupResource{Group: "extensions", Resource: "ingresses"}, "testingress", fmt.Errorf("the object has been modified"))
+	patches := 0
+	var patchedVersions []string
+	f := solverFixture{
+		Builder: &test.Builder{
+			KubeObjects: []runtime.Object{
+				fakeSolverService(),
+				&v1beta1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:            "testingress",
+						Namespace:       defaultTestNamespace,
+						ResourceVersion: "1",
+					},
+					Spec: v1beta1.IngressSpec{
+						Rules: []v1beta1.IngressRule{
+							{
+								Host: "example.com",
+								IngressRuleValue: v1beta1.IngressRuleValue{
+									HTTP: &v1beta1.HTTPIngressRuleValue{
+										Paths: []v1beta1.HTTPIngressPath{ChallengeIngressPath("abcd", "fakeservice", acmeSolverListenPort)},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Challenge: &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+							Name: "testingress",
+						},
+					},
+				},
+			},
+		},
+		PreFn: func(t *testing.T, s *solverFixture) {
+			tracker := s.Builder.FakeKubeClient().Tracker()
+			s.Builder.FakeKubeClient().PrependReactor("patch", "ingresses", func(action coretesting.Action) (bool, runtime.Object, error) {
+				patches++
+				var patch struct {
+					Metadata struct {
+						ResourceVersion string `json:"resourceVersion"`
+					} `json:"metadata"`
+				}
+				if err := json.Unmarshal(action.(coretesting.PatchAction).GetPatch(), &patch); err != nil {
+					return true, nil, err
+				}
+				patchedVersions = append(patchedVersions, patch.Metadata.ResourceVersion)
+				if patches > 1 {
+					return false, nil, nil
+				}
+				// simulate another challenge adding a rule before our patch
+				// is applied
+				obj, err := tracker.Get(ingressGVR, defaultTestNamespace, "testingress")
+				if err != nil {
+					return true, nil, err
+				}
+				ing := obj.(*v1beta1.Ingress).DeepCopy()
+				ing.ResourceVersion = "2"
+				ing.Spec.Rules = append(ing.Spec.Rules, v1beta1.IngressRule{
+					Host: "concurrent.example.com",
+					IngressRuleValue: v1beta1.IngressRuleValue{
+						HTTP: &v1beta1.HTTPIngressRuleValue{
+							Paths: []v1beta1.HTTPIngressPath{ChallengeIngressPath("efgh", "otherservice", acmeSolverListenPort)},
+						},
+					},
+				})
+				if err := tracker.Update(ingressGVR, ing, defaultTestNamespace); err != nil {
+					return true, nil, err
+				}
+				return true, nil, conflict
+			})
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	if err := f.Solver.cleanupIngresses(context.TODO(), f.Challenge); err != nil {
+		t.Fatalf("unexpected error cleaning up ingress: %v", err)
+	}
+	if expected := []string{"1", "2"}; !reflect.DeepEqual(patchedVersions, expected) {
+		t.Errorf("expected patches conditional on resource versions %v but got %v", expected, patchedVersions)
+	}
+	ing, err := f.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Get("testingress", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("error getting ingress: %v", err)
+	}
+	var hosts []string
+	for _, rule := range ing.Spec.Rules {
+		hosts = append(hosts, rule.Host)
+	}
+	if expected := []string{"concurrent.example.com"}; !reflect.DeepEqual(hosts, expected) {
+		t.Errorf("expected concurrently added rule to be retained, expected hosts %v but got %v", expected, hosts)
+	}
+}
+
+func TestEnsureIngressTemplateRules(t *testing.T) {
+	f := solverFixture{
+		Challenge: &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+							IngressTemplate: &cmacme.ACMEChallengeSolverHTTP01IngressTemplate{
+								Spec: &v1beta1.IngressSpec{
+									Rules: []v1beta1.IngressRule{
+										{
+											Host: "other.example.com",
+											IngressRuleValue: v1beta1.IngressRuleValue{
+												HTTP: &v1beta1.HTTPIngressRuleValue{
+													Paths: []v1beta1.HTTPIngressPath{
+														{Path: "/", Backend: v1beta1.IngressBackend{ServiceName: "other", ServicePort: intstr.FromInt(80)}},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	ing, action, err := f.Solver.ensureIngress(context.TODO(), f.Challenge, "fakeservice")
+	if err != nil {
+		t.Fatalf("unexpected error creating ingress: %v", err)
+	}
+	if action != IngressActionCreated {
+		t.Errorf("expected action %q but got %q", IngressActionCreated, action)
+	}
+	if svcName := ingressServiceName(ing, f.Challenge.Spec.DNSName); svcName != "fakeservice" {
+		t.Errorf("expected challenge paths to route to %q but got %q", "fakeservice", svcName)
+	}
+	f.Builder.Sync()
+
+	// the template rule comes first, which must not be mistaken for a change
+	// of the solver service
+	if _, action, err = f.Solver.ensureIngress(context.TODO(), f.Challenge, "fakeservice"); err != nil {
+		t.Fatalf("unexpected error ensuring existing ingress: %v", err)
+	}
+	if action != IngressActionNone {
+		t.Errorf("expected action %q but got %q", IngressActionNone, action)
+	}
+}
+
+// ingressHasPath returns true if the given ingress has a rule for host with
+// the given path.
+func ingressHasPath(ing *v1beta1.Ingress, host, path string) bool {
+	for _, rule := range ing.Spec.Rules {
+		if rule.Host != host || rule.HTTP == nil {
+			continue
+		}
+		for _, p := range rule.HTTP.Paths {
+			if p.Path == path {
+				return true
+			}
+		}
 	}
-	for name, test := range tests {
-		t.Run(name, func(t *testing.T) {
-			test.Setup(t)
-			resp, err := test.Solver.ensureIngress(context.TODO(), test.Challenge, "fakeservice")
-			if err != nil && !test.Err {
-				t.Errorf("Expected function to not error, but got: %v", err)
-			}
-			if err == nil && test.Err {
-				t.Errorf("Expected function to get an error, but got: %v", err)
-			}
-			test.Finish(t, resp, err)
-		})
-	}
+	return false
 }
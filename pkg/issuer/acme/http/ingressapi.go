@@ -0,0 +1,295 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"encoding/json"
+
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+	extv1beta1listers "k8s.io/client-go/listers/extensions/v1beta1"
+	networkingv1beta1listers "k8s.io/client-go/listers/networking/v1beta1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/flowcontrol"
+
+	"github.com/jetstack/cert-manager/pkg/controller"
+)
+
+const (
+	// IngressAPIGroupExtensions is the deprecated extensions API group, which
+	// serves Ingress resources at extensions/v1beta1.
+	IngressAPIGroupExtensions = "extensions"
+	// IngressAPIGroupNetworking is the networking.k8s.io API group, which
+	// serves Ingress resources at networking.k8s.io/v1beta1.
+	IngressAPIGroupNetworking = "networking.k8s.io"
+)
+
+// DiscoverIngressAPIGroup returns the API group that the solver should use
+// to manage Ingress resources. The networking.k8s.io group is preferred if
+// the apiserver serves Ingresses in it, otherwise the extensions group is
+// used.
+func DiscoverIngressAPIGroup(d discovery.DiscoveryInterface) string {
+	resources, err := d.ServerResourcesForGroupVersion(networkingv1beta1.SchemeGroupVersion.String())
+	if err != nil {
+		return IngressAPIGroupExtensions
+	}
+	for _, r := range resources.APIResources {
+		if r.Name == "ingresses" {
+			return IngressAPIGroupNetworking
+		}
+	}
+	return IngressAPIGroupExtensions
+}
+
+// IngressInformer returns the shared informer for Ingress resources in the
+// API group configured on the given context.
+func IngressInformer(ctx *controller.Context) cache.SharedIndexInformer {
+	if ctx.HTTP01IngressAPIGroup == IngressAPIGroupNetworking {
+		return ctx.KubeSharedInformerFactory.Networking().V1beta1().Ingresses().Informer()
+	}
+	return ctx.KubeSharedInformerFactory.Extensions().V1beta1().Ingresses().Informer()
+}
+
+// ingressClient provides access to Ingress resources regardless of the API
+// group they are served in. Ingresses are always represented using the
+// extensions/v1beta1 types, which are converted to and from the types of the
+// API group in use.
+// The context is used to wait for any client side rate limiting, and is
+// otherwise ignored.
+type ingressClient interface {
+	Ingresses(ctx context.Context, namespace string) ingressInterface
+}
+
+// ingressInterface is the subset of the typed Ingress client used by the
+// solver.
+type ingressInterface interface {
+	Create(*extv1beta1.Ingress) (*extv1beta1.Ingress, error)
+	Get(name string, options metav1.GetOptions) (*extv1beta1.Ingress, error)
+	List(opts metav1.ListOptions) (*extv1beta1.IngressList, error)
+	Update(*extv1beta1.Ingress) (*extv1beta1.Ingress, error)
+	Patch(name string, pt types.PatchType, data []byte, subresources ...string) (*extv1beta1.Ingress, error)
+	Delete(name string, options *metav1.DeleteOptions) error
+}
+
+// ingressAPIFor returns the client and lister used to manage Ingress
+// resources in the given API group.
+func ingressAPIFor(ctx *controller.Context, group string) (ingressClient, extv1beta1listers.IngressLister) {
+	if group == IngressAPIGroupNetworking {
+		return &networkingIngressClient{client: ctx.Client},
+			&networkingIngressLister{lister: ctx.KubeSharedInformerFactory.Networking().V1beta1().Ingresses().Lister()}
+	}
+	return &extensionsIngressClient{client: ctx.Client},
+		ctx.KubeSharedInformerFactory.Extensions().V1beta1().Ingresses().Lister()
+}
+
+type extensionsIngressClient struct {
+	client kubernetes.Interface
+}
+
+func (c *extensionsIngressClient) Ingresses(_ context.Context, namespace string) ingressInterface {
+	return c.client.ExtensionsV1beta1().Ingresses(namespace)
+}
+
+type networkingIngressClient struct {
+	client kubernetes.Interface
+}
+
+func (c *networkingIngressClient) Ingresses(_ context.Context, namespace string) ingressInterface {
+	return &networkingIngresses{namespace: namespace, client: c.client}
+}
+
+type networkingIngresses struct {
+	namespace string
+	client    kubernetes.Interface
+}
+
+func (c *networkingIngresses) Create(ing *extv1beta1.Ingress) (*extv1beta1.Ingress, error) {
+	in, err := toNetworkingIngress(ing)
+	if err != nil {
+		return nil, err
+	}
+	return fromNetworkingIngress(c.client.NetworkingV1beta1().Ingresses(c.namespace).Create(in))
+}
+
+func (c *networkingIngresses) Get(name string, options metav1.GetOptions) (*extv1beta1.Ingress, error) {
+	return fromNetworkingIngress(c.client.NetworkingV1beta1().Ingresses(c.namespace).Get(name, options))
+}
+
+func (c *networkingIngresses) List(opts metav1.ListOptions) (*extv1beta1.IngressList, error) {
+	list, err := c.client.NetworkingV1beta1().Ingresses(c.namespace).List(opts)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return nil, err
+	}
+	out := &extv1beta1.IngressList{}
+	if err := json.Unmarshal(data, out); err != nil {
+		return nil, err
+	}
+	out.TypeMeta = metav1.TypeMeta{}
+	return out, nil
+}
+
+func (c *networkingIngresses) Update(ing *extv1beta1.Ingress) (*extv1beta1.Ingress, error) {
+	in, err := toNetworkingIngress(ing)
+	if err != nil {
+		return nil, err
+	}
+	return fromNetworkingIngress(c.client.NetworkingV1beta1().Ingresses(c.namespace).Update(in))
+}
+
+func (c *networkingIngresses) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (*extv1beta1.Ingress, error) {
+	return fromNetworkingIngress(c.client.NetworkingV1beta1().Ingresses(c.namespace).Patch(name, pt, data, subresources...))
+}
+
+func (c *networkingIngresses) Delete(name string, options *metav1.DeleteOptions) error {
+	return c.client.NetworkingV1beta1().Ingresses(c.namespace).Delete(name, options)
+}
+
+// rateLimitedIngressClient limits the rate of the Create, Update, Patch and
+// Delete calls made through the wrapped client, so that many challenges
+// being solved at once do not overwhelm the apiserver. A single limiter is
+// shared by all namespaces. Get and List calls are not limited. Calls wait for
+// the limiter until the context passed to Ingresses is cancelled, returning
+// the context's error.
+type rateLimitedIngressClient struct {
+	client  ingressClient
+	limiter flowcontrol.RateLimiter
+}
+
+func (c *rateLimitedIngressClient) Ingresses(ctx context.Context, namespace string) ingressInterface {
+	return &rateLimitedIngresses{ingressInterface: c.client.Ingresses(ctx, namespace), ctx: ctx, limiter: c.limiter}
+}
+
+type rateLimitedIngresses struct {
+	ingressInterface
+	ctx     context.Context
+	limiter flowcontrol.RateLimiter
+}
+
+func (c *rateLimitedIngresses) Create(ing *extv1beta1.Ingress) (*extv1beta1.Ingress, error) {
+	if err := c.limiter.Wait(c.ctx); err != nil {
+		return nil, err
+	}
+	return c.ingressInterface.Create(ing)
+}
+
+func (c *rateLimitedIngresses) Update(ing *extv1beta1.Ingress) (*extv1beta1.Ingress, error) {
+	if err := c.limiter.Wait(c.ctx); err != nil {
+		return nil, err
+	}
+	return c.ingressInterface.Update(ing)
+}
+
+func (c *rateLimitedIngresses) Patch(name string, pt types.PatchType, data []byte, subresources ...string) (*extv1beta1.Ingress, error) {
+	if err := c.limiter.Wait(c.ctx); err != nil {
+		return nil, err
+	}
+	return c.ingressInterface.Patch(name, pt, data, subresources...)
+}
+
+func (c *rateLimitedIngresses) Delete(name string, options *metav1.DeleteOptions) error {
+	if err := c.limiter.Wait(c.ctx); err != nil {
+		return err
+	}
+	return c.ingressInterface.Delete(name, options)
+}
+
+// networkingIngressLister implements the extensions/v1beta1 IngressLister
+// interface using a networking.k8s.io/v1beta1 IngressLister.
+type networkingIngressLister struct {
+	lister networkingv1beta1listers.IngressLister
+}
+
+var _ extv1beta1listers.IngressLister = &networkingIngressLister{}
+
+func (l *networkingIngressLister) List(selector labels.Selector) ([]*extv1beta1.Ingress, error) {
+	return fromNetworkingIngressList(l.lister.List(selector))
+}
+
+func (l *networkingIngressLister) Ingresses(namespace string) extv1beta1listers.IngressNamespaceLister {
+	return &networkingIngressNamespaceLister{lister: l.lister.Ingresses(namespace)}
+}
+
+type networkingIngressNamespaceLister struct {
+	lister networkingv1beta1listers.IngressNamespaceLister
+}
+
+func (l *networkingIngressNamespaceLister) List(selector labels.Selector) ([]*extv1beta1.Ingress, error) {
+	return fromNetworkingIngressList(l.lister.List(selector))
+}
+
+func (l *networkingIngressNamespaceLister) Get(name string) (*extv1beta1.Ingress, error) {
+	return fromNetworkingIngress(l.lister.Get(name))
+}
+
+// toNetworkingIngress converts an extensions/v1beta1 Ingress to its
+// networking.k8s.io/v1beta1 equivalent. The two types are identical apart
+// from their API group, so the conversion round trips through JSON.
+func toNetworkingIngress(ing *extv1beta1.Ingress) (*networkingv1beta1.Ingress, error) {
+	data, err := json.Marshal(ing)
+	if err != nil {
+		return nil, err
+	}
+	out := &networkingv1beta1.Ingress{}
+	if err := json.Unmarshal(data, out); err != nil {
+		return nil, err
+	}
+	out.TypeMeta = metav1.TypeMeta{}
+	return out, nil
+}
+
+// fromNetworkingIngress converts the result of a networking.k8s.io/v1beta1
+// Ingress client call to its extensions/v1beta1 equivalent.
+func fromNetworkingIngress(ing *networkingv1beta1.Ingress, err error) (*extv1beta1.Ingress, error) {
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(ing)
+	if err != nil {
+		return nil, err
+	}
+	out := &extv1beta1.Ingress{}
+	if err := json.Unmarshal(data, out); err != nil {
+		return nil, err
+	}
+	out.TypeMeta = metav1.TypeMeta{}
+	return out, nil
+}
+
+func fromNetworkingIngressList(ings []*networkingv1beta1.Ingress, err error) ([]*extv1beta1.Ingress, error) {
+	if err != nil {
+		return nil, err
+	}
+	out := make([]*extv1beta1.Ingress, 0, len(ings))
+	for _, ing := range ings {
+		converted, err := fromNetworkingIngress(ing, nil)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, converted)
+	}
+	return out, nil
+}
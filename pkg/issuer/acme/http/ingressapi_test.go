@@ -0,0 +1,205 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"testing"
+
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/util/flowcontrol"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/controller"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+)
+
+func TestDiscoverIngressAPIGroup(t *testing.T) {
+	tests := map[string]struct {
+		resources []*metav1.APIResourceList
+		expected  string
+	}{
+		"should use the extensions group if networking.k8s.io is not served": {
+			expected: IngressAPIGroupExtensions,
+		},
+		"should use the extensions group if networking.k8s.io does not serve ingresses": {
+			resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "networking.k8s.io/v1beta1",
+					APIResources: []metav1.APIResource{{Name: "networkpolicies"}},
+				},
+			},
+			expected: IngressAPIGroupExtensions,
+		},
+		"should use the networking.k8s.io group if it serves ingresses": {
+			resources: []*metav1.APIResourceList{
+				{
+					GroupVersion: "extensions/v1beta1",
+					APIResources: []metav1.APIResource{{Name: "ingresses"}},
+				},
+				{
+					GroupVersion: "networking.k8s.io/v1beta1",
+					APIResources: []metav1.APIResource{{Name: "ingresses"}},
+				},
+			},
+			expected: IngressAPIGroupNetworking,
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cl := kubefake.NewSimpleClientset()
+			cl.Discovery().(*fakediscovery.FakeDiscovery).Resources = tc.resources
+			if group := DiscoverIngressAPIGroup(cl.Discovery()); group != tc.expected {
+				t.Errorf("expected ingress API group %q but got %q", tc.expected, group)
+			}
+		})
+	}
+}
+
+func TestNetworkingIngressAPI(t *testing.T) {
+	chal := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Token:   "abcd",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+				},
+			},
+		},
+	}
+	f := solverFixture{
+		Challenge: chal,
+		Builder: &test.Builder{
+			Context: &controller.Context{
+				RootContext: context.Background(),
+				ACMEOptions: controller.ACMEOptions{
+					HTTP01IngressAPIGroup: IngressAPIGroupNetworking,
+				},
+			},
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	created, err := f.Solver.createIngress(context.TODO(), chal, "fakeservice")
+	if err != nil {
+		t.Fatalf("unexpected error creating ingress: %v", err)
+	}
+	f.Builder.Sync()
+
+	// the ingress should only have been created in the networking.k8s.io group
+	if _, err := f.Builder.FakeKubeClient().NetworkingV1beta1().Ingresses(chal.Namespace).Get(created.Name, metav1.GetOptions{}); err != nil {
+		t.Errorf("expected ingress to be created in the networking.k8s.io group: %v", err)
+	}
+	extIngresses, err := f.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(chal.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("error listing ingresses: %v", err)
+	}
+	if len(extIngresses.Items) != 0 {
+		t.Errorf("expected no ingresses to be created in the extensions group but got %d", len(extIngresses.Items))
+	}
+
+	ingresses, err := f.Solver.getIngressesForChallenge(context.TODO(), chal)
+	if err != nil {
+		t.Fatalf("unexpected error listing ingresses: %v", err)
+	}
+	if len(ingresses) != 1 || ingresses[0].Name != created.Name {
+		t.Fatalf("expected ingress %q to be listed but got %v", created.Name, ingresses)
+	}
+	if rules := ingresses[0].Spec.Rules; len(rules) != 1 || rules[0].Host != "example.com" || len(rules[0].HTTP.Paths) != 1 {
+		t.Errorf("expected listed ingress to have a single challenge path for example.com but got %+v", rules)
+	}
+
+	if err := f.Solver.cleanupIngresses(context.TODO(), chal); err != nil {
+		t.Fatalf("unexpected error cleaning up ingresses: %v", err)
+	}
+	f.Builder.Sync()
+	remaining, err := f.Solver.ingressLister.List(labels.Everything())
+	if err != nil {
+		t.Fatalf("error listing ingresses: %v", err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("expected ingress to be cleaned up but found %d ingresses", len(remaining))
+	}
+}
+
+// countingRateLimiter is a rate limiter that never blocks and counts the
+// number of tokens taken.
+type countingRateLimiter struct {
+	flowcontrol.RateLimiter
+	accepted int
+}
+
+func (l *countingRateLimiter) Wait(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	l.accepted++
+	return nil
+}
+
+func TestRateLimitedIngressClient(t *testing.T) {
+	cl := kubefake.NewSimpleClientset()
+	limiter := &countingRateLimiter{RateLimiter: flowcontrol.NewFakeAlwaysRateLimiter()}
+	c := &rateLimitedIngressClient{
+		client:  &extensionsIngressClient{client: cl},
+		limiter: limiter,
+	}
+	ingresses := c.Ingresses(context.TODO(), defaultTestNamespace)
+
+	ing, err := ingresses.Create(&extv1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "test"}})
+	if err != nil {
+		t.Fatalf("unexpected error creating ingress: %v", err)
+	}
+	if _, err := ingresses.Get(ing.Name, metav1.GetOptions{}); err != nil {
+		t.Fatalf("unexpected error getting ingress: %v", err)
+	}
+	if _, err := ingresses.Update(ing); err != nil {
+		t.Fatalf("unexpected error updating ingress: %v", err)
+	}
+	if _, err := ingresses.Patch(ing.Name, types.MergePatchType, []byte(`{}`)); err != nil {
+		t.Fatalf("unexpected error patching ingress: %v", err)
+	}
+	if err := ingresses.Delete(ing.Name, nil); err != nil {
+		t.Fatalf("unexpected error deleting ingress: %v", err)
+	}
+
+	// every call apart from the Get should have been rate limited
+	if limiter.accepted != 4 {
+		t.Errorf("expected 4 rate limited calls but got %d", limiter.accepted)
+	}
+
+	// calls waiting for the limiter give up once the context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Ingresses(ctx, defaultTestNamespace).Create(&extv1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Name: "test"}}); err != context.Canceled {
+		t.Errorf("expected create to fail with %v but got: %v", context.Canceled, err)
+	}
+	if len(cl.Actions()) != 5 {
+		t.Errorf("expected no API call to be made once the context is cancelled but got %d calls", len(cl.Actions())-5)
+	}
+}
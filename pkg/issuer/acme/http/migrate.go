@@ -0,0 +1,155 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	logf "github.com/jetstack/cert-manager/pkg/logs"
+)
+
+// legacyOrderURLAnnotationKey was set to the URL of the ACME order on solver
+// ingresses created by older versions of cert-manager, which did not label
+// them. Such ingresses are not found by label selectors until they have been
+// migrated by MigrateLegacyIngresses.
+const legacyOrderURLAnnotationKey = "certmanager.k8s.io/acme-order-url"
+
+// MigrateLegacyIngresses labels solver ingresses created by older versions of
+// cert-manager, which only marked them with an annotation, so that they are
+// found and cleaned up like any other solver ingress. Each ingress is matched
+// to the challenge for the same order whose domain and token it routes, and
+// is adopted by that challenge. Ingresses that have already been migrated, or
+// that do not match a challenge, are left unchanged, so it is safe to call
+// this repeatedly.
+func (s *Solver) MigrateLegacyIngresses(ctx context.Context) error {
+	log := logf.FromContext(ctx, "migrateLegacyIngresses")
+
+	ingresses, err := s.ingressLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, ing := range ingresses {
+		orderURL, ok := ing.Annotations[legacyOrderURLAnnotationKey]
+		if !ok || ing.Labels[solverIdentificationLabelKey] == "true" {
+			continue
+		}
+		log := logf.WithRelatedResource(log, ing)
+
+		ch, err := s.legacyIngressChallenge(ing, orderURL)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if ch == nil {
+			log.V(logf.DebugLevel).Info("no challenge found for legacy solver ingress, skipping it")
+			continue
+		}
+
+		log.Info("migrating legacy solver ingress", "challenge", ch.Name)
+		_, err = s.ingressClient.Ingresses(ctx, ing.Namespace).Update(s.migratedIngress(ch, ing))
+		if k8sErrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			log.Error(err, "failed to migrate legacy solver ingress")
+			errs = append(errs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(errs)
+}
+
+// legacyIngressChallenge returns the HTTP01 challenge for the given order URL
+// whose domain and token are routed by the given legacy solver ingress, or
+// nil if there is none.
+func (s *Solver) legacyIngressChallenge(ing *extv1beta1.Ingress, orderURL string) (*cmacme.Challenge, error) {
+	chs, err := s.challengeLister.Challenges(ing.Namespace).List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, ch := range chs {
+		if ch.Spec.Type != cmacme.ACMEChallengeTypeHTTP01 || ch.Annotations[cmacme.ACMEOrderURLAnnotationKey] != orderURL {
+			continue
+		}
+		if ingressRoutesToken(ing, ch.Spec.DNSName, ch.Spec.Token) {
+			return ch, nil
+		}
+	}
+	return nil, nil
+}
+
+// ingressRoutesToken returns true if the given ingress has a challenge path
+// for the given token on a rule for the given domain. Legacy solver ingresses
+// only ever used the plain challenge path.
+func ingressRoutesToken(ing *extv1beta1.Ingress, domain, token string) bool {
+	host := normalizeHost(domain)
+	for _, rule := range ing.Spec.Rules {
+		if normalizeHost(rule.Host) != host || rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			if path.Path == ChallengePath(token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// migratedIngress returns a copy of the given legacy solver ingress with the
+// labels of the given challenge, adopted by the challenge in place of any
+// previous controller. If the solver uses plain owner references, any
+// existing controller is kept.
+func (s *Solver) migratedIngress(ch *cmacme.Challenge, ing *extv1beta1.Ingress) *extv1beta1.Ingress {
+	ing = ing.DeepCopy()
+
+	if ing.Labels == nil {
+		ing.Labels = make(map[string]string)
+	}
+	for k, v := range podLabels(ch) {
+		ing.Labels[k] = v
+	}
+	if h := orderHashForChallenge(ch); h != "" {
+		ing.Labels[orderHashLabelKey] = h
+	}
+
+	if ing.Annotations == nil {
+		ing.Annotations = make(map[string]string)
+	}
+	ing.Annotations[domainAnnotationKey] = ch.Spec.DNSName
+	delete(ing.Annotations, legacyOrderURLAnnotationKey)
+
+	var refs []metav1.OwnerReference
+	for _, ref := range ing.OwnerReferences {
+		if ref.Controller != nil && *ref.Controller && !s.plainOwnerRefs {
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	ing.OwnerReferences = append(refs, s.challengeOwnerRef(ch))
+
+	return ing
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"testing"
+
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+)
+
+func legacyTestIngress(name, orderURL, domain, token string) *extv1beta1.Ingress {
+	return &extv1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   defaultTestNamespace,
+			Annotations: map[string]string{legacyOrderURLAnnotationKey: orderURL},
+		},
+		Spec: extv1beta1.IngressSpec{
+			Rules: []extv1beta1.IngressRule{
+				{
+					Host: domain,
+					IngressRuleValue: extv1beta1.IngressRuleValue{
+						HTTP: &extv1beta1.HTTPIngressRuleValue{
+							Paths: []extv1beta1.HTTPIngressPath{
+								{
+									Path: ChallengePath(token),
+									Backend: extv1beta1.IngressBackend{
+										ServiceName: "legacyservice",
+										ServicePort: intstr.FromInt(acmeSolverListenPort),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestMigrateLegacyIngresses(t *testing.T) {
+	const orderURL = "https://acme.example.com/order/1"
+	ch := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "testchal",
+			Namespace:   defaultTestNamespace,
+			UID:         "chal-uid",
+			Annotations: map[string]string{cmacme.ACMEOrderURLAnnotationKey: orderURL},
+		},
+		Spec: cmacme.ChallengeSpec{
+			Type:    cmacme.ACMEChallengeTypeHTTP01,
+			DNSName: "example.com",
+			Token:   "token",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+				},
+			},
+		},
+	}
+	legacy := legacyTestIngress("legacy", orderURL, "example.com", "token")
+	otherOrder := legacyTestIngress("otherorder", "https://acme.example.com/order/2", "example.com", "token")
+	otherToken := legacyTestIngress("othertoken", orderURL, "example.com", "othertoken")
+
+	f := solverFixture{
+		Builder: &test.Builder{
+			CertManagerObjects: []runtime.Object{ch},
+			KubeObjects:        []runtime.Object{legacy, otherOrder, otherToken},
+		},
+		Challenge: ch,
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	if err := f.Solver.MigrateLegacyIngresses(context.TODO()); err != nil {
+		t.Fatalf("unexpected error migrating legacy ingresses: %v", err)
+	}
+	f.Builder.Sync()
+
+	ingresses, err := f.Solver.getIngressesForChallenge(context.TODO(), ch)
+	if err != nil {
+		t.Fatalf("unexpected error listing ingresses: %v", err)
+	}
+	if len(ingresses) != 1 || ingresses[0].Name != legacy.Name {
+		t.Fatalf("expected only the legacy ingress %q to be found for the challenge but got %v", legacy.Name, ingresses)
+	}
+	migrated := ingresses[0]
+	if _, ok := migrated.Annotations[legacyOrderURLAnnotationKey]; ok {
+		t.Errorf("expected legacy annotation to be removed from migrated ingress")
+	}
+	if migrated.Labels[orderHashLabelKey] != orderHashForChallenge(ch) {
+		t.Errorf("expected migrated ingress to be labelled with the order hash %q but got %q", orderHashForChallenge(ch), migrated.Labels[orderHashLabelKey])
+	}
+
+	for _, name := range []string{otherOrder.Name, otherToken.Name} {
+		ing, err := f.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(defaultTestNamespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error getting ingress %q: %v", name, err)
+		}
+		if len(ing.Labels) != 0 || len(ing.OwnerReferences) != 0 {
+			t.Errorf("expected ingress %q not matching the challenge to be unchanged but got labels %v and owners %v", name, ing.Labels, ing.OwnerReferences)
+		}
+	}
+
+	// running the migration again must not modify any ingress
+	actions := len(f.Builder.FakeKubeClient().Actions())
+	if err := f.Solver.MigrateLegacyIngresses(context.TODO()); err != nil {
+		t.Fatalf("unexpected error migrating legacy ingresses again: %v", err)
+	}
+	if n := len(f.Builder.FakeKubeClient().Actions()); n != actions {
+		t.Errorf("expected no further API calls when migrating again but got %d", n-actions)
+	}
+}
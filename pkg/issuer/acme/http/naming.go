@@ -0,0 +1,110 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+)
+
+const (
+	// GenerateNamingStrategy names solver resources by appending a random
+	// suffix, chosen by the API server, to the solver name prefix.
+	GenerateNamingStrategy = "generate"
+	// DeterministicNamingStrategy names solver resources by appending a hash
+	// of the challenge to the solver name prefix, so that the same challenge
+	// always results in the same names.
+	DeterministicNamingStrategy = "deterministic"
+)
+
+// NamingStrategy names the services and ingresses created by the solver.
+// Solver resources are always found by their labels, so a strategy may name
+// them freely, e.g. to comply with an organisation's naming policy.
+type NamingStrategy interface {
+	// SetName sets the Name or GenerateName of a solver service or ingress
+	// created to solve the given challenge, given the configured solver name
+	// prefix. Names must be valid for both.
+	SetName(ch *cmacme.Challenge, prefix string, obj metav1.Object)
+}
+
+var (
+	namingStrategies = map[string]NamingStrategy{
+		GenerateNamingStrategy:      generateNameStrategy{},
+		DeterministicNamingStrategy: deterministicNameStrategy{},
+	}
+	namingStrategiesLock sync.RWMutex
+)
+
+// RegisterNamingStrategy will register a naming strategy so that it can be
+// selected using the controller's solver naming strategy option. 'name'
+// should be unique. Strategies must be registered before the controller is
+// started.
+func RegisterNamingStrategy(name string, n NamingStrategy) {
+	namingStrategiesLock.Lock()
+	defer namingStrategiesLock.Unlock()
+	namingStrategies[name] = n
+}
+
+// NamingStrategyRegistered returns true if a naming strategy has been
+// registered with the given name.
+func NamingStrategyRegistered(name string) bool {
+	namingStrategiesLock.RLock()
+	defer namingStrategiesLock.RUnlock()
+	_, ok := namingStrategies[name]
+	return ok
+}
+
+// namingStrategyFor returns the naming strategy registered with the given
+// name. The generate strategy is used if name is empty or not registered.
+func namingStrategyFor(name string) NamingStrategy {
+	namingStrategiesLock.RLock()
+	defer namingStrategiesLock.RUnlock()
+	if n, ok := namingStrategies[name]; ok {
+		return n
+	}
+	return generateNameStrategy{}
+}
+
+// generateNameStrategy uses the prefix as the GenerateName of resources.
+type generateNameStrategy struct{}
+
+func (generateNameStrategy) SetName(_ *cmacme.Challenge, prefix string, obj metav1.Object) {
+	obj.SetGenerateName(prefix)
+}
+
+// deterministicNameStrategy names resources using the prefix followed by a
+// hash of the namespace, name and domain of the challenge. The prefix is
+// truncated if needed so that names are valid service names. If a resource
+// with the same name is still being deleted, creating its replacement fails
+// until it has been removed.
+type deterministicNameStrategy struct{}
+
+func (deterministicNameStrategy) SetName(ch *cmacme.Challenge, prefix string, obj metav1.Object) {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%s/%s/%s", ch.Namespace, ch.Name, ch.Spec.DNSName)
+	suffix := fmt.Sprintf("%08x", h.Sum32())
+	// service names are limited to 63 characters
+	if max := 63 - len(suffix); len(prefix) > max {
+		prefix = prefix[:max]
+	}
+	obj.SetName(prefix + suffix)
+}
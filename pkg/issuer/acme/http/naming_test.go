@@ -0,0 +1,146 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+)
+
+func namingTestChallenge(name, dnsName string) *cmacme.Challenge {
+	return &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: defaultTestNamespace,
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: dnsName,
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+				},
+			},
+		},
+	}
+}
+
+func TestNamingStrategyFor(t *testing.T) {
+	ch := namingTestChallenge("testchal", "example.com")
+	tests := map[string]struct {
+		strategy         string
+		prefix           string
+		expectGenerate   bool
+		expectNamePrefix string
+	}{
+		"empty strategy uses GenerateName": {
+			prefix:         "cm-acme-http-solver-",
+			expectGenerate: true,
+		},
+		"unknown strategy uses GenerateName": {
+			strategy:       "unknown",
+			prefix:         "cm-acme-http-solver-",
+			expectGenerate: true,
+		},
+		"generate strategy uses GenerateName": {
+			strategy:       GenerateNamingStrategy,
+			prefix:         "cm-acme-http-solver-",
+			expectGenerate: true,
+		},
+		"deterministic strategy sets the name": {
+			strategy:         DeterministicNamingStrategy,
+			prefix:           "cm-acme-http-solver-",
+			expectNamePrefix: "cm-acme-http-solver-",
+		},
+		"deterministic strategy truncates long prefixes": {
+			strategy:         DeterministicNamingStrategy,
+			prefix:           strings.Repeat("a", 70) + "-",
+			expectNamePrefix: strings.Repeat("a", 55),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			obj := &metav1.ObjectMeta{}
+			namingStrategyFor(test.strategy).SetName(ch, test.prefix, obj)
+			if test.expectGenerate {
+				if obj.GenerateName != test.prefix || obj.Name != "" {
+					t.Errorf("expected GenerateName %q and no name but got GenerateName %q and name %q", test.prefix, obj.GenerateName, obj.Name)
+				}
+				return
+			}
+			if obj.GenerateName != "" {
+				t.Errorf("expected no GenerateName but got %q", obj.GenerateName)
+			}
+			if !strings.HasPrefix(obj.Name, test.expectNamePrefix) {
+				t.Errorf("expected name %q to begin with %q", obj.Name, test.expectNamePrefix)
+			}
+			if errs := validation.IsDNS1035Label(obj.Name); len(errs) > 0 {
+				t.Errorf("expected name %q to be a valid service name: %s", obj.Name, strings.Join(errs, ", "))
+			}
+		})
+	}
+}
+
+func TestDeterministicNamingStrategy(t *testing.T) {
+	nameFor := func(ch *cmacme.Challenge) string {
+		obj := &metav1.ObjectMeta{}
+		deterministicNameStrategy{}.SetName(ch, "cm-acme-http-solver-", obj)
+		return obj.Name
+	}
+
+	name := nameFor(namingTestChallenge("testchal", "example.com"))
+	if again := nameFor(namingTestChallenge("testchal", "example.com")); again != name {
+		t.Errorf("expected the same challenge to be named %q but got %q", name, again)
+	}
+	if other := nameFor(namingTestChallenge("testchal", "www.example.com")); other == name {
+		t.Errorf("expected challenges for different domains to be named differently but both were named %q", name)
+	}
+	if other := nameFor(namingTestChallenge("otherchal", "example.com")); other == name {
+		t.Errorf("expected different challenges to be named differently but both were named %q", name)
+	}
+}
+
+func TestSolverNamingStrategy(t *testing.T) {
+	ch := namingTestChallenge("testchal", "example.com")
+	f := solverFixture{Challenge: ch}
+	f.Setup(t)
+	defer f.Finish(t)
+	f.Solver.naming = deterministicNameStrategy{}
+
+	expected := &metav1.ObjectMeta{}
+	f.Solver.naming.SetName(ch, f.Solver.namePrefix, expected)
+
+	svc, err := f.Solver.buildService(ch)
+	if err != nil {
+		t.Fatalf("unexpected error building service: %v", err)
+	}
+	if svc.Name != expected.Name || svc.GenerateName != "" {
+		t.Errorf("expected service to be named %q but got name %q and GenerateName %q", expected.Name, svc.Name, svc.GenerateName)
+	}
+
+	ing, err := f.Solver.buildIngressResource(ch, svc.Name)
+	if err != nil {
+		t.Fatalf("unexpected error building ingress: %v", err)
+	}
+	if ing.Name != expected.Name || ing.GenerateName != "" {
+		t.Errorf("expected ingress to be named %q but got name %q and GenerateName %q", expected.Name, ing.Name, ing.GenerateName)
+	}
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	logf "github.com/jetstack/cert-manager/pkg/logs"
+)
+
+const (
+	reasonNodePortExposed = "NodePortExposed"
+)
+
+// ensureNodePort verifies that the given solver service exposes the solver
+// pod on a node port. It is used in place of ensureIngress for challenges
+// configured to use the NodePort strategy, where routing port 80 traffic to
+// the node port is handled outside of the cluster.
+func (s *Solver) ensureNodePort(ctx context.Context, ch *cmacme.Challenge, svc *corev1.Service) error {
+	log := logf.FromContext(ctx).WithName("ensureNodePort")
+	log = logf.WithRelatedResource(log, svc)
+
+	if svc.Spec.Type != corev1.ServiceTypeNodePort {
+		return fmt.Errorf("existing HTTP01 solver service %q has type %q, expected %q", svc.Name, svc.Spec.Type, corev1.ServiceTypeNodePort)
+	}
+	port := solverNodePort(svc)
+	if port == 0 {
+		return fmt.Errorf("HTTP01 solver service %q has not been allocated a node port", svc.Name)
+	}
+	if want := nodePortCfgForChallenge(ch).Port; want != 0 && want != port {
+		return fmt.Errorf("HTTP01 solver service %q is exposed on node port %d, expected %d", svc.Name, port, want)
+	}
+
+	log.V(logf.DebugLevel).Info("HTTP01 solver is exposed on node port", "node_port", port)
+	return nil
+}
+
+// solverNodePort returns the node port allocated to the solver port of the
+// given service, or zero if one has not been allocated.
+func solverNodePort(svc *corev1.Service) int32 {
+	for _, p := range svc.Spec.Ports {
+		if p.Port == acmeSolverListenPort {
+			return p.NodePort
+		}
+	}
+	return 0
+}
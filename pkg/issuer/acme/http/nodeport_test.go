@@ -0,0 +1,167 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	coretesting "k8s.io/client-go/testing"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+)
+
+func TestPresentNodePort(t *testing.T) {
+	newChallenge := func(port int32) *cmacme.Challenge {
+		return &cmacme.Challenge{
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						NodePort: &cmacme.ACMEChallengeSolverHTTP01NodePort{
+							Port: port,
+						},
+					},
+				},
+			},
+		}
+	}
+	// allocateNodePort simulates the apiserver allocating a node port for
+	// created services that do not request a specific one, and fails the
+	// test if any ingress is created.
+	allocateNodePort := func(t *testing.T, s *solverFixture) {
+		s.Builder.FakeKubeClient().PrependReactor("create", "services", func(action coretesting.Action) (bool, runtime.Object, error) {
+			svc := action.(coretesting.CreateAction).GetObject().(*v1.Service)
+			for i := range svc.Spec.Ports {
+				if svc.Spec.Ports[i].NodePort == 0 {
+					svc.Spec.Ports[i].NodePort = 31234
+				}
+			}
+			return false, nil, nil
+		})
+		s.Builder.FakeKubeClient().PrependReactor("create", "ingresses", func(action coretesting.Action) (bool, runtime.Object, error) {
+			t.Errorf("Present should not create an ingress when using the NodePort strategy")
+			return false, nil, nil
+		})
+	}
+	expectNodePortService := func(expectedPort int32) func(*testing.T, *solverFixture, ...interface{}) {
+		return func(t *testing.T, s *solverFixture, args ...interface{}) {
+			services, err := s.Solver.serviceLister.List(labels.NewSelector())
+			if err != nil {
+				t.Errorf("unexpected error listing services: %v", err)
+				return
+			}
+			if len(services) != 1 {
+				t.Errorf("expected one service to be created but got %d", len(services))
+				return
+			}
+			svc := services[0]
+			if svc.Spec.Type != v1.ServiceTypeNodePort {
+				t.Errorf("expected service type %q but got %q", v1.ServiceTypeNodePort, svc.Spec.Type)
+			}
+			if port := solverNodePort(svc); port != expectedPort {
+				t.Errorf("expected node port %d but got %d", expectedPort, port)
+			}
+			ingresses, err := s.Solver.ingressLister.List(labels.NewSelector())
+			if err != nil {
+				t.Errorf("unexpected error listing ingresses: %v", err)
+				return
+			}
+			if len(ingresses) != 0 {
+				t.Errorf("expected no ingresses to be created but got %d", len(ingresses))
+			}
+		}
+	}
+
+	tests := map[string]solverFixture{
+		"should expose the solver on an allocated node port without creating an ingress": {
+			Challenge: newChallenge(0),
+			PreFn:     allocateNodePort,
+			CheckFn:   expectNodePortService(31234),
+		},
+		"should expose the solver on the configured node port": {
+			Challenge: newChallenge(30080),
+			PreFn:     allocateNodePort,
+			CheckFn:   expectNodePortService(30080),
+		},
+		"should error if the service has not been allocated a node port": {
+			Challenge: newChallenge(0),
+			CheckFn:   expectNodePortService(0),
+			Err:       true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			err := test.Solver.Present(context.TODO(), nil, test.Challenge)
+			if err != nil && !test.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && test.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+			test.Finish(t, err)
+		})
+	}
+}
+
+func TestCleanUpNodePort(t *testing.T) {
+	test := solverFixture{
+		Challenge: &cmacme.Challenge{
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						NodePort: &cmacme.ACMEChallengeSolverHTTP01NodePort{
+							Port: 30080,
+						},
+					},
+				},
+			},
+		},
+		PreFn: func(t *testing.T, s *solverFixture) {
+			if _, err := s.Solver.createService(context.TODO(), s.Challenge); err != nil {
+				t.Errorf("error preparing test: %v", err)
+			}
+			s.Builder.FakeKubeClient().PrependReactor("*", "ingresses", func(action coretesting.Action) (bool, runtime.Object, error) {
+				t.Errorf("CleanUp should not act on ingresses when using the NodePort strategy")
+				return false, nil, nil
+			})
+		},
+		CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+			services, err := s.Solver.serviceLister.List(labels.NewSelector())
+			if err != nil {
+				t.Errorf("unexpected error listing services: %v", err)
+				return
+			}
+			if len(services) != 0 {
+				t.Errorf("expected service to be cleaned up, but %d remain", len(services))
+			}
+		},
+	}
+	test.Setup(t)
+	err := test.Solver.CleanUp(context.TODO(), nil, test.Challenge)
+	if err != nil {
+		t.Errorf("Expected function to not error, but got: %v", err)
+	}
+	test.Finish(t, err)
+}
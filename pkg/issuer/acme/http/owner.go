@@ -0,0 +1,167 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha2"
+	cmacmelisters "github.com/jetstack/cert-manager/pkg/client/listers/acme/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/controller"
+	logf "github.com/jetstack/cert-manager/pkg/logs"
+)
+
+// maxOwnerChainHops is the maximum number of controller references followed
+// when checking whether a solver resource is owned by a resource, which
+// guards against reference cycles.
+const maxOwnerChainHops = 5
+
+// ownerLookup returns the resource referred to by the given controller
+// reference of a resource in the given namespace.
+type ownerLookup func(namespace string, ref metav1.OwnerReference) (metav1.Object, error)
+
+// defaultOwnerChain returns lookups for the resources that indirectly own
+// solver resources: each challenge is controlled by an order, which is
+// controlled by a certificate request, which is in turn controlled by a
+// certificate.
+func defaultOwnerChain(ctx *controller.Context) map[schema.GroupKind]ownerLookup {
+	challengeLister := ctx.SharedInformerFactory.Acme().V1alpha2().Challenges().Lister()
+	orderLister := ctx.SharedInformerFactory.Acme().V1alpha2().Orders().Lister()
+	crLister := ctx.SharedInformerFactory.Certmanager().V1alpha2().CertificateRequests().Lister()
+	return map[schema.GroupKind]ownerLookup{
+		challengeGvk.GroupKind(): func(namespace string, ref metav1.OwnerReference) (metav1.Object, error) {
+			return challengeLister.Challenges(namespace).Get(ref.Name)
+		},
+		cmacme.SchemeGroupVersion.WithKind("Order").GroupKind(): func(namespace string, ref metav1.OwnerReference) (metav1.Object, error) {
+			return orderLister.Orders(namespace).Get(ref.Name)
+		},
+		v1alpha2.SchemeGroupVersion.WithKind(v1alpha2.CertificateRequestKind).GroupKind(): func(namespace string, ref metav1.OwnerReference) (metav1.Object, error) {
+			return crLister.CertificateRequests(namespace).Get(ref.Name)
+		},
+	}
+}
+
+// IsOwnedBy returns true if the given solver resource is controlled by owner,
+// either directly or through a chain of controllers such as
+// ingress -> challenge -> order. The solver resource itself may refer to its
+// challenge with either a controller or a plain owner reference. Only controllers of the kinds in the solver's
+// owner chain are followed. Solver resources created outside of the
+// namespace of their challenge are only considered to be owned by the
+// challenge itself.
+func (s *Solver) IsOwnedBy(obj, owner metav1.Object) (bool, error) {
+	if obj.GetNamespace() != owner.GetNamespace() {
+		return owner.GetUID() != "" && obj.GetLabels()[challengeUIDLabelKey] == string(owner.GetUID()), nil
+	}
+	current := obj
+	for i := 0; i < maxOwnerChainHops; i++ {
+		ref := metav1.GetControllerOf(current)
+		// solver resources may only have a plain owner reference to their
+		// challenge, which takes precedence over any other controller
+		if i == 0 {
+			if chRef := challengeRefOf(current); chRef != nil {
+				ref = chRef
+			}
+		}
+		if ref == nil {
+			return false, nil
+		}
+		if ref.UID == owner.GetUID() {
+			return true, nil
+		}
+		lookup, ok := s.ownerChain[schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind).GroupKind()]
+		if !ok {
+			return false, nil
+		}
+		next, err := lookup(current.GetNamespace(), *ref)
+		if k8sErrors.IsNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		// the controller has since been replaced by a resource with the
+		// same name, which does not own this resource
+		if next.GetUID() != ref.UID {
+			return false, nil
+		}
+		current = next
+	}
+	return false, nil
+}
+
+// IngressesOwnedBy returns the solver ingresses in the namespace of owner
+// that are controlled by it, either directly or through the solver's owner
+// chain, e.g. all solver ingresses created for the challenges of an order.
+func (s *Solver) IngressesOwnedBy(ctx context.Context, owner metav1.Object) ([]*extv1beta1.Ingress, error) {
+	log := logf.FromContext(ctx)
+
+	selector := labels.SelectorFromSet(labels.Set{solverIdentificationLabelKey: "true"})
+	ingresses, err := s.ingressLister.Ingresses(owner.GetNamespace()).List(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var owned []*extv1beta1.Ingress
+	for _, ing := range ingresses {
+		ok, err := s.IsOwnedBy(ing, owner)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			logf.WithRelatedResource(log, ing).V(logf.DebugLevel).Info("skipping solver ingress not owned by resource")
+			continue
+		}
+		owned = append(owned, ing)
+	}
+	return owned, nil
+}
+
+// SolverChallenge returns the challenge that the given solver resource was
+// created for, or nil if it cannot be found. Resources in the namespace of
+// their challenge are matched by owner reference, whether or not it is a
+// controller reference, and those created in the solver namespace by the
+// challenge UID label.
+func SolverChallenge(lister cmacmelisters.ChallengeLister, obj metav1.Object) (*cmacme.Challenge, error) {
+	if ref := challengeRefOf(obj); ref != nil {
+		ch, err := lister.Challenges(obj.GetNamespace()).Get(ref.Name)
+		if k8sErrors.IsNotFound(err) || (err == nil && ch.UID != ref.UID) {
+			return nil, nil
+		}
+		return ch, err
+	}
+	uid := obj.GetLabels()[challengeUIDLabelKey]
+	if uid == "" {
+		return nil, nil
+	}
+	chs, err := lister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, ch := range chs {
+		if string(ch.UID) == uid {
+			return ch, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"testing"
+
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
+)
+
+func ownerTestMeta(name, uid string, owner metav1.Object, gvk schema.GroupVersionKind) metav1.ObjectMeta {
+	meta := metav1.ObjectMeta{
+		Name:      name,
+		Namespace: defaultTestNamespace,
+		UID:       types.UID(uid),
+		Labels:    map[string]string{solverIdentificationLabelKey: "true"},
+	}
+	if owner != nil {
+		meta.OwnerReferences = []metav1.OwnerReference{*metav1.NewControllerRef(owner, gvk)}
+	}
+	return meta
+}
+
+func TestIsOwnedBy(t *testing.T) {
+	crGvk := v1alpha2.SchemeGroupVersion.WithKind(v1alpha2.CertificateRequestKind)
+	orderGvk := cmacme.SchemeGroupVersion.WithKind("Order")
+	cr := &v1alpha2.CertificateRequest{ObjectMeta: ownerTestMeta("testcr", "cr-uid", nil, schema.GroupVersionKind{})}
+	order := &cmacme.Order{ObjectMeta: ownerTestMeta("testorder", "order-uid", cr, crGvk)}
+	otherOrder := &cmacme.Order{ObjectMeta: ownerTestMeta("otherorder", "other-order-uid", nil, schema.GroupVersionKind{})}
+	ch := &cmacme.Challenge{ObjectMeta: ownerTestMeta("testchal", "chal-uid", order, orderGvk)}
+	ing := &extv1beta1.Ingress{ObjectMeta: ownerTestMeta("testing", "ing-uid", ch, challengeGvk)}
+	// an ingress owned by a challenge that no longer exists
+	orphan := &extv1beta1.Ingress{ObjectMeta: ownerTestMeta("orphan", "orphan-uid",
+		&cmacme.Challenge{ObjectMeta: ownerTestMeta("deletedchal", "deleted-uid", nil, schema.GroupVersionKind{})}, challengeGvk)}
+	// an ingress owned by a previous challenge with the same name
+	replaced := &extv1beta1.Ingress{ObjectMeta: ownerTestMeta("replaced", "replaced-uid",
+		&cmacme.Challenge{ObjectMeta: ownerTestMeta("testchal", "old-chal-uid", nil, schema.GroupVersionKind{})}, challengeGvk)}
+
+	tests := map[string]struct {
+		obj      metav1.Object
+		owner    metav1.Object
+		expected bool
+	}{
+		"ingress is owned by its challenge": {
+			obj:      ing,
+			owner:    ch,
+			expected: true,
+		},
+		"ingress is owned by the order of its challenge": {
+			obj:      ing,
+			owner:    order,
+			expected: true,
+		},
+		"ingress is owned by the certificate request of the order of its challenge": {
+			obj:      ing,
+			owner:    cr,
+			expected: true,
+		},
+		"ingress is not owned by an unrelated order": {
+			obj:   ing,
+			owner: otherOrder,
+		},
+		"challenge is not owned by its ingress": {
+			obj:   ch,
+			owner: ing,
+		},
+		"ingress whose challenge no longer exists is not owned by the order": {
+			obj:   orphan,
+			owner: order,
+		},
+		"ingress whose challenge has been replaced is not owned by the order": {
+			obj:   replaced,
+			owner: order,
+		},
+	}
+
+	f := solverFixture{
+		Builder: &test.Builder{
+			CertManagerObjects: []runtime.Object{cr, order, otherOrder, ch},
+			KubeObjects:        []runtime.Object{ing, orphan, replaced},
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			owned, err := f.Solver.IsOwnedBy(test.obj, test.owner)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if owned != test.expected {
+				t.Errorf("expected IsOwnedBy to return %t but got %t", test.expected, owned)
+			}
+		})
+	}
+
+	ingresses, err := f.Solver.IngressesOwnedBy(context.TODO(), order)
+	if err != nil {
+		t.Fatalf("unexpected error listing ingresses owned by order: %v", err)
+	}
+	if len(ingresses) != 1 || ingresses[0].Name != ing.Name {
+		t.Errorf("expected only ingress %q to be owned by the order but got %v", ing.Name, ingresses)
+	}
+}
@@ -20,32 +20,74 @@ import (
 	"context"
 	"fmt"
 	"hash/adler32"
+	"path"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/selection"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
 	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/issuer/acme/http/solver"
 	logf "github.com/jetstack/cert-manager/pkg/logs"
 )
 
 func podLabels(ch *cmacme.Challenge) map[string]string {
 	domainHash := fmt.Sprintf("%d", adler32.Checksum([]byte(ch.Spec.DNSName)))
 	tokenHash := fmt.Sprintf("%d", adler32.Checksum([]byte(ch.Spec.Token)))
+	// ACME servers may re-use a pending authorization (and therefore token)
+	// for multiple orders, so two Challenge resources in the same namespace
+	// can share the same domain and token. Include a hash of the challenge
+	// name so that label selectors only ever match resources for a single
+	// challenge.
+	challengeHash := fmt.Sprintf("%d", adler32.Checksum([]byte(ch.Name)))
 	solverIdent := "true"
 	return map[string]string{
-		// TODO: we need to support domains longer than 63 characters
-		// this value should probably be hashed, and then the full plain text
-		// value stored as an annotation to make it easier for users to read
-		// see #425 for details: https://github.com/jetstack/cert-manager/issues/425
+		// label values are limited to 63 characters, so the domain is
+		// hashed and its full value recorded in an annotation by
+		// setResourceOwner
 		domainLabelKey:               domainHash,
 		tokenLabelKey:                tokenHash,
+		challengeLabelKey:            challengeHash,
 		solverIdentificationLabelKey: solverIdent,
 	}
 }
 
+// solverSelector returns a selector for the solver resources of the given
+// challenge.
+func solverSelector(ch *cmacme.Challenge) labels.Selector {
+	return labels.SelectorFromSet(podLabels(ch))
+}
+
+// legacySolverSelector returns a selector for the solver resources of the
+// given challenge that were created before the challenge label was
+// introduced. Only resources without the challenge label are matched, so
+// resources of other challenges with the same domain and token are never
+// selected unless they are also unlabeled, in which case they are filtered
+// out by their owner.
+func legacySolverSelector(ch *cmacme.Challenge) labels.Selector {
+	// challengeLabelKey is a valid label key, so this cannot fail
+	req, _ := labels.NewRequirement(challengeLabelKey, selection.DoesNotExist, nil)
+	return labels.SelectorFromSet(legacySolverLabels(ch)).Add(*req)
+}
+
+// solverSelectors returns the selectors that together match every solver
+// resource of the given challenge.
+func solverSelectors(ch *cmacme.Challenge) []labels.Selector {
+	return []labels.Selector{solverSelector(ch), legacySolverSelector(ch)}
+}
+
+// legacySolverLabels returns the labels of solver resources created before
+// the challenge label was introduced.
+func legacySolverLabels(ch *cmacme.Challenge) map[string]string {
+	lbls := podLabels(ch)
+	delete(lbls, challengeLabelKey)
+	return lbls
+}
+
 func (s *Solver) ensurePod(ctx context.Context, ch *cmacme.Challenge) (*corev1.Pod, error) {
 	log := logf.FromContext(ctx).WithName("ensurePod")
 
@@ -69,32 +111,49 @@ func (s *Solver) ensurePod(ctx context.Context, ch *cmacme.Challenge) (*corev1.P
 
 	log.Info("creating HTTP01 challenge solver pod")
 
-	return s.createPod(ch)
+	return s.createPod(ctx, ch)
 }
 
 // getPodsForChallenge returns a list of pods that were created to solve
 // the given challenge
 func (s *Solver) getPodsForChallenge(ctx context.Context, ch *cmacme.Challenge) ([]*corev1.Pod, error) {
-	log := logf.FromContext(ctx)
-
-	podLabels := podLabels(ch)
-	orderSelector := labels.NewSelector()
-	for key, val := range podLabels {
-		req, err := labels.NewRequirement(key, selection.Equals, []string{val})
+	var podList []*corev1.Pod
+	for _, selector := range solverSelectors(ch) {
+		pods, err := s.podLister.Pods(s.resourceNamespace(ch)).List(selector)
 		if err != nil {
 			return nil, err
 		}
-		orderSelector = orderSelector.Add(*req)
+		podList = append(podList, pods...)
 	}
 
-	podList, err := s.podLister.Pods(ch.Namespace).List(orderSelector)
-	if err != nil {
-		return nil, err
+	return s.filterPodsForChallenge(ctx, ch, podList), nil
+}
+
+// listPodsForChallenge is like getPodsForChallenge, but queries the apiserver
+// directly rather than the lister's cache, so that pods that have only just
+// been created are also returned.
+func (s *Solver) listPodsForChallenge(ctx context.Context, ch *cmacme.Challenge) ([]*corev1.Pod, error) {
+	var pods []*corev1.Pod
+	for _, selector := range solverSelectors(ch) {
+		podList, err := s.Client.CoreV1().Pods(s.resourceNamespace(ch)).List(metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return nil, err
+		}
+		for i := range podList.Items {
+			pods = append(pods, &podList.Items[i])
+		}
 	}
+	return s.filterPodsForChallenge(ctx, ch, pods), nil
+}
+
+// filterPodsForChallenge returns the pods in the given list that were created
+// by the solver for the given challenge.
+func (s *Solver) filterPodsForChallenge(ctx context.Context, ch *cmacme.Challenge, podList []*corev1.Pod) []*corev1.Pod {
+	log := logf.FromContext(ctx)
 
 	var relevantPods []*corev1.Pod
 	for _, pod := range podList {
-		if !metav1.IsControlledBy(pod, ch) {
+		if !s.isResourceOwner(ch, pod) {
 			logf.WithRelatedResource(log, pod).Info("found existing solver pod for this challenge resource, however " +
 				"it does not have an appropriate OwnerReference referencing this challenge. Skipping it altogether.")
 			continue
@@ -102,7 +161,7 @@ func (s *Solver) getPodsForChallenge(ctx context.Context, ch *cmacme.Challenge)
 		relevantPods = append(relevantPods, pod)
 	}
 
-	return relevantPods, nil
+	return relevantPods
 }
 
 func (s *Solver) cleanupPods(ctx context.Context, ch *cmacme.Challenge) error {
@@ -118,6 +177,10 @@ func (s *Solver) cleanupPods(ctx context.Context, ch *cmacme.Challenge) error {
 		log.Info("deleting pod resource")
 
 		err := s.Client.CoreV1().Pods(pod.Namespace).Delete(pod.Name, nil)
+		if k8sErrors.IsNotFound(err) {
+			log.Info("pod resource has already been deleted")
+			continue
+		}
 		if err != nil {
 			log.Info("failed to delete pod resource", "error", err)
 			errs = append(errs, err)
@@ -131,9 +194,22 @@ func (s *Solver) cleanupPods(ctx context.Context, ch *cmacme.Challenge) error {
 
 // createPod will create a challenge solving pod for the given certificate,
 // domain, token and key.
-func (s *Solver) createPod(ch *cmacme.Challenge) (*corev1.Pod, error) {
-	return s.Client.CoreV1().Pods(ch.Namespace).Create(
-		s.buildPod(ch))
+func (s *Solver) createPod(ctx context.Context, ch *cmacme.Challenge) (*corev1.Pod, error) {
+	pod := s.buildPod(ch)
+	var created *corev1.Pod
+	err := s.retryCreate(ctx, func() error {
+		var err error
+		created, err = s.Client.CoreV1().Pods(pod.Namespace).Create(pod)
+		return err
+	}, func() (bool, error) {
+		existing, err := s.listPodsForChallenge(ctx, ch)
+		if err != nil || len(existing) == 0 {
+			return false, err
+		}
+		created = existing[0]
+		return true, nil
+	})
+	return created, err
 }
 
 // buildPod will build a challenge solving pod for the given certificate,
@@ -142,28 +218,83 @@ func (s *Solver) buildPod(ch *cmacme.Challenge) *corev1.Pod {
 	pod := s.buildDefaultPod(ch)
 
 	// Override defaults if they have changed in the pod template.
-	if ch.Spec.Solver != nil &&
-		ch.Spec.Solver.HTTP01 != nil &&
-		ch.Spec.Solver.HTTP01.Ingress != nil {
-		pod = s.mergePodObjectMetaWithPodTemplate(pod,
-			ch.Spec.Solver.HTTP01.Ingress.PodTemplate)
+	if ch.Spec.Solver != nil && ch.Spec.Solver.HTTP01 != nil {
+		if basePaths := s.extraBasePaths(ch); len(basePaths) > 0 {
+			pod.Spec.Containers[0].Args = append(pod.Spec.Containers[0].Args,
+				fmt.Sprintf("--extra-base-paths=%s", strings.Join(basePaths, ",")))
+		}
+		switch {
+		case ch.Spec.Solver.HTTP01.Ingress != nil:
+			if port := hostNetworkPort(ch); port != 0 {
+				// a pod in the host network namespace must use the same
+				// host and container port
+				pod.Spec.HostNetwork = true
+				pod.Spec.DNSPolicy = corev1.DNSClusterFirstWithHostNet
+				pod.Spec.Containers[0].Args[0] = fmt.Sprintf("--listen-port=%d", port)
+				pod.Spec.Containers[0].Ports[0].ContainerPort = port
+				pod.Spec.Containers[0].Ports[0].HostPort = port
+			}
+			pod = s.mergePodObjectMetaWithPodTemplate(pod,
+				ch.Spec.Solver.HTTP01.Ingress.PodTemplate)
+		case ch.Spec.Solver.HTTP01.NodePort != nil:
+			pod = s.mergePodObjectMetaWithPodTemplate(pod,
+				ch.Spec.Solver.HTTP01.NodePort.PodTemplate)
+		case ch.Spec.Solver.HTTP01.GatewayHTTPRoute != nil:
+			pod = s.mergePodObjectMetaWithPodTemplate(pod,
+				ch.Spec.Solver.HTTP01.GatewayHTTPRoute.PodTemplate)
+		}
+	}
+	if tlsALPNCfg := tlsALPNCfgForChallenge(ch); tlsALPNCfg != nil {
+		pod.Spec.Containers[0].Args = append(pod.Spec.Containers[0].Args,
+			fmt.Sprintf("--challenge-type=%s", cmacme.ACMEChallengeTypeTLSALPN01))
+		pod.Spec.Containers[0].Ports[0].Name = "https"
+		pod = s.mergePodObjectMetaWithPodTemplate(pod, tlsALPNCfg.PodTemplate)
 	}
 
 	return pod
 }
 
+// extraBasePaths returns the base paths, besides the canonical challenge
+// path, that the solver pod for the given challenge must accept requests on:
+// the extra path prefixes of an ingress solver, and the base of the challenge
+// path if it has been customised, e.g. by a path template.
+func (s *Solver) extraBasePaths(ch *cmacme.Challenge) []string {
+	var basePaths []string
+	if httpDomainCfg, err := httpDomainCfgForChallenge(ch); err == nil {
+		basePaths = append(basePaths, httpDomainCfg.ExtraPathPrefixes...)
+	}
+	base := path.Dir(s.challengePath(ch))
+	if base == solver.HTTPChallengePath {
+		return basePaths
+	}
+	for _, p := range basePaths {
+		if p == base {
+			return basePaths
+		}
+	}
+	return append(basePaths, base)
+}
+
+// hostNetworkPort returns the node port that the solver pod for the given
+// challenge should listen on in the host network namespace, or zero if the
+// solver pod should use the pod network.
+func hostNetworkPort(ch *cmacme.Challenge) int32 {
+	if ch.Spec.Solver == nil || ch.Spec.Solver.HTTP01 == nil || ch.Spec.Solver.HTTP01.Ingress == nil {
+		return 0
+	}
+	return ch.Spec.Solver.HTTP01.Ingress.HostNetworkPort
+}
+
 func (s *Solver) buildDefaultPod(ch *cmacme.Challenge) *corev1.Pod {
 	podLabels := podLabels(ch)
 
-	return &corev1.Pod{
+	pod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
-			GenerateName: "cm-acme-http-solver-",
-			Namespace:    ch.Namespace,
+			GenerateName: s.namePrefix,
 			Labels:       podLabels,
 			Annotations: map[string]string{
 				"sidecar.istio.io/inject": "false",
 			},
-			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(ch, challengeGvk)},
 		},
 		Spec: corev1.PodSpec{
 			RestartPolicy: corev1.RestartPolicyOnFailure,
@@ -200,6 +331,11 @@ func (s *Solver) buildDefaultPod(ch *cmacme.Challenge) *corev1.Pod {
 			},
 		},
 	}
+	for _, name := range s.ACMEOptions.HTTP01SolverImagePullSecrets {
+		pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, corev1.LocalObjectReference{Name: name})
+	}
+	s.setResourceOwner(ch, pod)
+	return pod
 }
 
 // Merge object meta from the pod template. Fall back to default values.
@@ -244,5 +380,19 @@ func (s *Solver) mergePodObjectMetaWithPodTemplate(pod *corev1.Pod, podTempl *cm
 		pod.Spec.Affinity = podTempl.Spec.Affinity
 	}
 
+	pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, podTempl.Spec.ImagePullSecrets...)
+
+	// resources set in the template override the controller's defaults,
+	// leaving any that are not set in the template as they are
+	if podTempl.Spec.Resources != nil {
+		resources := &pod.Spec.Containers[0].Resources
+		for name, quantity := range podTempl.Spec.Resources.Requests {
+			resources.Requests[name] = quantity
+		}
+		for name, quantity := range podTempl.Spec.Resources.Limits {
+			resources.Limits[name] = quantity
+		}
+	}
+
 	return pod
 }
@@ -22,6 +22,8 @@ import (
 	"testing"
 
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	coretesting "k8s.io/client-go/testing"
@@ -46,7 +48,7 @@ func TestEnsurePod(t *testing.T) {
 				},
 			},
 			PreFn: func(t *testing.T, s *solverFixture) {
-				ing, err := s.Solver.createPod(s.Challenge)
+				ing, err := s.Solver.createPod(context.TODO(), s.Challenge)
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
@@ -142,11 +144,11 @@ func TestEnsurePod(t *testing.T) {
 			},
 			Err: true,
 			PreFn: func(t *testing.T, s *solverFixture) {
-				_, err := s.Solver.createPod(s.Challenge)
+				_, err := s.Solver.createPod(context.TODO(), s.Challenge)
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
-				_, err = s.Solver.createPod(s.Challenge)
+				_, err = s.Solver.createPod(context.TODO(), s.Challenge)
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
@@ -196,7 +198,7 @@ func TestGetPodsForCertificate(t *testing.T) {
 				},
 			},
 			PreFn: func(t *testing.T, s *solverFixture) {
-				ing, err := s.Solver.createPod(s.Challenge)
+				ing, err := s.Solver.createPod(context.TODO(), s.Challenge)
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
@@ -231,7 +233,7 @@ func TestGetPodsForCertificate(t *testing.T) {
 			PreFn: func(t *testing.T, s *solverFixture) {
 				differentChallenge := s.Challenge.DeepCopy()
 				differentChallenge.Spec.DNSName = "notexample.com"
-				_, err := s.Solver.createPod(differentChallenge)
+				_, err := s.Solver.createPod(context.TODO(), differentChallenge)
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
@@ -305,14 +307,16 @@ func TestMergePodObjectMetaWithPodTemplate(t *testing.T) {
 			PreFn: func(t *testing.T, s *solverFixture) {
 				resultingPod := s.Solver.buildDefaultPod(s.Challenge)
 				resultingPod.Labels = map[string]string{
-					"this is a":                          "label",
-					"acme.cert-manager.io/http-domain":   "44655555555",
-					"acme.cert-manager.io/http-token":    "1",
-					"acme.cert-manager.io/http01-solver": "true",
+					"this is a":                           "label",
+					"acme.cert-manager.io/http-domain":    "44655555555",
+					"acme.cert-manager.io/http-token":     "1",
+					"acme.cert-manager.io/http-challenge": "1",
+					"acme.cert-manager.io/http01-solver":  "true",
 				}
 				resultingPod.Annotations = map[string]string{
-					"sidecar.istio.io/inject": "true",
-					"foo":                     "bar",
+					"sidecar.istio.io/inject":          "true",
+					"foo":                              "bar",
+					"acme.cert-manager.io/http-domain": "example.com",
 				}
 				resultingPod.Spec.NodeSelector = map[string]string{
 					"node": "selector",
@@ -348,6 +352,93 @@ func TestMergePodObjectMetaWithPodTemplate(t *testing.T) {
 				}
 			},
 		},
+		"should override default resources with those set in the template": {
+			Challenge: &cmacme.Challenge{
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+								PodTemplate: &cmacme.ACMEChallengeSolverHTTP01IngressPodTemplate{
+									Spec: cmacme.ACMEChallengeSolverHTTP01IngressPodSpec{
+										Resources: &v1.ResourceRequirements{
+											Requests: v1.ResourceList{
+												v1.ResourceCPU: resource.MustParse("50m"),
+											},
+											Limits: v1.ResourceList{
+												v1.ResourceMemory: resource.MustParse("128Mi"),
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			PreFn: func(t *testing.T, s *solverFixture) {
+				s.Solver.ACMEOptions.HTTP01SolverResourceRequestCPU = resource.MustParse("10m")
+				s.Solver.ACMEOptions.HTTP01SolverResourceRequestMemory = resource.MustParse("64Mi")
+				s.Solver.ACMEOptions.HTTP01SolverResourceLimitsCPU = resource.MustParse("100m")
+				s.Solver.ACMEOptions.HTTP01SolverResourceLimitsMemory = resource.MustParse("64Mi")
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				expected := v1.ResourceRequirements{
+					Requests: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("50m"),
+						v1.ResourceMemory: resource.MustParse("64Mi"),
+					},
+					Limits: v1.ResourceList{
+						v1.ResourceCPU:    resource.MustParse("100m"),
+						v1.ResourceMemory: resource.MustParse("128Mi"),
+					},
+				}
+
+				resp, ok := args[0].(*v1.Pod)
+				if !ok {
+					t.Errorf("expected pod to be returned, but got %v", args[0])
+					return
+				}
+
+				if actual := resp.Spec.Containers[0].Resources; actual.String() != expected.String() {
+					t.Errorf("unexpected pod resources generated from merge\nexp=%s\ngot=%s", expected.String(), actual.String())
+				}
+			},
+		},
+		"should append image pull secrets from the template to those configured on the controller": {
+			Challenge: &cmacme.Challenge{
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+								PodTemplate: &cmacme.ACMEChallengeSolverHTTP01IngressPodTemplate{
+									Spec: cmacme.ACMEChallengeSolverHTTP01IngressPodSpec{
+										ImagePullSecrets: []v1.LocalObjectReference{{Name: "issuer-registry"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			PreFn: func(t *testing.T, s *solverFixture) {
+				s.Solver.ACMEOptions.HTTP01SolverImagePullSecrets = []string{"controller-registry"}
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				expected := []v1.LocalObjectReference{{Name: "controller-registry"}, {Name: "issuer-registry"}}
+
+				resp, ok := args[0].(*v1.Pod)
+				if !ok {
+					t.Errorf("expected pod to be returned, but got %v", args[0])
+					return
+				}
+
+				if !reflect.DeepEqual(resp.Spec.ImagePullSecrets, expected) {
+					t.Errorf("unexpected image pull secrets generated from merge\nexp=%v\ngot=%v", expected, resp.Spec.ImagePullSecrets)
+				}
+			},
+		},
 		"should use default if nothing has changed in template": {
 			Challenge: &cmacme.Challenge{
 				Spec: cmacme.ChallengeSpec{
@@ -408,3 +499,159 @@ func TestMergePodObjectMetaWithPodTemplate(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildPodHostNetwork(t *testing.T) {
+	ch := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Token:   "token",
+			Key:     "key",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+						HostNetworkPort: 8189,
+					},
+				},
+			},
+		},
+	}
+	f := solverFixture{Challenge: ch}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	pod := f.Solver.buildPod(ch)
+	if !pod.Spec.HostNetwork {
+		t.Errorf("expected solver pod to use the host network")
+	}
+	if pod.Spec.DNSPolicy != v1.DNSClusterFirstWithHostNet {
+		t.Errorf("expected DNS policy %q but got %q", v1.DNSClusterFirstWithHostNet, pod.Spec.DNSPolicy)
+	}
+	container := pod.Spec.Containers[0]
+	if container.Args[0] != "--listen-port=8189" {
+		t.Errorf("expected solver to listen on the host network port but got args %v", container.Args)
+	}
+	if port := container.Ports[0]; port.ContainerPort != 8189 || port.HostPort != 8189 {
+		t.Errorf("expected container and host port 8189 but got %+v", port)
+	}
+
+	if _, err := f.Solver.createPod(context.TODO(), ch); err != nil {
+		t.Fatalf("unexpected error creating pod: %v", err)
+	}
+	f.Builder.Sync()
+	if err := f.Solver.cleanupPods(context.TODO(), ch); err != nil {
+		t.Fatalf("unexpected error cleaning up pods: %v", err)
+	}
+	f.Builder.Sync()
+	pods, err := f.Solver.podLister.List(labels.Everything())
+	if err != nil {
+		t.Fatalf("error listing pods: %v", err)
+	}
+	if len(pods) != 0 {
+		t.Errorf("expected host network solver pod to be cleaned up but found %d pods", len(pods))
+	}
+}
+
+func TestBuildPodExtraBasePaths(t *testing.T) {
+	tests := map[string]struct {
+		cfg          *cmacme.ACMEChallengeSolverHTTP01Ingress
+		expectedArgs []string
+	}{
+		"canonical challenge path needs no extra base paths": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+		},
+		"extra path prefixes are accepted": {
+			cfg:          &cmacme.ACMEChallengeSolverHTTP01Ingress{ExtraPathPrefixes: []string{"/acme"}},
+			expectedArgs: []string{"--extra-base-paths=/acme"},
+		},
+		"base of a path template is accepted": {
+			cfg:          &cmacme.ACMEChallengeSolverHTTP01Ingress{PathTemplate: "/cdn/{domain}/{token}"},
+			expectedArgs: []string{"--extra-base-paths=/cdn/example.com"},
+		},
+		"base of a path template is not repeated if it is also a prefix": {
+			cfg: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+				PathTemplate:      "/cdn/{token}",
+				ExtraPathPrefixes: []string{"/acme", "/cdn"},
+			},
+			expectedArgs: []string{"--extra-base-paths=/acme,/cdn"},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ch := &cmacme.Challenge{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testchal",
+					Namespace: defaultTestNamespace,
+				},
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Token:   "token",
+					Key:     "key",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{Ingress: test.cfg},
+					},
+				},
+			}
+			f := solverFixture{Challenge: ch}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			defaultArgs := f.Solver.buildDefaultPod(ch).Spec.Containers[0].Args
+			args := f.Solver.buildPod(ch).Spec.Containers[0].Args
+			if extra := args[len(defaultArgs):]; !reflect.DeepEqual(extra, test.expectedArgs) && (len(extra) > 0 || len(test.expectedArgs) > 0) {
+				t.Errorf("expected extra solver args %v but got %v", test.expectedArgs, extra)
+			}
+		})
+	}
+}
+
+func TestSolverSelector(t *testing.T) {
+	newChallenge := func(name string) *cmacme.Challenge {
+		return &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "token",
+			},
+		}
+	}
+	ch := newChallenge("challenge-a")
+	// a challenge for the same domain and token, e.g. because the ACME
+	// server re-used a pending authorization for another order
+	other := newChallenge("challenge-b")
+
+	tests := map[string]struct {
+		labels         map[string]string
+		expectSelected bool
+		expectLegacy   bool
+	}{
+		"should select resources of the challenge": {
+			labels:         podLabels(ch),
+			expectSelected: true,
+		},
+		"should not select resources of another challenge with the same domain and token": {
+			labels: podLabels(other),
+		},
+		"should only select unlabeled resources with the legacy selector": {
+			labels:       legacySolverLabels(ch),
+			expectLegacy: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			lbls := labels.Set(test.labels)
+			if selected := solverSelector(ch).Matches(lbls); selected != test.expectSelected {
+				t.Errorf("expected solverSelector to match %v but got %v", test.expectSelected, selected)
+			}
+			if selected := legacySolverSelector(ch).Matches(lbls); selected != test.expectLegacy {
+				t.Errorf("expected legacySolverSelector to match %v but got %v", test.expectLegacy, selected)
+			}
+		})
+	}
+}
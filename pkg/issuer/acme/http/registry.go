@@ -0,0 +1,107 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/controller"
+)
+
+// Backend is an implementation of the HTTP01 challenge solver. The Solver
+// itself is the default Backend, and presents challenges using ingresses,
+// node ports or Gateway API HTTPRoutes. Alternative implementations can be
+// registered using RegisterBackend and selected using the 'strategy' field
+// of the HTTP01 solver configuration.
+type Backend interface {
+	// Present makes the challenge key available at the challenge path for
+	// the challenged domain.
+	Present(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error
+	// Check returns an error if the challenge is not yet being served.
+	Check(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error
+	// CleanUp removes any resources created to present the challenge.
+	CleanUp(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error
+}
+
+var _ Backend = &Solver{}
+
+// BackendConstructor constructs a Backend given a Context.
+type BackendConstructor func(*controller.Context) (Backend, error)
+
+var (
+	backends     = make(map[string]BackendConstructor)
+	backendsLock sync.RWMutex
+)
+
+// RegisterBackend will register a HTTP01 solver backend constructor so that
+// it can be selected by challenges whose HTTP01 solver configuration names
+// it as their 'strategy'. 'name' should be unique. Backends must be
+// registered before the controller is started, as each is constructed once
+// when the Solver is created.
+func RegisterBackend(name string, c BackendConstructor) {
+	backendsLock.Lock()
+	defer backendsLock.Unlock()
+	backends[name] = c
+}
+
+// strategyForChallenge returns the name of the custom backend the given
+// challenge should be solved with, or an empty string if the built-in
+// solvers should be used.
+func strategyForChallenge(ch *cmacme.Challenge) string {
+	if ch.Spec.Solver == nil || ch.Spec.Solver.HTTP01 == nil {
+		return ""
+	}
+	return ch.Spec.Solver.HTTP01.Strategy
+}
+
+// backendInstance is an instance of a registered Backend, or the error
+// returned when constructing it.
+type backendInstance struct {
+	Backend
+	err error
+}
+
+// newBackends constructs an instance of each registered Backend, so that
+// backends can keep state between calls. Errors constructing a Backend are
+// returned when a challenge using it is solved.
+func newBackends(ctx *controller.Context) map[string]backendInstance {
+	backendsLock.RLock()
+	defer backendsLock.RUnlock()
+	instances := make(map[string]backendInstance, len(backends))
+	for name, constructor := range backends {
+		b, err := constructor(ctx)
+		instances[name] = backendInstance{Backend: b, err: err}
+	}
+	return instances
+}
+
+// backendFor returns the instance of the Backend registered with the given
+// name. If no Backend is registered with that name, an error is returned.
+func (s *Solver) backendFor(name string) (Backend, error) {
+	b, ok := s.backends[name]
+	if !ok {
+		return nil, fmt.Errorf("HTTP01 solver strategy %q not registered", name)
+	}
+	if b.err != nil {
+		return nil, fmt.Errorf("error constructing HTTP01 solver strategy %q: %v", name, b.err)
+	}
+	return b.Backend, nil
+}
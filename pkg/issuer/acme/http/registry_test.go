@@ -0,0 +1,98 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/controller"
+)
+
+type fakeBackend struct {
+	calls []string
+}
+
+func (f *fakeBackend) Present(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+	f.calls = append(f.calls, "Present")
+	return nil
+}
+
+func (f *fakeBackend) Check(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+	f.calls = append(f.calls, "Check")
+	return nil
+}
+
+func (f *fakeBackend) CleanUp(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+	f.calls = append(f.calls, "CleanUp")
+	return nil
+}
+
+func TestCustomBackend(t *testing.T) {
+	constructed := 0
+	backend := &fakeBackend{}
+	RegisterBackend("test-backend", func(*controller.Context) (Backend, error) {
+		constructed++
+		return backend, nil
+	})
+	newChallenge := func(strategy string) *cmacme.Challenge {
+		return &cmacme.Challenge{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "testchal",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Strategy: strategy,
+					},
+				},
+			},
+		}
+	}
+	ctx := &controller.Context{}
+	s := &Solver{Context: ctx, backends: newBackends(ctx)}
+
+	chal := newChallenge("test-backend")
+	if err := s.Present(context.TODO(), nil, chal); err != nil {
+		t.Errorf("unexpected error presenting challenge: %v", err)
+	}
+	if err := s.Check(context.TODO(), nil, chal); err != nil {
+		t.Errorf("unexpected error checking challenge: %v", err)
+	}
+	if err := s.CleanUp(context.TODO(), nil, chal); err != nil {
+		t.Errorf("unexpected error cleaning up challenge: %v", err)
+	}
+	if constructed != 1 {
+		t.Errorf("expected the backend to be constructed once but it was constructed %d times", constructed)
+	}
+	expected := []string{"Present", "Check", "CleanUp"}
+	if !reflect.DeepEqual(expected, backend.calls) {
+		t.Errorf("expected backend calls %v but got %v", expected, backend.calls)
+	}
+
+	if err := s.Present(context.TODO(), nil, newChallenge("unregistered")); err == nil {
+		t.Errorf("expected an error presenting a challenge with an unregistered strategy")
+	}
+}
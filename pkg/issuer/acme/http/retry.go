@@ -0,0 +1,100 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"time"
+
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	logf "github.com/jetstack/cert-manager/pkg/logs"
+)
+
+// defaultRetryBackoff is the backoff used between attempts when retrying
+// transient API server errors.
+var defaultRetryBackoff = wait.Backoff{
+	Duration: 200 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    10,
+	Cap:      5 * time.Second,
+}
+
+// isTransientAPIError returns true if the given error returned by the API
+// server is likely to succeed if the request is retried.
+func isTransientAPIError(err error) bool {
+	return k8sErrors.IsServerTimeout(err) ||
+		k8sErrors.IsTimeout(err) ||
+		k8sErrors.IsTooManyRequests(err) ||
+		k8sErrors.IsInternalError(err) ||
+		k8sErrors.IsServiceUnavailable(err) ||
+		k8sErrors.IsUnexpectedServerError(err)
+}
+
+// retryTransient calls fn, retrying with an exponential backoff for as long as
+// it returns transient API server errors.
+// It gives up once the configured number of retries have been made or the
+// configured timeout has been exceeded, returning the last error observed.
+func (s *Solver) retryTransient(ctx context.Context, fn func() error) error {
+	log := logf.FromContext(ctx)
+
+	// a nil channel is never ready, so no timeout applies if none is configured
+	var timeout <-chan time.Time
+	if s.retryTimeout > 0 {
+		timeout = s.clock.After(s.retryTimeout)
+	}
+
+	backoff := s.retryBackoff
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || !isTransientAPIError(err) || attempt > s.retries {
+			return err
+		}
+
+		delay := backoff.Step()
+		log.V(logf.DebugLevel).Info("transient error from apiserver, retrying", "error", err, "attempt", attempt, "delay", delay)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-timeout:
+			return err
+		case <-s.clock.After(delay):
+		}
+	}
+}
+
+// retryCreate is like retryTransient, but for creating a solver resource.
+// A create that fails with a transient error may still have been persisted,
+// and as solver resources are usually created with a generated name, retrying
+// it would create a duplicate. Before each retry findExisting is called to
+// query the apiserver for a resource created by an earlier attempt, and no
+// further attempts are made if one is found.
+func (s *Solver) retryCreate(ctx context.Context, create func() error, findExisting func() (bool, error)) error {
+	attempted := false
+	return s.retryTransient(ctx, func() error {
+		if attempted {
+			found, err := findExisting()
+			if err != nil || found {
+				return err
+			}
+		}
+		attempted = true
+		return create()
+	})
+}
@@ -0,0 +1,260 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/api/extensions/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	extv1beta1listers "k8s.io/client-go/listers/extensions/v1beta1"
+	coretesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+)
+
+func TestIsTransientAPIError(t *testing.T) {
+	ingressGR := schema.GroupResource{Group: "extensions", Resource: "ingresses"}
+	tests := map[string]struct {
+		err       error
+		transient bool
+	}{
+		"server timeout": {
+			err:       apierrors.NewServerTimeout(ingressGR, "create", 1),
+			transient: true,
+		},
+		"too many requests": {
+			err:       apierrors.NewTooManyRequests("slow down", 1),
+			transient: true,
+		},
+		"internal error": {
+			err:       apierrors.NewInternalError(fmt.Errorf("boom")),
+			transient: true,
+		},
+		"service unavailable": {
+			err:       apierrors.NewServiceUnavailable("unavailable"),
+			transient: true,
+		},
+		"forbidden": {
+			err:       apierrors.NewForbidden(ingressGR, "test", fmt.Errorf("denied")),
+			transient: false,
+		},
+		"invalid": {
+			err:       apierrors.NewBadRequest("bad"),
+			transient: false,
+		},
+		"non api error": {
+			err:       fmt.Errorf("some error"),
+			transient: false,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if isTransientAPIError(test.err) != test.transient {
+				t.Errorf("expected isTransientAPIError to return %t for %v", test.transient, test.err)
+			}
+		})
+	}
+}
+
+func TestRetryTransientTimeout(t *testing.T) {
+	clk, stop := autoStepClock(500 * time.Millisecond)
+	defer stop()
+	s := &Solver{
+		retries:      100,
+		retryTimeout: 5 * time.Second,
+		retryBackoff: wait.Backoff{Duration: time.Second, Factor: 1, Steps: 100},
+		clock:        clk,
+	}
+
+	calls := 0
+	start := clk.Now()
+	err := s.retryTransient(context.TODO(), func() error {
+		calls++
+		return apierrors.NewInternalError(fmt.Errorf("simulated error"))
+	})
+	if !apierrors.IsInternalError(err) {
+		t.Errorf("expected the last error to be returned but got: %v", err)
+	}
+	if calls >= 100 {
+		t.Errorf("expected retries to stop once the timeout was exceeded but got %d calls", calls)
+	}
+	if elapsed := clk.Since(start); elapsed < 5*time.Second {
+		t.Errorf("expected retries to continue until the timeout but gave up after %s", elapsed)
+	}
+}
+
+func TestCreateIngressRetries(t *testing.T) {
+	const createCallsKey = "createCalls"
+	newChallenge := func() *cmacme.Challenge {
+		return &cmacme.Challenge{
+			Spec: cmacme.ChallengeSpec{
+				DNSName: "example.com",
+				Token:   "abcd",
+				Solver: &cmacme.ACMEChallengeSolver{
+					HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+						Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+					},
+				},
+			},
+		}
+	}
+	// failCreates returns a PreFn that configures the solver to retry up to
+	// 'retries' times and makes the first 'failures' create calls fail with err
+	failCreates := func(retries, failures int, err error) func(*testing.T, *solverFixture) {
+		return func(t *testing.T, s *solverFixture) {
+			s.Solver.retries = retries
+			s.Solver.retryBackoff = wait.Backoff{Duration: time.Millisecond, Steps: 10}
+			calls := 0
+			s.testResources[createCallsKey] = &calls
+			s.Builder.FakeKubeClient().PrependReactor("create", "ingresses", func(action coretesting.Action) (bool, runtime.Object, error) {
+				calls++
+				if calls <= failures {
+					return true, nil, err
+				}
+				return false, nil, nil
+			})
+		}
+	}
+	expectCreateCalls := func(expected int) func(*testing.T, *solverFixture, ...interface{}) {
+		return func(t *testing.T, s *solverFixture, args ...interface{}) {
+			calls := *s.testResources[createCallsKey].(*int)
+			if calls != expected {
+				t.Errorf("expected %d create calls but got %d", expected, calls)
+			}
+		}
+	}
+
+	// alreadyExists returns a PreFn that creates an ingress for the challenge
+	// with the given name and makes all further create calls fail with an
+	// AlreadyExists error
+	alreadyExists := func(name string) func(*testing.T, *solverFixture) {
+		return func(t *testing.T, s *solverFixture) {
+			ing, err := s.Solver.buildIngressResource(s.Challenge, "fakeservice")
+			if err != nil {
+				t.Fatalf("error preparing test: %v", err)
+			}
+			ing.GenerateName = ""
+			ing.Name = name
+			if _, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(s.Challenge.Namespace).Create(ing); err != nil {
+				t.Fatalf("error preparing test: %v", err)
+			}
+			failCreates(3, 10, apierrors.NewAlreadyExists(schema.GroupResource{Resource: "ingresses"}, name))(t, s)
+		}
+	}
+
+	tests := map[string]solverFixture{
+		"should retry transient errors and succeed": {
+			Challenge: newChallenge(),
+			PreFn:     failCreates(3, 2, apierrors.NewInternalError(fmt.Errorf("simulated error"))),
+			CheckFn:   expectCreateCalls(3),
+		},
+		"should not retry if a failed create persisted the ingress": {
+			Challenge: newChallenge(),
+			PreFn: func(t *testing.T, s *solverFixture) {
+				failCreates(3, 0, nil)(t, s)
+				s.Builder.FakeKubeClient().PrependReactor("create", "ingresses", func(action coretesting.Action) (bool, runtime.Object, error) {
+					*s.testResources[createCallsKey].(*int)++
+					ing := action.(coretesting.CreateAction).GetObject().(*v1beta1.Ingress).DeepCopy()
+					ing.Name = "persisted-ingress"
+					if err := s.Builder.FakeKubeClient().Tracker().Create(action.GetResource(), ing, action.GetNamespace()); err != nil {
+						t.Fatalf("error preparing test: %v", err)
+					}
+					return true, nil, apierrors.NewServerTimeout(schema.GroupResource{Resource: "ingresses"}, "create", 1)
+				})
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				expectCreateCalls(1)(t, s, args...)
+				ing := args[0].(*v1beta1.Ingress)
+				if ing == nil || ing.Name != "persisted-ingress" {
+					t.Errorf("expected persisted ingress %q to be returned but got: %+v", "persisted-ingress", ing)
+				}
+				ingresses, err := s.Builder.FakeKubeClient().ExtensionsV1beta1().Ingresses(s.Challenge.Namespace).List(metav1.ListOptions{})
+				if err != nil {
+					t.Fatalf("error listing ingresses: %v", err)
+				}
+				if len(ingresses.Items) != 1 {
+					t.Errorf("expected exactly one ingress to exist but got %d", len(ingresses.Items))
+				}
+			},
+		},
+		"should give up once retries are exhausted": {
+			Challenge: newChallenge(),
+			PreFn:     failCreates(2, 5, apierrors.NewServerTimeout(schema.GroupResource{Resource: "ingresses"}, "create", 1)),
+			CheckFn:   expectCreateCalls(3),
+			Err:       true,
+		},
+		"should not retry non-transient errors": {
+			Challenge: newChallenge(),
+			PreFn:     failCreates(3, 1, apierrors.NewForbidden(schema.GroupResource{Resource: "ingresses"}, "", fmt.Errorf("denied"))),
+			CheckFn:   expectCreateCalls(1),
+			Err:       true,
+		},
+		"should return the existing ingress if create reports it already exists": {
+			Challenge: newChallenge(),
+			PreFn:     alreadyExists("existing-ingress"),
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				expectCreateCalls(1)(t, s, args...)
+				ing := args[0].(*v1beta1.Ingress)
+				if ing == nil || ing.Name != "existing-ingress" {
+					t.Errorf("expected existing ingress %q to be returned but got: %+v", "existing-ingress", ing)
+				}
+			},
+		},
+		"should return the existing ingress if it already exists but is not yet in the lister's cache": {
+			Challenge: newChallenge(),
+			PreFn: func(t *testing.T, s *solverFixture) {
+				alreadyExists("existing-ingress")(t, s)
+				s.Solver.ingressLister = extv1beta1listers.NewIngressLister(cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}))
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				expectCreateCalls(1)(t, s, args...)
+				ing := args[0].(*v1beta1.Ingress)
+				if ing == nil || ing.Name != "existing-ingress" {
+					t.Errorf("expected existing ingress %q to be returned but got: %+v", "existing-ingress", ing)
+				}
+			},
+		},
+		"should return the error if create reports an ingress already exists but none is found": {
+			Challenge: newChallenge(),
+			PreFn:     failCreates(3, 10, apierrors.NewAlreadyExists(schema.GroupResource{Resource: "ingresses"}, "existing-ingress")),
+			CheckFn:   expectCreateCalls(1),
+			Err:       true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			resp, err := test.Solver.createIngress(context.TODO(), test.Challenge, "fakeservice")
+			if err != nil && !test.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && test.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+			test.Finish(t, resp, err)
+		})
+	}
+}
@@ -21,9 +21,8 @@ import (
 	"fmt"
 
 	corev1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/labels"
-	"k8s.io/apimachinery/pkg/selection"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/intstr"
 
@@ -41,7 +40,7 @@ func (s *Solver) ensureService(ctx context.Context, ch *cmacme.Challenge) (*core
 	}
 	if len(existingServices) == 1 {
 		logf.WithRelatedResource(log, existingServices[0]).Info("found one existing HTTP01 solver Service for challenge resource")
-		return existingServices[0], nil
+		return s.reconcilePort80(ctx, ch, existingServices[0])
 	}
 	if len(existingServices) > 1 {
 		log.Info("multiple challenge solver services found for challenge. cleaning up all existing services.")
@@ -53,32 +52,49 @@ func (s *Solver) ensureService(ctx context.Context, ch *cmacme.Challenge) (*core
 	}
 
 	log.Info("creating HTTP01 challenge solver service")
-	return s.createService(ch)
+	return s.createService(ctx, ch)
 }
 
 // getServicesForChallenge returns a list of services that were created to solve
 // http challenges for the given domain
 func (s *Solver) getServicesForChallenge(ctx context.Context, ch *cmacme.Challenge) ([]*corev1.Service, error) {
-	log := logf.FromContext(ctx)
-
-	podLabels := podLabels(ch)
-	selector := labels.NewSelector()
-	for key, val := range podLabels {
-		req, err := labels.NewRequirement(key, selection.Equals, []string{val})
+	var serviceList []*corev1.Service
+	for _, selector := range solverSelectors(ch) {
+		services, err := s.serviceLister.Services(s.resourceNamespace(ch)).List(selector)
 		if err != nil {
 			return nil, err
 		}
-		selector = selector.Add(*req)
+		serviceList = append(serviceList, services...)
 	}
 
-	serviceList, err := s.serviceLister.Services(ch.Namespace).List(selector)
-	if err != nil {
-		return nil, err
+	return s.filterServicesForChallenge(ctx, ch, serviceList), nil
+}
+
+// listServicesForChallenge is like getServicesForChallenge, but queries the
+// apiserver directly rather than the lister's cache, so that services that
+// have only just been created are also returned.
+func (s *Solver) listServicesForChallenge(ctx context.Context, ch *cmacme.Challenge) ([]*corev1.Service, error) {
+	var services []*corev1.Service
+	for _, selector := range solverSelectors(ch) {
+		serviceList, err := s.Client.CoreV1().Services(s.resourceNamespace(ch)).List(metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return nil, err
+		}
+		for i := range serviceList.Items {
+			services = append(services, &serviceList.Items[i])
+		}
 	}
+	return s.filterServicesForChallenge(ctx, ch, services), nil
+}
+
+// filterServicesForChallenge returns the services in the given list that
+// were created by the solver for the given challenge.
+func (s *Solver) filterServicesForChallenge(ctx context.Context, ch *cmacme.Challenge, serviceList []*corev1.Service) []*corev1.Service {
+	log := logf.FromContext(ctx)
 
 	var relevantServices []*corev1.Service
 	for _, service := range serviceList {
-		if !metav1.IsControlledBy(service, ch) {
+		if !s.isResourceOwner(ch, service) {
 			logf.WithRelatedResource(log, service).Info("found existing solver pod for this challenge resource, however " +
 				"it does not have an appropriate OwnerReference referencing this challenge. Skipping it altogether.")
 			continue
@@ -86,36 +102,180 @@ func (s *Solver) getServicesForChallenge(ctx context.Context, ch *cmacme.Challen
 		relevantServices = append(relevantServices, service)
 	}
 
-	return relevantServices, nil
+	return relevantServices
+}
+
+// existingServiceName returns the name of the existing service that has been
+// configured to route to the solver for the given challenge, or an empty
+// string if a solver service should be created.
+func existingServiceName(ch *cmacme.Challenge) string {
+	if ch.Spec.Solver == nil || ch.Spec.Solver.HTTP01 == nil || ch.Spec.Solver.HTTP01.Ingress == nil {
+		return ""
+	}
+	return ch.Spec.Solver.HTTP01.Ingress.ServiceName
+}
+
+// checkExistingService returns an error if the named service does not exist
+// in the challenge's namespace, or does not expose the port that solver
+// ingress paths are routed to.
+func (s *Solver) checkExistingService(ch *cmacme.Challenge, name string) error {
+	svc, err := s.serviceLister.Services(s.resourceNamespace(ch)).Get(name)
+	if k8sErrors.IsNotFound(err) {
+		return fmt.Errorf("existing solver service %q not found", name)
+	}
+	if err != nil {
+		return err
+	}
+	if !s.exposesSolverPort(svc) {
+		expected := s.servicePort()
+		return fmt.Errorf("existing solver service %q does not expose port %s", name, expected.String())
+	}
+	return nil
+}
+
+// checkIngressBackendService returns an error if the named service that
+// challenge paths are about to be routed to does not exist, or does not expose
+// the solver port, so that an ingress is never updated to route to a missing
+// backend. The apiserver is queried directly, as the service may only just
+// have been created and not yet be in the lister's cache.
+func (s *Solver) checkIngressBackendService(namespace, name string) error {
+	svc, err := s.Client.CoreV1().Services(namespace).Get(name, metav1.GetOptions{})
+	if k8sErrors.IsNotFound(err) {
+		return fmt.Errorf("solver service %q does not exist, it must be created before challenge paths can be routed to it", name)
+	}
+	if err != nil {
+		return err
+	}
+	if !s.exposesSolverPort(svc) {
+		expected := s.servicePort()
+		return fmt.Errorf("solver service %q does not expose port %s", name, expected.String())
+	}
+	return nil
+}
+
+// exposesSolverPort returns true if the given service exposes the port that
+// solver ingress paths are routed to.
+func (s *Solver) exposesSolverPort(svc *corev1.Service) bool {
+	expected := s.servicePort()
+	for _, port := range svc.Spec.Ports {
+		if expected.Type == intstr.String && port.Name == expected.StrVal {
+			return true
+		}
+		if expected.Type == intstr.Int && port.Port == expected.IntVal {
+			return true
+		}
+	}
+	return false
+}
+
+// exposesPort80 returns true if the solver service for the given challenge
+// should additionally expose the solver on port 80. Only solver ingresses
+// reference the port.
+func (s *Solver) exposesPort80(ch *cmacme.Challenge) bool {
+	if !s.servicePort80 {
+		return false
+	}
+	return nodePortCfgForChallenge(ch) == nil && tlsALPNCfgForChallenge(ch) == nil && gatewayCfgForChallenge(ch) == nil
+}
+
+// reconcilePort80 adds or removes the additional port 80 of the given solver
+// service, so that services created before the solver was configured to
+// expose port 80, or to stop exposing it, are updated accordingly.
+func (s *Solver) reconcilePort80(ctx context.Context, ch *cmacme.Challenge, svc *corev1.Service) (*corev1.Service, error) {
+	log := logf.WithRelatedResource(logf.FromContext(ctx), svc)
+
+	want := s.exposesPort80(ch)
+	idx := -1
+	for i, port := range svc.Spec.Ports {
+		if port.Name == port80ServicePortName {
+			idx = i
+		}
+	}
+	if want == (idx >= 0) || len(svc.Spec.Ports) == 0 {
+		return svc, nil
+	}
+
+	svc = svc.DeepCopy()
+	if want {
+		log.Info("adding port 80 to HTTP01 solver service")
+		svc.Spec.Ports = append(svc.Spec.Ports, port80ServicePortFor(svc.Spec.Ports[0]))
+	} else {
+		log.Info("removing port 80 from HTTP01 solver service")
+		svc.Spec.Ports = append(svc.Spec.Ports[:idx], svc.Spec.Ports[idx+1:]...)
+	}
+	return s.Client.CoreV1().Services(svc.Namespace).Update(svc)
+}
+
+// port80ServicePortFor returns the additional port 80 of a solver service,
+// which targets the same port of the solver pod as the given solver port.
+func port80ServicePortFor(solverPort corev1.ServicePort) corev1.ServicePort {
+	return corev1.ServicePort{
+		Name:       port80ServicePortName,
+		Port:       port80ServicePort,
+		TargetPort: solverPort.TargetPort,
+	}
 }
 
 // createService will create the service required to solve this challenge
 // in the target API server.
-func (s *Solver) createService(ch *cmacme.Challenge) (*corev1.Service, error) {
-	svc, err := buildService(ch)
+func (s *Solver) createService(ctx context.Context, ch *cmacme.Challenge) (*corev1.Service, error) {
+	svc, err := s.buildService(ch)
 	if err != nil {
 		return nil, err
 	}
-	return s.Client.CoreV1().Services(ch.Namespace).Create(svc)
+	// a solver pod created before the challenge label was introduced would
+	// not be selected by a service re-created for it, so the label is left
+	// out of the selector
+	pods, err := s.getPodsForChallenge(ctx, ch)
+	if err != nil {
+		return nil, err
+	}
+	for _, pod := range pods {
+		if _, ok := pod.Labels[challengeLabelKey]; !ok {
+			svc.Spec.Selector = legacySolverLabels(ch)
+		}
+	}
+	var created *corev1.Service
+	err = s.retryCreate(ctx, func() error {
+		var err error
+		created, err = s.Client.CoreV1().Services(svc.Namespace).Create(svc)
+		return err
+	}, func() (bool, error) {
+		existing, err := s.listServicesForChallenge(ctx, ch)
+		if err != nil || len(existing) == 0 {
+			return false, err
+		}
+		created = existing[0]
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if nodePortCfgForChallenge(ch) != nil {
+		s.Recorder.Eventf(ch, corev1.EventTypeNormal, reasonNodePortExposed,
+			"Exposed HTTP01 challenge solver on node port %d", solverNodePort(created))
+	}
+	return created, nil
 }
 
-func buildService(ch *cmacme.Challenge) (*corev1.Service, error) {
+func (s *Solver) buildService(ch *cmacme.Challenge) (*corev1.Service, error) {
 	podLabels := podLabels(ch)
+	portName := s.servicePortName
+	if portName == "" {
+		portName = "http"
+	}
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			GenerateName: "cm-acme-http-solver-",
-			Namespace:    ch.Namespace,
-			Labels:       podLabels,
+			Labels: podLabels,
 			Annotations: map[string]string{
 				"auth.istio.io/8089": "NONE",
 			},
-			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(ch, challengeGvk)},
 		},
 		Spec: corev1.ServiceSpec{
 			Type: corev1.ServiceTypeNodePort,
 			Ports: []corev1.ServicePort{
 				{
-					Name:       "http",
+					Name:       portName,
 					Port:       acmeSolverListenPort,
 					TargetPort: intstr.FromInt(acmeSolverListenPort),
 				},
@@ -123,6 +283,36 @@ func buildService(ch *cmacme.Challenge) (*corev1.Service, error) {
 			Selector: podLabels,
 		},
 	}
+	s.setResourceName(ch, service)
+	s.setResourceOwner(ch, service)
+
+	// solvers using the NodePort strategy always expose the solver pod on a
+	// node port, optionally using the port specified in the config
+	if nodePortCfg := nodePortCfgForChallenge(ch); nodePortCfg != nil {
+		service.Spec.Ports[0].NodePort = nodePortCfg.Port
+		return service, nil
+	}
+
+	// TLS-ALPN-01 challenges are validated on port 443, so the service
+	// exposes the solver on that port in case it is reached directly. It has
+	// a stable name so that users can route to it in advance.
+	if tlsALPNCfg := tlsALPNCfgForChallenge(ch); tlsALPNCfg != nil {
+		service.GenerateName = ""
+		service.Name = TLSALPNServiceName(ch.Spec.DNSName)
+		service.Spec.Ports[0].Name = "https"
+		service.Spec.Ports[0].Port = tlsALPNPort
+		if tlsALPNCfg.ServiceType != "" {
+			service.Spec.Type = tlsALPNCfg.ServiceType
+		}
+		return service, nil
+	}
+
+	if gatewayCfg := gatewayCfgForChallenge(ch); gatewayCfg != nil {
+		if gatewayCfg.ServiceType != "" {
+			service.Spec.Type = gatewayCfg.ServiceType
+		}
+		return service, nil
+	}
 
 	// checking for presence of http01 config and if set serviceType is set, override our default (NodePort)
 	httpDomainCfg, err := httpDomainCfgForChallenge(ch)
@@ -133,11 +323,44 @@ func buildService(ch *cmacme.Challenge) (*corev1.Service, error) {
 		service.Spec.Type = httpDomainCfg.ServiceType
 	}
 
+	// headless services keep their port so that solver ingress paths
+	// referencing it by name or number remain valid
+	if httpDomainCfg.HeadlessService {
+		service.Spec.Type = corev1.ServiceTypeClusterIP
+		service.Spec.ClusterIP = corev1.ClusterIPNone
+	}
+
+	// a solver pod in the host network namespace has the IP of its node, so
+	// a headless service makes ingress controllers route to the node directly
+	if port := hostNetworkPort(ch); port != 0 {
+		service.Spec.Type = corev1.ServiceTypeClusterIP
+		service.Spec.ClusterIP = corev1.ClusterIPNone
+		service.Spec.Ports[0].TargetPort = intstr.FromInt(int(port))
+	}
+
+	if s.exposesPort80(ch) {
+		service.Spec.Ports = append(service.Spec.Ports, port80ServicePortFor(service.Spec.Ports[0]))
+	}
+
 	return service, nil
 }
 
+// servicePort returns the solver service port as it is referenced by solver
+// ingress paths, i.e. by name if a service port name has been configured and
+// by number otherwise. If the solver exposes services on port 80, paths
+// reference that port.
+func (s *Solver) servicePort() intstr.IntOrString {
+	if s.servicePort80 {
+		return intstr.FromInt(port80ServicePort)
+	}
+	if s.servicePortName != "" {
+		return intstr.FromString(s.servicePortName)
+	}
+	return intstr.FromInt(acmeSolverListenPort)
+}
+
 func (s *Solver) cleanupServices(ctx context.Context, ch *cmacme.Challenge) error {
-	log := logf.FromContext(ctx, "cleanupPods")
+	log := logf.FromContext(ctx, "cleanupServices")
 
 	services, err := s.getServicesForChallenge(ctx, ch)
 	if err != nil {
@@ -149,6 +372,10 @@ func (s *Solver) cleanupServices(ctx context.Context, ch *cmacme.Challenge) erro
 		log.Info("deleting service resource")
 
 		err := s.Client.CoreV1().Services(service.Namespace).Delete(service.Name, nil)
+		if k8sErrors.IsNotFound(err) {
+			log.Info("service resource has already been deleted")
+			continue
+		}
 		if err != nil {
 			log.Info("failed to delete pod resource", "error", err)
 			errs = append(errs, err)
@@ -18,15 +18,18 @@ package http
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"testing"
 
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	coretesting "k8s.io/client-go/testing"
 
 	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/controller/test"
 )
 
 func TestEnsureService(t *testing.T) {
@@ -44,7 +47,7 @@ func TestEnsureService(t *testing.T) {
 				},
 			},
 			PreFn: func(t *testing.T, s *solverFixture) {
-				svc, err := s.Solver.createService(s.Challenge)
+				svc, err := s.Solver.createService(context.TODO(), s.Challenge)
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
@@ -85,7 +88,7 @@ func TestEnsureService(t *testing.T) {
 				},
 			},
 			PreFn: func(t *testing.T, s *solverFixture) {
-				expectedService, err := buildService(s.Challenge)
+				expectedService, err := s.Solver.buildService(s.Challenge)
 				if err != nil {
 					t.Errorf("expectedService returned an error whilst building test fixture: %v", err)
 				}
@@ -139,11 +142,11 @@ func TestEnsureService(t *testing.T) {
 			},
 			Err: true,
 			PreFn: func(t *testing.T, s *solverFixture) {
-				_, err := s.Solver.createService(s.Challenge)
+				_, err := s.Solver.createService(context.TODO(), s.Challenge)
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
-				_, err = s.Solver.createService(s.Challenge)
+				_, err = s.Solver.createService(context.TODO(), s.Challenge)
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
@@ -193,7 +196,7 @@ func TestGetServicesForChallenge(t *testing.T) {
 				},
 			},
 			PreFn: func(t *testing.T, s *solverFixture) {
-				ing, err := s.Solver.createService(s.Challenge)
+				ing, err := s.Solver.createService(context.TODO(), s.Challenge)
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
@@ -214,6 +217,43 @@ func TestGetServicesForChallenge(t *testing.T) {
 				}
 			},
 		},
+		"should return a service created before the challenge label was introduced": {
+			Challenge: &cmacme.Challenge{
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+						},
+					},
+				},
+			},
+			PreFn: func(t *testing.T, s *solverFixture) {
+				svc, err := s.Solver.buildService(s.Challenge)
+				if err != nil {
+					t.Fatalf("error preparing test: %v", err)
+				}
+				svc.Labels = legacySolverLabels(s.Challenge)
+				svc, err = s.Client.CoreV1().Services(svc.Namespace).Create(svc)
+				if err != nil {
+					t.Fatalf("error preparing test: %v", err)
+				}
+
+				s.testResources[createdServiceKey] = svc
+				s.Builder.Sync()
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				createdService := s.testResources[createdServiceKey].(*v1.Service)
+				resp := args[0].([]*v1.Service)
+				if len(resp) != 1 {
+					t.Errorf("expected one service to be returned, but got %d", len(resp))
+					return
+				}
+				if !reflect.DeepEqual(resp[0], createdService) {
+					t.Errorf("Expected %v to equal %v", resp[0], createdService)
+				}
+			},
+		},
 		"should not return a service for the same certificate but different domain": {
 			Challenge: &cmacme.Challenge{
 				Spec: cmacme.ChallengeSpec{
@@ -228,7 +268,7 @@ func TestGetServicesForChallenge(t *testing.T) {
 			PreFn: func(t *testing.T, s *solverFixture) {
 				differentChallenge := s.Challenge.DeepCopy()
 				differentChallenge.Spec.DNSName = "invaliddomain"
-				_, err := s.Solver.createService(differentChallenge)
+				_, err := s.Solver.createService(context.TODO(), differentChallenge)
 				if err != nil {
 					t.Errorf("error preparing test: %v", err)
 				}
@@ -259,3 +299,404 @@ func TestGetServicesForChallenge(t *testing.T) {
 		})
 	}
 }
+
+func TestCreateServiceForLegacyPod(t *testing.T) {
+	ch := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+				},
+			},
+		},
+	}
+	f := solverFixture{
+		Challenge: ch,
+		PreFn: func(t *testing.T, s *solverFixture) {
+			// solver pods created before the challenge label was introduced
+			// do not have it
+			pod := s.Solver.buildPod(ch)
+			delete(pod.Labels, challengeLabelKey)
+			if _, err := s.Client.CoreV1().Pods(pod.Namespace).Create(pod); err != nil {
+				t.Fatalf("error preparing test: %v", err)
+			}
+			s.Builder.Sync()
+		},
+	}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	svc, err := f.Solver.createService(context.TODO(), ch)
+	if err != nil {
+		t.Fatalf("unexpected error creating service: %v", err)
+	}
+	if _, ok := svc.Spec.Selector[challengeLabelKey]; ok {
+		t.Errorf("expected the service selector not to require the challenge label, got %v", svc.Spec.Selector)
+	}
+	if _, ok := svc.Labels[challengeLabelKey]; !ok {
+		t.Errorf("expected the service to still be labelled with the challenge label, got %v", svc.Labels)
+	}
+}
+
+func TestPresentExistingService(t *testing.T) {
+	chal := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Token:   "abcd",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+						ServiceName: "existing-solver",
+					},
+				},
+			},
+		},
+	}
+	existingService := func(port int32) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "existing-solver",
+				Namespace: defaultTestNamespace,
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{Name: "http", Port: port}},
+			},
+		}
+	}
+	expectResources := func(expectedIngresses int) func(*testing.T, *solverFixture, ...interface{}) {
+		return func(t *testing.T, s *solverFixture, args ...interface{}) {
+			services, err := s.Solver.serviceLister.List(labels.NewSelector())
+			if err != nil {
+				t.Errorf("unexpected error listing services: %v", err)
+				return
+			}
+			if len(services) != 1 || services[0].Name != "existing-solver" {
+				t.Errorf("expected only the existing service to exist but got %d services", len(services))
+			}
+			ingresses, err := s.Solver.ingressLister.List(labels.NewSelector())
+			if err != nil {
+				t.Errorf("unexpected error listing ingresses: %v", err)
+				return
+			}
+			if len(ingresses) != expectedIngresses {
+				t.Errorf("expected %d ingresses to be created but got %d", expectedIngresses, len(ingresses))
+				return
+			}
+			for _, ing := range ingresses {
+				if svcName := ing.Spec.Rules[0].HTTP.Paths[0].Backend.ServiceName; svcName != "existing-solver" {
+					t.Errorf("expected ingress to route to service %q but got %q", "existing-solver", svcName)
+				}
+			}
+		}
+	}
+
+	tests := map[string]solverFixture{
+		"should route the ingress to the existing service without creating one": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{existingService(acmeSolverListenPort)},
+			},
+			Challenge: chal,
+			CheckFn:   expectResources(1),
+		},
+		"should error if the existing service does not exist": {
+			Challenge: chal,
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				ingresses, _ := s.Solver.ingressLister.List(labels.NewSelector())
+				if len(ingresses) != 0 {
+					t.Errorf("expected no ingresses to be created but got %d", len(ingresses))
+				}
+			},
+			Err: true,
+		},
+		"should error if the existing service does not expose the solver port": {
+			Builder: &test.Builder{
+				KubeObjects: []runtime.Object{existingService(80)},
+			},
+			Challenge: chal,
+			CheckFn:   expectResources(0),
+			Err:       true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			err := test.Solver.Present(context.TODO(), nil, test.Challenge)
+			if err != nil && !test.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && test.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+			test.Finish(t, err)
+		})
+	}
+}
+
+func TestSolverService(t *testing.T) {
+	const createdServiceKey = "createdService"
+	tests := map[string]solverFixture{
+		"should return the name and port of the created solver service": {
+			Challenge: &cmacme.Challenge{
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+						},
+					},
+				},
+			},
+			PreFn: func(t *testing.T, s *solverFixture) {
+				svc, err := s.Solver.createService(context.TODO(), s.Challenge)
+				if err != nil {
+					t.Errorf("error preparing test: %v", err)
+				}
+				s.testResources[createdServiceKey] = svc
+				s.Builder.Sync()
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				createdService := s.testResources[createdServiceKey].(*v1.Service)
+				expected := fmt.Sprintf("%s:%d", createdService.Name, acmeSolverListenPort)
+				if resp := args[0].(string); resp != expected {
+					t.Errorf("expected solver service %q but got %q", expected, resp)
+				}
+			},
+		},
+		"should return the name and port of an existing service": {
+			Challenge: &cmacme.Challenge{
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+								ServiceName: "existing-solver",
+							},
+						},
+					},
+				},
+			},
+			CheckFn: func(t *testing.T, s *solverFixture, args ...interface{}) {
+				expected := fmt.Sprintf("existing-solver:%d", acmeSolverListenPort)
+				if resp := args[0].(string); resp != expected {
+					t.Errorf("expected solver service %q but got %q", expected, resp)
+				}
+			},
+		},
+		"should error if no solver service exists": {
+			Challenge: &cmacme.Challenge{
+				Spec: cmacme.ChallengeSpec{
+					DNSName: "example.com",
+					Solver: &cmacme.ACMEChallengeSolver{
+						HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+							Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+						},
+					},
+				},
+			},
+			Err: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			test.Setup(t)
+			resp, err := test.Solver.SolverService(context.TODO(), test.Challenge)
+			if err != nil && !test.Err {
+				t.Errorf("Expected function to not error, but got: %v", err)
+			}
+			if err == nil && test.Err {
+				t.Errorf("Expected function to get an error, but got: %v", err)
+			}
+			test.Finish(t, resp, err)
+		})
+	}
+}
+
+func TestBuildServiceHostNetwork(t *testing.T) {
+	ch := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+						HostNetworkPort: 8189,
+					},
+				},
+			},
+		},
+	}
+	f := solverFixture{Challenge: ch}
+	f.Setup(t)
+	defer f.Finish(t)
+
+	svc, err := f.Solver.buildService(ch)
+	if err != nil {
+		t.Fatalf("unexpected error building service: %v", err)
+	}
+	if svc.Spec.Type != v1.ServiceTypeClusterIP || svc.Spec.ClusterIP != v1.ClusterIPNone {
+		t.Errorf("expected a headless ClusterIP service but got type %q with cluster IP %q", svc.Spec.Type, svc.Spec.ClusterIP)
+	}
+	port := svc.Spec.Ports[0]
+	if port.Port != acmeSolverListenPort {
+		t.Errorf("expected service port %d but got %d", acmeSolverListenPort, port.Port)
+	}
+	if port.TargetPort.IntValue() != 8189 {
+		t.Errorf("expected service to target the host network port 8189 but got %s", port.TargetPort.String())
+	}
+}
+
+func TestBuildServiceHeadless(t *testing.T) {
+	ch := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{
+						HeadlessService: true,
+					},
+				},
+			},
+		},
+	}
+	for _, portName := range []string{"", "acme-http"} {
+		t.Run("port name "+portName, func(t *testing.T) {
+			f := solverFixture{Challenge: ch}
+			f.Setup(t)
+			defer f.Finish(t)
+			f.Solver.servicePortName = portName
+
+			svc, err := f.Solver.buildService(ch)
+			if err != nil {
+				t.Fatalf("unexpected error building service: %v", err)
+			}
+			if svc.Spec.Type != v1.ServiceTypeClusterIP || svc.Spec.ClusterIP != v1.ClusterIPNone {
+				t.Errorf("expected a headless ClusterIP service but got type %q with cluster IP %q", svc.Spec.Type, svc.Spec.ClusterIP)
+			}
+			if !f.Solver.exposesSolverPort(svc) {
+				t.Errorf("expected headless service to expose the port referenced by solver ingress paths but got %v", svc.Spec.Ports)
+			}
+		})
+	}
+}
+
+func TestServicePort80(t *testing.T) {
+	ingressChallenge := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+				},
+			},
+		},
+	}
+	nodePortChallenge := ingressChallenge.DeepCopy()
+	nodePortChallenge.Spec.Solver.HTTP01 = &cmacme.ACMEChallengeSolverHTTP01{
+		NodePort: &cmacme.ACMEChallengeSolverHTTP01NodePort{},
+	}
+
+	f := solverFixture{Challenge: ingressChallenge}
+	f.Setup(t)
+	defer f.Finish(t)
+	f.Solver.servicePort80 = true
+
+	svc, err := f.Solver.buildService(ingressChallenge)
+	if err != nil {
+		t.Fatalf("unexpected error building service: %v", err)
+	}
+	if len(svc.Spec.Ports) != 2 || svc.Spec.Ports[1].Port != 80 || svc.Spec.Ports[1].TargetPort != svc.Spec.Ports[0].TargetPort {
+		t.Errorf("expected service to additionally expose the solver on port 80 but got %v", svc.Spec.Ports)
+	}
+	if port := f.Solver.servicePort(); port.IntValue() != 80 {
+		t.Errorf("expected ingress paths to reference port 80 but got %s", port.String())
+	}
+	if !f.Solver.exposesSolverPort(svc) {
+		t.Errorf("expected service to expose the port referenced by solver ingress paths but got %v", svc.Spec.Ports)
+	}
+
+	svc, err = f.Solver.buildService(nodePortChallenge)
+	if err != nil {
+		t.Fatalf("unexpected error building service: %v", err)
+	}
+	if len(svc.Spec.Ports) != 1 {
+		t.Errorf("expected NodePort solver service not to expose port 80 but got %v", svc.Spec.Ports)
+	}
+}
+
+func TestEnsureServiceReconcilesPort80(t *testing.T) {
+	ch := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Solver: &cmacme.ACMEChallengeSolver{
+				HTTP01: &cmacme.ACMEChallengeSolverHTTP01{
+					Ingress: &cmacme.ACMEChallengeSolverHTTP01Ingress{},
+				},
+			},
+		},
+	}
+	for _, expose := range []bool{true, false} {
+		t.Run(fmt.Sprintf("expose port 80 %t", expose), func(t *testing.T) {
+			f := solverFixture{
+				Challenge: ch,
+				PreFn: func(t *testing.T, s *solverFixture) {
+					// the existing service was created with the opposite setting
+					s.Solver.servicePort80 = !expose
+					svc, err := s.Solver.createService(context.TODO(), ch)
+					if err != nil {
+						t.Fatalf("error creating service: %v", err)
+					}
+					s.Solver.servicePort80 = expose
+					s.testResources["service"] = svc
+				},
+			}
+			f.Setup(t)
+			defer f.Finish(t)
+
+			svc, err := f.Solver.ensureService(context.TODO(), ch)
+			if err != nil {
+				t.Fatalf("unexpected error ensuring service: %v", err)
+			}
+			created := f.testResources["service"].(*v1.Service)
+			if svc.Name != created.Name {
+				t.Errorf("expected existing service %q to be updated but got %q", created.Name, svc.Name)
+			}
+			updated, err := f.Builder.FakeKubeClient().CoreV1().Services(defaultTestNamespace).Get(svc.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("error getting service: %v", err)
+			}
+			hasPort80 := false
+			for _, port := range updated.Spec.Ports {
+				if port.Port == 80 {
+					hasPort80 = true
+				}
+			}
+			if hasPort80 != expose || len(updated.Spec.Ports) == 0 || updated.Spec.Ports[0].Port != acmeSolverListenPort {
+				t.Errorf("expected service to expose port 80 %t and keep the solver port but got %v", expose, updated.Spec.Ports)
+			}
+		})
+	}
+}
@@ -19,4 +19,8 @@ package solver
 const (
 	// HTTPChallengePath is the path prefix used for http-01 challenge requests
 	HTTPChallengePath = "/.well-known/acme-challenge"
+
+	// ACMETLSProtocol is the ALPN protocol name negotiated by tls-alpn-01
+	// challenge requests
+	ACMETLSProtocol = "acme-tls/1"
 )
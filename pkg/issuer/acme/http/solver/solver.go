@@ -32,6 +32,24 @@ type HTTP01Solver struct {
 	Domain string
 	Token  string
 	Key    string
+
+	// ExtraBasePaths are additional base paths, besides HTTPChallengePath,
+	// that challenge requests will be accepted on.
+	ExtraBasePaths []string
+}
+
+// validBasePath returns true if the given request base path is either the
+// canonical challenge path or one of the configured extra base paths.
+func (h *HTTP01Solver) validBasePath(basePath string) bool {
+	if basePath == HTTPChallengePath {
+		return true
+	}
+	for _, p := range h.ExtraBasePaths {
+		if basePath == p {
+			return true
+		}
+	}
+	return false
 }
 
 func (h *HTTP01Solver) Listen(ctx context.Context) error {
@@ -40,6 +58,7 @@ func (h *HTTP01Solver) Listen(ctx context.Context) error {
 		"expected_domain", h.Domain,
 		"expected_token", h.Token,
 		"expected_key", h.Key,
+		"extra_base_paths", h.ExtraBasePaths,
 		"listen_port", h.ListenPort,
 	)
 
@@ -63,7 +82,7 @@ func (h *HTTP01Solver) Listen(ctx context.Context) error {
 		}
 		log.Info("validating request")
 		// verify the base path is correct
-		if basePath != HTTPChallengePath {
+		if !h.validBasePath(basePath) {
 			log.Info("invalid base_path", "expected_base_path", HTTPChallengePath)
 			http.NotFound(w, r)
 			return
@@ -0,0 +1,164 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package solver
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	logf "github.com/jetstack/cert-manager/pkg/logs"
+)
+
+// idPeACMEIdentifier is the OID of the acmeIdentifier certificate extension
+// used by the tls-alpn-01 challenge, as defined in RFC 8737.
+var idPeACMEIdentifier = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 31}
+
+type TLSALPN01Solver struct {
+	ListenPort int
+
+	Domain string
+	// Key is the key authorization for the challenge.
+	Key string
+}
+
+func (t *TLSALPN01Solver) Listen(ctx context.Context) error {
+	log := logf.FromContext(ctx)
+	log.Info("starting tls-alpn-01 listener",
+		"expected_domain", t.Domain,
+		"expected_key", t.Key,
+		"listen_port", t.ListenPort,
+	)
+
+	cert, err := ChallengeCertificate(t.Domain, t.Key)
+	if err != nil {
+		return err
+	}
+	l, err := tls.Listen("tcp", fmt.Sprintf(":%d", t.ListenPort), &tls.Config{
+		Certificates: []tls.Certificate{*cert},
+		NextProtos:   []string{ACMETLSProtocol},
+	})
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			// the challenge is solved by completing the handshake, after
+			// which no application data is exchanged
+			if err := conn.(*tls.Conn).Handshake(); err != nil {
+				log.Info("tls-alpn-01 handshake failed", "remote_addr", conn.RemoteAddr().String(), "error", err)
+				return
+			}
+			log.Info("completed tls-alpn-01 handshake", "remote_addr", conn.RemoteAddr().String())
+		}(conn)
+	}
+}
+
+// ChallengeCertificate returns a self signed certificate for the given domain
+// that contains the acmeIdentifier extension for the given key authorization,
+// as required to solve a tls-alpn-01 challenge.
+func ChallengeCertificate(domain, keyAuth string) (*tls.Certificate, error) {
+	ext, err := acmeIdentifierExtension(keyAuth)
+	if err != nil {
+		return nil, err
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:    serial,
+		Subject:         pkix.Name{CommonName: domain},
+		DNSNames:        []string{domain},
+		NotBefore:       time.Now().Add(-time.Hour),
+		NotAfter:        time.Now().Add(24 * time.Hour),
+		KeyUsage:        x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		ExtraExtensions: []pkix.Extension{ext},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Certificate{
+		Certificate: [][]byte{der},
+		PrivateKey:  key,
+	}, nil
+}
+
+// VerifyChallengeCertificate returns an error if the given certificate is
+// not a valid tls-alpn-01 challenge certificate for the given domain and key
+// authorization.
+func VerifyChallengeCertificate(cert *x509.Certificate, domain, keyAuth string) error {
+	if len(cert.DNSNames) != 1 || cert.DNSNames[0] != domain {
+		return fmt.Errorf("challenge certificate has DNS names %v, expected only %q", cert.DNSNames, domain)
+	}
+	expected, err := acmeIdentifierExtension(keyAuth)
+	if err != nil {
+		return err
+	}
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(idPeACMEIdentifier) {
+			continue
+		}
+		if !ext.Critical {
+			return fmt.Errorf("acmeIdentifier extension of challenge certificate is not critical")
+		}
+		if !bytes.Equal(ext.Value, expected.Value) {
+			return fmt.Errorf("acmeIdentifier extension of challenge certificate does not match key authorization")
+		}
+		return nil
+	}
+	return fmt.Errorf("challenge certificate does not contain an acmeIdentifier extension")
+}
+
+// acmeIdentifierExtension returns the critical acmeIdentifier extension,
+// containing the SHA-256 digest of the given key authorization.
+func acmeIdentifierExtension(keyAuth string) (pkix.Extension, error) {
+	digest := sha256.Sum256([]byte(keyAuth))
+	value, err := asn1.Marshal(digest[:])
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{
+		Id:       idPeACMEIdentifier,
+		Critical: true,
+		Value:    value,
+	}, nil
+}
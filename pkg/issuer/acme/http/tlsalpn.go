@@ -0,0 +1,209 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"strconv"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/issuer/acme/http/solver"
+	logf "github.com/jetstack/cert-manager/pkg/logs"
+)
+
+const (
+	// tlsALPNPort is the port that tls-alpn-01 challenges are validated on
+	tlsALPNPort = 443
+
+	// tlsALPNServiceNamePrefix is the prefix of the names of tls-alpn-01
+	// solver services
+	tlsALPNServiceNamePrefix = "cm-acme-tls-alpn-"
+)
+
+// TLSALPNSolver is an implementation of the acme tls-alpn-01 challenge solver
+// protocol. It reuses the solver pods and services of the HTTP01 Solver, with
+// the solver pod serving the challenge certificate rather than the key.
+// Routing TLS connections for the challenged domain to the solver service is
+// left to the user, e.g. using an ingress controller with TLS passthrough.
+// The solver service is named using TLSALPNServiceName so that this routing
+// can be configured before challenges are presented.
+type TLSALPNSolver struct {
+	*Solver
+
+	testTLSALPN tlsALPNTest
+}
+
+// tlsALPNTest checks that the tls-alpn-01 challenge certificate for the given
+// domain and key authorization is served at addr.
+type tlsALPNTest func(ctx context.Context, addr, domain, key string) error
+
+// NewTLSALPNSolver returns a new ACME tls-alpn-01 solver that manages its
+// solver pods and services using the given HTTP01 solver.
+func NewTLSALPNSolver(s *Solver) *TLSALPNSolver {
+	return &TLSALPNSolver{
+		Solver:      s,
+		testTLSALPN: testTLSALPNReachability,
+	}
+}
+
+// TLSALPNServiceName returns the name of the solver service for tls-alpn-01
+// challenges for the given domain. It is the domain prefixed with
+// 'cm-acme-tls-alpn-', with dots replaced by dashes, e.g.
+// 'cm-acme-tls-alpn-example-com' for 'example.com'. Names that would exceed
+// the maximum length of a service name are truncated and suffixed with a hash
+// of the domain.
+func TLSALPNServiceName(domain string) string {
+	name := tlsALPNServiceNamePrefix + strings.Replace(normalizeHost(domain), ".", "-", -1)
+	if len(name) <= validation.DNS1035LabelMaxLength {
+		return name
+	}
+	h := fnv.New32a()
+	fmt.Fprint(h, normalizeHost(domain))
+	suffix := fmt.Sprintf("-%08x", h.Sum32())
+	return strings.TrimRight(name[:validation.DNS1035LabelMaxLength-len(suffix)], "-") + suffix
+}
+
+func tlsALPNLogCtx(ctx context.Context) context.Context {
+	return logf.NewContext(ctx, nil, "tlsalpn01")
+}
+
+func tlsALPNCfgForChallenge(ch *cmacme.Challenge) *cmacme.ACMEChallengeSolverTLSALPN01 {
+	if ch.Spec.Solver == nil {
+		return nil
+	}
+	return ch.Spec.Solver.TLSALPN01
+}
+
+// Present will create the solver pod and service required to solve the given
+// tls-alpn-01 challenge. If those resources already exist, it will return nil
+// (i.e. this function is idempotent).
+func (s *TLSALPNSolver) Present(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+	ctx = tlsALPNLogCtx(ctx)
+
+	if err := s.checkNamespaceAllowed(s.resourceNamespace(ch)); err != nil {
+		return err
+	}
+
+	_, podErr := s.ensurePod(ctx, ch)
+	_, svcErr := s.ensureService(ctx, ch)
+	return utilerrors.NewAggregate([]error{podErr, svcErr})
+}
+
+// Check verifies that the challenge certificate is served for the challenged
+// domain on port 443.
+func (s *TLSALPNSolver) Check(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+	ctx = logf.NewContext(tlsALPNLogCtx(ctx), nil, "selfCheck")
+	log := logf.FromContext(ctx)
+
+	// if the listers are nil, that means we're in the present checks test
+	if s.podLister != nil && s.serviceLister != nil {
+		log.V(logf.DebugLevel).Info("calling Present function before running self check to ensure required resources exist")
+		if err := s.Present(ctx, issuer, ch); err != nil {
+			log.V(logf.DebugLevel).Info("failed to call Present function", "error", err)
+			return err
+		}
+	}
+
+	timeout := s.selfCheckTimeout
+	if s.timeout > 0 {
+		remaining, err := s.remainingTime(ch)
+		if err != nil {
+			return err
+		}
+		if remaining < timeout {
+			timeout = remaining
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(ch.Spec.DNSName, strconv.Itoa(tlsALPNPort))
+	log.V(logf.DebugLevel).Info("running self check multiple times to ensure challenge has propagated", "address", addr, "required_passes", s.requiredPasses)
+	for i := 0; i < s.requiredPasses; i++ {
+		if err := s.testTLSALPN(ctx, addr, ch.Spec.DNSName, ch.Spec.Key); err != nil {
+			return err
+		}
+		log.V(logf.DebugLevel).Info("reachability test passed, re-checking after interval", "interval", s.selfCheckInterval)
+		if err := s.sleep(ctx, s.selfCheckInterval); err != nil {
+			return err
+		}
+	}
+
+	log.V(logf.DebugLevel).Info("self check succeeded")
+	return nil
+}
+
+// CleanUp will delete the solver pod and service created for the challenge.
+func (s *TLSALPNSolver) CleanUp(ctx context.Context, issuer v1alpha2.GenericIssuer, ch *cmacme.Challenge) error {
+	ctx = tlsALPNLogCtx(ctx)
+
+	return utilerrors.NewAggregate([]error{
+		s.cleanupPods(ctx, ch),
+		s.cleanupServices(ctx, ch),
+	})
+}
+
+// testTLSALPNReachability connects to addr, negotiating the acme-tls/1
+// protocol, and verifies that the certificate presented is the challenge
+// certificate for the given domain and key authorization.
+func testTLSALPNReachability(ctx context.Context, addr, domain, key string) error {
+	log := logf.FromContext(ctx)
+	log.V(logf.DebugLevel).Info("performing TLS-ALPN-01 reachability check", "address", addr)
+
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", addr, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName: domain,
+		NextProtos: []string{solver.ACMETLSProtocol},
+		// the challenge certificate is self signed
+		InsecureSkipVerify: true,
+	})
+	if err := tlsConn.Handshake(); err != nil {
+		return fmt.Errorf("TLS handshake with %s failed: %v", addr, err)
+	}
+	state := tlsConn.ConnectionState()
+	if state.NegotiatedProtocol != solver.ACMETLSProtocol {
+		return fmt.Errorf("%s did not negotiate the %q protocol", addr, solver.ACMETLSProtocol)
+	}
+	if len(state.PeerCertificates) == 0 {
+		return fmt.Errorf("%s did not present a certificate", addr)
+	}
+	if err := solver.VerifyChallengeCertificate(state.PeerCertificates[0], domain, key); err != nil {
+		return fmt.Errorf("%s presented an invalid challenge certificate: %v", addr, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2019 The Jetstack cert-manager contributors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
+	"github.com/jetstack/cert-manager/pkg/issuer/acme/http/solver"
+)
+
+func TestTLSALPNReachability(t *testing.T) {
+	// serve starts a TLS listener presenting the challenge certificate for
+	// the given domain and key, negotiating the given protocols
+	serve := func(t *testing.T, domain, key string, protos []string) string {
+		cert, err := solver.ChallengeCertificate(domain, key)
+		if err != nil {
+			t.Fatalf("error generating challenge certificate: %v", err)
+		}
+		l, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+			Certificates: []tls.Certificate{*cert},
+			NextProtos:   protos,
+		})
+		if err != nil {
+			t.Fatalf("error listening: %v", err)
+		}
+		go func() {
+			for {
+				conn, err := l.Accept()
+				if err != nil {
+					return
+				}
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}
+		}()
+		t.Cleanup(func() { l.Close() })
+		return l.Addr().String()
+	}
+
+	tests := map[string]struct {
+		domain    string
+		key       string
+		protos    []string
+		expectErr bool
+	}{
+		"should pass if the challenge certificate is served": {
+			domain: "example.com",
+			key:    "abcd.key",
+			protos: []string{solver.ACMETLSProtocol},
+		},
+		"should fail if the certificate is for a different key": {
+			domain:    "example.com",
+			key:       "other.key",
+			protos:    []string{solver.ACMETLSProtocol},
+			expectErr: true,
+		},
+		"should fail if the certificate is for a different domain": {
+			domain:    "other.com",
+			key:       "abcd.key",
+			protos:    []string{solver.ACMETLSProtocol},
+			expectErr: true,
+		},
+		"should fail if the acme-tls/1 protocol is not negotiated": {
+			domain:    "example.com",
+			key:       "abcd.key",
+			protos:    []string{"h2"},
+			expectErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			addr := serve(t, test.domain, test.key, test.protos)
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			err := testTLSALPNReachability(ctx, addr, "example.com", "abcd.key")
+			if err != nil && !test.expectErr {
+				t.Errorf("expected check to pass but got: %v", err)
+			}
+			if err == nil && test.expectErr {
+				t.Errorf("expected check to fail but it passed")
+			}
+		})
+	}
+}
+
+func TestTLSALPNSolverPresent(t *testing.T) {
+	chal := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "testchal",
+			Namespace: defaultTestNamespace,
+		},
+		Spec: cmacme.ChallengeSpec{
+			Type:    cmacme.ACMEChallengeTypeTLSALPN01,
+			DNSName: "example.com",
+			Token:   "abcd",
+			Key:     "abcd.key",
+			Solver: &cmacme.ACMEChallengeSolver{
+				TLSALPN01: &cmacme.ACMEChallengeSolverTLSALPN01{
+					ServiceType: corev1.ServiceTypeLoadBalancer,
+				},
+			},
+		},
+	}
+	f := solverFixture{Challenge: chal}
+	f.Setup(t)
+	defer f.Finish(t)
+	s := NewTLSALPNSolver(f.Solver)
+
+	if err := s.Present(context.TODO(), nil, chal); err != nil {
+		t.Fatalf("unexpected error presenting challenge: %v", err)
+	}
+	f.Builder.Sync()
+
+	pods, err := s.getPodsForChallenge(context.TODO(), chal)
+	if err != nil || len(pods) != 1 {
+		t.Fatalf("expected one solver pod but got %d: %v", len(pods), err)
+	}
+	found := false
+	for _, arg := range pods[0].Spec.Containers[0].Args {
+		if arg == "--challenge-type=tls-alpn-01" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected solver pod to be started in tls-alpn-01 mode but got args %v", pods[0].Spec.Containers[0].Args)
+	}
+
+	svcs, err := s.getServicesForChallenge(context.TODO(), chal)
+	if err != nil || len(svcs) != 1 {
+		t.Fatalf("expected one solver service but got %d: %v", len(svcs), err)
+	}
+	if svcs[0].Name != "cm-acme-tls-alpn-example-com" {
+		t.Errorf("expected service to be named %q but got %q", "cm-acme-tls-alpn-example-com", svcs[0].Name)
+	}
+	if svcs[0].Spec.Type != corev1.ServiceTypeLoadBalancer {
+		t.Errorf("expected service type %q but got %q", corev1.ServiceTypeLoadBalancer, svcs[0].Spec.Type)
+	}
+	if port := svcs[0].Spec.Ports[0]; port.Port != tlsALPNPort || port.TargetPort.IntValue() != acmeSolverListenPort {
+		t.Errorf("expected service to expose port %d on port %d but got %+v", acmeSolverListenPort, tlsALPNPort, port)
+	}
+
+	if err := s.CleanUp(context.TODO(), nil, chal); err != nil {
+		t.Fatalf("unexpected error cleaning up challenge: %v", err)
+	}
+	f.Builder.Sync()
+	pods, _ = s.getPodsForChallenge(context.TODO(), chal)
+	svcs, _ = s.getServicesForChallenge(context.TODO(), chal)
+	if len(pods) != 0 || len(svcs) != 0 {
+		t.Errorf("expected solver resources to be cleaned up but found %d pods and %d services", len(pods), len(svcs))
+	}
+}
+
+func TestTLSALPNServiceName(t *testing.T) {
+	long := strings.Repeat("a", 60) + ".example.com"
+	tests := map[string]struct {
+		domain   string
+		expected string
+	}{
+		"should replace dots with dashes": {
+			domain:   "www.example.com",
+			expected: "cm-acme-tls-alpn-www-example-com",
+		},
+		"should ignore case and the trailing dot": {
+			domain:   "WWW.Example.com.",
+			expected: "cm-acme-tls-alpn-www-example-com",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if name := TLSALPNServiceName(test.domain); name != test.expected {
+				t.Errorf("expected service name %q but got %q", test.expected, name)
+			}
+		})
+	}
+
+	name := TLSALPNServiceName(long)
+	if errs := validation.IsDNS1035Label(name); len(errs) > 0 {
+		t.Errorf("expected a valid service name for a long domain but got %q: %v", name, errs)
+	}
+	if name == TLSALPNServiceName(strings.Repeat("a", 60)+".example.org") {
+		t.Errorf("expected long domains to result in distinct service names but both were %q", name)
+	}
+}
@@ -19,6 +19,9 @@ package http
 import (
 	"testing"
 
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
 	"github.com/jetstack/cert-manager/pkg/controller/test"
 	"github.com/jetstack/cert-manager/test/unit/gen"
@@ -90,3 +93,18 @@ func buildFakeSolver(b *test.Builder) *Solver {
 func strPtr(s string) *string {
 	return &s
 }
+
+// fakeSolverService returns the solver service that tests route challenge
+// paths to, which must exist before paths are added to an existing ingress.
+func fakeSolverService() *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "fakeservice",
+			Namespace: defaultTestNamespace,
+			Labels:    map[string]string{solverIdentificationLabelKey: "true"},
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Name: "http", Port: acmeSolverListenPort}},
+		},
+	}
+}
@@ -18,11 +18,15 @@ limitations under the License.
 // cert-manager exposes the following metrics:
 // certificate_expiration_timestamp_seconds{name, namespace}
 // certificate_ready_status{name, namespace, condition}
+// http01_solver_operation_count{namespace, issuer_name, issuer_kind, operation}
+// http01_solver_operation_error_count{namespace, issuer_name, issuer_kind, operation}
+// http01_solver_time_to_reachable_seconds{namespace, issuer_name, issuer_kind}
 package metrics
 
 import (
 	"context"
 	"crypto/x509"
+	"encoding/json"
 	"net/http"
 	"sync"
 	"time"
@@ -36,6 +40,7 @@ import (
 	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha2"
 	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
 	cmlisters "github.com/jetstack/cert-manager/pkg/client/listers/certmanager/v1alpha2"
@@ -52,6 +57,10 @@ const (
 	prometheusMetricsServerReadTimeout     = 8 * time.Second
 	prometheusMetricsServerWriteTimeout    = 8 * time.Second
 	prometheusMetricsServerMaxHeaderBytes  = 1 << 20 // 1 MiB
+
+	// activeChallengesPath is the path the in-flight ACME challenges are
+	// served on by the metrics server
+	activeChallengesPath = "/debug/acme/challenges"
 )
 
 var readyConditionStatuses = [...]string{string(cmmeta.ConditionTrue), string(cmmeta.ConditionFalse), string(cmmeta.ConditionUnknown)}
@@ -111,6 +120,63 @@ var ControllerSyncCallCount = prometheus.NewCounterVec(
 	[]string{"controller"},
 )
 
+// Operations performed by the HTTP01 challenge solver, used as the value of
+// the 'operation' label on the HTTP01 solver metrics.
+const (
+	HTTP01SolverOperationCreateIngress = "create_ingress"
+	HTTP01SolverOperationAddPath       = "add_path"
+	HTTP01SolverOperationCleanup       = "cleanup"
+)
+
+// HTTP01SolverOperationCount is a Prometheus counter of the number of
+// operations performed by the HTTP01 challenge solver.
+// Challenges are labelled by the issuer used to solve them rather than by
+// domain or token in order to keep the cardinality of the metric bounded.
+var HTTP01SolverOperationCount = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http01_solver_operation_count",
+		Help:      "The number of operations performed by the HTTP01 challenge solver.",
+	},
+	[]string{"namespace", "issuer_name", "issuer_kind", "operation"},
+)
+
+// HTTP01SolverOperationErrorCount is a Prometheus counter of the number of
+// operations performed by the HTTP01 challenge solver that failed.
+var HTTP01SolverOperationErrorCount = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http01_solver_operation_error_count",
+		Help:      "The number of operations performed by the HTTP01 challenge solver that failed.",
+	},
+	[]string{"namespace", "issuer_name", "issuer_kind", "operation"},
+)
+
+// HTTP01SolverTimeToReachableSeconds is a Prometheus histogram of the time
+// taken between a challenge being created and its HTTP01 solver passing the
+// self check.
+var HTTP01SolverTimeToReachableSeconds = prometheus.NewHistogramVec(
+	prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http01_solver_time_to_reachable_seconds",
+		Help:      "The time taken for a HTTP01 challenge solver to become reachable after the challenge was created.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	},
+	[]string{"namespace", "issuer_name", "issuer_kind"},
+)
+
+// ACMEChallengesPendingCleanup is a Prometheus gauge of the number of ACME
+// challenges that are awaiting clean up of their solver, e.g. because
+// deleting a DNS01 TXT record failed.
+var ACMEChallengesPendingCleanup = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "acme_challenges_pending_cleanup",
+		Help:      "The number of ACME challenges whose solver has not yet been successfully cleaned up.",
+	},
+	[]string{"type"},
+)
+
 // registeredCertificates holds the set of all certificates which are currently
 // registered by Prometheus
 var registeredCertificates = &struct {
@@ -143,6 +209,18 @@ type Metrics struct {
 	ACMEClientRequestDurationSeconds *prometheus.SummaryVec
 	ACMEClientRequestCount           *prometheus.CounterVec
 	ControllerSyncCallCount          *prometheus.CounterVec
+
+	HTTP01SolverOperationCount         *prometheus.CounterVec
+	HTTP01SolverOperationErrorCount    *prometheus.CounterVec
+	HTTP01SolverTimeToReachableSeconds *prometheus.HistogramVec
+
+	ACMEChallengesPendingCleanup *prometheus.GaugeVec
+
+	// activeChallengesFunc returns the in-flight ACME challenges served on
+	// activeChallengesPath. It is set once the challenges controller has
+	// been registered.
+	activeChallengesFunc func() (interface{}, error)
+	activeChallengesLock sync.RWMutex
 }
 
 func New(ctx context.Context) *Metrics {
@@ -165,13 +243,49 @@ func New(ctx context.Context) *Metrics {
 		ACMEClientRequestDurationSeconds: ACMEClientRequestDurationSeconds,
 		ACMEClientRequestCount:           ACMEClientRequestCount,
 		ControllerSyncCallCount:          ControllerSyncCallCount,
+
+		HTTP01SolverOperationCount:         HTTP01SolverOperationCount,
+		HTTP01SolverOperationErrorCount:    HTTP01SolverOperationErrorCount,
+		HTTP01SolverTimeToReachableSeconds: HTTP01SolverTimeToReachableSeconds,
+
+		ACMEChallengesPendingCleanup: ACMEChallengesPendingCleanup,
 	}
 
 	router.Handle("/metrics", promhttp.HandlerFor(s.registry, promhttp.HandlerOpts{}))
+	router.HandleFunc(activeChallengesPath, s.serveActiveChallenges)
 
 	return s
 }
 
+// SetActiveChallengesFunc sets the function used to list the in-flight ACME
+// challenges served as JSON by the metrics server, so that operators can see
+// which domains are currently being solved.
+func (m *Metrics) SetActiveChallengesFunc(fn func() (interface{}, error)) {
+	m.activeChallengesLock.Lock()
+	defer m.activeChallengesLock.Unlock()
+	m.activeChallengesFunc = fn
+}
+
+func (m *Metrics) serveActiveChallenges(w http.ResponseWriter, r *http.Request) {
+	m.activeChallengesLock.RLock()
+	fn := m.activeChallengesFunc
+	m.activeChallengesLock.RUnlock()
+
+	if fn == nil {
+		http.Error(w, "ACME challenges controller is not running", http.StatusServiceUnavailable)
+		return
+	}
+	active, err := fn()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(active); err != nil {
+		logf.FromContext(m.ctx).Error(err, "error writing active ACME challenges")
+	}
+}
+
 func (m *Metrics) waitShutdown(stopCh <-chan struct{}) {
 	log := logf.FromContext(m.ctx)
 	<-stopCh
@@ -196,6 +310,10 @@ func (m *Metrics) Start(stopCh <-chan struct{}) {
 	m.registry.MustRegister(m.ACMEClientRequestDurationSeconds)
 	m.registry.MustRegister(m.ACMEClientRequestCount)
 	m.registry.MustRegister(m.ControllerSyncCallCount)
+	m.registry.MustRegister(m.HTTP01SolverOperationCount)
+	m.registry.MustRegister(m.HTTP01SolverOperationErrorCount)
+	m.registry.MustRegister(m.HTTP01SolverTimeToReachableSeconds)
+	m.registry.MustRegister(m.ACMEChallengesPendingCleanup)
 
 	go func() {
 		log := log.WithValues("address", m.Addr)
@@ -385,3 +503,38 @@ func (m *Metrics) IncrementSyncCallCount(controllerName string) {
 	log.V(logf.DebugLevel).Info("incrementing controller sync call count", "controllerName", controllerName)
 	ControllerSyncCallCount.WithLabelValues(controllerName).Inc()
 }
+
+// ObserveHTTP01SolverOperation records that the HTTP01 challenge solver
+// performed the given operation for a challenge, and whether it failed.
+func (m *Metrics) ObserveHTTP01SolverOperation(ch *cmacme.Challenge, operation string, err error) {
+	labels := prometheus.Labels{
+		"namespace":   ch.Namespace,
+		"issuer_name": ch.Spec.IssuerRef.Name,
+		"issuer_kind": ch.Spec.IssuerRef.Kind,
+		"operation":   operation,
+	}
+	m.HTTP01SolverOperationCount.With(labels).Inc()
+	if err != nil {
+		m.HTTP01SolverOperationErrorCount.With(labels).Inc()
+	}
+}
+
+// ObserveHTTP01SolverTimeToReachable records the time taken for the HTTP01
+// challenge solver for the given challenge to become reachable.
+func (m *Metrics) ObserveHTTP01SolverTimeToReachable(ch *cmacme.Challenge, d time.Duration) {
+	m.HTTP01SolverTimeToReachableSeconds.With(prometheus.Labels{
+		"namespace":   ch.Namespace,
+		"issuer_name": ch.Spec.IssuerRef.Name,
+		"issuer_kind": ch.Spec.IssuerRef.Kind,
+	}).Observe(d.Seconds())
+}
+
+// SetACMEChallengesPendingCleanup records the number of ACME challenges of
+// each type that are awaiting clean up of their solver. Any previously
+// recorded types that are not present in counts are removed.
+func (m *Metrics) SetACMEChallengesPendingCleanup(counts map[string]int) {
+	m.ACMEChallengesPendingCleanup.Reset()
+	for challengeType, count := range counts {
+		m.ACMEChallengesPendingCleanup.WithLabelValues(challengeType).Set(float64(count))
+	}
+}
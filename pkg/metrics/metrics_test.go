@@ -17,7 +17,11 @@ limitations under the License.
 package metrics
 
 import (
+	"context"
 	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
@@ -25,6 +29,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
 	"github.com/jetstack/cert-manager/pkg/apis/certmanager/v1alpha2"
 	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
 )
@@ -266,3 +271,89 @@ func TestCleanUp(t *testing.T) {
 		})
 	}
 }
+
+func TestObserveHTTP01SolverOperation(t *testing.T) {
+	const metadata = `
+	# HELP certmanager_http01_solver_operation_count The number of operations performed by the HTTP01 challenge solver.
+	# TYPE certmanager_http01_solver_operation_count counter
+`
+	const metadataErrors = `
+	# HELP certmanager_http01_solver_operation_error_count The number of operations performed by the HTTP01 challenge solver that failed.
+	# TYPE certmanager_http01_solver_operation_error_count counter
+`
+	defer HTTP01SolverOperationCount.Reset()
+	defer HTTP01SolverOperationErrorCount.Reset()
+
+	ch := &cmacme.Challenge{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-challenge",
+			Namespace: "default",
+		},
+		Spec: cmacme.ChallengeSpec{
+			DNSName: "example.com",
+			Token:   "abcd",
+			IssuerRef: cmmeta.ObjectReference{
+				Name: "letsencrypt",
+				Kind: "ClusterIssuer",
+			},
+		},
+	}
+
+	m := New(context.Background())
+	m.ObserveHTTP01SolverOperation(ch, HTTP01SolverOperationCreateIngress, nil)
+	m.ObserveHTTP01SolverOperation(ch, HTTP01SolverOperationCreateIngress, fmt.Errorf("failed"))
+	m.ObserveHTTP01SolverOperation(ch, HTTP01SolverOperationCleanup, nil)
+
+	if err := testutil.CollectAndCompare(
+		HTTP01SolverOperationCount,
+		strings.NewReader(metadata+`
+	certmanager_http01_solver_operation_count{issuer_kind="ClusterIssuer",issuer_name="letsencrypt",namespace="default",operation="cleanup"} 1
+	certmanager_http01_solver_operation_count{issuer_kind="ClusterIssuer",issuer_name="letsencrypt",namespace="default",operation="create_ingress"} 2
+`),
+		"certmanager_http01_solver_operation_count",
+	); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+
+	if err := testutil.CollectAndCompare(
+		HTTP01SolverOperationErrorCount,
+		strings.NewReader(metadataErrors+`
+	certmanager_http01_solver_operation_error_count{issuer_kind="ClusterIssuer",issuer_name="letsencrypt",namespace="default",operation="create_ingress"} 1
+`),
+		"certmanager_http01_solver_operation_error_count",
+	); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+}
+
+func TestServeActiveChallenges(t *testing.T) {
+	m := New(context.Background())
+
+	get := func() *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		m.Handler.ServeHTTP(rec, httptest.NewRequest("GET", activeChallengesPath, nil))
+		return rec
+	}
+
+	if rec := get(); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d before a challenges func is set but got %d", http.StatusServiceUnavailable, rec.Code)
+	}
+
+	m.SetActiveChallengesFunc(func() (interface{}, error) {
+		return []string{"example.com"}, nil
+	})
+	rec := get()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d but got %d", http.StatusOK, rec.Code)
+	}
+	if body := strings.TrimSpace(rec.Body.String()); body != `["example.com"]` {
+		t.Errorf("unexpected response body %q", body)
+	}
+
+	m.SetActiveChallengesFunc(func() (interface{}, error) {
+		return nil, fmt.Errorf("lister failed")
+	})
+	if rec := get(); rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status %d when listing challenges fails but got %d", http.StatusInternalServerError, rec.Code)
+	}
+}
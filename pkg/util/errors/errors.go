@@ -16,7 +16,10 @@ limitations under the License.
 
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"net"
+)
 
 type invalidDataError struct{ error }
 
@@ -30,3 +33,29 @@ func IsInvalidData(err error) bool {
 	}
 	return true
 }
+
+type issuerUnavailableError struct{ error }
+
+// NewIssuerUnavailable wraps err to mark that it was caused by the issuer
+// being unreachable, rather than by a problem with the request or the issuer's
+// configuration. Such errors are expected to resolve without intervention.
+func NewIssuerUnavailable(err error) error {
+	return &issuerUnavailableError{error: err}
+}
+
+func IsIssuerUnavailable(err error) bool {
+	if _, ok := err.(*issuerUnavailableError); !ok {
+		return false
+	}
+	return true
+}
+
+// IsConnectionError returns true if err was caused by a failure to
+// communicate with a remote server, such as a refused connection, a timeout
+// or a failed DNS lookup.
+func IsConnectionError(err error) bool {
+	if _, ok := err.(net.Error); !ok {
+		return false
+	}
+	return true
+}
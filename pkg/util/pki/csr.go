@@ -19,7 +19,9 @@ package pki
 import (
 	"bytes"
 	"crypto"
+	"crypto/ecdsa"
 	"crypto/rand"
+	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
@@ -28,6 +30,7 @@ import (
 	"math/big"
 	"net"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 
@@ -107,6 +110,35 @@ func URLsToString(uris []*url.URL) []string {
 	return uriStrs
 }
 
+// sortedDNSNames returns a sorted copy of the given DNS names.
+// SANs are sorted when generating CSRs and certificate templates so that
+// re-issued certificates only differ when the set of SANs actually changes,
+// and not when they are re-ordered on the Certificate resource.
+func sortedDNSNames(dnsNames []string) []string {
+	if dnsNames == nil {
+		return nil
+	}
+	sorted := make([]string, len(dnsNames))
+	copy(sorted, dnsNames)
+	sort.Strings(sorted)
+	return sorted
+}
+
+// sortIPAddresses sorts the given IP addresses in place by their byte
+// representation.
+func sortIPAddresses(ips []net.IP) {
+	sort.Slice(ips, func(i, j int) bool {
+		return bytes.Compare(ips[i].To16(), ips[j].To16()) < 0
+	})
+}
+
+// sortURIs sorts the given URIs in place by their string representation.
+func sortURIs(uris []*url.URL) {
+	sort.Slice(uris, func(i, j int) bool {
+		return uris[i].String() < uris[j].String()
+	})
+}
+
 func removeDuplicates(in []string) []string {
 	var found []string
 Outer:
@@ -192,6 +224,10 @@ func GenerateCSR(crt *v1alpha2.Certificate) (*x509.CertificateRequest, error) {
 		return nil, fmt.Errorf("no common name, DNS name, or URI SAN specified on certificate")
 	}
 
+	dnsNames = sortedDNSNames(dnsNames)
+	sortIPAddresses(iPAddresses)
+	sortURIs(uriNames)
+
 	pubKeyAlgo, sigAlgo, err := SignatureAlgorithm(crt)
 	if err != nil {
 		return nil, err
@@ -226,8 +262,9 @@ func GenerateCSR(crt *v1alpha2.Certificate) (*x509.CertificateRequest, error) {
 // The PublicKey field must be populated by the caller.
 func GenerateTemplate(crt *v1alpha2.Certificate) (*x509.Certificate, error) {
 	commonName := crt.Spec.CommonName
-	dnsNames := crt.Spec.DNSNames
+	dnsNames := sortedDNSNames(crt.Spec.DNSNames)
 	ipAddresses := IPAddressesForCertificate(crt)
+	sortIPAddresses(ipAddresses)
 	organization := OrganizationForCertificate(crt)
 	subject := SubjectForCertificate(crt)
 	keyUsages, extKeyUsages, err := BuildKeyUsages(crt.Spec.Usages, crt.Spec.IsCA)
@@ -480,3 +517,43 @@ func SignatureAlgorithm(crt *v1alpha2.Certificate) (x509.PublicKeyAlgorithm, x50
 	}
 	return pubKeyAlgo, sigAlgo, nil
 }
+
+var signatureAlgorithms = map[v1alpha2.SignatureAlgorithm]struct {
+	pubKeyAlgo x509.PublicKeyAlgorithm
+	sigAlgo    x509.SignatureAlgorithm
+}{
+	v1alpha2.SHA256WithRSA:   {x509.RSA, x509.SHA256WithRSA},
+	v1alpha2.SHA384WithRSA:   {x509.RSA, x509.SHA384WithRSA},
+	v1alpha2.SHA512WithRSA:   {x509.RSA, x509.SHA512WithRSA},
+	v1alpha2.ECDSAWithSHA256: {x509.ECDSA, x509.ECDSAWithSHA256},
+	v1alpha2.ECDSAWithSHA384: {x509.ECDSA, x509.ECDSAWithSHA384},
+	v1alpha2.ECDSAWithSHA512: {x509.ECDSA, x509.ECDSAWithSHA512},
+}
+
+// SignatureAlgorithmForKey returns the x509 signature algorithm for the given
+// SignatureAlgorithm, returning an error if it cannot be used to sign with the
+// private key corresponding to the given public key. If algo is empty,
+// x509.UnknownSignatureAlgorithm is returned so that the algorithm implied by
+// the signing key is used.
+func SignatureAlgorithmForKey(algo v1alpha2.SignatureAlgorithm, pub crypto.PublicKey) (x509.SignatureAlgorithm, error) {
+	if algo == "" {
+		return x509.UnknownSignatureAlgorithm, nil
+	}
+	a, ok := signatureAlgorithms[algo]
+	if !ok {
+		return x509.UnknownSignatureAlgorithm, fmt.Errorf("unsupported signature algorithm specified: %s", algo)
+	}
+	var pubKeyAlgo x509.PublicKeyAlgorithm
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		pubKeyAlgo = x509.RSA
+	case *ecdsa.PublicKey:
+		pubKeyAlgo = x509.ECDSA
+	default:
+		return x509.UnknownSignatureAlgorithm, fmt.Errorf("unsupported public key type: %T", pub)
+	}
+	if a.pubKeyAlgo != pubKeyAlgo {
+		return x509.UnknownSignatureAlgorithm, fmt.Errorf("signature algorithm %s cannot be used with a %s key", algo, pubKeyAlgo)
+	}
+	return a.sigAlgo, nil
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package pki
 
 import (
+	"crypto"
 	"crypto/x509"
 	"reflect"
 	"testing"
@@ -339,6 +340,66 @@ func TestSignatureAlgorithmForCertificate(t *testing.T) {
 	}
 }
 
+func TestSignatureAlgorithmForKey(t *testing.T) {
+	rsaKey, err := GenerateRSAPrivateKey(MinRSAKeySize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := GenerateECPrivateKey(ECCurve256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		algo            v1alpha2.SignatureAlgorithm
+		key             crypto.Signer
+		expectedSigAlgo x509.SignatureAlgorithm
+		expectErr       bool
+	}{
+		"empty algorithm defers to the key": {
+			key:             rsaKey,
+			expectedSigAlgo: x509.UnknownSignatureAlgorithm,
+		},
+		"SHA384WithRSA with an rsa key": {
+			algo:            v1alpha2.SHA384WithRSA,
+			key:             rsaKey,
+			expectedSigAlgo: x509.SHA384WithRSA,
+		},
+		"ECDSAWithSHA384 with an ecdsa key": {
+			algo:            v1alpha2.ECDSAWithSHA384,
+			key:             ecKey,
+			expectedSigAlgo: x509.ECDSAWithSHA384,
+		},
+		"ECDSAWithSHA384 with an rsa key": {
+			algo:      v1alpha2.ECDSAWithSHA384,
+			key:       rsaKey,
+			expectErr: true,
+		},
+		"SHA256WithRSA with an ecdsa key": {
+			algo:      v1alpha2.SHA256WithRSA,
+			key:       ecKey,
+			expectErr: true,
+		},
+		"unknown algorithm": {
+			algo:      v1alpha2.SignatureAlgorithm("SHA1WithRSA"),
+			key:       rsaKey,
+			expectErr: true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			sigAlgo, err := SignatureAlgorithmForKey(test.algo, test.key.Public())
+			if test.expectErr != (err != nil) {
+				t.Errorf("expected error %t but got: %v", test.expectErr, err)
+				return
+			}
+			if sigAlgo != test.expectedSigAlgo {
+				t.Errorf("expected %q but got %q", test.expectedSigAlgo, sigAlgo)
+			}
+		})
+	}
+}
+
 func TestRemoveDuplicates(t *testing.T) {
 	type testT struct {
 		input  []string
@@ -371,3 +432,59 @@ func TestRemoveDuplicates(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateCSRSANOrdering(t *testing.T) {
+	crt := &v1alpha2.Certificate{
+		Spec: v1alpha2.CertificateSpec{
+			CommonName:  "cn",
+			DNSNames:    []string{"c.example.com", "a.example.com", "b.example.com"},
+			IPAddresses: []string{"10.0.0.2", "10.0.0.1"},
+			URISANs:     []string{"spiffe://cluster.local/b", "spiffe://cluster.local/a"},
+		},
+	}
+	reordered := crt.DeepCopy()
+	reordered.Spec.DNSNames = []string{"b.example.com", "c.example.com", "a.example.com"}
+	reordered.Spec.IPAddresses = []string{"10.0.0.1", "10.0.0.2"}
+	reordered.Spec.URISANs = []string{"spiffe://cluster.local/a", "spiffe://cluster.local/b"}
+
+	csr, err := GenerateCSR(crt)
+	if err != nil {
+		t.Fatalf("unexpected error generating CSR: %v", err)
+	}
+	reorderedCSR, err := GenerateCSR(reordered)
+	if err != nil {
+		t.Fatalf("unexpected error generating CSR: %v", err)
+	}
+
+	expectedDNSNames := []string{"a.example.com", "b.example.com", "c.example.com"}
+	if !reflect.DeepEqual(csr.DNSNames, expectedDNSNames) {
+		t.Errorf("expected DNS names %q but got %q", expectedDNSNames, csr.DNSNames)
+	}
+	if !reflect.DeepEqual(csr.DNSNames, reorderedCSR.DNSNames) {
+		t.Errorf("expected DNS names to be stable across orderings, got %q and %q", csr.DNSNames, reorderedCSR.DNSNames)
+	}
+	expectedIPs := []string{"10.0.0.1", "10.0.0.2"}
+	if ips := IPAddressesToString(csr.IPAddresses); !reflect.DeepEqual(ips, expectedIPs) {
+		t.Errorf("expected IP addresses %q but got %q", expectedIPs, ips)
+	}
+	if !reflect.DeepEqual(IPAddressesToString(csr.IPAddresses), IPAddressesToString(reorderedCSR.IPAddresses)) {
+		t.Errorf("expected IP addresses to be stable across orderings")
+	}
+	expectedURIs := []string{"spiffe://cluster.local/a", "spiffe://cluster.local/b"}
+	if uris := URLsToString(csr.URIs); !reflect.DeepEqual(uris, expectedURIs) {
+		t.Errorf("expected URIs %q but got %q", expectedURIs, uris)
+	}
+
+	// the Certificate resource itself must not be modified
+	if crt.Spec.DNSNames[0] != "c.example.com" {
+		t.Errorf("expected certificate DNS names to be left unmodified, got %q", crt.Spec.DNSNames)
+	}
+
+	tmpl, err := GenerateTemplate(crt)
+	if err != nil {
+		t.Fatalf("unexpected error generating template: %v", err)
+	}
+	if !reflect.DeepEqual(tmpl.DNSNames, expectedDNSNames) {
+		t.Errorf("expected template DNS names %q but got %q", expectedDNSNames, tmpl.DNSNames)
+	}
+}
@@ -69,16 +69,24 @@ func (b *rfc2136Handler) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
 	// updates are currently accepted for *all* zones
 	if req.Opcode == dns.OpcodeUpdate {
 		for _, rr := range req.Ns {
-			txt := rr.(*dns.TXT)
-			log := log.WithValues("value", txt.Hdr.Name, "class", dns.ClassToString[rr.Header().Class], "txt", txt.Txt)
-			if rr.Header().Class == dns.ClassNONE {
-				log.Info("deleting txt record value due to NONE class")
-				// TODO: can we only delete the named record here somehow?
-				delete(b.txtRecords, txt.Hdr.Name)
-				continue
+			name := rr.Header().Name
+			log := log.WithValues("value", name, "class", dns.ClassToString[rr.Header().Class])
+			switch rr.Header().Class {
+			case dns.ClassANY:
+				log.Info("deleting all TXT record values due to ANY class")
+				delete(b.txtRecords, name)
+			case dns.ClassNONE:
+				txt := rr.(*dns.TXT)
+				log.Info("deleting TXT record value due to NONE class", "txt", txt.Txt)
+				b.txtRecords[name] = removeTxtValues(b.txtRecords[name], txt.Txt)
+				if len(b.txtRecords[name]) == 0 {
+					delete(b.txtRecords, name)
+				}
+			default:
+				txt := rr.(*dns.TXT)
+				log.Info("adding TXT record value", "txt", txt.Txt)
+				b.txtRecords[name] = append(removeTxtValues(b.txtRecords[name], txt.Txt), txt.Txt...)
 			}
-			log.Info("setting TXT record value")
-			b.txtRecords[txt.Hdr.Name] = txt.Txt
 		}
 	}
 
@@ -99,6 +107,25 @@ func (b *rfc2136Handler) ServeDNS(w dns.ResponseWriter, req *dns.Msg) {
 	}
 }
 
+// removeTxtValues returns the given TXT record values without those in
+// remove. As with a real nameserver, each value is only stored once.
+func removeTxtValues(values, remove []string) []string {
+	var remaining []string
+	for _, v := range values {
+		found := false
+		for _, r := range remove {
+			if v == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			remaining = append(remaining, v)
+		}
+	}
+	return remaining
+}
+
 func (b *rfc2136Handler) zoneForFQDN(s string) string {
 	for _, z := range b.zones {
 		if dns.IsSubDomain(z, s) {
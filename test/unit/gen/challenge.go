@@ -17,6 +17,8 @@ limitations under the License.
 package gen
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	cmacme "github.com/jetstack/cert-manager/pkg/apis/acme/v1alpha2"
 	cmmeta "github.com/jetstack/cert-manager/pkg/apis/meta/v1"
 )
@@ -66,6 +68,24 @@ func SetChallengePresented(p bool) ChallengeModifier {
 	}
 }
 
+func SetChallengePresentedURL(u string) ChallengeModifier {
+	return func(ch *cmacme.Challenge) {
+		ch.Status.PresentedURL = u
+	}
+}
+
+func SetChallengeSolverService(s string) ChallengeModifier {
+	return func(ch *cmacme.Challenge) {
+		ch.Status.SolverService = s
+	}
+}
+
+func SetChallengeSolverAddress(s string) ChallengeModifier {
+	return func(ch *cmacme.Challenge) {
+		ch.Status.SolverAddress = s
+	}
+}
+
 func SetChallengeWildcard(p bool) ChallengeModifier {
 	return func(ch *cmacme.Challenge) {
 		ch.Spec.Wildcard = p
@@ -90,8 +110,38 @@ func SetChallengeURL(s string) ChallengeModifier {
 	}
 }
 
+func SetChallengeAuthzURL(s string) ChallengeModifier {
+	return func(ch *cmacme.Challenge) {
+		ch.Spec.AuthzURL = s
+	}
+}
+
 func SetChallengeProcessing(b bool) ChallengeModifier {
 	return func(ch *cmacme.Challenge) {
 		ch.Status.Processing = b
 	}
 }
+
+func SetChallengeValidatedAt(ts metav1.Time) ChallengeModifier {
+	return func(ch *cmacme.Challenge) {
+		ch.Status.ValidatedAt = &ts
+	}
+}
+
+func SetChallengePresentStartedAt(ts metav1.Time) ChallengeModifier {
+	return func(ch *cmacme.Challenge) {
+		ch.Status.PresentStartedAt = &ts
+	}
+}
+
+func SetChallengeFinalizers(finalizers []string) ChallengeModifier {
+	return func(ch *cmacme.Challenge) {
+		ch.Finalizers = finalizers
+	}
+}
+
+func SetChallengeDeletionTimestamp(ts metav1.Time) ChallengeModifier {
+	return func(ch *cmacme.Challenge) {
+		ch.DeletionTimestamp = &ts
+	}
+}